@@ -77,6 +77,9 @@ func SetDefaults(cfg Provider) {
 	// See note in search/document/document.go
 	cfg.SetDefault("languages", []string{}) // e.g. JIVESEARCH_LANGUAGES="en fr de"
 
+	// the catalogs that translate our UI (see frontend/i18n)
+	cfg.SetDefault("translations", "i18n/locales")
+
 	// Elasticsearch
 	cfg.SetDefault("elasticsearch.url", "http://127.0.0.1:9200")
 	cfg.SetDefault("elasticsearch.search.index", "test-search")
@@ -94,6 +97,15 @@ func SetDefaults(cfg Provider) {
 	cfg.SetDefault("elasticsearch.robots.index", "test-robots")
 	cfg.SetDefault("elasticsearch.robots.type", "robots")
 
+	cfg.SetDefault("elasticsearch.anchors.index", "test-anchors")
+	cfg.SetDefault("elasticsearch.anchors.type", "anchor")
+
+	cfg.SetDefault("elasticsearch.links.index", "test-links")
+	cfg.SetDefault("elasticsearch.links.type", "link")
+
+	// search results with a higher spam score (0 to 1) are filtered out
+	cfg.SetDefault("search.spam.max", .8)
+
 	// PostgreSQL
 	// Note: there is a security concern if postgres password is stored in env variable
 	// but setting it as an env var w/in systemd nullifies this.
@@ -123,9 +135,21 @@ func SetDefaults(cfg Provider) {
 	cfg.SetDefault("crawler.workers", workers)
 	cfg.SetDefault("crawler.max.bytes", 1024000) // 1MB...too little? too much??? Rememer <script> tags can take up a lot of bytes.
 	cfg.SetDefault("crawler.timeout", 25*time.Second)
+	cfg.SetDefault("crawler.dns.timeout", 5*time.Second)
+	cfg.SetDefault("crawler.dns.ttl.min", 1*time.Minute)
+	cfg.SetDefault("crawler.dns.ttl.max", 24*time.Hour)
+	cfg.SetDefault("crawler.dns.ttl.negative", 15*time.Minute) // failed lookups
+	cfg.SetDefault("crawler.dns.cache", 100000)                // hosts
 	cfg.SetDefault("crawler.max.queue.links", 100000)
 	cfg.SetDefault("crawler.max.links", 100)
 	cfg.SetDefault("crawler.max.domain.links", 10000)
+	cfg.SetDefault("crawler.max.redirects", 5)
+	cfg.SetDefault("crawler.feeds.interval", 15*time.Minute)
+	cfg.SetDefault("crawler.feeds.prefix", "jivesearch:")      // of their redis keys
+	cfg.SetDefault("crawler.stale.failures", 3)                // consecutive failed crawls before a document goes stale
+	cfg.SetDefault("crawler.stale.retention", 30*24*time.Hour) // how long we keep stale documents
+	cfg.SetDefault("crawler.anchors.max.domain", 3)            // unique anchor texts per linking domain
+	cfg.SetDefault("crawler.anchors.max.domains", 100)         // linking domains aggregated per page
 	cfg.SetDefault("crawler.truncate.title", 100)
 	cfg.SetDefault("crawler.truncate.keywords", 25)
 	cfg.SetDefault("crawler.truncate.description", 250)
@@ -134,6 +158,42 @@ func SetDefaults(cfg Provider) {
 	cfg.SetDefault("nsfw.host", "http://127.0.0.1:8080")
 	cfg.SetDefault("nsfw.workers", 10)
 	cfg.SetDefault("nsfw.since", now().AddDate(0, -1, 0))
+	cfg.SetDefault("nsfw.classifier", "http") // "http" (nsfw.host) or "heuristic" (offline)
+	cfg.SetDefault("nsfw.batch", 10)
+	cfg.SetDefault("nsfw.retries", 2)
+	cfg.SetDefault("nsfw.backoff", time.Second)
+	cfg.SetDefault("images.max.bytes", 10<<20) // we don't decode larger images
+	cfg.SetDefault("images.thumbnail.dir", "") // thumbnails are disabled if empty
+	cfg.SetDefault("images.thumbnail.size", 225)
+	cfg.SetDefault("images.thumbnail.quality", 75)
+	cfg.SetDefault("images.similar.candidates", 1000) // the most images we compare with a hash
+
+	// JSON API
+	cfg.SetDefault("api.keys", "")        // a JSON file of keys (see frontend/api)
+	cfg.SetDefault("api.anonymous", true) // allow requests without a key
+
+	// rate limits (requests a minute & burst) of each client by route
+	cfg.SetDefault("ratelimit.store", "memory")      // "memory", "redis" or "" to turn off
+	cfg.SetDefault("ratelimit.period", 24*time.Hour) // how often the salt of the IP hashes changes
+	cfg.SetDefault("ratelimit.trustproxy", false)    // trust the X-Real-IP & X-Forwarded-For headers
+	cfg.SetDefault("ratelimit.search.rate", 60)
+	cfg.SetDefault("ratelimit.search.burst", 20)
+	cfg.SetDefault("ratelimit.autocomplete.rate", 300)
+	cfg.SetDefault("ratelimit.autocomplete.burst", 60)
+	cfg.SetDefault("ratelimit.proxy.rate", 30)
+	cfg.SetDefault("ratelimit.proxy.burst", 10)
+	cfg.SetDefault("ratelimit.image.rate", 600)
+	cfg.SetDefault("ratelimit.image.burst", 200)
+
+	// instant answers that call an API or a database
+	cfg.SetDefault("instant.timeout", 1*time.Second)           // of solving each answer
+	cfg.SetDefault("instant.breaker.threshold", 5)             // failures in a row before an answer is skipped
+	cfg.SetDefault("instant.breaker.cooldown", 30*time.Second) // before we try a skipped answer again
+
+	// health checks of our dependencies
+	cfg.SetDefault("health.timeout", 2*time.Second) // of each probe
+	cfg.SetDefault("health.ready", 2*time.Second)   // how long /readyz keeps its answer
+	cfg.SetDefault("status.password", "")           // basic auth of /status, which is turned off without one
 
 	// Tor
 	cfg.SetDefault("onion", "jivexx2rbi6llz37jq37n4uqff4kdipqbqd24c437c56om6uxbzhtdid.onion")
@@ -226,6 +286,10 @@ func SetDefaults(cfg Provider) {
 
 	cfg.BindPFlag("wikipedia.workers", cmd.Flags().Lookup("workers"))
 
+	// stale documents
+	cmd.Flags().Bool("purge", false, "delete stale documents past the retention period")
+	cfg.BindPFlag("crawler.stale.purge", cmd.Flags().Lookup("purge"))
+
 	if err := cmd.Execute(); err != nil {
 		panic(err)
 	}
@@ -68,6 +68,9 @@ func TestSetDefaults(t *testing.T) {
 		// Server
 		{"server.host", fmt.Sprintf("http://127.0.0.1:%d", port)},
 
+		// translations
+		{"translations", "i18n/locales"},
+
 		// Elasticsearch
 		{"elasticsearch.url", "http://127.0.0.1:9200"},
 		{"elasticsearch.search.index", "test-search"},
@@ -80,6 +83,12 @@ func TestSetDefaults(t *testing.T) {
 		{"elasticsearch.query.type", "query"},
 		{"elasticsearch.robots.index", "test-robots"},
 		{"elasticsearch.robots.type", "robots"},
+		{"elasticsearch.anchors.index", "test-anchors"},
+		{"elasticsearch.anchors.type", "anchor"},
+		{"elasticsearch.links.index", "test-links"},
+		{"elasticsearch.links.type", "link"},
+
+		{"search.spam.max", .8},
 
 		// PostgreSQL
 		{"postgresql.host", "localhost"},
@@ -104,9 +113,21 @@ func TestSetDefaults(t *testing.T) {
 		{"crawler.workers", 100},
 		{"crawler.max.bytes", 1024000},
 		{"crawler.timeout", 25 * time.Second},
+		{"crawler.dns.timeout", 5 * time.Second},
+		{"crawler.dns.ttl.min", 1 * time.Minute},
+		{"crawler.dns.ttl.max", 24 * time.Hour},
+		{"crawler.dns.ttl.negative", 15 * time.Minute},
+		{"crawler.dns.cache", 100000},
 		{"crawler.max.queue.links", 100000},
 		{"crawler.max.links", 100},
 		{"crawler.max.domain.links", 10000},
+		{"crawler.max.redirects", 5},
+		{"crawler.feeds.interval", 15 * time.Minute},
+		{"crawler.feeds.prefix", "jivesearch:"},
+		{"crawler.stale.failures", 3},
+		{"crawler.stale.retention", 30 * 24 * time.Hour},
+		{"crawler.anchors.max.domain", 3},
+		{"crawler.anchors.max.domains", 100},
 		{"crawler.truncate.title", 100},
 		{"crawler.truncate.keywords", 25},
 		{"crawler.truncate.description", 250},
@@ -117,6 +138,42 @@ func TestSetDefaults(t *testing.T) {
 		// image nsfw scoring and metadata
 		{"nsfw.workers", 10},
 		{"nsfw.since", time.Date(2018, 01, 06, 20, 34, 58, 651387237, time.UTC)},
+		{"nsfw.classifier", "http"},
+		{"nsfw.batch", 10},
+		{"nsfw.retries", 2},
+		{"nsfw.backoff", time.Second},
+		{"images.max.bytes", 10 << 20},
+		{"images.thumbnail.dir", ""},
+		{"images.thumbnail.size", 225},
+		{"images.thumbnail.quality", 75},
+		{"images.similar.candidates", 1000},
+
+		// JSON API
+		{"api.keys", ""},
+		{"api.anonymous", true},
+
+		// rate limits
+		{"ratelimit.store", "memory"},
+		{"ratelimit.period", 24 * time.Hour},
+		{"ratelimit.trustproxy", false},
+		{"ratelimit.search.rate", 60},
+		{"ratelimit.search.burst", 20},
+		{"ratelimit.autocomplete.rate", 300},
+		{"ratelimit.autocomplete.burst", 60},
+		{"ratelimit.proxy.rate", 30},
+		{"ratelimit.proxy.burst", 10},
+		{"ratelimit.image.rate", 600},
+		{"ratelimit.image.burst", 200},
+
+		// instant answers
+		{"instant.timeout", 1 * time.Second},
+		{"instant.breaker.threshold", 5},
+		{"instant.breaker.cooldown", 30 * time.Second},
+
+		// health checks
+		{"health.timeout", 2 * time.Second},
+		{"health.ready", 2 * time.Second},
+		{"status.password", ""},
 
 		// Tor
 		{"onion", "jivexx2rbi6llz37jq37n4uqff4kdipqbqd24c437c56om6uxbzhtdid.onion"},
@@ -1,8 +1,11 @@
 package frontend
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+
+	"github.com/jivesearch/jivesearch/health"
 )
 
 // GitHub holds settings for GitHub's API
@@ -45,7 +48,9 @@ func (f *Frontend) aboutHandler(w http.ResponseWriter, r *http.Request) *respons
 		Onion:   f.Onion,
 	}
 
+	applySettings(r)
 	abt.setTheme(r)
+	f.localize(abt.Context, r)
 
 	resp := &response{
 		status:   http.StatusOK,
@@ -76,3 +81,9 @@ func (f *Frontend) aboutHandler(w http.ResponseWriter, r *http.Request) *respons
 
 	return resp
 }
+
+// HealthCheck makes sure GitHub's API is up
+func (g *GitHub) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: g.HTTPClient, URL: "https://api.github.com"}
+	return h.HealthCheck(ctx)
+}
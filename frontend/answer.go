@@ -1,6 +1,7 @@
 package frontend
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"github.com/jivesearch/jivesearch/instant/whois"
 	"github.com/jivesearch/jivesearch/instant/wikipedia"
 	"github.com/jivesearch/jivesearch/log"
+	"github.com/pkg/errors"
 	"golang.org/x/text/language"
 )
 
@@ -42,12 +44,7 @@ func (f *Frontend) getAnswer(r *http.Request, dd data, ic chan instant.Data) {
 	lang, _, _ := f.Wikipedia.Matcher.Match(dd.Context.Preferred...)
 	key := cacheKey("instant", lang, f.detectRegion(lang, r), r.URL)
 
-	v, err := f.Cache.Get(key)
-	if err != nil {
-		log.Info.Println(err)
-	}
-
-	if v != nil {
+	if v := f.cacheGet("instant", key); v != nil {
 		ir := &Instant{
 			instant.Data{},
 		}
@@ -89,9 +86,7 @@ func (f *Frontend) getAnswer(r *http.Request, dd data, ic chan instant.Data) {
 			d = f.Cache.Instant
 		}
 
-		if err := f.Cache.Put(key, res, d); err != nil {
-			log.Info.Println(err)
-		}
+		f.cachePut(key, res, d)
 	}
 
 	ic <- res
@@ -163,23 +158,105 @@ func (f *Frontend) DetectInstantAnswer(r *http.Request, lang language.Tag, onlyM
 		}
 	}
 
-	// Necessary to use goroutines??? setSolution called only when triggered.
-	// Also, the order of some answers matters, like Wikipedia, which is a catch-all
+	// Answers are triggered in order as some take priority over others (e.g. Speed
+	// before Length and Wikipedia, a catch-all, last). Those that call an API or a database
+	// are solved at the same time and the first answer in order with a solution wins.
+	// The answers still being solved when we have a winner are cancelled.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	solutions := []chan instant.Data{}
+
 	for _, ia := range answers {
-		if triggered := f.Instant.Trigger(ia, r, lang); triggered {
-			sol := f.Instant.Solve(ia, r)
-			if sol.Err != nil {
-				log.Debug.Println(sol.Err)
+		name := providerName(ia)
+
+		start := time.Now()
+		triggered := f.Instant.Trigger(ia, r, lang)
+		instantDuration.Since(start, name, "trigger")
+
+		if !triggered {
+			continue
+		}
+
+		if instant.External(ia) {
+			if !f.Instant.Breaker.Allow(name) {
+				breakerSkips.Inc(name)
 				continue
 			}
 
-			return sol
+			solutions = append(solutions, f.solve(ctx, ia, r, name))
+			continue
+		}
+
+		start = time.Now()
+		sol := f.Instant.Solve(ia, r)
+		instantDuration.Since(start, name, "solve")
+
+		ch := make(chan instant.Data, 1)
+		ch <- sol
+		solutions = append(solutions, ch)
+
+		if sol.Err == nil { // the answers after it can't win
+			break
 		}
 	}
 
+	for _, ch := range solutions {
+		sol := <-ch
+		if sol.Err != nil {
+			log.Debug.Println(sol.Err)
+			continue
+		}
+
+		return sol
+	}
+
 	return instant.Data{}
 }
 
+// solve solves an external answer in the background. Its solution is an error
+// if it isn't solved within f.Instant.Timeout. An answer that is too slow or
+// whose fetcher fails counts towards opening its circuit breaker, unless
+// parent was cancelled first (the request is gone or another answer won).
+func (f *Frontend) solve(parent context.Context, ia instant.Answerer, r *http.Request, name string) chan instant.Data {
+	ctx, cancel := parent, context.CancelFunc(func() {})
+	if f.Instant.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, f.Instant.Timeout)
+	}
+
+	done := make(chan instant.Data, 1) // a fetcher that ignores ctx can't hold up the others
+	go func() {
+		start := time.Now()
+		sol := f.Instant.Solve(ia, r.WithContext(ctx))
+		instantDuration.Since(start, name, "solve")
+
+		switch {
+		case parent.Err() != nil: // not the answer's fault
+		case ctx.Err() != nil, sol.Err != nil && !instant.InvalidInput(sol.Err):
+			providerErrors.Inc(name)
+			f.Instant.Breaker.Failure(name)
+		case sol.Err == nil:
+			f.Instant.Breaker.Success(name)
+		}
+
+		done <- sol
+	}()
+
+	ch := make(chan instant.Data, 1)
+	go func() {
+		defer cancel()
+
+		select {
+		case sol := <-done:
+			ch <- sol
+		case <-ctx.Done():
+			ch <- instant.Data{Err: errors.Wrapf(ctx.Err(), "%v answer", name)}
+		}
+	}()
+
+	return ch
+}
+
 // UnmarshalJSON unmarshals an instant answer to the correct data structure
 func (d *Instant) UnmarshalJSON(b []byte) error {
 	type alias Instant
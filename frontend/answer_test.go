@@ -1,8 +1,14 @@
 package frontend
 
 import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jivesearch/jivesearch/instant"
 	"github.com/jivesearch/jivesearch/instant/breach"
@@ -13,6 +19,7 @@ import (
 	"github.com/jivesearch/jivesearch/instant/weather"
 	"github.com/jivesearch/jivesearch/instant/whois"
 	"github.com/jivesearch/jivesearch/instant/wikipedia"
+	"golang.org/x/text/language"
 )
 
 func TestDetectType(t *testing.T) {
@@ -57,3 +64,136 @@ func TestDetectType(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectInstantAnswer(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		q       string
+		stock   *mockStockFetcher
+		breaker *instant.Breaker
+		want    instant.Type
+		calls   int // of the stock fetcher over 3 requests
+	}{
+		{"local", "2+2", &mockStockFetcher{}, nil, instant.CalculatorType, 0},
+		{"priority", "aapl quote", &mockStockFetcher{sleep: 20 * time.Millisecond}, nil, instant.StockQuoteType, 3},
+		{"timeout", "aapl quote", &mockStockFetcher{sleep: time.Second}, nil, instant.WikipediaType, 3},
+		{"error", "aapl quote", &mockStockFetcher{err: errors.New("down")}, nil, instant.WikipediaType, 3},
+		{
+			"breaker", "aapl quote", &mockStockFetcher{err: errors.New("down")},
+			&instant.Breaker{Threshold: 2, Cooldown: time.Hour}, instant.WikipediaType, 2,
+		},
+		{
+			"invalid input", "aapl quote", &mockStockFetcher{err: stock.ErrInvalidTicker},
+			&instant.Breaker{Threshold: 2, Cooldown: time.Hour}, instant.WikipediaType, 3,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{
+				Instant: &instant.Instant{
+					QueryVar:          "q",
+					StockQuoteFetcher: c.stock,
+					WikipediaFetcher:  &mockWikipediaFetcher{},
+					Timeout:           100 * time.Millisecond,
+					Breaker:           c.breaker,
+				},
+			}
+
+			for i := 0; i < 3; i++ {
+				r := httptest.NewRequest("GET", "/?q="+url.QueryEscape(c.q), nil)
+
+				start := time.Now()
+				got := f.DetectInstantAnswer(r, language.English, false)
+				if d := time.Since(start); d > 500*time.Millisecond {
+					t.Fatalf("took %v", d)
+				}
+
+				if got.Type != c.want {
+					t.Fatalf("got %q; want %q", got.Type, c.want)
+				}
+			}
+
+			if got := c.stock.count(); got != c.calls {
+				t.Fatalf("got %d calls; want %d", got, c.calls)
+			}
+
+			// the fetcher gets the timeout of the answer
+			for _, ctx := range c.stock.contexts() {
+				if _, ok := ctx.Deadline(); !ok {
+					t.Fatal("the fetcher didn't get the context of the answer")
+				}
+			}
+		})
+	}
+}
+
+// The answers we are still solving when a higher priority answer wins
+// are cancelled and don't count towards opening their circuit breaker
+func TestDetectInstantAnswerCancel(t *testing.T) {
+	breaker := &instant.Breaker{Threshold: 1, Cooldown: time.Hour}
+	wiki := &mockSlowWikipediaFetcher{sleep: 200 * time.Millisecond}
+
+	f := &Frontend{
+		Instant: &instant.Instant{
+			QueryVar:          "q",
+			StockQuoteFetcher: &mockStockFetcher{},
+			WikipediaFetcher:  wiki,
+			Timeout:           100 * time.Millisecond,
+			Breaker:           breaker,
+		},
+	}
+
+	r := httptest.NewRequest("GET", "/?q="+url.QueryEscape("aapl quote"), nil)
+	if got := f.DetectInstantAnswer(r, language.English, false); got.Type != instant.StockQuoteType {
+		t.Fatalf("got %q; want %q", got.Type, instant.StockQuoteType)
+	}
+
+	time.Sleep(300 * time.Millisecond) // until the wikipedia fetcher returns
+
+	if !breaker.Allow("wikipedia") {
+		t.Fatal("the cancelled wikipedia answer opened its circuit breaker")
+	}
+}
+
+type mockSlowWikipediaFetcher struct {
+	mockWikipediaFetcher
+	sleep time.Duration
+}
+
+func (mf *mockSlowWikipediaFetcher) Fetch(query string, lang language.Tag) ([]*wikipedia.Item, error) {
+	time.Sleep(mf.sleep)
+	return mf.mockWikipediaFetcher.Fetch(query, lang)
+}
+
+type mockStockFetcher struct {
+	sleep time.Duration
+	err   error
+	sync.Mutex
+	ctxs []context.Context
+}
+
+func (m *mockStockFetcher) Fetch(ctx context.Context, ticker string) (*stock.Quote, error) {
+	m.Lock()
+	m.ctxs = append(m.ctxs, ctx)
+	m.Unlock()
+
+	select {
+	case <-time.After(m.sleep):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return &stock.Quote{Ticker: ticker}, m.err
+}
+
+func (m *mockStockFetcher) count() int {
+	m.Lock()
+	defer m.Unlock()
+	return len(m.ctxs)
+}
+
+// contexts returns the contexts the fetcher was called with
+func (m *mockStockFetcher) contexts() []context.Context {
+	m.Lock()
+	defer m.Unlock()
+	return m.ctxs
+}
@@ -0,0 +1,407 @@
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jivesearch/jivesearch/bangs"
+	"github.com/jivesearch/jivesearch/frontend/api"
+	"github.com/jivesearch/jivesearch/instant"
+	"github.com/jivesearch/jivesearch/log"
+	img "github.com/jivesearch/jivesearch/search/image"
+	"github.com/jivesearch/jivesearch/suggest"
+)
+
+// apiVersion is the version of the JSON API. The fields of a version are
+// only ever added to. Renaming or removing a field needs a new version.
+const apiVersion = "v1"
+
+// apiKeyHeader is the header of an API key. The key can also be passed as ?key=
+const apiKeyHeader = "X-API-Key"
+
+var (
+	errAPIKeyRequired = fmt.Errorf("an api key is required")
+	errAPIUnknownKey  = fmt.Errorf("invalid api key")
+	errAPIRateLimit   = fmt.Errorf("rate limit exceeded")
+	errAPINoQuery     = fmt.Errorf("a query is required (?q=)")
+)
+
+// apiResponse is the body of every successful response of the JSON API
+type apiResponse struct {
+	Version     string             `json:"version"`
+	Query       string             `json:"query"`
+	Language    string             `json:"language,omitempty"`
+	Region      string             `json:"region,omitempty"`
+	Redirect    string             `json:"redirect,omitempty"` // a !bang or first result shortcut
+	Answer      *apiAnswer         `json:"answer,omitempty"`
+	Search      *apiSearch         `json:"search,omitempty"`
+	Images      *apiImages         `json:"images,omitempty"`
+	Suggestions []string           `json:"suggestions,omitempty"`
+	Bangs       []bangs.Suggestion `json:"bangs,omitempty"`
+}
+
+// apiError is the body of a failed response of the JSON API
+type apiError struct {
+	Version string `json:"version"`
+	Status  int    `json:"status"`
+	Error   string `json:"error"`
+}
+
+type apiAnswer struct {
+	Type   instant.Type `json:"type"`
+	Answer interface{}  `json:"answer"`
+}
+
+type apiPagination struct {
+	Page     int `json:"page"`
+	Previous int `json:"previous,omitempty"`
+	Next     int `json:"next,omitempty"`
+}
+
+type apiSearch struct {
+	Provider    string        `json:"provider,omitempty"`
+	Count       int64         `json:"count"`
+	Alternative string        `json:"alternative,omitempty"` // a spelling correction
+	Pagination  apiPagination `json:"pagination"`
+	Results     []apiResult   `json:"results"`
+}
+
+type apiResult struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Date        string `json:"date,omitempty"`
+}
+
+type apiImages struct {
+	Count      int64         `json:"count"`
+	Pagination apiPagination `json:"pagination"`
+	Images     []apiImage    `json:"images"`
+}
+
+type apiImage struct {
+	URL       string `json:"url"`
+	Alt       string `json:"alt,omitempty"`
+	Page      string `json:"page,omitempty"` // the page the image is on
+	PageTitle string `json:"page_title,omitempty"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	Thumbnail string `json:"thumbnail"` // a resized copy served by us
+}
+
+// apiRequest is a copy of an API request for our html handlers.
+// The key is removed so that it isn't part of our cache keys.
+func apiRequest(r *http.Request, t string) *http.Request {
+	rr := r.WithContext(r.Context())
+
+	u := *r.URL
+	q := u.Query()
+	q.Del("key")
+	q.Del("o")
+	q.Set("t", t)
+	u.RawQuery = q.Encode()
+
+	rr.URL = &u
+	rr.Form = nil
+	return rr
+}
+
+// apiAuth checks the API key of a request and its rate limit
+// before counting the request against the key.
+// Anonymous requests are counted under an empty key.
+func (f *Frontend) apiAuth(endpoint string, next appHandler) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) *response {
+		key := apiKey(r)
+
+		var k *api.Key
+		switch {
+		case key == "" && !f.API.Anonymous:
+			return &response{status: http.StatusUnauthorized, template: "json", err: errAPIKeyRequired}
+		case key == "":
+		case f.API.Keys == nil:
+			return &response{status: http.StatusUnauthorized, template: "json", err: errAPIUnknownKey}
+		default:
+			k, _ = r.Context().Value(apiKeyContext{}).(*api.Key) // looked up by apiLimit
+			if k == nil {
+				var err error
+				if k, err = f.API.Keys.Get(key); err != nil {
+					return &response{status: http.StatusUnauthorized, template: "json", err: errAPIUnknownKey}
+				}
+			}
+		}
+
+		if k != nil && k.Rate > 0 && f.API.Limiter != nil {
+			if rsp := f.apiRateLimit(w, k); rsp != nil {
+				return rsp
+			}
+		}
+
+		if f.API.Usage != nil {
+			if err := f.API.Usage.Increment(key, endpoint); err != nil {
+				log.Info.Println(err)
+			}
+		}
+
+		return next(w, r)
+	}
+}
+
+// apiKeyContext is the context key of an API key we've looked up
+type apiKeyContext struct{}
+
+// apiLimit limits the requests of a client by IP like our other routes
+// unless they have a valid API key. apiAuth takes care of the bucket of a key.
+func (f *Frontend) apiLimit(route string, next http.Handler) http.Handler {
+	limited := f.rateLimit(route, next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := apiKey(r)
+		if key == "" || f.API.Keys == nil {
+			limited.ServeHTTP(w, r)
+			return
+		}
+
+		k, err := f.API.Keys.Get(key)
+		if err != nil {
+			limited.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContext{}, k)))
+	})
+}
+
+// apiRateLimit takes a token from the bucket of a key. The reset is when the
+// key has its whole rate again. Requests are let through if we can't reach
+// the store, like those of our other rate limits.
+func (f *Frontend) apiRateLimit(w http.ResponseWriter, k *api.Key) *response {
+	now := time.Now()
+	lim := k.Limit()
+
+	ok, remaining, retry, err := f.API.Limiter.Take("api:"+api.Hash(k.Key), lim, now)
+	if err != nil {
+		log.Info.Println(err)
+		return nil
+	}
+
+	reset := now.Add(time.Duration(float64(lim.Burst-remaining) / lim.Rate * float64(time.Second)))
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(k.Rate))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int((retry+time.Second-1)/time.Second)))
+		return &response{status: http.StatusTooManyRequests, template: "json", err: errAPIRateLimit}
+	}
+
+	return nil
+}
+
+func apiKey(r *http.Request) string {
+	if k := strings.TrimSpace(r.Header.Get(apiKeyHeader)); k != "" {
+		return k
+	}
+
+	return strings.TrimSpace(r.URL.Query().Get("key"))
+}
+
+func (f *Frontend) apiSearchHandler(w http.ResponseWriter, r *http.Request) *response {
+	if strings.TrimSpace(r.FormValue("q")) == "" {
+		return &response{status: http.StatusBadRequest, template: "json", err: errAPINoQuery}
+	}
+
+	rsp := f.searchHandler(w, apiRequest(r, ""))
+	switch rsp.status {
+	case http.StatusOK:
+	case http.StatusFound:
+		return &response{
+			status:   http.StatusOK,
+			template: "json",
+			data: apiResponse{
+				Version:  apiVersion,
+				Query:    strings.TrimSpace(r.FormValue("q")),
+				Redirect: rsp.redirect,
+			},
+		}
+	default:
+		rsp.template = "json"
+		return rsp
+	}
+
+	d := rsp.data.(data)
+	res := newAPIResponse(d)
+
+	if d.Instant.Triggered {
+		res.Answer = &apiAnswer{Type: d.Instant.Type, Answer: d.Instant.Solution}
+	}
+
+	if d.Search != nil {
+		res.Search = &apiSearch{
+			Provider:    string(d.Search.Provider),
+			Count:       d.Search.Count,
+			Alternative: d.Alternative,
+			Pagination:  newAPIPagination(d.Context.Page, d.Search.Next),
+			Results:     []apiResult{},
+		}
+
+		for _, doc := range d.Search.Documents {
+			res.Search.Results = append(res.Search.Results, apiResult{
+				URL:         doc.ID,
+				Title:       doc.Title,
+				Description: doc.Description,
+				Date:        doc.Date,
+			})
+		}
+	}
+
+	return &response{status: http.StatusOK, template: "json", data: res}
+}
+
+func (f *Frontend) apiImagesHandler(w http.ResponseWriter, r *http.Request) *response {
+	rr := apiRequest(r, "images")
+	d := f.getData(rr)
+	if d.Context.Q == "" {
+		return &response{status: http.StatusBadRequest, template: "json", err: errAPINoQuery}
+	}
+
+	res := newAPIResponse(d)
+	res.Images = &apiImages{
+		Pagination: apiPagination{Page: d.Context.Page},
+		Images:     []apiImage{},
+	}
+
+	ir := f.imageResults(d, d.Context.lang, d.Context.Region, rr.URL)
+	if ir != nil {
+		res.Images.Count = ir.Count
+		if int64(d.Context.Page*imagesPerPage) < ir.Count {
+			res.Images.Pagination.Next = d.Context.Page + 1
+		}
+		if d.Context.Page > 1 {
+			res.Images.Pagination.Previous = d.Context.Page - 1
+		}
+
+		for _, im := range ir.Images {
+			res.Images.Images = append(res.Images.Images, newAPIImage(f.Images.Thumbnails, im))
+		}
+	}
+
+	return &response{status: http.StatusOK, template: "json", data: res}
+}
+
+func (f *Frontend) apiAnswerHandler(w http.ResponseWriter, r *http.Request) *response {
+	rr := apiRequest(r, "")
+	d := f.getData(rr)
+	if d.Context.Q == "" {
+		return &response{status: http.StatusBadRequest, template: "json", err: errAPINoQuery}
+	}
+
+	ic := make(chan instant.Data)
+	go f.getAnswer(rr, d, ic)
+	d.Instant = <-ic
+
+	res := newAPIResponse(d)
+	if d.Instant.Triggered {
+		res.Answer = &apiAnswer{Type: d.Instant.Type, Answer: d.Instant.Solution}
+	}
+
+	return &response{status: http.StatusOK, template: "json", data: res}
+}
+
+func (f *Frontend) apiSuggestHandler(w http.ResponseWriter, r *http.Request) *response {
+	q := strings.TrimSpace(r.FormValue("q"))
+	if q == "" {
+		return &response{status: http.StatusBadRequest, template: "json", err: errAPINoQuery}
+	}
+
+	rsp := f.autocompleteHandler(w, apiRequest(r, ""))
+	if rsp.status != http.StatusOK {
+		rsp.template = "json"
+		return rsp
+	}
+
+	res := apiResponse{
+		Version: apiVersion,
+		Query:   q,
+	}
+
+	switch v := rsp.data.(type) {
+	case suggest.Results:
+		res.Suggestions = v.Suggestions
+	case bangs.Results:
+		res.Bangs = v.Suggestions
+	}
+
+	return &response{status: http.StatusOK, template: "json", data: res}
+}
+
+// apiUsageHandler tells a key how many requests it has made to each endpoint
+func (f *Frontend) apiUsageHandler(w http.ResponseWriter, r *http.Request) *response {
+	key := apiKey(r)
+	if key == "" {
+		return &response{status: http.StatusUnauthorized, template: "json", err: errAPIKeyRequired}
+	}
+
+	usage := map[string]int64{}
+	if f.API.Usage != nil {
+		u, err := f.API.Usage.Get(key)
+		if err != nil {
+			return &response{status: http.StatusInternalServerError, template: "json", err: err}
+		}
+		usage = u
+	}
+
+	return &response{
+		status:   http.StatusOK,
+		template: "json",
+		data: struct {
+			Version string           `json:"version"`
+			Usage   map[string]int64 `json:"usage"`
+		}{apiVersion, usage},
+	}
+}
+
+func newAPIResponse(d data) apiResponse {
+	return apiResponse{
+		Version:  apiVersion,
+		Query:    d.Context.Q,
+		Language: d.Context.lang.String(),
+		Region:   d.Context.Region.String(),
+	}
+}
+
+// newAPIPagination uses the next page of our results, if any
+func newAPIPagination(page int, next string) apiPagination {
+	p := apiPagination{Page: page}
+	if page > 1 {
+		p.Previous = page - 1
+	}
+
+	if n, err := strconv.Atoi(next); err == nil {
+		p.Next = n
+	}
+
+	return p
+}
+
+func newAPIImage(thumbnails img.Store, im *img.Image) apiImage {
+	i := apiImage{
+		URL:       im.ID,
+		Alt:       im.Alt,
+		Page:      im.Page,
+		PageTitle: im.PageTitle,
+		Width:     im.Width,
+		Height:    im.Height,
+		Thumbnail: proxiedImage(im.ID),
+	}
+
+	if thumbnails != nil && im.Thumbnail != "" {
+		i.Thumbnail = fmt.Sprintf("/thumbnail/%v?u=%v&s=%v", im.Thumbnail, url.QueryEscape(im.ID), url.QueryEscape(hmacKey(im.ID)))
+	}
+
+	return i
+}
@@ -0,0 +1,113 @@
+// Package api manages the keys of our JSON API, their rate limits and usage.
+// Keys are rate limited with the token buckets of the ratelimit package.
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/jivesearch/jivesearch/frontend/ratelimit"
+	"github.com/spf13/afero"
+)
+
+// Key is an API key
+type Key struct {
+	Key  string `json:"key"`
+	Name string `json:"name"` // who the key belongs to
+	Rate int    `json:"rate"` // requests per minute (0 is unlimited)
+}
+
+// Store looks up API keys
+type Store interface {
+	Get(key string) (*Key, error)
+}
+
+// ErrUnknownKey is returned by a Store for a key it doesn't have
+var ErrUnknownKey = fmt.Errorf("unknown api key")
+
+var appFs = afero.NewOsFs()
+
+// File is a Store of keys in a JSON file
+// [{"key": "abc", "name": "internal tools", "rate": 600}]
+type File struct {
+	keys map[string]*Key
+}
+
+// Open loads the keys of a file
+func Open(path string) (*File, error) {
+	b, err := afero.ReadFile(appFs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []*Key{}
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, err
+	}
+
+	f := &File{keys: map[string]*Key{}}
+	for _, k := range keys {
+		if k.Key == "" {
+			return nil, fmt.Errorf("%v has a key without a value", path)
+		}
+		f.keys[k.Key] = k
+	}
+
+	return f, nil
+}
+
+// Get returns a key
+func (f *File) Get(key string) (*Key, error) {
+	k, ok := f.keys[key]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return k, nil
+}
+
+// Limit is the token bucket of a key. A key can use its whole rate at once.
+func (k *Key) Limit() ratelimit.Limit {
+	return ratelimit.PerMinute(k.Rate, k.Rate)
+}
+
+// Hash identifies a key in our stores so that we don't keep the key itself
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Usage counts the requests of each key by endpoint
+type Usage interface {
+	Increment(key, endpoint string) error
+	Get(key string) (map[string]int64, error)
+}
+
+const usagePrefix = "jivesearch::api::usage::"
+
+// Redis keeps the usage of our keys in Redis so that it
+// survives a restart and is shared by our frontends.
+// The usage of a key is a hash of its requests by endpoint.
+type Redis struct {
+	RedisPool *redis.Pool
+}
+
+// Increment counts a request of a key
+func (r *Redis) Increment(key, endpoint string) error {
+	c := r.RedisPool.Get()
+	defer c.Close()
+
+	_, err := c.Do("HINCRBY", usagePrefix+Hash(key), endpoint, 1)
+	return err
+}
+
+// Get returns the requests of a key by endpoint
+func (r *Redis) Get(key string) (map[string]int64, error) {
+	c := r.RedisPool.Get()
+	defer c.Close()
+
+	return redis.Int64Map(c.Do("HGETALL", usagePrefix+Hash(key)))
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/jivesearch/jivesearch/frontend/ratelimit"
+	"github.com/rafaeljusto/redigomock"
+	"github.com/spf13/afero"
+)
+
+func TestOpen(t *testing.T) {
+	appFs = afero.NewMemMapFs()
+
+	for _, c := range []struct {
+		name    string
+		content string
+		key     string
+		want    *Key
+		err     error
+	}{
+		{
+			name:    "found",
+			content: `[{"key": "abc", "name": "internal tools", "rate": 600}, {"key": "def"}]`,
+			key:     "abc",
+			want:    &Key{Key: "abc", Name: "internal tools", Rate: 600},
+		},
+		{
+			name:    "unlimited",
+			content: `[{"key": "abc", "name": "internal tools", "rate": 600}, {"key": "def"}]`,
+			key:     "def",
+			want:    &Key{Key: "def"},
+		},
+		{
+			name:    "unknown",
+			content: `[{"key": "abc"}]`,
+			key:     "xyz",
+			err:     ErrUnknownKey,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if err := afero.WriteFile(appFs, "keys.json", []byte(c.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := Open("keys.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := f.Get(c.key)
+			if err != c.err {
+				t.Fatalf("got err %v; want %v", err, c.err)
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %+v; want %+v", got, c.want)
+			}
+		})
+	}
+
+	for _, content := range []string{`{"key": "abc"}`, `[{"name": "no key"}]`} {
+		if err := afero.WriteFile(appFs, "keys.json", []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Open("keys.json"); err == nil {
+			t.Fatalf("expected an error for %v", content)
+		}
+	}
+}
+
+func TestLimit(t *testing.T) {
+	m := ratelimit.NewMemory()
+	k := &Key{Key: "abc", Rate: 2}
+	start := time.Date(2018, 1, 1, 12, 0, 10, 0, time.UTC)
+
+	type result struct {
+		ok        bool
+		remaining int
+	}
+
+	for _, c := range []struct {
+		name string
+		now  time.Time
+		want result
+	}{
+		{"first", start, result{true, 1}},
+		{"second", start.Add(time.Second), result{true, 0}},
+		{"limited", start.Add(2 * time.Second), result{false, 0}},
+		{"refilled", start.Add(32 * time.Second), result{true, 0}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			ok, remaining, _, err := m.Take(Hash(k.Key), k.Limit(), c.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := (result{ok, remaining}); got != c.want {
+				t.Fatalf("got %+v; want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	got := Hash("abc")
+	if strings.Contains(got, "abc") || len(got) != 32 {
+		t.Fatalf("got %q", got)
+	}
+
+	if Hash("abd") == got {
+		t.Fatal("expected another key to have another hash")
+	}
+}
+
+func TestRedisUsage(t *testing.T) {
+	conn := redigomock.NewConn()
+	r := &Redis{
+		RedisPool: &redis.Pool{
+			Dial: func() (redis.Conn, error) { return conn, nil },
+		},
+	}
+
+	incr := conn.Command("HINCRBY", usagePrefix+Hash("abc"), "search", 1).Expect(int64(1))
+	if err := r.Increment("abc", "search"); err != nil {
+		t.Fatal(err)
+	}
+
+	if conn.Stats(incr) != 1 {
+		t.Fatal("expected the usage to be incremented")
+	}
+
+	conn.Command("HGETALL", usagePrefix+Hash("abc")).Expect([]interface{}{
+		[]byte("search"), []byte("2"), []byte("images"), []byte("1"),
+	})
+	conn.Command("HGETALL", usagePrefix+Hash("xyz")).Expect([]interface{}{})
+
+	got, err := r.Get("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int64{"search": 2, "images": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+
+	if got, err = r.Get("xyz"); err != nil || len(got) != 0 {
+		t.Fatalf("got %v, %v; want nothing", got, err)
+	}
+}
@@ -0,0 +1,487 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jivesearch/jivesearch/frontend/api"
+	"github.com/jivesearch/jivesearch/frontend/ratelimit"
+	"github.com/jivesearch/jivesearch/instant"
+	img "github.com/jivesearch/jivesearch/search/image"
+	"golang.org/x/text/language"
+)
+
+func TestAPIAuth(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		anonymous bool
+		header    string
+		param     string
+		requests  int // requests to make before the one we check
+		status    int
+		headers   map[string]string
+		usage     map[string]int64
+	}{
+		{
+			name:      "anonymous",
+			anonymous: true,
+			status:    http.StatusOK,
+			usage:     map[string]int64{"search": 1},
+		},
+		{
+			name:   "anonymous disabled",
+			status: http.StatusUnauthorized,
+			usage:  map[string]int64{},
+		},
+		{
+			name:   "unknown key",
+			param:  "nope",
+			status: http.StatusUnauthorized,
+			usage:  map[string]int64{},
+		},
+		{
+			name:   "header",
+			header: "unlimited",
+			status: http.StatusOK,
+			usage:  map[string]int64{"search": 1},
+		},
+		{
+			name:    "param",
+			param:   "limited",
+			status:  http.StatusOK,
+			headers: map[string]string{"X-RateLimit-Limit": "2", "X-RateLimit-Remaining": "1"},
+			usage:   map[string]int64{"search": 1},
+		},
+		{
+			name:     "rate limited",
+			param:    "limited",
+			requests: 2,
+			status:   http.StatusTooManyRequests,
+			headers:  map[string]string{"X-RateLimit-Limit": "2", "X-RateLimit-Remaining": "0"},
+			usage:    map[string]int64{"search": 2},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{}
+			f.API.Keys = &mockKeys{}
+			f.API.Anonymous = c.anonymous
+			f.API.Limiter = ratelimit.NewMemory()
+			f.API.Usage = &mockUsage{}
+
+			h := f.apiAuth("search", func(w http.ResponseWriter, r *http.Request) *response {
+				return &response{status: http.StatusOK, template: "json", data: apiResponse{Version: apiVersion}}
+			})
+
+			req := httptest.NewRequest("GET", "/api/v1/search?q=cats&key="+c.param, nil)
+			if c.header != "" {
+				req.Header.Set(apiKeyHeader, c.header)
+			}
+
+			for i := 0; i < c.requests; i++ {
+				appHandler(h).ServeHTTP(httptest.NewRecorder(), req)
+			}
+
+			rr := httptest.NewRecorder()
+			appHandler(h).ServeHTTP(rr, req)
+
+			if rr.Code != c.status {
+				t.Fatalf("got status %d; want %d", rr.Code, c.status)
+			}
+
+			for k, v := range c.headers {
+				if got := rr.Header().Get(k); got != v {
+					t.Fatalf("got %v %q; want %q", k, got, v)
+				}
+			}
+
+			if c.status == http.StatusTooManyRequests && rr.Header().Get("Retry-After") == "" {
+				t.Fatal("no Retry-After header")
+			}
+
+			if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+				t.Fatalf("got Content-Type %q; want application/json", ct)
+			}
+
+			if c.status != http.StatusOK {
+				e := apiError{}
+				if err := json.NewDecoder(rr.Body).Decode(&e); err != nil {
+					t.Fatal(err)
+				}
+
+				if e.Status != c.status || e.Version != apiVersion || e.Error == "" {
+					t.Fatalf("got error %+v", e)
+				}
+			}
+
+			if got, _ := f.API.Usage.Get(apiKey(req)); !reflect.DeepEqual(got, c.usage) {
+				t.Fatalf("got usage %v; want %v", got, c.usage)
+			}
+		})
+	}
+}
+
+func TestAPILimit(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{"no key", "", http.StatusTooManyRequests},
+		{"unknown key", "nope", http.StatusTooManyRequests},
+		{"key", "unlimited", http.StatusOK},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{RateLimiter: mockLimiter()}
+			f.API.Keys = &mockKeys{}
+
+			var key *api.Key
+			h := f.apiLimit("search", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				key, _ = r.Context().Value(apiKeyContext{}).(*api.Key)
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			// the first request empties the bucket of the IP
+			var rr *httptest.ResponseRecorder
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest("GET", "/api/v1/search?q=cats", nil)
+				req.Header.Set(apiKeyHeader, c.key)
+				rr = httptest.NewRecorder()
+				h.ServeHTTP(rr, req)
+			}
+
+			if rr.Code != c.status {
+				t.Fatalf("got %d; want %d", rr.Code, c.status)
+			}
+
+			if c.status == http.StatusOK && (key == nil || key.Key != c.key) {
+				t.Fatalf("got key %+v; want %q", key, c.key)
+			}
+		})
+	}
+}
+
+func TestAPIRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/search?q=cats&key=secret&o=rss&t=maps", nil)
+	req.ParseForm()
+
+	got := apiRequest(req, "images")
+
+	if got.URL.RawQuery != "q=cats&t=images" {
+		t.Fatalf("got %q; want %q", got.URL.RawQuery, "q=cats&t=images")
+	}
+
+	if got.FormValue("t") != "images" || req.URL.Query().Get("key") != "secret" {
+		t.Fatal("the original request was changed")
+	}
+}
+
+func TestAPISearchHandler(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		q      string
+		status int
+		want   apiResponse
+	}{
+		{
+			name:   "no query",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "results",
+			q:      "some query",
+			status: http.StatusOK,
+			want: apiResponse{
+				Version:  apiVersion,
+				Query:    "some query",
+				Language: "en",
+				Region:   "US",
+				Search: &apiSearch{
+					Count:      25,
+					Pagination: apiPagination{Page: 1, Next: 2},
+					Results: []apiResult{
+						{URL: "https://example.com"},
+						{URL: "https://examples.com"},
+					},
+				},
+			},
+		},
+		{
+			name:   "!bang",
+			q:      "!g some query",
+			status: http.StatusOK,
+			want: apiResponse{
+				Version:  apiVersion,
+				Query:    "!g some query",
+				Redirect: "https://encrypted.google.com/search?hl=en&q=some+query",
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := mockAPIFrontend(t)
+
+			req := httptest.NewRequest("GET", "/api/v1/search?l=en&q="+url.QueryEscape(c.q), nil)
+			got := f.apiSearchHandler(httptest.NewRecorder(), req)
+
+			if got.status != c.status || got.template != "json" {
+				t.Fatalf("got %d %q; want %d %q", got.status, got.template, c.status, "json")
+			}
+
+			if c.status != http.StatusOK {
+				return
+			}
+
+			res := got.data.(apiResponse)
+			res.Answer = nil // depends on the instant answers
+			if res.Search != nil {
+				for i := range res.Search.Results {
+					res.Search.Results[i].Title, res.Search.Results[i].Description = "", ""
+				}
+			}
+
+			if !reflect.DeepEqual(res, c.want) {
+				t.Fatalf("got %+v; want %+v", res, c.want)
+			}
+		})
+	}
+}
+
+func TestAPIImagesHandler(t *testing.T) {
+	f := mockAPIFrontend(t)
+	f.Images.Fetcher = &mockAPIImages{}
+	f.Images.Thumbnails = &mockThumbnails{}
+
+	req := httptest.NewRequest("GET", "/api/v1/images?l=en&q=cats&p=2", nil)
+	got := f.apiImagesHandler(httptest.NewRecorder(), req)
+
+	want := &response{
+		status:   http.StatusOK,
+		template: "json",
+		data: apiResponse{
+			Version:  apiVersion,
+			Query:    "cats",
+			Language: "en",
+			Region:   "US",
+			Images: &apiImages{
+				Count:      250,
+				Pagination: apiPagination{Page: 2, Previous: 1, Next: 3},
+				Images: []apiImage{
+					{
+						URL:       "https://example.com/cat.jpg",
+						Alt:       "a cat",
+						Page:      "https://example.com/cats",
+						Width:     640,
+						Height:    480,
+						Thumbnail: proxiedImage("https://example.com/cat.jpg"),
+					},
+					{
+						URL:       "https://example.com/kitten.png",
+						Thumbnail: "/thumbnail/" + mockThumbnailKey + "?u=https%3A%2F%2Fexample.com%2Fkitten.png&s=" + url.QueryEscape(hmacKey("https://example.com/kitten.png")),
+					},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v; want %+v", got, want)
+	}
+}
+
+func TestAPISuggestHandler(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		q    string
+		want apiResponse
+	}{
+		{
+			name: "suggestions",
+			q:    "r",
+			want: apiResponse{
+				Version: apiVersion,
+				Query:   "r",
+				Suggestions: []string{
+					"radiohead",
+					"rage against the machine",
+					"red hot chili peppers",
+					"r.e.m.",
+					"rolling stones",
+					"rollins band",
+					"rusted root",
+				},
+			},
+		},
+		{
+			name: "!bangs",
+			q:    "!gh",
+			want: apiResponse{
+				Version: apiVersion,
+				Query:   "!gh",
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := mockAPIFrontend(t)
+
+			req := httptest.NewRequest("GET", "/api/v1/suggest?q="+url.QueryEscape(c.q), nil)
+			got := f.apiSuggestHandler(httptest.NewRecorder(), req)
+
+			if got.status != http.StatusOK {
+				t.Fatalf("got status %d", got.status)
+			}
+
+			res := got.data.(apiResponse)
+			if c.name == "!bangs" {
+				if len(res.Bangs) == 0 {
+					t.Fatal("no !bangs")
+				}
+				res.Bangs = nil
+			}
+
+			if !reflect.DeepEqual(res, c.want) {
+				t.Fatalf("got %+v; want %+v", res, c.want)
+			}
+		})
+	}
+}
+
+func TestAPIAnswerHandler(t *testing.T) {
+	f := mockAPIFrontend(t)
+
+	req := httptest.NewRequest("GET", "/api/v1/answer?l=en&q=some+query", nil)
+	got := f.apiAnswerHandler(httptest.NewRecorder(), req)
+
+	if got.status != http.StatusOK || got.template != "json" {
+		t.Fatalf("got %d %q", got.status, got.template)
+	}
+
+	res := got.data.(apiResponse)
+	if res.Version != apiVersion || res.Query != "some query" || res.Search != nil {
+		t.Fatalf("got %+v", res)
+	}
+}
+
+func TestAPIUsageHandler(t *testing.T) {
+	f := &Frontend{}
+	f.API.Usage = &mockUsage{}
+	f.API.Usage.Increment("limited", "search")
+	f.API.Usage.Increment("limited", "search")
+	f.API.Usage.Increment("other", "images")
+
+	req := httptest.NewRequest("GET", "/api/v1/usage?key=limited", nil)
+	got := f.apiUsageHandler(httptest.NewRecorder(), req)
+
+	b, err := json.Marshal(got.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"version":"v1","usage":{"search":2}}`
+	if string(b) != want {
+		t.Fatalf("got %v; want %v", string(b), want)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/usage", nil)
+	if got := f.apiUsageHandler(httptest.NewRecorder(), req); got.status != http.StatusUnauthorized {
+		t.Fatalf("got status %d; want %d", got.status, http.StatusUnauthorized)
+	}
+}
+
+func mockAPIFrontend(t *testing.T) *Frontend {
+	matcher := language.NewMatcher([]language.Tag{language.English, language.French})
+
+	bngs, err := bangsFromConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bngs.Suggester = &mockBangSuggester{}
+
+	f := &Frontend{
+		Document: Document{Matcher: matcher},
+		Bangs:    bngs,
+		Instant: &instant.Instant{
+			WikipediaFetcher:     &mockWikipediaFetcher{},
+			StackOverflowFetcher: &mockStackOverflowFetcher{},
+		},
+		Suggest:   &mockSuggester{},
+		Search:    &mockSearch{},
+		Wikipedia: Wikipedia{Matcher: matcher},
+	}
+
+	f.Images.Client = &http.Client{}
+	f.Images.Fetcher = &mockImages{}
+	f.Cache.Cacher = &mockCacher{}
+	f.Cache.Instant = 10 * time.Second
+	f.Cache.Search = 10 * time.Second
+	return f
+}
+
+type mockKeys struct{}
+
+func (m *mockKeys) Get(key string) (*api.Key, error) {
+	switch key {
+	case "unlimited":
+		return &api.Key{Key: key}, nil
+	case "limited":
+		return &api.Key{Key: key, Rate: 2}, nil
+	}
+
+	return nil, api.ErrUnknownKey
+}
+
+type mockUsage struct {
+	sync.Mutex
+	counts map[string]map[string]int64
+}
+
+func (m *mockUsage) Increment(key, endpoint string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.counts == nil {
+		m.counts = map[string]map[string]int64{}
+	}
+	if m.counts[key] == nil {
+		m.counts[key] = map[string]int64{}
+	}
+
+	m.counts[key][endpoint]++
+	return nil
+}
+
+func (m *mockUsage) Get(key string) (map[string]int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	u := map[string]int64{}
+	for endpoint, n := range m.counts[key] {
+		u[endpoint] = n
+	}
+
+	return u, nil
+}
+
+type mockAPIImages struct {
+	mockImages
+}
+
+func (m *mockAPIImages) Fetch(q string, safe bool, f img.Filters, number int, offset int) (*img.Results, error) {
+	return &img.Results{
+		Count: 250,
+		Images: []*img.Image{
+			{
+				ID:     "https://example.com/cat.jpg",
+				Alt:    "a cat",
+				Width:  640,
+				Height: 480,
+				Source: img.Source{Page: "https://example.com/cats"},
+			},
+			{ID: "https://example.com/kitten.png", Thumbnail: mockThumbnailKey},
+		},
+	}, nil
+}
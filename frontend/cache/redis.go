@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
@@ -59,3 +60,9 @@ func (r *Redis) Put(key string, value interface{}, ttl time.Duration) error {
 func seconds(ttl time.Duration) int {
 	return int(ttl / time.Second)
 }
+
+// HealthCheck pings redis
+func (r *Redis) HealthCheck(ctx context.Context) error {
+	_, err := r.do("PING")
+	return err
+}
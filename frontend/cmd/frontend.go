@@ -2,8 +2,10 @@
 package main
 
 import (
+	"crypto/rand"
 	"database/sql"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"path"
@@ -30,7 +32,11 @@ import (
 	"github.com/jivesearch/jivesearch/bangs"
 	"github.com/jivesearch/jivesearch/config"
 	"github.com/jivesearch/jivesearch/frontend"
+	"github.com/jivesearch/jivesearch/frontend/api"
 	"github.com/jivesearch/jivesearch/frontend/cache"
+	"github.com/jivesearch/jivesearch/frontend/i18n"
+	"github.com/jivesearch/jivesearch/frontend/ratelimit"
+	"github.com/jivesearch/jivesearch/health"
 	"github.com/jivesearch/jivesearch/instant"
 	"github.com/jivesearch/jivesearch/instant/discography/musicbrainz"
 	"github.com/jivesearch/jivesearch/instant/parcel"
@@ -41,6 +47,7 @@ import (
 	"github.com/jivesearch/jivesearch/search"
 	"github.com/jivesearch/jivesearch/search/document"
 	img "github.com/jivesearch/jivesearch/search/image"
+	"github.com/jivesearch/jivesearch/search/link"
 	"github.com/jivesearch/jivesearch/search/provider"
 	"github.com/jivesearch/jivesearch/suggest"
 	"github.com/olivere/elastic"
@@ -121,6 +128,7 @@ func main() {
 				Index:  v.GetString("elasticsearch.search.index"),
 				Type:   v.GetString("elasticsearch.search.type"),
 			},
+			MaxSpam: v.GetFloat64("search.spam.max"),
 		}
 	}
 
@@ -128,7 +136,7 @@ func main() {
 		Transport: &http.Transport{
 			Dial: (&nett.Dialer{
 				Resolver: &nett.CacheResolver{TTL: 10 * time.Minute},
-				IPFilter: nett.DualStack,
+				IPFilter: func(ips []net.IP) []net.IP { return nett.DualStack(frontend.PublicIPs(ips)) },
 			}).Dial,
 			DisableKeepAlives: true,
 		},
@@ -139,10 +147,31 @@ func main() {
 		Index:         v.GetString("elasticsearch.images.index"),
 		Type:          v.GetString("elasticsearch.images.type"),
 		NSFWThreshold: .80,
+		MaxCandidates: v.GetInt("images.similar.candidates"),
+	}
+
+	if dir := v.GetString("images.thumbnail.dir"); dir != "" {
+		f.Images.Thumbnails = &img.DiskStore{Dir: dir}
+	}
+
+	// pages linking to a url or domain
+	f.Links = &link.ElasticSearch{
+		Client: client,
+		Index:  v.GetString("elasticsearch.links.index"),
+		Type:   v.GetString("elasticsearch.links.type"),
 	}
 
 	f.MapBoxKey = v.GetString("mapbox.key")
 
+	// JSON API
+	f.API.Anonymous = v.GetBool("api.anonymous")
+	if pth := v.GetString("api.keys"); pth != "" {
+		f.API.Keys, err = api.Open(pth)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	// autocomplete & phrase suggestor
 	f.Suggest = &suggest.ElasticSearch{
 		Client: client,
@@ -237,6 +266,19 @@ func main() {
 	f.Cache.Instant = v.GetDuration("cache.instant")
 	f.Cache.Search = v.GetDuration("cache.search")
 
+	// rate limits (of clients & of API keys) and the usage of API keys
+	store, err := rateLimitStore(v, rds)
+	if err != nil {
+		panic(err)
+	}
+
+	if f.RateLimiter, err = rateLimiter(v, store); err != nil {
+		panic(err)
+	}
+
+	f.API.Limiter = store
+	f.API.Usage = &api.Redis{RedisPool: rds.RedisPool}
+
 	// The database needs to be setup beforehand.
 	db, err := sql.Open("postgres",
 		fmt.Sprintf(
@@ -325,6 +367,11 @@ func main() {
 		WikipediaFetcher: &wikipedia.PostgreSQL{
 			DB: db,
 		},
+		Timeout: v.GetDuration("instant.timeout"),
+		Breaker: &instant.Breaker{
+			Threshold: v.GetInt("instant.breaker.threshold"),
+			Cooldown:  v.GetDuration("instant.breaker.cooldown"),
+		},
 	}
 
 	if err := f.Instant.WikipediaFetcher.Setup(); err != nil {
@@ -345,6 +392,12 @@ func main() {
 	f.Document.Languages = document.Languages(supported)
 	f.Document.Matcher = language.NewMatcher(f.Document.Languages)
 
+	// translations of our UI
+	f.Translations, err = i18n.Open(v.GetString("translations"))
+	if err != nil {
+		panic(err)
+	}
+
 	if v.GetBool("jivedata") {
 		f.LocationFetcher = &location.JiveData{
 			HTTPClient: httpClient,
@@ -352,6 +405,10 @@ func main() {
 		}
 	}
 
+	// health checks of our dependencies for /readyz & /status
+	f.Health = healthMonitor(v, f, client, rds, db)
+	f.Status.Password = v.GetString("status.password")
+
 	log.Info.Printf("Listening at http://127.0.0.1%v", s.Addr)
 	log.Info.Fatal(s.ListenAndServe())
 }
@@ -365,3 +422,93 @@ func languages(cfg config.Provider) ([]language.Tag, []language.Tag) {
 
 	return wikipedia.Languages(supported)
 }
+
+// rateLimitStore keeps the token buckets of our rate limits (nil if they are off)
+func rateLimitStore(v *viper.Viper, rds *cache.Redis) (ratelimit.Store, error) {
+	switch store := v.GetString("ratelimit.store"); store {
+	case "":
+		return nil, nil
+	case "memory":
+		return ratelimit.NewMemory(), nil
+	case "redis":
+		return &ratelimit.Redis{RedisPool: rds.RedisPool}, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", store)
+	}
+}
+
+// rateLimiter limits the requests of each client to our routes.
+// Without a secret the salt of the IP hashes is random and can't
+// be shared with other frontends.
+func rateLimiter(v *viper.Viper, store ratelimit.Store) (*ratelimit.Limiter, error) {
+	if store == nil {
+		return nil, nil
+	}
+
+	l := &ratelimit.Limiter{
+		Store: store,
+		Hasher: &ratelimit.Hasher{
+			Secret: []byte(v.GetString("hmac.secret")),
+			Period: v.GetDuration("ratelimit.period"),
+		},
+		Limits:     map[string]ratelimit.Limit{},
+		TrustProxy: v.GetBool("ratelimit.trustproxy"),
+	}
+
+	if len(l.Hasher.Secret) == 0 {
+		l.Hasher.Secret = make([]byte, 32)
+		if _, err := rand.Read(l.Hasher.Secret); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, route := range []string{"search", "autocomplete", "proxy", "image"} {
+		l.Limits[route] = ratelimit.PerMinute(
+			v.GetInt(fmt.Sprintf("ratelimit.%v.rate", route)), v.GetInt(fmt.Sprintf("ratelimit.%v.burst", route)),
+		)
+	}
+
+	return l, nil
+}
+
+// healthMonitor probes our dependencies. We can't serve results without
+// Elasticsearch & Redis. Without the others an instant answer is missing.
+// Each fetcher that can tell us if it is up knows which API to probe.
+func healthMonitor(v *viper.Viper, f *frontend.Frontend, client *elastic.Client, rds *cache.Redis, db *sql.DB) *health.Monitor {
+	m := &health.Monitor{
+		Timeout:  v.GetDuration("health.timeout"),
+		ReadyTTL: v.GetDuration("health.ready"),
+	}
+
+	m.Add("elasticsearch", true, &document.ElasticSearch{Client: client})
+	m.Add("redis", true, rds)
+	m.Add("postgresql", false, &wikipedia.PostgreSQL{DB: db})
+
+	for _, fetcher := range []struct {
+		name string
+		f    interface{}
+	}{
+		{"breach", f.BreachFetcher},
+		{"congress", f.CongressFetcher},
+		{"crypto", f.CryptoFetcher},
+		{"fx", f.FXFetcher},
+		{"fedex", f.FedExFetcher},
+		{"gdp", f.GDPFetcher},
+		{"github", &f.GitHub},
+		{"location", f.LocationFetcher},
+		{"population", f.PopulationFetcher},
+		{"shortener", f.LinkShortener},
+		{"stackoverflow", f.StackOverflowFetcher},
+		{"stock", f.StockQuoteFetcher},
+		{"ups", f.UPSFetcher},
+		{"usps", f.USPSFetcher},
+		{"weather", f.WeatherFetcher},
+		{"whois", f.WHOISFetcher},
+	} {
+		if c, ok := fetcher.f.(health.HealthChecker); ok {
+			m.Add(fetcher.name, false, c)
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,239 @@
+package frontend
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jivesearch/jivesearch/search"
+	"github.com/jivesearch/jivesearch/search/document"
+)
+
+// Search results as RSS 2.0 or Atom feeds with the OpenSearch response elements
+// http://www.opensearch.org/Specifications/OpenSearch/1.1#OpenSearch_response_elements
+const (
+	atomNS       = "http://www.w3.org/2005/Atom"
+	openSearchNS = "http://a9.com/-/spec/opensearch/1.1/"
+)
+
+// openSearchResponse are the OpenSearch elements of a feed
+type openSearchResponse struct {
+	TotalResults int64           `xml:"opensearch:totalResults"`
+	StartIndex   int             `xml:"opensearch:startIndex"`
+	ItemsPerPage int             `xml:"opensearch:itemsPerPage"`
+	Query        openSearchQuery `xml:"opensearch:Query"`
+}
+
+type openSearchQuery struct {
+	Role        string `xml:"role,attr"`
+	SearchTerms string `xml:"searchTerms,attr"`
+	StartPage   int    `xml:"startPage,attr"`
+}
+
+type rss struct {
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	OpenSearchNS string     `xml:"xmlns:opensearch,attr"`
+	AtomNS       string     `xml:"xmlns:atom,attr"`
+	Channel      rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description"`
+	AtomLinks   []atomNSLink `xml:"atom:link"`
+	openSearchResponse
+	Items []rssItem `xml:"item"`
+}
+
+// atomNSLink is an Atom link in an RSS feed
+type atomNSLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description,omitempty"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate,omitempty"`
+}
+
+type atom struct {
+	XMLName      xml.Name   `xml:"feed"`
+	NS           string     `xml:"xmlns,attr"`
+	OpenSearchNS string     `xml:"xmlns:opensearch,attr"`
+	Title        string     `xml:"title"`
+	ID           string     `xml:"id"`
+	Updated      string     `xml:"updated"`
+	Author       atomAuthor `xml:"author"`
+	Links        []atomLink `xml:"link"`
+	openSearchResponse
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Link    atomLink `xml:"link"`
+	Updated string   `xml:"updated"`
+	Summary string   `xml:"summary,omitempty"`
+}
+
+// feed has what RSS & Atom feeds need from a search
+type feed struct {
+	brand  Brand
+	q      string
+	page   int
+	number int
+	res    *search.Results
+	now    time.Time
+}
+
+func (fd feed) name() string {
+	name := fd.brand.Name
+	if name == "" {
+		name = "Jive Search"
+	}
+
+	return fmt.Sprintf("%v: %v", name, fd.q)
+}
+
+// link is the url of the search with the output of a feed
+func (fd feed) link(o string) string {
+	v := url.Values{}
+	v.Set("q", fd.q)
+	if o != "" {
+		v.Set("o", o)
+	}
+	if fd.page > 1 {
+		v.Set("p", strconv.Itoa(fd.page))
+	}
+
+	return fd.brand.Host + "/?" + v.Encode()
+}
+
+func (fd feed) openSearch() openSearchResponse {
+	return openSearchResponse{
+		TotalResults: fd.res.Count,
+		StartIndex:   (fd.page-1)*fd.number + 1,
+		ItemsPerPage: fd.number,
+		Query: openSearchQuery{
+			Role:        "request",
+			SearchTerms: fd.q,
+			StartPage:   fd.page,
+		},
+	}
+}
+
+func (fd feed) rss() rss {
+	r := rss{
+		Version:      "2.0",
+		OpenSearchNS: openSearchNS,
+		AtomNS:       atomNS,
+		Channel: rssChannel{
+			Title:       fd.name(),
+			Link:        fd.link(""),
+			Description: fmt.Sprintf("Search results for %q", fd.q),
+			AtomLinks: []atomNSLink{
+				{Rel: "self", Type: "application/rss+xml", Href: fd.link("rss")},
+				{Rel: "search", Type: "application/opensearchdescription+xml", Href: fd.brand.Host + "/opensearch.xml"},
+			},
+			openSearchResponse: fd.openSearch(),
+			Items:              []rssItem{},
+		},
+	}
+
+	for _, doc := range fd.res.Documents {
+		item := rssItem{
+			Title:       doc.Title,
+			Link:        doc.ID,
+			Description: doc.Description,
+			GUID:        doc.ID,
+		}
+
+		if t, ok := documentDate(doc); ok {
+			item.PubDate = t.Format(time.RFC1123Z)
+		}
+
+		r.Channel.Items = append(r.Channel.Items, item)
+	}
+
+	return r
+}
+
+func (fd feed) atom() atom {
+	a := atom{
+		NS:           atomNS,
+		OpenSearchNS: openSearchNS,
+		Title:        fd.name(),
+		ID:           fd.link(""),
+		Author:       atomAuthor{Name: fd.name()},
+		Links: []atomLink{
+			{Href: fd.link("")},
+			{Rel: "self", Type: "application/atom+xml", Href: fd.link("atom")},
+			{Rel: "search", Type: "application/opensearchdescription+xml", Href: fd.brand.Host + "/opensearch.xml"},
+		},
+		openSearchResponse: fd.openSearch(),
+		Entries:            []atomEntry{},
+	}
+
+	// a feed was last updated when its newest entry was
+	updated := time.Time{}
+	for _, doc := range fd.res.Documents {
+		e := atomEntry{
+			Title:   doc.Title,
+			ID:      doc.ID,
+			Link:    atomLink{Href: doc.ID},
+			Summary: doc.Description,
+		}
+
+		// Atom requires a date for every entry
+		t, ok := documentDate(doc)
+		if !ok {
+			t = fd.now
+		}
+		if t.After(updated) {
+			updated = t
+		}
+
+		e.Updated = t.Format(time.RFC3339)
+		a.Entries = append(a.Entries, e)
+	}
+
+	if updated.IsZero() {
+		updated = fd.now
+	}
+
+	a.Updated = updated.Format(time.RFC3339)
+	return a
+}
+
+// documentDate is when a document was published or, if we
+// don't know that, when we crawled it
+func documentDate(doc *document.Document) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, doc.Date); err == nil {
+		return t.UTC(), true
+	}
+
+	if t, err := time.Parse("20060102", doc.Crawled); err == nil {
+		return t.UTC(), true
+	}
+
+	return time.Time{}, false
+}
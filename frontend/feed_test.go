@@ -0,0 +1,125 @@
+package frontend
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jivesearch/jivesearch/search"
+	"github.com/jivesearch/jivesearch/search/document"
+)
+
+func TestFeed(t *testing.T) {
+	fd := feed{
+		brand:  Brand{Name: "Jive Search", Host: "https://jivesearch.com"},
+		q:      "site:golang.org release",
+		page:   2,
+		number: 25,
+		now:    time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC),
+		res: &search.Results{
+			Count: 52,
+			Documents: []*document.Document{
+				{
+					ID: "https://golang.org/doc/go1.10",
+					Content: document.Content{
+						Title:       "Go 1.10 Release Notes",
+						Description: "The latest Go release & its changes",
+						Date:        "2018-02-16T00:00:00Z",
+					},
+				},
+				{ID: "https://golang.org/doc/devel/release.html", Crawled: "20180301"},
+				{ID: "https://golang.org/dl/"},
+			},
+		},
+	}
+
+	for _, c := range []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			"rss", fd.rss(),
+			`<rss version="2.0" xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/" xmlns:atom="http://www.w3.org/2005/Atom">` +
+				`<channel><title>Jive Search: site:golang.org release</title>` +
+				`<link>https://jivesearch.com/?p=2&amp;q=site%3Agolang.org+release</link>` +
+				`<description>Search results for &#34;site:golang.org release&#34;</description>` +
+				`<atom:link rel="self" type="application/rss+xml" href="https://jivesearch.com/?o=rss&amp;p=2&amp;q=site%3Agolang.org+release"></atom:link>` +
+				`<atom:link rel="search" type="application/opensearchdescription+xml" href="https://jivesearch.com/opensearch.xml"></atom:link>` +
+				`<opensearch:totalResults>52</opensearch:totalResults><opensearch:startIndex>26</opensearch:startIndex>` +
+				`<opensearch:itemsPerPage>25</opensearch:itemsPerPage>` +
+				`<opensearch:Query role="request" searchTerms="site:golang.org release" startPage="2"></opensearch:Query>` +
+				`<item><title>Go 1.10 Release Notes</title><link>https://golang.org/doc/go1.10</link>` +
+				`<description>The latest Go release &amp; its changes</description><guid>https://golang.org/doc/go1.10</guid>` +
+				`<pubDate>Fri, 16 Feb 2018 00:00:00 +0000</pubDate></item>` +
+				`<item><title></title><link>https://golang.org/doc/devel/release.html</link><guid>https://golang.org/doc/devel/release.html</guid>` +
+				`<pubDate>Thu, 01 Mar 2018 00:00:00 +0000</pubDate></item>` +
+				`<item><title></title><link>https://golang.org/dl/</link><guid>https://golang.org/dl/</guid></item>` +
+				`</channel></rss>`,
+		},
+		{
+			"atom", fd.atom(),
+			`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/">` +
+				`<title>Jive Search: site:golang.org release</title>` +
+				`<id>https://jivesearch.com/?p=2&amp;q=site%3Agolang.org+release</id>` +
+				`<updated>2018-03-04T05:06:07Z</updated>` +
+				`<author><name>Jive Search: site:golang.org release</name></author>` +
+				`<link href="https://jivesearch.com/?p=2&amp;q=site%3Agolang.org+release"></link>` +
+				`<link rel="self" type="application/atom+xml" href="https://jivesearch.com/?o=atom&amp;p=2&amp;q=site%3Agolang.org+release"></link>` +
+				`<link rel="search" type="application/opensearchdescription+xml" href="https://jivesearch.com/opensearch.xml"></link>` +
+				`<opensearch:totalResults>52</opensearch:totalResults><opensearch:startIndex>26</opensearch:startIndex>` +
+				`<opensearch:itemsPerPage>25</opensearch:itemsPerPage>` +
+				`<opensearch:Query role="request" searchTerms="site:golang.org release" startPage="2"></opensearch:Query>` +
+				`<entry><title>Go 1.10 Release Notes</title><id>https://golang.org/doc/go1.10</id>` +
+				`<link href="https://golang.org/doc/go1.10"></link><updated>2018-02-16T00:00:00Z</updated>` +
+				`<summary>The latest Go release &amp; its changes</summary></entry>` +
+				`<entry><title></title><id>https://golang.org/doc/devel/release.html</id>` +
+				`<link href="https://golang.org/doc/devel/release.html"></link><updated>2018-03-01T00:00:00Z</updated></entry>` +
+				`<entry><title></title><id>https://golang.org/dl/</id>` +
+				`<link href="https://golang.org/dl/"></link><updated>2018-03-04T05:06:07Z</updated></entry>` +
+				`</feed>`,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := xml.Marshal(c.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != c.want {
+				t.Fatalf("got %v\nwant %v", string(b), c.want)
+			}
+		})
+	}
+}
+
+func TestSearchHandlerFeed(t *testing.T) {
+	for _, o := range []string{"rss", "atom"} {
+		t.Run(o, func(t *testing.T) {
+			f := mockAPIFrontend(t)
+
+			req := httptest.NewRequest("GET", "/?l=en&q=some+query&o="+o, nil)
+			got := f.searchHandler(httptest.NewRecorder(), req)
+
+			if got.status != http.StatusOK || got.template != o {
+				t.Fatalf("got %d %q; want %d %q", got.status, got.template, http.StatusOK, o)
+			}
+
+			var total int64
+			switch v := got.data.(type) {
+			case rss:
+				total = v.Channel.TotalResults
+			case atom:
+				total = v.TotalResults
+			default:
+				t.Fatalf("got %T", got.data)
+			}
+
+			if total != mockSearchResults.Count {
+				t.Fatalf("got %d results; want %d", total, mockSearchResults.Count)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ package frontend
 import (
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -11,11 +12,16 @@ import (
 	"time"
 
 	"github.com/jivesearch/jivesearch/bangs"
+	"github.com/jivesearch/jivesearch/frontend/api"
 	"github.com/jivesearch/jivesearch/frontend/cache"
+	"github.com/jivesearch/jivesearch/frontend/i18n"
+	"github.com/jivesearch/jivesearch/frontend/ratelimit"
+	"github.com/jivesearch/jivesearch/health"
 	"github.com/jivesearch/jivesearch/instant"
 	"github.com/jivesearch/jivesearch/log"
 	"github.com/jivesearch/jivesearch/search"
 	img "github.com/jivesearch/jivesearch/search/image"
+	"github.com/jivesearch/jivesearch/search/link"
 	"github.com/jivesearch/jivesearch/suggest"
 	"github.com/oxtoacart/bpool"
 	"golang.org/x/text/language"
@@ -25,22 +31,36 @@ import (
 type Frontend struct {
 	Brand
 	Document
+	API struct {
+		Keys      api.Store       // nil if we don't issue keys
+		Anonymous bool            // allow requests without a key
+		Limiter   ratelimit.Store // the token buckets of our keys (nil to not limit them)
+		Usage     api.Usage       // nil to not count the requests of our keys
+	}
 	*bangs.Bangs
 	Cache struct {
 		cache.Cacher
 		Instant time.Duration
 		Search  time.Duration
 	}
+	Health *health.Monitor // the dependencies of /readyz & /status (optional)
 	Images struct {
 		img.Fetcher
 		*http.Client
+		Thumbnails img.Store // thumbnails made by the images command (optional)
 	}
 	*instant.Instant
+	Links       link.Fetcher // pages linking to a url or domain (optional)
 	MapBoxKey   string
 	Onion       string
 	ProxyClient *http.Client
+	RateLimiter *ratelimit.Limiter // optional
 	Suggest     suggest.Suggester
 	Search      search.Fetcher
+	Status      struct {
+		Password string // of /status, which is turned off without one
+	}
+	Translations *i18n.Catalog // the catalogs of our UI (English only if nil)
 	Wikipedia
 	GitHub
 }
@@ -104,14 +124,27 @@ func (fn appHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			defer bufpool.Put(buf)
 
 			switch rsp.template {
-			case "json":
-				w.Header().Set("Content-Type", "application/json") // the default for json is utf-8
+			case "json", "suggestions":
+				switch rsp.template {
+				case "suggestions": // OpenSearch suggestions for browsers
+					w.Header().Set("Content-Type", "application/x-suggestions+json")
+				default:
+					w.Header().Set("Content-Type", "application/json") // the default for json is utf-8
+				}
 				err := json.NewEncoder(buf).Encode(rsp.data)
 				if err != nil {
 					rsp.status, rsp.err = http.StatusInternalServerError, err
 					errHandler(w, rsp)
 					return
 				}
+			case "rss", "atom":
+				w.Header().Set("Content-Type", "application/"+rsp.template+"+xml; charset=utf-8")
+				buf.WriteString(xml.Header)
+				if err := xml.NewEncoder(buf).Encode(rsp.data); err != nil {
+					rsp.status, rsp.err = http.StatusInternalServerError, err
+					errHandler(w, rsp)
+					return
+				}
 			case "proxy_css":
 				w.Header().Set("Content-Type", "text/css; charset=utf-8")
 
@@ -180,7 +213,7 @@ func (fn appHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			default: // !bang
 				http.Redirect(w, r, rsp.redirect, http.StatusFound)
 			}
-		case http.StatusBadRequest, http.StatusInternalServerError:
+		case http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusInternalServerError:
 			errHandler(w, rsp)
 		default:
 			log.Info.Printf("Unknown status %d\n", rsp.status)
@@ -190,13 +223,27 @@ func (fn appHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func errHandler(w http.ResponseWriter, rsp *response) {
 	switch rsp.status {
-	case http.StatusBadRequest:
-		log.Debug.Println(rsp.err)
 	case http.StatusInternalServerError:
 		log.Info.Println(rsp.err)
+	default:
+		log.Debug.Println(rsp.err)
 	}
 
-	http.Error(w, http.StatusText(rsp.status), rsp.status)
+	if rsp.template != "json" {
+		http.Error(w, http.StatusText(rsp.status), rsp.status)
+		return
+	}
+
+	// the JSON API tells clients what they did wrong but not what we did wrong
+	msg := http.StatusText(rsp.status)
+	if rsp.err != nil && rsp.status != http.StatusInternalServerError {
+		msg = rsp.err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(rsp.status)
+	json.NewEncoder(w).Encode(apiError{Version: apiVersion, Status: rsp.status, Error: msg})
 }
 
 func (f *Frontend) autocompleteHandler(w http.ResponseWriter, r *http.Request) *response {
@@ -323,6 +370,33 @@ var ParseTemplates = func() {
 				"templates/proxy.html",
 			),
 	)
+	templates["settings"] = template.Must(
+		template.New("base.html").
+			Funcs(funcMap).
+			ParseFiles(
+				"templates/base.html",
+				"templates/search_form.html",
+				"templates/settings.html",
+			),
+	)
+	templates["ratelimit"] = template.Must(
+		template.New("base.html").
+			Funcs(funcMap).
+			ParseFiles(
+				"templates/base.html",
+				"templates/search_form.html",
+				"templates/ratelimit.html",
+			),
+	)
+	templates["status"] = template.Must(
+		template.New("base.html").
+			Funcs(funcMap).
+			ParseFiles(
+				"templates/base.html",
+				"templates/search_form.html",
+				"templates/status.html",
+			),
+	)
 	templates["search"] = template.Must(
 		template.New("base.html").
 			Funcs(funcMap).
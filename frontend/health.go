@@ -0,0 +1,91 @@
+package frontend
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/jivesearch/jivesearch/health"
+)
+
+type status struct {
+	Brand        `json:"-"`
+	*Context     `json:"-"`
+	Ready        bool            `json:"ready"`
+	Dependencies []health.Status `json:"dependencies"`
+}
+
+// healthzHandler tells a load balancer that we are alive ("/healthz")
+func (f *Frontend) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n"))
+}
+
+// readyzHandler tells a load balancer if our critical dependencies
+// are up ("/readyz"). It doesn't tell it which are down.
+func (f *Frontend) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if f.Health != nil && !f.Health.IsReady(r.Context()) {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n"))
+}
+
+// statusHandler probes all of our dependencies for an operator ("/status").
+// It is behind basic auth (any user with the status password) and
+// is turned off without a password.
+func (f *Frontend) statusHandler(w http.ResponseWriter, r *http.Request) {
+	if f.Status.Password == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	_, password, _ := r.BasicAuth()
+	if subtle.ConstantTimeCompare([]byte(password), []byte(f.Status.Password)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="status"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	s := status{
+		Brand:        f.Brand,
+		Context:      &Context{},
+		Dependencies: []health.Status{},
+	}
+
+	if f.Health != nil {
+		s.Dependencies = f.Health.Check(r.Context(), false)
+	}
+	s.Ready = health.Ready(s.Dependencies)
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	code := http.StatusOK
+	if !s.Ready {
+		code = http.StatusServiceUnavailable
+	}
+
+	if r.FormValue("o") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(s)
+		return
+	}
+
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+
+	if err := templates["status"].Execute(buf, s); err != nil {
+		errHandler(w, &response{status: http.StatusInternalServerError, err: err})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	buf.WriteTo(w)
+}
@@ -0,0 +1,123 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jivesearch/jivesearch/health"
+)
+
+type mockHealthChecker struct {
+	err error
+}
+
+func (m *mockHealthChecker) HealthCheck(ctx context.Context) error {
+	return m.err
+}
+
+func mockMonitor(critical, optional error) *health.Monitor {
+	m := &health.Monitor{}
+	m.Add("elasticsearch", true, &mockHealthChecker{critical})
+	m.Add("stackexchange", false, &mockHealthChecker{optional})
+	return m
+}
+
+func TestHealthzHandler(t *testing.T) {
+	f := &Frontend{Health: mockMonitor(errors.New("down"), nil)}
+
+	rec := httptest.NewRecorder()
+	f.healthzHandler(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d; want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestReadyzHandler(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		health *health.Monitor
+		want   int
+	}{
+		{"no dependencies", nil, http.StatusOK},
+		{"up", mockMonitor(nil, nil), http.StatusOK},
+		{"optional down", mockMonitor(nil, errors.New("down")), http.StatusOK},
+		{"critical down", mockMonitor(errors.New("down"), nil), http.StatusServiceUnavailable},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{Health: c.health}
+
+			rec := httptest.NewRecorder()
+			f.readyzHandler(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+			if rec.Code != c.want {
+				t.Fatalf("got %d; want %d", rec.Code, c.want)
+			}
+
+			if strings.Contains(rec.Body.String(), "elasticsearch") {
+				t.Fatal("readyz shouldn't tell which dependency is down")
+			}
+		})
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	ParseTemplates()
+
+	for _, c := range []struct {
+		name     string
+		password string
+		user     string
+		pass     string
+		output   string
+		want     int
+		contains string
+	}{
+		{"turned off", "", "", "", "", http.StatusNotFound, ""},
+		{"no auth", "secret", "", "", "", http.StatusUnauthorized, ""},
+		{"wrong password", "secret", "admin", "wrong", "", http.StatusUnauthorized, ""},
+		{"html", "secret", "admin", "secret", "", http.StatusOK, "<td>stackexchange</td>"},
+		{"json", "secret", "admin", "secret", "json", http.StatusOK, `"name":"stackexchange"`},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{Health: mockMonitor(nil, errors.New("timeout"))}
+			f.Status.Password = c.password
+
+			req := httptest.NewRequest("GET", "/status?o="+c.output, nil)
+			if c.user != "" {
+				req.SetBasicAuth(c.user, c.pass)
+			}
+
+			rec := httptest.NewRecorder()
+			f.statusHandler(rec, req)
+
+			if rec.Code != c.want {
+				t.Fatalf("got %d; want %d", rec.Code, c.want)
+			}
+
+			if c.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("expected a WWW-Authenticate header")
+			}
+
+			if !strings.Contains(rec.Body.String(), c.contains) {
+				t.Fatalf("expected %q in %v", c.contains, rec.Body.String())
+			}
+
+			if c.output == "json" {
+				s := status{}
+				if err := json.NewDecoder(rec.Body).Decode(&s); err != nil {
+					t.Fatal(err)
+				}
+
+				if !s.Ready || len(s.Dependencies) != 2 || s.Dependencies[1].Up || s.Dependencies[1].LastError != "timeout" {
+					t.Fatalf("unexpected status %+v", s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package frontend
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/jivesearch/jivesearch/frontend/i18n"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// localize sets the language of our UI for a user. It is matched against
+// our catalogs, not the languages we have documents for, and not the
+// language of the query. Without catalogs our UI is in English.
+func (f *Frontend) localize(c *Context, r *http.Request) {
+	if f.Translations == nil {
+		return
+	}
+
+	c.locale = f.Translations.Match(f.userLanguages(r)...)
+	c.printer = f.Translations.Printer(c.locale)
+}
+
+// localizer is the printer of a Context. An English one if it wasn't localized.
+func (c *Context) localizer() *message.Printer {
+	if c == nil || c.printer == nil {
+		return message.NewPrinter(i18n.Source)
+	}
+
+	return c.printer
+}
+
+// Locale is the language of our UI (e.g. for <html lang="">)
+func (c *Context) Locale() string {
+	if c == nil || c.locale == language.Und {
+		return i18n.Source.String()
+	}
+
+	return c.locale.String()
+}
+
+// Tr translates a string of our templates. args are formatted as
+// they are by fmt.Sprintf, except that numbers are localized.
+func (c *Context) Tr(key string, args ...interface{}) string {
+	return c.localizer().Sprintf(key, args...)
+}
+
+// TrHTML translates a string with markup. Our catalogs are trusted like
+// our templates are but the args are escaped.
+func (c *Context) TrHTML(key string, args ...interface{}) template.HTML {
+	for i, a := range args {
+		switch a.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			continue // so they are still localized
+		}
+
+		args[i] = template.HTMLEscapeString(fmt.Sprint(a))
+	}
+
+	return template.HTML(c.localizer().Sprintf(key, args...))
+}
+
+// FormatNumber formats a number with the separators of the user's language
+func (c *Context) FormatNumber(n interface{}) string {
+	return c.localizer().Sprint(number.Decimal(n))
+}
+
+// FormatDate formats a time in the user's language. The layout is translated too.
+func (c *Context) FormatDate(t time.Time, layout string) string {
+	return i18n.Date(c.localizer(), t, layout)
+}
@@ -0,0 +1,96 @@
+// Command i18n lists the strings of our templates that a catalog doesn't translate.
+// Run it from the frontend directory. With -w the missing strings are added
+// to the catalogs with an empty translation for translators to fill in.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/jivesearch/jivesearch/frontend/i18n"
+	"github.com/jivesearch/jivesearch/instant/weather"
+	"github.com/jivesearch/jivesearch/search/link"
+	"golang.org/x/text/language"
+)
+
+// keys are the strings of our templates, the labels of our instant answers
+// & the descriptions of our link results
+func keys(templates string) ([]string, error) {
+	ks, err := i18n.ExtractDir(templates)
+	if err != nil {
+		return nil, err
+	}
+
+	ks = append(ks, i18n.DateKeys...)
+	for _, d := range weather.Descriptions {
+		ks = append(ks, string(d))
+	}
+	ks = append(ks, link.Descriptions...)
+
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, k := range ks {
+		if !seen[k] {
+			seen[k] = true
+			unique = append(unique, k)
+		}
+	}
+
+	sort.Strings(unique)
+	return unique, nil
+}
+
+// report writes the untranslated strings of each catalog and tells us if there were any
+func report(w io.Writer, catalogs string, ks []string) (bool, error) {
+	cats, err := i18n.Load(catalogs)
+	if err != nil {
+		return false, err
+	}
+
+	missing := i18n.Untranslated(cats, ks)
+
+	tags := []language.Tag{}
+	for tag := range missing {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].String() < tags[j].String() })
+
+	for _, tag := range tags {
+		fmt.Fprintf(w, "%v: %d untranslated\n", tag, len(missing[tag]))
+		for _, k := range missing[tag] {
+			fmt.Fprintf(w, "  %q\n", k)
+		}
+	}
+
+	return len(tags) > 0, nil
+}
+
+func main() {
+	templates := flag.String("templates", "templates", "the directory of our templates")
+	catalogs := flag.String("catalogs", "i18n/locales", "the directory of our catalogs")
+	write := flag.Bool("w", false, "add the untranslated strings to the catalogs")
+	flag.Parse()
+
+	ks, err := keys(*templates)
+	if err != nil {
+		panic(err)
+	}
+
+	if *write {
+		if err := i18n.Update(*catalogs, ks); err != nil {
+			panic(err)
+		}
+	}
+
+	untranslated, err := report(os.Stdout, *catalogs, ks)
+	if err != nil {
+		panic(err)
+	}
+
+	if untranslated {
+		os.Exit(1)
+	}
+}
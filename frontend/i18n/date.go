@@ -0,0 +1,63 @@
+package i18n
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/text/message"
+)
+
+// dateNames are the names in a time layout that Go only formats in English.
+// Longer names come first so that "January" isn't mistaken for "Jan".
+var dateNames = []struct {
+	layout string
+	name   func(t time.Time) string
+}{
+	{"January", func(t time.Time) string { return t.Month().String() }},
+	{"Monday", func(t time.Time) string { return t.Weekday().String() }},
+	{"Jan", func(t time.Time) string { return t.Month().String()[:3] }},
+	{"Mon", func(t time.Time) string { return t.Weekday().String()[:3] }},
+}
+
+// DateKeys are the month and day names that Date translates
+var DateKeys = func() []string {
+	keys := []string{}
+	for m := time.January; m <= time.December; m++ {
+		keys = append(keys, m.String(), m.String()[:3])
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		keys = append(keys, d.String(), d.String()[:3])
+	}
+	return keys
+}()
+
+// Date formats a time in a printer's language. The layout is translated
+// first (e.g. "Jan 2, 2006" is "2 Jan 2006" in French) and then the
+// names of the month and day.
+func Date(p *message.Printer, t time.Time, layout string) string {
+	layout = p.Sprintf(layout)
+
+	// names are swapped for placeholders that Format leaves alone
+	names := []string{}
+	for _, dn := range dateNames {
+		if !strings.Contains(layout, dn.layout) {
+			continue
+		}
+
+		layout = strings.Replace(layout, dn.layout, placeholder(len(names)), -1)
+		names = append(names, p.Sprintf(dn.name(t)))
+	}
+
+	s := t.Format(layout)
+	for i, name := range names {
+		s = strings.Replace(s, placeholder(i), name, -1)
+	}
+
+	return s
+}
+
+// placeholder is a character from the private use area as
+// digits would be mistaken for parts of the layout
+func placeholder(i int) string {
+	return string(rune(0xE000 + i))
+}
@@ -0,0 +1,207 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"text/template/parse"
+
+	"github.com/spf13/afero"
+	"golang.org/x/text/language"
+)
+
+// reUndefined is the parser's error for a function it doesn't know
+var reUndefined = regexp.MustCompile(`function "([^"]+)" not defined`)
+
+// funcs are the template methods that translate a string and which of their args it is
+var funcs = map[string]int{"Tr": 1, "TrHTML": 1, "FormatDate": 2}
+
+// Extract finds the strings that a template translates, e.g. "Search" in
+// {{.Tr "Search"}}, "<em>%v</em>" in {{$.TrHTML "<em>%v</em>" .Q}} or the
+// layout of {{.FormatDate .Created "Jan 2, 2006"}}.
+func Extract(name, text string) ([]string, error) {
+	// The parser needs to know the functions of a template (e.g. our filters).
+	// We don't so each one it doesn't know is stubbed out and we parse again.
+	stubs := map[string]interface{}{}
+
+	var trees map[string]*parse.Tree
+	for {
+		var err error
+		trees, err = parse.Parse(name, text, "", "", stubs)
+		if err == nil {
+			break
+		}
+
+		m := reUndefined.FindStringSubmatch(err.Error())
+		if m == nil || stubs[m[1]] != nil {
+			return nil, err
+		}
+		stubs[m[1]] = func() {}
+	}
+
+	found := map[string]bool{}
+	for _, tree := range trees {
+		walk(tree.Root, found)
+	}
+
+	return sorted(found), nil
+}
+
+// ExtractDir finds the strings that the templates of a directory translate
+func ExtractDir(dir string) ([]string, error) {
+	found := map[string]bool{}
+
+	for _, pattern := range []string{"*.html", "*.xml"} {
+		files, err := afero.Glob(appFs, filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range files {
+			b, err := afero.ReadFile(appFs, f)
+			if err != nil {
+				return nil, err
+			}
+
+			keys, err := Extract(filepath.Base(f), string(b))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, k := range keys {
+				found[k] = true
+			}
+		}
+	}
+
+	return sorted(found), nil
+}
+
+// Untranslated are the keys that each catalog doesn't have a translation for
+func Untranslated(catalogs map[language.Tag]Messages, keys []string) map[language.Tag][]string {
+	missing := map[language.Tag][]string{}
+
+	for tag, msgs := range catalogs {
+		if tag == Source {
+			continue
+		}
+
+		for _, k := range keys {
+			if msgs[k] == "" {
+				missing[tag] = append(missing[tag], k)
+			}
+		}
+	}
+
+	return missing
+}
+
+// Update adds the keys that are missing from the catalogs of a directory
+// with an empty translation for translators to fill in.
+func Update(dir string, keys []string) error {
+	catalogs, err := Load(dir)
+	if err != nil {
+		return err
+	}
+
+	for tag, msgs := range catalogs {
+		if tag == Source {
+			continue
+		}
+
+		for _, k := range keys {
+			if _, ok := msgs[k]; !ok {
+				msgs[k] = ""
+			}
+		}
+
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(msgs); err != nil {
+			return err
+		}
+
+		if err := afero.WriteFile(appFs, filepath.Join(dir, tag.String()+".json"), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walk(n parse.Node, found map[string]bool) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			walk(c, found)
+		}
+	case *parse.ActionNode:
+		walk(n.Pipe, found)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, found)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, found)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, found)
+	case *parse.TemplateNode:
+		walk(n.Pipe, found)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			walk(c, found)
+		}
+	case *parse.CommandNode:
+		if i := translates(n.Args[0]); i > 0 && i < len(n.Args) {
+			if s, ok := n.Args[i].(*parse.StringNode); ok {
+				found[s.Text] = true
+			}
+		}
+		for _, a := range n.Args {
+			walk(a, found)
+		}
+	}
+}
+
+func walkBranch(b *parse.BranchNode, found map[string]bool) {
+	walk(b.Pipe, found)
+	walk(b.List, found)
+	walk(b.ElseList, found)
+}
+
+// translates tells us which arg of a command is translated if its node
+// is a call to one of our translation methods (.Tr, $.Tr, $context.Tr...)
+func translates(n parse.Node) int {
+	var idents []string
+
+	switch n := n.(type) {
+	case *parse.FieldNode:
+		idents = n.Ident
+	case *parse.VariableNode:
+		idents = n.Ident[1:]
+	}
+
+	if len(idents) == 0 {
+		return 0
+	}
+
+	return funcs[idents[len(idents)-1]]
+}
+
+func sorted(m map[string]bool) []string {
+	s := []string{}
+	for k := range m {
+		s = append(s, k)
+	}
+
+	sort.Strings(s)
+	return s
+}
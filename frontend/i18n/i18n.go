@@ -0,0 +1,127 @@
+// Package i18n translates our UI. A catalog is a JSON file named after its
+// language (e.g. "fr.json") that maps the English strings of our templates
+// to their translation. Strings that aren't translated are shown in English.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+var appFs = afero.NewOsFs()
+
+// Source is the language of the strings of our templates
+var Source = language.English
+
+// Messages are the translations of a catalog, keyed by their English string
+type Messages map[string]string
+
+// Catalog has the translations of our UI
+type Catalog struct {
+	*catalog.Builder
+	languages []language.Tag
+	matcher   language.Matcher
+}
+
+// Open loads the catalogs of a directory
+func Open(dir string) (*Catalog, error) {
+	c := &Catalog{
+		Builder:   catalog.NewBuilder(catalog.Fallback(Source)),
+		languages: []language.Tag{Source}, // the first language is our default
+	}
+
+	catalogs, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for tag, msgs := range catalogs {
+		for k, v := range msgs {
+			if v == "" { // not translated yet
+				continue
+			}
+
+			if err := c.SetString(tag, k, v); err != nil {
+				return nil, err
+			}
+		}
+
+		if tag != Source {
+			c.languages = append(c.languages, tag)
+		}
+	}
+
+	sort.Slice(c.languages[1:], func(i, j int) bool {
+		return c.languages[i+1].String() < c.languages[j+1].String()
+	})
+
+	c.matcher = language.NewMatcher(c.languages)
+	return c, nil
+}
+
+// Languages are the languages we have a catalog for
+func (c *Catalog) Languages() []language.Tag {
+	return c.languages
+}
+
+// Match is the first of the preferred languages we have a catalog for.
+// A nil Catalog (or no match) gets our Source language.
+func (c *Catalog) Match(preferred ...language.Tag) language.Tag {
+	if c == nil {
+		return Source
+	}
+
+	_, i, conf := c.matcher.Match(preferred...)
+	if conf == language.No {
+		return Source
+	}
+
+	return c.languages[i]
+}
+
+// Printer translates to a language. Numbers are formatted for it too.
+func (c *Catalog) Printer(tag language.Tag) *message.Printer {
+	if c == nil {
+		return message.NewPrinter(tag)
+	}
+
+	return message.NewPrinter(tag, message.Catalog(c.Builder))
+}
+
+// Load reads the catalogs of a directory by language
+func Load(dir string) (map[language.Tag]Messages, error) {
+	files, err := afero.Glob(appFs, filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	catalogs := map[language.Tag]Messages{}
+	for _, f := range files {
+		tag, err := language.Parse(strings.TrimSuffix(filepath.Base(f), ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", f, err)
+		}
+
+		b, err := afero.ReadFile(appFs, f)
+		if err != nil {
+			return nil, err
+		}
+
+		msgs := Messages{}
+		if err := json.Unmarshal(b, &msgs); err != nil {
+			return nil, fmt.Errorf("%v: %v", f, err)
+		}
+
+		catalogs[tag] = msgs
+	}
+
+	return catalogs, nil
+}
@@ -0,0 +1,176 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"golang.org/x/text/language"
+)
+
+func mockCatalogs(t *testing.T) {
+	appFs = afero.NewMemMapFs()
+
+	for name, content := range map[string]string{
+		"locales/fr.json": `{"Search": "Rechercher", "%d results": "%d résultats", "Jan 2, 2006": "2 Jan 2006", "Jan": "janv.", "Settings": ""}`,
+		"locales/de.json": `{"Search": "Suchen"}`,
+	} {
+		if err := afero.WriteFile(appFs, name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	mockCatalogs(t)
+
+	c, err := Open("locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []language.Tag{language.English, language.German, language.French}
+	if !reflect.DeepEqual(c.Languages(), want) {
+		t.Fatalf("got %v; want %v", c.Languages(), want)
+	}
+
+	for _, tt := range []struct {
+		preferred []language.Tag
+		key       string
+		args      []interface{}
+		want      string
+	}{
+		{[]language.Tag{language.French}, "Search", nil, "Rechercher"},
+		{[]language.Tag{language.MustParse("fr-CA")}, "Search", nil, "Rechercher"},
+		{[]language.Tag{language.Japanese, language.German}, "Search", nil, "Suchen"},
+		{[]language.Tag{language.Japanese}, "Search", nil, "Search"},
+		{nil, "Search", nil, "Search"},
+		{[]language.Tag{language.French}, "%d results", []interface{}{1234}, "1\u00a0234 résultats"},
+		{[]language.Tag{language.English}, "%d results", []interface{}{1234}, "1,234 results"},
+		{[]language.Tag{language.French}, "Settings", nil, "Settings"}, // not translated yet
+		{[]language.Tag{language.French}, "Not in the catalog", nil, "Not in the catalog"},
+	} {
+		t.Run(tt.key, func(t *testing.T) {
+			p := c.Printer(c.Match(tt.preferred...))
+			if got := p.Sprintf(tt.key, tt.args...); got != tt.want {
+				t.Fatalf("got %q; want %q", got, tt.want)
+			}
+		})
+	}
+
+	// without catalogs
+	var nilCatalog *Catalog
+	if got := nilCatalog.Match(language.French); got != Source {
+		t.Fatalf("got %v; want %v", got, Source)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"locales/notalanguage!.json": `{}`,
+		"locales/fr.json":            `{"Search": `,
+	} {
+		appFs = afero.NewMemMapFs()
+		if err := afero.WriteFile(appFs, name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Open("locales"); err == nil {
+			t.Fatalf("expected an error for %v", name)
+		}
+	}
+}
+
+func TestDate(t *testing.T) {
+	mockCatalogs(t)
+
+	c, err := Open("locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := time.Date(2018, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		lang   language.Tag
+		layout string
+		want   string
+	}{
+		{language.English, "Jan 2, 2006", "Jan 5, 2018"},
+		{language.French, "Jan 2, 2006", "5 janv. 2018"},
+		{language.French, "Monday, January 2", "Friday, January 5"}, // not translated
+		{language.German, "Jan 2, 2006", "Jan 5, 2018"},
+	} {
+		t.Run(tt.lang.String()+" "+tt.layout, func(t *testing.T) {
+			if got := Date(c.Printer(tt.lang), d, tt.layout); got != tt.want {
+				t.Fatalf("got %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	text := `{{define "content"}}
+<h1>{{.Context.Tr "Settings"}}</h1>
+{{$context := .Context}}
+{{range .Items}}{{$.Tr "%d results" .Count}} {{$context.TrHTML "No results for <strong>%v</strong>" .Q}}{{end}}
+{{if .Q}}{{.Tr (print "dynamic")}}{{else}}{{.FormatDate .Created "Jan 2, 2006"}}{{end}}
+{{template "other" .Tr "Search"}}
+{{Title "not translated"}} {{.Context.T}}
+{{end}}`
+
+	got, err := Extract("test", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"%d results", "Jan 2, 2006", "No results for <strong>%v</strong>", "Search", "Settings"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q; want %q", got, want)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	mockCatalogs(t)
+
+	keys := []string{"Search", "Settings", "Next"}
+
+	catalogs, err := Load("locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[language.Tag][]string{
+		language.French: {"Settings", "Next"},
+		language.German: {"Settings", "Next"},
+	}
+
+	if got := Untranslated(catalogs, keys); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q; want %q", got, want)
+	}
+
+	if err := Update("locales", keys); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := afero.ReadFile(appFs, "locales/de.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	de := "{\n  \"Next\": \"\",\n  \"Search\": \"Suchen\",\n  \"Settings\": \"\"\n}\n"
+	if string(b) != de {
+		t.Fatalf("got %q; want %q", b, de)
+	}
+
+	// the new keys are still untranslated
+	catalogs, err = Load("locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Untranslated(catalogs, keys); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package frontend
+
+import (
+	"bytes"
+	"html/template"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jivesearch/jivesearch/frontend/i18n"
+	"github.com/jivesearch/jivesearch/instant/weather"
+)
+
+func TestLocalize(t *testing.T) {
+	translations, err := i18n.Open("i18n/locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name     string
+		f        *Frontend
+		url      string
+		accept   string
+		locale   string
+		settings string
+		results  string
+		created  string
+	}{
+		{"no catalogs", &Frontend{}, "/settings", "fr", "en", "Settings", "1,234 results", "Jan 5, 2018"},
+		{"english", &Frontend{Translations: translations}, "/settings", "en-US", "en", "Settings", "1,234 results", "Jan 5, 2018"},
+		{"accept-language", &Frontend{Translations: translations}, "/settings", "fr-CA,fr;q=0.8", "fr", "Paramètres", "1\u00a0234 résultats", "5 janv. 2018"},
+		{"l param", &Frontend{Translations: translations}, "/settings?l=de", "fr", "de", "Einstellungen", "1.234 Ergebnisse", "5. Jan. 2018"},
+		{"unsupported", &Frontend{Translations: translations}, "/settings", "ja", "en", "Settings", "1,234 results", "Jan 5, 2018"},
+		{
+			"language of the query", &Frontend{Translations: translations},
+			"/?q=" + url.QueryEscape("das ist ein sehr schöner tag für einen langen spaziergang im park mit meinem hund und meiner katze"),
+			"fr", "fr", "Paramètres", "1\u00a0234 résultats", "5 janv. 2018",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", c.url, nil)
+			req.Header.Set("Accept-Language", c.accept)
+
+			ctx := &Context{}
+			c.f.localize(ctx, req)
+
+			if got := ctx.Locale(); got != c.locale {
+				t.Fatalf("got %q; want %q", got, c.locale)
+			}
+
+			if got := ctx.Tr("Settings"); got != c.settings {
+				t.Fatalf("got %q; want %q", got, c.settings)
+			}
+
+			if got := ctx.Tr("%d results", 1234); got != c.results {
+				t.Fatalf("got %q; want %q", got, c.results)
+			}
+
+			if got := ctx.FormatDate(time.Date(2018, 1, 5, 0, 0, 0, 0, time.UTC), "Jan 2, 2006"); got != c.created {
+				t.Fatalf("got %q; want %q", got, c.created)
+			}
+		})
+	}
+}
+
+func TestTrHTML(t *testing.T) {
+	c := &Context{}
+
+	got := c.TrHTML("No results for <strong>%v</strong> (%d)", "<script>", 1234)
+	want := template.HTML("No results for <strong>&lt;script&gt;</strong> (1,234)")
+	if got != want {
+		t.Fatalf("got %q; want %q", got, want)
+	}
+}
+
+func TestLocalizedTemplate(t *testing.T) {
+	translations, err := i18n.Open("i18n/locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hmacSecret = func() string { return "very secret" }
+
+	f := &Frontend{Translations: translations}
+
+	req := httptest.NewRequest("GET", "/settings", nil)
+	req.Header.Set("Accept-Language", "fr")
+
+	rsp := f.settingsHandler(httptest.NewRecorder(), req)
+
+	ParseTemplates()
+	var buf bytes.Buffer
+	if err := templates[rsp.template].Execute(&buf, rsp.data); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{`<html lang="fr">`, "<h1>Paramètres</h1>", `title="Rechercher"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in %v", want, buf.String())
+		}
+	}
+}
+
+// TestCatalogs makes sure our catalogs translate every string of our templates.
+// Run "go run ./i18n/cmd -w" from the frontend directory to add the missing strings.
+func TestCatalogs(t *testing.T) {
+	keys, err := i18n.ExtractDir("templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys = append(keys, i18n.DateKeys...)
+	for _, d := range weather.Descriptions {
+		keys = append(keys, string(d))
+	}
+
+	catalogs, err := i18n.Load("i18n/locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(catalogs) == 0 {
+		t.Fatal("expected catalogs")
+	}
+
+	for tag, missing := range i18n.Untranslated(catalogs, keys) {
+		t.Errorf("%v doesn't translate %q", tag, missing)
+	}
+}
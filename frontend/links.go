@@ -0,0 +1,110 @@
+package frontend
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jivesearch/jivesearch/search"
+	"github.com/jivesearch/jivesearch/search/document"
+	"github.com/jivesearch/jivesearch/search/link"
+)
+
+// backlinks are the pages that link to a URL or domain
+type backlinks struct {
+	*link.Results
+	Page int `json:"page"`
+	Next int `json:"next,omitempty"`
+}
+
+var errNoLinks = fmt.Errorf("link lookups are disabled")
+
+// linksHandler lists the pages that link to a URL ("?q=https://example.com/page")
+// or to any page of a domain ("?q=example.com"). The pages with the most
+// authority come first.
+func (f *Frontend) linksHandler(w http.ResponseWriter, r *http.Request) *response {
+	if f.Links == nil {
+		return &response{status: http.StatusInternalServerError, err: errNoLinks}
+	}
+
+	q := strings.TrimSpace(r.FormValue("q"))
+	if !strings.HasPrefix(strings.ToLower(q), link.Operator) {
+		q = link.Operator + q
+	}
+
+	target, domain, ok := link.Parse(q)
+	if !ok {
+		return &response{
+			status: http.StatusBadRequest,
+			err:    fmt.Errorf("invalid url or domain: %q", r.FormValue("q")),
+		}
+	}
+
+	number, page := pageParams(r)
+
+	res, err := f.Links.Fetch(target, domain, number, page*number-number)
+	if err != nil {
+		return &response{status: http.StatusInternalServerError, err: err}
+	}
+
+	bl := &backlinks{Results: res, Page: page}
+	if int64(page*number) < res.Count {
+		bl.Next = page + 1
+	}
+
+	return &response{
+		status:   http.StatusOK,
+		template: "json",
+		data:     bl,
+	}
+}
+
+// pageParams are the number of results wanted ("n") and the page ("p")
+func pageParams(r *http.Request) (int, int) {
+	page, err := strconv.Atoi(strings.TrimSpace(r.FormValue("p")))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	number, err := strconv.Atoi(strings.TrimSpace(r.FormValue("n")))
+	if err != nil || number < 1 || number > 100 {
+		number = 25
+	}
+
+	return number, clampPage(page, number)
+}
+
+// linkResults are the results of a "link:" query.
+// Each page that links to the target is a search result
+// with a description in the language of our UI.
+func (f *Frontend) linkResults(c *Context, target string, domain bool, number, offset int) (*search.Results, error) {
+	sr := &search.Results{}
+
+	res, err := f.Links.Fetch(target, domain, number, offset)
+	if err != nil {
+		return sr, err
+	}
+
+	sr.Count = res.Count
+
+	for _, l := range res.Links {
+		des := c.Tr(link.LinksTo, l.Target)
+		if l.Anchor != "" {
+			des = c.Tr(link.LinksToWithAnchor, l.Target, l.Anchor)
+		}
+		if l.NoFollow {
+			des = c.Tr(link.LinksToNoFollow, des)
+		}
+
+		sr.Documents = append(sr.Documents, &document.Document{
+			ID: l.Source,
+			Content: document.Content{
+				Title:       l.Source,
+				Description: des,
+			},
+		})
+	}
+
+	return sr, nil
+}
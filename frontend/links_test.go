@@ -0,0 +1,188 @@
+package frontend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/jivesearch/jivesearch/frontend/i18n"
+	"github.com/jivesearch/jivesearch/search"
+	"github.com/jivesearch/jivesearch/search/document"
+	"github.com/jivesearch/jivesearch/search/link"
+)
+
+func TestLinksHandler(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		query string
+		page  string
+		want  *response
+	}{
+		{
+			name:  "domain",
+			query: "example.com",
+			want: &response{
+				status:   http.StatusOK,
+				template: "json",
+				data: &backlinks{
+					Results: &link.Results{Target: "example.com", Domain: true, Count: 30, Links: mockLinks},
+					Page:    1,
+					Next:    2,
+				},
+			},
+		},
+		{
+			name:  "url with operator",
+			query: "link:https://www.example.com/page",
+			page:  "2",
+			want: &response{
+				status:   http.StatusOK,
+				template: "json",
+				data: &backlinks{
+					Results: &link.Results{Target: "https://www.example.com/page", Count: 30, Links: mockLinks},
+					Page:    2,
+				},
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{Links: &mockLinkFetcher{}}
+
+			req, err := http.NewRequest("GET", "/links", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			q := req.URL.Query()
+			q.Add("q", c.query)
+			q.Add("p", c.page)
+			req.URL.RawQuery = q.Encode()
+
+			got := f.linksHandler(httptest.NewRecorder(), req)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %+v; want %+v", got, c.want)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		f := &Frontend{Links: &mockLinkFetcher{}}
+
+		req, err := http.NewRequest("GET", "/links?q=not+a+domain", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := f.linksHandler(httptest.NewRecorder(), req); got.status != http.StatusBadRequest {
+			t.Fatalf("got status %d; want %d", got.status, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestLinkResults(t *testing.T) {
+	translations, err := i18n.Open("i18n/locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name   string
+		accept string
+		want   []string
+	}{
+		{
+			name:   "english",
+			accept: "en-US",
+			want: []string{
+				`Links to https://www.example.com/ with "An example"`,
+				"Links to https://www.example.com/page (nofollow)",
+			},
+		},
+		{
+			name:   "french",
+			accept: "fr",
+			want: []string{
+				`Liens vers https://www.example.com/ avec "An example"`,
+				"Liens vers https://www.example.com/page (nofollow)",
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{Links: &mockLinkFetcher{}, Translations: translations}
+
+			req := httptest.NewRequest("GET", "/?q=link:example.com", nil)
+			req.Header.Set("Accept-Language", c.accept)
+
+			ctx := &Context{}
+			f.localize(ctx, req)
+
+			got, err := f.linkResults(ctx, "example.com", true, 25, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := &search.Results{
+				Count: 30,
+				Documents: []*document.Document{
+					{
+						ID: "https://www.example.org/",
+						Content: document.Content{
+							Title:       "https://www.example.org/",
+							Description: c.want[0],
+						},
+					},
+					{
+						ID: "https://www.example.net/",
+						Content: document.Content{
+							Title:       "https://www.example.net/",
+							Description: c.want[1],
+						},
+					},
+				},
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %+v; want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestPageParams(t *testing.T) {
+	for _, c := range []struct {
+		url    string
+		number int
+		page   int
+	}{
+		{"/links", 25, 1},
+		{"/links?p=3&n=50", 50, 3},
+		{"/links?p=-1&n=1000", 25, 1},
+		{"/links?p=1000&n=25", 25, 400},
+		{"/links?p=1000000&n=100", 100, 100},
+	} {
+		t.Run(c.url, func(t *testing.T) {
+			number, page := pageParams(httptest.NewRequest("GET", c.url, nil))
+			if number != c.number || page != c.page {
+				t.Fatalf("got %d, %d; want %d, %d", number, page, c.number, c.page)
+			}
+		})
+	}
+}
+
+var mockLinks = []*link.Link{
+	{
+		Source: "https://www.example.org/", Target: "https://www.example.com/",
+		Domain: "example.org", TargetDomain: "example.com", Anchor: "An example", Authority: 20,
+	},
+	{
+		Source: "https://www.example.net/", Target: "https://www.example.com/page",
+		Domain: "example.net", TargetDomain: "example.com", NoFollow: true, Authority: 2,
+	},
+}
+
+type mockLinkFetcher struct{}
+
+func (m *mockLinkFetcher) Fetch(target string, domain bool, number, offset int) (*link.Results, error) {
+	return &link.Results{Target: target, Domain: domain, Count: 30, Links: mockLinks}, nil
+}
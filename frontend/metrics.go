@@ -0,0 +1,154 @@
+package frontend
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jivesearch/jivesearch/log"
+	"github.com/jivesearch/jivesearch/metrics"
+)
+
+// Labels are route names, stages, etc. Never the query: it would be
+// stored by Prometheus and it would make our series unbounded.
+var (
+	registry = metrics.NewRegistry()
+
+	requestsTotal = registry.NewCounter(
+		"jivesearch_http_requests_total", "HTTP requests by route and status.", "route", "status",
+	)
+	requestDuration = registry.NewHistogram(
+		"jivesearch_http_request_duration_seconds", "HTTP request latencies by route.", metrics.DefBuckets, "route",
+	)
+	stageDuration = registry.NewHistogram(
+		"jivesearch_stage_duration_seconds", "Latencies of the stages of a search.", metrics.DefBuckets, "stage",
+	)
+	instantDuration = registry.NewHistogram(
+		"jivesearch_instant_duration_seconds", "Latencies of triggering & solving an instant answer.", metrics.DefBuckets, "answer", "stage",
+	)
+	breakerSkips = registry.NewCounter(
+		"jivesearch_instant_breaker_skips_total", "Instant answers skipped because their fetcher keeps failing.", "answer",
+	)
+	cacheRequests = registry.NewCounter(
+		"jivesearch_cache_requests_total", "Cache lookups by cache and result (hit or miss).", "cache", "result",
+	)
+	providerErrors = registry.NewCounter(
+		"jivesearch_provider_errors_total", "Errors of the backends & APIs we get results from.", "provider",
+	)
+)
+
+// the stages of a search
+const (
+	stageBang          = "bang"
+	stageCacheGet      = "cache_get"
+	stageCachePut      = "cache_put"
+	stageSearch        = "search"
+	stageImages        = "images"
+	stageSuggestInsert = "suggest_insert"
+)
+
+// metricsHandler exports our metrics to Prometheus ("/metrics").
+// It is behind basic auth if there is a status password.
+func (f *Frontend) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if f.Status.Password != "" {
+		_, password, _ := r.BasicAuth()
+		if subtle.ConstantTimeCompare([]byte(password), []byte(f.Status.Password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	registry.ServeHTTP(w, r)
+}
+
+// statusRecorder remembers the status code of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// instrument counts the requests of each route by status and times them
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if rt := mux.CurrentRoute(r); rt != nil && rt.GetName() != "" {
+			route = rt.GetName()
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		requestsTotal.Inc(route, strconv.Itoa(rec.status))
+		requestDuration.Since(start, route)
+	})
+}
+
+// cacheGet gets a value from cache, counting hits & misses of the
+// cache ("search", "images" or "instant")
+func (f *Frontend) cacheGet(cache, key string) interface{} {
+	start := time.Now()
+	v, err := f.Cache.Get(key)
+	stageDuration.Since(start, stageCacheGet)
+
+	if err != nil {
+		providerErrors.Inc("cache")
+		log.Info.Println(err)
+	}
+
+	result := "miss"
+	if v != nil {
+		result = "hit"
+	}
+	cacheRequests.Inc(cache, result)
+
+	return v
+}
+
+func (f *Frontend) cachePut(key string, v interface{}, d time.Duration) {
+	start := time.Now()
+	err := f.Cache.Put(key, v, d)
+	stageDuration.Since(start, stageCachePut)
+
+	if err != nil {
+		providerErrors.Inc("cache")
+		log.Info.Println(err)
+	}
+}
+
+// providerName is the lowercased type of a backend (e.g. "elasticsearch" or "yandex")
+func providerName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return "unknown"
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return strings.ToLower(t.Name())
+}
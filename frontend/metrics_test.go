@@ -0,0 +1,137 @@
+package frontend
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/text/language"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		password string
+		user     string
+		pass     string
+		want     int
+	}{
+		{"no password", "", "", "", http.StatusOK},
+		{"no auth", "secret", "", "", http.StatusUnauthorized},
+		{"wrong password", "secret", "prometheus", "wrong", http.StatusUnauthorized},
+		{"authorized", "secret", "prometheus", "secret", http.StatusOK},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{}
+			f.Status.Password = c.password
+
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			if c.user != "" {
+				req.SetBasicAuth(c.user, c.pass)
+			}
+
+			rec := httptest.NewRecorder()
+			f.metricsHandler(rec, req)
+
+			if rec.Code != c.want {
+				t.Fatalf("got %d; want %d", rec.Code, c.want)
+			}
+
+			if c.want != http.StatusOK {
+				return
+			}
+
+			if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+				t.Fatalf("unexpected Content-Type %q", rec.Header().Get("Content-Type"))
+			}
+
+			if !strings.Contains(rec.Body.String(), "# TYPE jivesearch_http_requests_total counter") {
+				t.Fatalf("expected our metrics in %v", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	router := mux.NewRouter()
+	router.NewRoute().Name("test_instrument").Path("/test").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not here", http.StatusTeapot)
+	})
+	router.NewRoute().Name("test_instrument_ok").Path("/ok").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	router.Use(instrument)
+
+	for _, u := range []string{"/test?q=a", "/test?q=b", "/ok"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", u, nil))
+	}
+
+	if got := requestsTotal.Value("test_instrument", "418"); got != 2 {
+		t.Fatalf("got %v; want 2", got)
+	}
+
+	if got := requestsTotal.Value("test_instrument_ok", "200"); got != 1 {
+		t.Fatalf("got %v; want 1", got)
+	}
+
+	if got := requestDuration.Count("test_instrument"); got != 2 {
+		t.Fatalf("got %v; want 2", got)
+	}
+}
+
+func TestSearchMetrics(t *testing.T) {
+	f := &Frontend{Search: &mockSearch{}}
+	f.Cache.Cacher = &mockCacher{}
+	f.Cache.Search = 10 * time.Second
+
+	hits, misses := cacheRequests.Value("search", "hit"), cacheRequests.Value("search", "miss")
+	searches, puts := stageDuration.Count(stageSearch), stageDuration.Count(stageCachePut)
+
+	lang, region := language.MustParse("en"), language.MustParseRegion("US")
+	for _, q := range []string{" some query ", "private query"} {
+		d := data{Context: &Context{Q: strings.TrimSpace(q), Page: 1, Number: 25}}
+		u := &url.URL{Path: "/", RawQuery: url.Values{"l": {"en"}, "o": {""}, "q": {q}}.Encode()}
+		f.searchResults(d, lang, region, u)
+	}
+
+	if got := cacheRequests.Value("search", "hit"); got != hits+1 {
+		t.Fatalf("got %v hits; want %v", got, hits+1)
+	}
+
+	if got := cacheRequests.Value("search", "miss"); got != misses+1 {
+		t.Fatalf("got %v misses; want %v", got, misses+1)
+	}
+
+	if stageDuration.Count(stageSearch) != searches+1 || stageDuration.Count(stageCachePut) != puts+1 {
+		t.Fatal("expected the search to be fetched & cached once")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := registry.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buf.String(), "private") || strings.Contains(buf.String(), "query") {
+		t.Fatalf("the query was exported: %v", buf.String())
+	}
+}
+
+func TestProviderName(t *testing.T) {
+	for _, c := range []struct {
+		v    interface{}
+		want string
+	}{
+		{&mockSearch{}, "mocksearch"},
+		{mockSearch{}, "mocksearch"},
+		{nil, "unknown"},
+	} {
+		if got := providerName(c.v); got != c.want {
+			t.Fatalf("got %q; want %q", got, c.want)
+		}
+	}
+}
@@ -1,6 +1,17 @@
 package frontend
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jivesearch/jivesearch/instant"
+	"github.com/jivesearch/jivesearch/log"
+)
+
+// maxOpenSearchSuggestions is the max number of suggestions we give a browser
+const maxOpenSearchSuggestions = 10
 
 func (f *Frontend) openSearchHandler(w http.ResponseWriter, r *http.Request) *response {
 	resp := &response{
@@ -14,3 +25,113 @@ func (f *Frontend) openSearchHandler(w http.ResponseWriter, r *http.Request) *re
 
 	return resp
 }
+
+// openSearchSuggestHandler gives browsers suggestions in the OpenSearch Suggestions format:
+// [query, [completions], [descriptions], [urls]]
+// http://www.opensearch.org/Specifications/OpenSearch/Extensions/Suggestions/1.1
+// An instant answer that we can solve without a backend (e.g. "2+2") comes first
+// as a preview, then the !bangs or the completions of the query.
+func (f *Frontend) openSearchSuggestHandler(w http.ResponseWriter, r *http.Request) *response {
+	q := strings.TrimSpace(r.FormValue("q"))
+
+	completions, descriptions, urls := []string{}, []string{}, []string{}
+	add := func(completion, description string) {
+		for _, c := range completions {
+			if c == completion {
+				return
+			}
+		}
+
+		completions = append(completions, completion)
+		descriptions = append(descriptions, description)
+		urls = append(urls, f.Brand.Host+"/?q="+url.QueryEscape(completion))
+	}
+
+	resp := &response{
+		status:   http.StatusOK,
+		template: "suggestions",
+	}
+
+	if q == "" {
+		resp.data = []interface{}{q, completions, descriptions, urls}
+		return resp
+	}
+
+	if preview, ok := f.previewAnswer(r); ok {
+		add(q, preview)
+	}
+
+	if len(q) > 1 && strings.HasPrefix(q, "!") && len(strings.Fields(q)) == 1 {
+		res, err := f.Bangs.Suggest(q, maxOpenSearchSuggestions)
+		if err != nil {
+			return &response{status: http.StatusInternalServerError, err: err}
+		}
+
+		for _, s := range res.Suggestions {
+			add("!"+s.Trigger, s.Name)
+		}
+	} else {
+		res, err := f.Suggest.Completion(q, maxOpenSearchSuggestions)
+		if err != nil {
+			return &response{status: http.StatusInternalServerError, err: err}
+		}
+
+		for _, s := range res.Suggestions {
+			add(s, "")
+		}
+	}
+
+	if len(completions) > maxOpenSearchSuggestions {
+		completions = completions[:maxOpenSearchSuggestions]
+		descriptions = descriptions[:maxOpenSearchSuggestions]
+		urls = urls[:maxOpenSearchSuggestions]
+	}
+
+	resp.data = []interface{}{q, completions, descriptions, urls}
+	return resp
+}
+
+// previewAnswer solves the instant answers that don't need a backend and
+// whose answer is short enough to show in a browser's suggestions
+func (f *Frontend) previewAnswer(r *http.Request) (string, bool) {
+	if f.Instant == nil {
+		return "", false
+	}
+
+	lang, _, _ := f.Document.Matcher.Match(f.detectLanguage(r)...)
+
+	answers := []instant.Answerer{
+		&instant.BirthStone{},
+		&instant.Calculator{},
+		&instant.CamelCase{},
+		&instant.Characters{},
+		&instant.Frequency{},
+		&instant.Potus{},
+		&instant.Prime{},
+		&instant.Reverse{},
+		&instant.Stats{},
+		&instant.URLDecode{},
+		&instant.URLEncode{},
+	}
+
+	for _, ia := range answers {
+		if !f.Instant.Trigger(ia, r, lang) {
+			continue
+		}
+
+		sol := f.Instant.Solve(ia, r)
+		if sol.Err != nil || !sol.Triggered {
+			log.Debug.Println(sol.Err)
+			continue
+		}
+
+		switch s := sol.Solution.(type) {
+		case string:
+			return s, true
+		case float64:
+			return fmt.Sprintf("= %v", s), true
+		}
+	}
+
+	return "", false
+}
@@ -3,8 +3,12 @@ package frontend
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"testing"
+
+	"github.com/jivesearch/jivesearch/instant"
+	"golang.org/x/text/language"
 )
 
 func TestOpenSearchHandler(t *testing.T) {
@@ -41,3 +45,82 @@ func TestOpenSearchHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenSearchSuggestHandler(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		q    string
+		want []interface{}
+	}{
+		{
+			"empty", "",
+			[]interface{}{"", []string{}, []string{}, []string{}},
+		},
+		{
+			"completions", "r",
+			[]interface{}{
+				"r",
+				[]string{"radiohead", "rage against the machine", "red hot chili peppers", "r.e.m.", "rolling stones", "rollins band", "rusted root"},
+				[]string{"", "", "", "", "", "", ""},
+				[]string{
+					"https://jivesearch.com/?q=radiohead",
+					"https://jivesearch.com/?q=rage+against+the+machine",
+					"https://jivesearch.com/?q=red+hot+chili+peppers",
+					"https://jivesearch.com/?q=r.e.m.",
+					"https://jivesearch.com/?q=rolling+stones",
+					"https://jivesearch.com/?q=rollins+band",
+					"https://jivesearch.com/?q=rusted+root",
+				},
+			},
+		},
+		{
+			"!bangs", "!g",
+			[]interface{}{
+				"!g",
+				[]string{"!g", "!gh"},
+				[]string{"Google", "GitHub"},
+				[]string{"https://jivesearch.com/?q=%21g", "https://jivesearch.com/?q=%21gh"},
+			},
+		},
+		{
+			"preview", "2+2",
+			[]interface{}{
+				"2+2",
+				[]string{"2+2"},
+				[]string{"= 4"},
+				[]string{"https://jivesearch.com/?q=2%2B2"},
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{
+				Brand: Brand{Host: "https://jivesearch.com"},
+				Document: Document{
+					Matcher: language.NewMatcher([]language.Tag{language.English}),
+				},
+				Instant: &instant.Instant{QueryVar: "q"},
+				Suggest: &mockSuggester{},
+			}
+
+			var err error
+			f.Bangs, err = bangsFromConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Bangs.Suggester = &mockBangSuggester{}
+
+			req := httptest.NewRequest("GET", "/opensearch/suggest?q="+url.QueryEscape(c.q), nil)
+			got := f.openSearchSuggestHandler(httptest.NewRecorder(), req)
+
+			want := &response{
+				status:   http.StatusOK,
+				template: "suggestions",
+				data:     c.want,
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %+v; want %+v", got, want)
+			}
+		})
+	}
+}
@@ -4,31 +4,36 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
-	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"regexp"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/gorilla/mux"
 	"github.com/jivesearch/jivesearch/log"
+	"golang.org/x/net/html/charset"
 )
 
 type proxyResponse struct {
 	Brand
-	Context `json:"-"`
-	HTML    string `json:"-"`
-	URL     string `json:"-"`
+	*Context `json:"-"`
+	HTML     string `json:"-"`
+	URL      string `json:"-"`
 }
 
 func (f *Frontend) proxyHeaderHandler(w http.ResponseWriter, r *http.Request) *response {
+	ctx := &Context{}
+	f.localize(ctx, r)
+
 	resp := &response{
 		status:   http.StatusOK,
 		template: "proxy_header",
 		data: proxyResponse{
-			Brand: f.Brand,
-			URL:   r.FormValue("q"),
+			Brand:   f.Brand,
+			Context: ctx,
+			URL:     r.FormValue("q"),
 		},
 		err: nil,
 	}
@@ -36,15 +41,21 @@ func (f *Frontend) proxyHeaderHandler(w http.ResponseWriter, r *http.Request) *r
 	return resp
 }
 
+// proxyHandler proxies a page. With "css" it proxies a stylesheet and
+// with "iframe" the page is the document of an iframe.
 func (f *Frontend) proxyHandler(w http.ResponseWriter, r *http.Request) *response {
 	u := r.FormValue("q")
+	ctx := &Context{}
+	f.localize(ctx, r)
+	proxyHeaders(w)
 
 	resp := &response{
 		status:   http.StatusOK,
 		template: "proxy",
 		data: proxyResponse{
-			Brand: f.Brand,
-			URL:   u,
+			Brand:   f.Brand,
+			Context: ctx,
+			URL:     u,
 		},
 		err: nil,
 	}
@@ -53,293 +64,223 @@ func (f *Frontend) proxyHandler(w http.ResponseWriter, r *http.Request) *respons
 		return resp
 	}
 
-	signature := r.FormValue("key")
-
-	css := r.FormValue("css")
-	if css == "true" {
-		uu, err := url.Parse(u)
-		if err != nil {
-			log.Debug.Println(err)
-			return resp
-		}
+	base, err := url.Parse(u)
+	if err != nil {
+		log.Debug.Println(err)
+		return resp
+	}
 
-		if !validSignature([]byte(hmacSecret()), uu, signature) {
-			return resp
-		}
+	if !validSignature([]byte(hmacSecret()), base, r.FormValue("key")) {
+		return resp
+	}
 
-		res, err := f.get(uu)
-		if err != nil {
-			log.Debug.Println(err)
-			return resp
-		}
+	if r.FormValue("css") == "true" {
+		return f.proxyCSS(resp, base)
+	}
 
-		defer res.Body.Close()
+	return f.proxyPage(resp, base, r.FormValue("iframe") == "true")
+}
 
-		h, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			log.Debug.Println(err)
-			return resp
-		}
+// proxyFormHandler proxies the submission of a GET form ("/proxy_form/{key}/{url}").
+// The action of the form is in the path as the browser replaces the query with the form's fields.
+func (f *Frontend) proxyFormHandler(w http.ResponseWriter, r *http.Request) *response {
+	ctx := &Context{}
+	f.localize(ctx, r)
+	proxyHeaders(w)
 
-		resp.data = replaceCSS(uu, string(h))
+	resp := &response{
+		status:   http.StatusOK,
+		template: "proxy",
+		data: proxyResponse{
+			Brand:   f.Brand,
+			Context: ctx,
+		},
+		err: nil,
+	}
 
-		resp.template = "proxy_css"
+	action, err := base64.RawURLEncoding.DecodeString(mux.Vars(r)["url"])
+	if err != nil {
+		log.Debug.Println(err)
 		return resp
 	}
 
-	base, err := url.Parse(u)
+	base, err := url.Parse(string(action))
 	if err != nil {
 		log.Debug.Println(err)
 		return resp
 	}
 
-	if !validSignature([]byte(hmacSecret()), base, signature) {
+	if !validSignature([]byte(hmacSecret()), base, mux.Vars(r)["key"]) {
 		return resp
 	}
 
-	res, err := f.get(base)
-	if err != nil {
-		log.Debug.Println(err)
-		return resp
+	base.RawQuery = r.URL.RawQuery
+	resp.data = proxyResponse{
+		Brand:   f.Brand,
+		Context: ctx,
+		URL:     base.String(),
 	}
 
-	defer res.Body.Close()
+	return f.proxyPage(resp, base, false)
+}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
-	if err != nil {
-		log.Debug.Println(err)
-		return resp
-	}
+// mediaTypes are the content types we proxy for <video>, <audio>,
+// <track>, <object>, <embed> & the fonts of a stylesheet
+var mediaTypes = []string{
+	"application/font-",
+	"application/ogg",
+	"application/pdf",
+	"application/vnd.ms-fontobject",
+	"application/x-font-",
+	"audio/",
+	"font/",
+	"image/",
+	"text/vtt",
+	"video/",
+}
 
-	// TODO: remove all comments...no need for them
-
-	// remove all javascript
-	doc.Find("script").Each(func(i int, s *goquery.Selection) {
-		s.Remove()
-	})
-
-	// disable all forms
-	doc.Find("form").Each(func(i int, s *goquery.Selection) {
-		s.SetAttr("disabled", "disabled") // doesn't seem to work....
-		s.SetAttr("action", "javascript:void(0);")
-	})
-
-	// proxy links
-	doc.Find("a").Each(func(i int, s *goquery.Selection) {
-		for _, href := range []string{"href"} {
-			if lnk, ok := s.Attr(href); ok {
-				u, err := createProxyLink(base, lnk, false)
-				if err != nil {
-					log.Debug.Println(err)
-					return
-				}
-
-				s.SetAttr(href, u.String())
-				s.SetAttr("target", "_top") // make all links open in the main page (not w/in the iframe)
-			}
-		}
-	})
-
-	// proxy iframe src
-	doc.Find("iframe").Each(func(i int, s *goquery.Selection) {
-		if lnk, ok := s.Attr("src"); ok {
-			u, err := createProxyLink(base, lnk, true)
-			if err != nil {
-				log.Debug.Println(err)
-				return
-			}
-
-			s.SetAttr("src", u.String())
-		}
-	})
-
-	// proxy images
-	doc.Find("img").Each(func(i int, s *goquery.Selection) {
-		for _, src := range []string{"src", "srcset"} {
-			if lnk, ok := s.Attr(src); ok {
-				if lnk == "" {
-					continue
-				}
-
-				matches := reSrcSet.FindAllStringSubmatch(lnk, -1)
-				if len(matches) == 0 {
-					u := createProxyImage(base, lnk)
-					s.SetAttr(src, u)
-					continue
-				}
-
-				lnks := []string{}
-
-				for _, m := range matches {
-					if isBase64(m[1]) {
-						lnks = append(lnks, m[1])
-						continue
-					}
-
-					u := createProxyImage(base, m[1])
-
-					lnks = append(lnks, fmt.Sprintf("%v %v", u, m[2]))
-				}
-
-				s.SetAttr(src, strings.Join(lnks, " "))
-			}
-		}
-	})
-
-	// proxy url() within <style></style> tags
-	doc.Find("style").Each(func(i int, s *goquery.Selection) {
-		h := replaceCSS(base, s.Text())
-		s.ReplaceWithHtml(fmt.Sprintf(`<style>%v</style>`, h))
-	})
-
-	// proxy url() for any inline styles
-	doc.Find("*").Each(func(i int, s *goquery.Selection) {
-		attr := "style"
-		if style, ok := s.Attr(attr); ok {
-			s.SetAttr(attr, replaceCSS(base, style))
-		}
-	})
-
-	// replace external stylesheets with a proxied version
-	doc.Find("link").Each(func(i int, s *goquery.Selection) {
-		if rel, ok := s.Attr("rel"); ok && strings.ToLower(rel) == "stylesheet" {
-			if lnk, ok := s.Attr("href"); ok {
-				u, err := createProxyCSSLink(base, lnk)
-				if err != nil {
-					log.Debug.Println(err)
-					return
-				}
-
-				s.SetAttr("href", u.String())
-			}
-		}
-	})
+// proxyMediaHandler streams audio, video & fonts ("/proxy_media"). The Range
+// header is passed on so that a video can be seeked.
+func (f *Frontend) proxyMediaHandler(w http.ResponseWriter, r *http.Request) {
+	u, err := url.Parse(r.FormValue("q"))
+	if err != nil || !validSignature([]byte(hmacSecret()), u, r.FormValue("key")) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
 
-	h, err := doc.Html()
+	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
-		log.Debug.Println(err)
-		return resp
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
 	}
 
-	switch r.FormValue("iframe") {
-	case "true":
-		resp.template = "proxy_iframe"
-		resp.data = string(h)
-	default:
-		resp.data = proxyResponse{
-			Brand: f.Brand,
-			HTML:  h,
-			URL:   u,
-		}
+	if rng := r.Header.Get("Range"); rng != "" {
+		req.Header.Set("Range", rng)
 	}
 
-	return resp
-}
+	res, err := f.ProxyClient.Do(req)
+	if err != nil {
+		log.Debug.Println(err)
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
 
-func isBase64(s string) bool {
-	return strings.HasPrefix(strings.ToLower(s), "data:")
-}
+	defer res.Body.Close()
 
-// can have ', ", or no quotes
-var reCSSLinkReplacer = regexp.MustCompile(`(url\(['"]?)(?P<link>.*?)['"]?\)`)
-var reSrcSet = regexp.MustCompile(`(?P<url>.*?),? (?P<size>[0-9]+(\.[0-9]+)?[wx],?)?\s?`)
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
 
-// https://stackoverflow.com/a/28005189/522962
-func replaceAllSubmatchFunc(re *regexp.Regexp, b []byte, f func(s []byte) []byte) []byte {
-	idxs := re.FindAllSubmatchIndex(b, -1)
-	if len(idxs) == 0 {
-		return b
+	if !isMedia(res.Header.Get("Content-Type")) {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
 	}
-	l := len(idxs)
-	ret := append([]byte{}, b[:idxs[0][0]]...)
-	for i, pair := range idxs {
-		ret = append(ret, f(b[pair[4]:pair[5]])...) // 2 & 3 are <url>. 4 & 5 are the <link>
-		if i+1 < l {
-			ret = append(ret, b[pair[1]:idxs[i+1][0]]...)
+
+	proxyHeaders(w)
+	for _, h := range []string{"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges", "Last-Modified"} {
+		if v := res.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
 		}
 	}
-	ret = append(ret, b[idxs[len(idxs)-1][1]:]...)
-	return ret
+
+	w.WriteHeader(res.StatusCode)
+	if _, err := io.Copy(w, res.Body); err != nil {
+		log.Debug.Println(err)
+	}
 }
 
-func replaceCSS(base *url.URL, s string) string {
-	// replace any urls with a proxied link
-	ss := replaceAllSubmatchFunc(reCSSLinkReplacer, []byte(s), func(ss []byte) []byte {
-		if isBase64(string(ss)) { // base64 image
-			return []byte(fmt.Sprintf("url(%q)", ss))
+func isMedia(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, t := range mediaTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
 		}
+	}
 
-		m := string(ss)
-
-		u, err := url.Parse(m)
-		if err != nil {
-			log.Info.Println(err)
-		}
+	return false
+}
 
-		u = base.ResolveReference(u)
+// proxyHeaders are the headers of everything we proxy
+func proxyHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Security-Policy", proxyCSP)
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+}
 
-		key := hmacKey(u.String())
-		uu := fmt.Sprintf("/image/,s%v/%v", key, u.String())
-		return []byte(fmt.Sprintf("url(%q)", uu))
-	})
+// proxyCSS proxies a stylesheet
+func (f *Frontend) proxyCSS(resp *response, base *url.URL) *response {
+	res, err := f.get(base)
+	if err != nil {
+		log.Debug.Println(err)
+		return resp
+	}
 
-	return string(ss)
-}
+	defer res.Body.Close()
 
-func createProxyImage(base *url.URL, lnk string) string {
-	u, err := url.Parse(lnk)
+	h, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		panic(err)
+		log.Debug.Println(err)
+		return resp
 	}
 
-	u = base.ResolveReference(u)
-	key := hmacKey(u.String())
-	l := fmt.Sprintf("/image/,s%v/%v", key, u.String())
-	return l
+	rw := &rewriter{base: location(res, base)} // urls are relative to the stylesheet
+	resp.data = rw.css(string(h))
+	resp.template = "proxy_css"
+	return resp
 }
 
-func createProxyCSSLink(base *url.URL, lnk string) (*url.URL, error) {
-	u, err := url.Parse(lnk)
+// proxyPage proxies a page (or the document of an iframe)
+func (f *Frontend) proxyPage(resp *response, base *url.URL, iframe bool) *response {
+	res, err := f.get(base)
 	if err != nil {
-		panic(err)
+		log.Debug.Println(err)
+		return resp
 	}
 
-	u = base.ResolveReference(u)
+	defer res.Body.Close()
 
-	uu, err := url.Parse("/proxy")
+	// the page is converted to UTF-8
+	body, err := charset.NewReader(res.Body, res.Header.Get("Content-Type"))
 	if err != nil {
-		return nil, err
+		log.Debug.Println(err)
+		return resp
 	}
 
-	q := uu.Query()
-	q.Add("key", hmacKey(u.String()))
-	q.Add("q", u.String())
-	q.Add("css", "true")
-	uu.RawQuery = q.Encode()
-	return uu, err
-}
-
-func createProxyLink(base *url.URL, lnk string, iframe bool) (*url.URL, error) {
-	u, err := url.Parse(lnk)
+	doc, err := goquery.NewDocumentFromReader(body)
 	if err != nil {
-		panic(err)
+		log.Debug.Println(err)
+		return resp
 	}
 
-	u = base.ResolveReference(u)
+	rw := &rewriter{base: location(res, base)} // urls are relative to the page
+	rw.rewrite(doc)
 
-	uu, err := url.Parse("/proxy")
+	h, err := doc.Html()
 	if err != nil {
-		return nil, err
+		log.Debug.Println(err)
+		return resp
 	}
 
-	q := uu.Query()
-	q.Add("key", hmacKey(u.String()))
-	q.Add("q", u.String())
 	if iframe {
-		q.Add("iframe", "true")
+		resp.template = "proxy_iframe"
+		resp.data = string(h)
+		return resp
 	}
-	uu.RawQuery = q.Encode()
-	return uu, err
+
+	data := resp.data.(proxyResponse)
+	data.HTML = h
+	resp.data = data
+	return resp
+}
+
+// location is the url of a response after any redirect
+func location(res *http.Response, u *url.URL) *url.URL {
+	if res.Request != nil && res.Request.URL != nil {
+		return res.Request.URL
+	}
+
+	return u
 }
 
 // validSignature returns whether the request signature is valid.
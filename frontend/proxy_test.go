@@ -1,14 +1,17 @@
 package frontend
 
 import (
+	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/gorilla/mux"
 	"github.com/jarcoal/httpmock"
 	"github.com/jivesearch/jivesearch/log"
 	"github.com/tdewolff/minify"
@@ -32,7 +35,8 @@ func TestProxyHeaderHandler(t *testing.T) {
 						Name:    "Some Name",
 						TagLine: "A great tagline",
 					},
-					URL: "https://example.com",
+					Context: &Context{},
+					URL:     "https://example.com",
 				},
 				err: nil,
 			},
@@ -117,16 +121,76 @@ func TestProxyHandler(t *testing.T) {
 				</style>
 				</head>
 				<body>
-					<form id=form disabled action=javascript:void(0);></form>
+					<form id=form action="/proxy_form/qbkCOvtvTS5KQq2e42NuRxfhO1v90XOEPMPjuKOH9xY=/aHR0cHM6Ly9leGFtcGxlLmNvbQ" target=_top></form>
 					<a href="/proxy?key=_Zbla8JTucVtfb7n-QIGsrKozkTGaGsuKlxppnXb6xM%3D&amp;q=https%3A%2F%2Fwww.example.com" target=_top>A link</a>
 					<a href="/proxy?key=j_gIsLDElFG1Qnp3TAYn1KD5dwvJ0gB_KqvUjXvM64g%3D&amp;q=https%3A%2F%2Fexample.com%2Frelative%2Flink" target=_top>A relative link</a>
 					<iframe src="/proxy?iframe=true&amp;key=QtzD41Rkf5VUsmVPv9kSn4VHfUqf2jMljGktkjYVOVc%3D&amp;q=https%3A%2F%2Fexample.com%2Fiframe%2Fstuff"></iframe>
 					<img src="/image/,sypKZuwtHssDFg_bLaExLhx4rYNnbr0KkzPeekQYRlGA=/https://example.com/nice.jpg" alt="nice image">
-					<img src=data:image/png;base64 alt="Red dot">
+					<img src="data:image/png;base64, iVBORw0KGgoAAAANSUhEUgAAAggg==" alt="Red dot">
 					<div style='background-image:url("/image/,s1aMOcTAkBGs07NYeV9NjCCrDMIAQ7vtELioY-qfeDpo=/https://example.com/paper.gif")'>Cool div you got there. Would be a shame if we proxied the url.</div>
 				</body>
 			</html>`,
 		},
+		{
+			"rewrite",
+			args{
+				q:      "https://example.com/dir/page.html",
+				secret: "my_secret",
+				resp: `<html>
+								<head>
+									<base href="https://cdn.example.com/base/">
+									<meta charset="iso-8859-1">
+									<meta http-equiv="set-cookie" content="a=b">
+									<meta http-equiv="refresh" content="5; URL='/next'">
+									<link rel="preload" href="font.woff2">
+									<link rel="icon" href="/favicon.ico">
+									<link rel="stylesheet" href="https://www.googletagmanager.com/style.css">
+									<style>@import "print.css"; @font-face {src: url(font.woff2?v=1)} a {background: url(#svg)}</style>
+									<script src="https://www.google-analytics.com/analytics.js"></script>
+								</head>
+								<body onload="track()">
+									<!-- a comment -->
+									<noscript><img src="https://www.facebook.com/tr?id=1"><p>JavaScript is disabled</p></noscript>
+									<form action="/search?x=y" method="GET"><input name="q"><button formaction="https://example.com/other">Go</button></form>
+									<form action="/login" method="post" enctype="multipart/form-data"><input name="password"></form>
+									<a href="javascript:alert(1)" ping="https://example.com/ping">Not a link</a>
+									<a href="mailto:me@example.com">Email</a>
+									<a href="https://ad.doubleclick.net/click">An ad</a>
+									<img srcset="small.jpg 1x, data:image/png;base64,AAA=, large.jpg 2x" width="100">
+									<img src="pixel.gif" width="1" height="1">
+									<picture><source srcset="a.webp 480w, b.webp 800w"></picture>
+									<video src="movie.mp4" poster="poster.jpg"><track src="subtitles.vtt"></video>
+									<audio><source src="song.ogg"></audio>
+									<object data="movie.swf" classid="clsid:1234"></object>
+									<embed src="https://cdn.example.com/doc.pdf">
+									<iframe src="https://www.googletagmanager.com/ns.html"></iframe>
+									<iframe srcdoc="<script>alert(1)</script>"></iframe>
+								</body>
+							</html>`,
+			},
+			`<html>
+				<head>
+				<meta http-equiv="refresh" content="5; url=/proxy?iframe=true&amp;key=c9izuTtZuz5LrAD5bXa8q7utew75wBrd3hwPCj9OT0A%3D&amp;q=https%3A%2F%2Fcdn.example.com%2Fnext">
+				<link rel=icon href="/image/,svHLa0Qum4FJJtXMRgs6mLbffgry7Y1QokomdzqKbHlw=/https://cdn.example.com/favicon.ico">
+				<style>@import url("/proxy?css=true&key=VCm7OR-m5HreGV3XzcpkJ7QRwaOsVATcmBeB-WwULm4%3D&q=https%3A%2F%2Fcdn.example.com%2Fbase%2Fprint.css"); @font-face {src: url("/proxy_media?key=MKrrxCRoJTvJxgs8JoRI4nvkM6f4Pvc8zm49ffK82AQ%3D&q=https%3A%2F%2Fcdn.example.com%2Fbase%2Ffont.woff2%3Fv%3D1")} a {background: url(#svg)}</style>
+				</head>
+				<body>
+					<p>JavaScript is disabled</p>
+					<form action="/proxy_form/u0FkBkX2AcuP-OcBAQ80ppoXK9M0olE_dowiXn9fjxA=/aHR0cHM6Ly9jZG4uZXhhbXBsZS5jb20vc2VhcmNo" method="get" target=_top><input name="q"><button>Go</button></form>
+					<div><input name="password"></div>
+					<a>Not a link</a>
+					<a href="mailto:me@example.com">Email</a>
+					<a>An ad</a>
+					<img srcset="/image/,sM6gHIbAdzKqqAnRoq8LdhNMM0BShj6MiS1T3iWbfACU=/https://cdn.example.com/base/small.jpg 1x, data:image/png;base64,AAA=, /image/,smxyyGfhC2gakrMjtBJ9VIumW_2dxaz2eHEEhDdmsBaU=/https://cdn.example.com/base/large.jpg 2x" width="100">
+					<picture><source srcset="/image/,s3FyyHxt3mXPq2gTj2v3j0ga1B4fW1Wqybf0FUS7JV9c=/https://cdn.example.com/base/a.webp 480w, /image/,s2LrvtFegJbHE0PbsHeWUJsagd_-v2zd2Bep8hszEIYs=/https://cdn.example.com/base/b.webp 800w"></picture>
+					<video src="/proxy_media?key=_9VJ_GcIYBIm7cJkZbUt_rHlJNDv4NUw4BrddvwQHfM%3D&amp;q=https%3A%2F%2Fcdn.example.com%2Fbase%2Fmovie.mp4" poster="/image/,sJblJthyG24tEfYm0XAMJc9LLp7gp-RqV4hEzRX6VAlE=/https://cdn.example.com/base/poster.jpg"><track src="/proxy_media?key=kv-0a6Mnv4c0RYmzIo5VM1yJSLXXtwz5vpduGIvzshg%3D&amp;q=https%3A%2F%2Fcdn.example.com%2Fbase%2Fsubtitles.vtt"></video>
+					<audio><source src="/proxy_media?key=3LEVPjQMl54IeiZJpF3wvJbpyvNXPziOp0sHzNx-Xck%3D&amp;q=https%3A%2F%2Fcdn.example.com%2Fbase%2Fsong.ogg"></audio>
+					<object data="/proxy_media?key=A9qY577Ep9VLjU43sqbApQ5c2wweBCAhvw7iceLAR60%3D&amp;q=https%3A%2F%2Fcdn.example.com%2Fbase%2Fmovie.swf"></object>
+					<embed src="/proxy_media?key=OaIsPr1KiI7-s4eEiduDvX4PyDNKAeI4GTRB0DKhkcU%3D&amp;q=https%3A%2F%2Fcdn.example.com%2Fdoc.pdf">
+					<iframe></iframe>
+				</body>
+			</html>`,
+		},
 		/*
 			{
 				"css",
@@ -199,9 +263,10 @@ func TestProxyHandler(t *testing.T) {
 				status:   http.StatusOK,
 				template: "proxy",
 				data: proxyResponse{
-					Brand: Brand{},
-					HTML:  s,
-					URL:   c.args.q,
+					Brand:   Brand{},
+					Context: &Context{},
+					HTML:    s,
+					URL:     c.args.q,
 				},
 			}
 
@@ -237,6 +302,146 @@ func TestProxyHandler(t *testing.T) {
 	httpmock.Reset()
 }
 
+func TestProxyFormHandler(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	hmacSecret = func() string { return "my_secret" }
+
+	httpmock.RegisterResponder("GET", "https://example.com/search?q=search+term",
+		httpmock.NewStringResponder(200, `<html><body><a href="/result">A result</a></body></html>`))
+
+	f := &Frontend{
+		Brand:       Brand{},
+		ProxyClient: &http.Client{},
+	}
+
+	action, err := url.Parse("https://example.com/search")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name string
+		key  string
+		want proxyResponse
+	}{
+		{
+			"valid", hmacKey(action.String()),
+			proxyResponse{
+				Context: &Context{},
+				HTML:    `<html><head></head><body><a href="/proxy?key=UQEhqMPkEgUsumIgb8bfTlfJQgrSad5tYmEPh6G8HvQ%3D&amp;q=https%3A%2F%2Fexample.com%2Fresult" target="_top">A result</a></body></html>`,
+				URL:     "https://example.com/search?q=search+term",
+			},
+		},
+		{
+			"invalid key", hmacKey("https://example.com/other"),
+			proxyResponse{
+				Context: &Context{},
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/proxy_form/?q=search+term", nil)
+			req = mux.SetURLVars(req, map[string]string{
+				"key": c.key,
+				"url": base64.RawURLEncoding.EncodeToString([]byte(action.String())),
+			})
+
+			got := f.proxyFormHandler(rec, req)
+
+			want := &response{
+				status:   http.StatusOK,
+				template: "proxy",
+				data:     c.want,
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %+v; want %+v", got, want)
+			}
+
+			if csp := rec.Header().Get("Content-Security-Policy"); csp != proxyCSP {
+				t.Fatalf("got %q; want %q", csp, proxyCSP)
+			}
+		})
+	}
+}
+
+func TestProxyMediaHandler(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	hmacSecret = func() string { return "my_secret" }
+
+	for _, c := range []struct {
+		name        string
+		contentType string
+		key         string
+		rng         string
+		status      int
+		body        string
+	}{
+		{"video", "video/mp4", "", "", http.StatusOK, "video"},
+		{"range", "video/mp4", "", "bytes=2-", http.StatusPartialContent, "deo"},
+		{"font", "font/woff2", "", "", http.StatusOK, "video"},
+		{"html", "text/html", "", "", http.StatusUnsupportedMediaType, "Unsupported Media Type\n"},
+		{"invalid key", "video/mp4", "invalid", "", http.StatusForbidden, "Forbidden\n"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			u := "https://example.com/video.mp4"
+
+			httpmock.RegisterResponder("GET", u, func(req *http.Request) (*http.Response, error) {
+				resp := httpmock.NewStringResponse(http.StatusOK, "video")
+				if rng := req.Header.Get("Range"); rng != "" {
+					resp = httpmock.NewStringResponse(http.StatusPartialContent, "video"[2:])
+					resp.Header.Set("Content-Range", "bytes 2-4/5")
+				}
+				resp.Header.Set("Content-Type", c.contentType)
+				resp.Header.Set("Set-Cookie", "tracking=1")
+				return resp, nil
+			})
+
+			f := &Frontend{
+				ProxyClient: &http.Client{},
+			}
+
+			key := c.key
+			if key == "" {
+				key = hmacKey(u)
+			}
+
+			req := httptest.NewRequest("GET", "/proxy_media", nil)
+			q := req.URL.Query()
+			q.Add("q", u)
+			q.Add("key", key)
+			req.URL.RawQuery = q.Encode()
+			if c.rng != "" {
+				req.Header.Set("Range", c.rng)
+			}
+
+			rec := httptest.NewRecorder()
+			f.proxyMediaHandler(rec, req)
+
+			if rec.Code != c.status {
+				t.Fatalf("got %d; want %d", rec.Code, c.status)
+			}
+
+			if rec.Body.String() != c.body {
+				t.Fatalf("got %q; want %q", rec.Body.String(), c.body)
+			}
+
+			if rec.Header().Get("Set-Cookie") != "" {
+				t.Fatal("expected cookies to be removed")
+			}
+
+			if c.status == http.StatusOK && rec.Header().Get("Content-Type") != c.contentType {
+				t.Fatalf("got %q; want %q", rec.Header().Get("Content-Type"), c.contentType)
+			}
+		})
+	}
+}
+
 func htmlMinify(s string) (string, error) {
 	m := minify.New()
 	m.AddFunc("text/html", html.Minify)
@@ -0,0 +1,97 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jivesearch/jivesearch/log"
+)
+
+// rateLimited is the data of our 429 page
+type rateLimited struct {
+	Brand
+	*Context
+	RetryAfter int // seconds
+}
+
+// rateLimit limits the requests of each client to a route. Requests are
+// let through if we can't reach the store so an outage doesn't take us down.
+func (f *Frontend) rateLimit(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.RateLimiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ok, retry, err := f.RateLimiter.Allow(route, r, time.Now())
+		if err != nil {
+			log.Info.Println(err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !ok {
+			f.tooManyRequests(w, r, retry)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tooManyRequests tells a client when to try again in the format it asked for
+func (f *Frontend) tooManyRequests(w http.ResponseWriter, r *http.Request, retry time.Duration) {
+	seconds := int((retry + time.Second - 1) / time.Second) // round up
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(apiError{
+			Version: apiVersion,
+			Status:  http.StatusTooManyRequests,
+			Error:   http.StatusText(http.StatusTooManyRequests),
+		})
+		return
+	}
+
+	tmpl, ok := templates["ratelimit"]
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+
+	d := rateLimited{Brand: f.Brand, Context: &Context{}, RetryAfter: seconds}
+	f.localize(d.Context, r)
+	if err := tmpl.Execute(buf, d); err != nil {
+		log.Info.Println(err)
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusTooManyRequests)
+	buf.WriteTo(w)
+}
+
+// wantsJSON tells us if a request is for our API, autocomplete or JSON output
+func wantsJSON(r *http.Request) bool {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/api/"), r.URL.Path == "/autocomplete", r.URL.Path == "/opensearch/suggest":
+		return true
+	case r.URL.Query().Get("o") == "json":
+		return true
+	}
+
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many calls to Take between removing the buckets that are full again
+const sweepEvery = 10000
+
+// Memory keeps the buckets in memory. Use Redis if you run more than one frontend.
+type Memory struct {
+	sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+// NewMemory creates a Memory store
+func NewMemory() *Memory {
+	return &Memory{buckets: map[string]*bucket{}}
+}
+
+// Take takes a token from a bucket
+func (m *Memory) Take(key string, l Limit, now time.Time) (bool, int, time.Duration, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.calls++
+	if m.calls%sweepEvery == 0 {
+		m.sweep(l, now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		m.buckets[key] = b
+	}
+
+	ok, retry := b.take(l, now)
+	return ok, int(b.tokens), retry, nil
+}
+
+// sweep forgets the buckets that haven't been used for long enough to be full
+// again. Buckets of other routes may take longer to refill so we wait at least an hour.
+func (m *Memory) sweep(l Limit, now time.Time) {
+	wait := l.full()
+	if wait < time.Hour {
+		wait = time.Hour
+	}
+
+	for k, b := range m.buckets {
+		if now.Sub(b.last) > wait {
+			delete(m.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package ratelimit limits the requests of a client with token buckets.
+// Clients are identified by a salted hash of their IP address. The salt
+// changes every period so a hash can't be tied to an IP address for long
+// and we never keep an IP address itself.
+package ratelimit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Limit is the token bucket of a route. A client can make Burst requests
+// at once, after which it gets Rate more requests per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute is a limit of n requests a minute with a burst of burst requests
+func PerMinute(n, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
+
+// Store keeps the token buckets
+type Store interface {
+	// Take takes a token from a bucket and returns the whole tokens left.
+	// If the bucket is empty it returns how long until the bucket has a token.
+	Take(key string, l Limit, now time.Time) (ok bool, remaining int, retry time.Duration, err error)
+}
+
+// Limiter limits the requests to each route
+type Limiter struct {
+	Store
+	*Hasher
+	Limits     map[string]Limit // by route (routes without a limit aren't limited)
+	TrustProxy bool             // take the IP address from the X-Real-IP or X-Forwarded-For header
+}
+
+// Allow takes a token from the client's bucket of a route
+func (l *Limiter) Allow(route string, r *http.Request, now time.Time) (bool, time.Duration, error) {
+	lim, ok := l.Limits[route]
+	if !ok {
+		return true, 0, nil
+	}
+
+	key := route + ":" + l.Hasher.Hash(l.IP(r), now)
+	ok, _, retry, err := l.Store.Take(key, lim, now)
+	return ok, retry, err
+}
+
+// IP is the IP address of a client
+func (l *Limiter) IP(r *http.Request) string {
+	if l.TrustProxy {
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip
+		}
+
+		// the last address is the one our proxy saw. The others are up to the client.
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			ips := strings.Split(xff, ",")
+			return strings.TrimSpace(ips[len(ips)-1])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// Hasher hashes IP addresses with a salt that rotates every Period.
+// Frontends that share a Store need the same Secret.
+// NOTE: as the hash of a client changes when the salt does, so does
+// its bucket. A client gets a full bucket at the start of each period.
+type Hasher struct {
+	Secret []byte
+	Period time.Duration
+}
+
+// Hash is the salted hash of an IP address
+func (h *Hasher) Hash(ip string, now time.Time) string {
+	epoch := make([]byte, 8)
+	binary.BigEndian.PutUint64(epoch, uint64(now.UnixNano()/int64(h.Period)))
+
+	// the salt of a period is derived from the secret so that we don't have to keep it
+	salt := hmac.New(sha256.New, h.Secret)
+	salt.Write(epoch)
+
+	m := hmac.New(sha256.New, salt.Sum(nil))
+	m.Write([]byte(ip))
+	return hex.EncodeToString(m.Sum(nil)[:16])
+}
+
+// bucket is a token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take refills a bucket for the time since it was last used and takes a token
+func (b *bucket) take(l Limit, now time.Time) (bool, time.Duration) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(l.Burst), b.tokens+elapsed*l.Rate)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if l.Rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+
+	return false, time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+}
+
+// full is how long until an empty bucket is full
+func (l Limit) full() time.Duration {
+	if l.Rate <= 0 {
+		return time.Hour
+	}
+
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
@@ -0,0 +1,192 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/rafaeljusto/redigomock"
+)
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	l := &Limiter{
+		Store:  NewMemory(),
+		Hasher: &Hasher{Secret: []byte("very secret"), Period: 24 * time.Hour},
+		Limits: map[string]Limit{"search": PerMinute(60, 2)},
+	}
+
+	r := httptest.NewRequest("GET", "/?q=cats", nil)
+	r.RemoteAddr = "1.2.3.4:5678"
+
+	for i, c := range []struct {
+		route string
+		now   time.Time
+		ok    bool
+		retry time.Duration
+	}{
+		{"search", now, true, 0},
+		{"search", now, true, 0},
+		{"search", now, false, time.Second},
+		{"search", now.Add(500 * time.Millisecond), false, 500 * time.Millisecond},
+		{"search", now.Add(time.Second), true, 0},
+		{"about", now.Add(time.Second), true, 0}, // not limited
+	} {
+		ok, retry, err := l.Allow(c.route, r, c.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ok != c.ok || retry != c.retry {
+			t.Fatalf("%d: got %v, %v; want %v, %v", i, ok, retry, c.ok, c.retry)
+		}
+	}
+
+	// another client has its own bucket
+	r.RemoteAddr = "5.6.7.8:5678"
+	if ok, _, _ := l.Allow("search", r, now); !ok {
+		t.Fatal("expected another client to be allowed")
+	}
+}
+
+func TestIP(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		trust   bool
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"remote", false, "1.2.3.4:5678", nil, "1.2.3.4"},
+		{"ipv6", false, "[::1]:5678", nil, "::1"},
+		{"no port", false, "1.2.3.4", nil, "1.2.3.4"},
+		{"untrusted headers", false, "1.2.3.4:5678", map[string]string{"X-Real-IP": "9.9.9.9"}, "1.2.3.4"},
+		{"real ip", true, "1.2.3.4:5678", map[string]string{"X-Real-IP": "9.9.9.9"}, "9.9.9.9"},
+		{"forwarded for", true, "1.2.3.4:5678", map[string]string{"X-Forwarded-For": "8.8.8.8, 9.9.9.9"}, "9.9.9.9"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = c.remote
+			for k, v := range c.headers {
+				r.Header.Set(k, v)
+			}
+
+			l := &Limiter{TrustProxy: c.trust}
+			if got := l.IP(r); got != c.want {
+				t.Fatalf("got %q; want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	h := &Hasher{Secret: []byte("very secret"), Period: time.Hour}
+	now := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	got := h.Hash("1.2.3.4", now)
+	if strings.Contains(got, "1.2.3.4") || len(got) != 32 {
+		t.Fatalf("got %q", got)
+	}
+
+	if h.Hash("1.2.3.4", now.Add(59*time.Minute)) != got {
+		t.Fatal("expected the same hash within a period")
+	}
+
+	if h.Hash("1.2.3.4", now.Add(time.Hour)) == got {
+		t.Fatal("expected the hash to change with the period")
+	}
+
+	if h.Hash("1.2.3.5", now) == got {
+		t.Fatal("expected another IP to have another hash")
+	}
+
+	if (&Hasher{Secret: []byte("another secret"), Period: time.Hour}).Hash("1.2.3.4", now) == got {
+		t.Fatal("expected another secret to have another hash")
+	}
+}
+
+func TestMemoryTake(t *testing.T) {
+	m := NewMemory()
+	l := PerMinute(60, 3)
+	now := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, c := range []struct {
+		now       time.Time
+		ok        bool
+		remaining int
+	}{
+		{now, true, 2},
+		{now, true, 1},
+		{now, true, 0},
+		{now, false, 0},
+		{now.Add(2 * time.Second), true, 1},
+	} {
+		ok, remaining, _, err := m.Take("abc", l, c.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ok != c.ok || remaining != c.remaining {
+			t.Fatalf("%d: got %v, %d; want %v, %d", i, ok, remaining, c.ok, c.remaining)
+		}
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	m := NewMemory()
+	l := PerMinute(60, 1)
+	now := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	m.Take("old", l, now)
+	m.Take("new", l, now.Add(2*time.Hour))
+	m.sweep(l, now.Add(2*time.Hour))
+
+	if _, ok := m.buckets["old"]; ok {
+		t.Fatal("expected the old bucket to be removed")
+	}
+
+	if _, ok := m.buckets["new"]; !ok {
+		t.Fatal("expected the new bucket to be kept")
+	}
+}
+
+func TestRedisTake(t *testing.T) {
+	now := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	l := PerMinute(60, 10)
+
+	for _, c := range []struct {
+		name      string
+		reply     []interface{}
+		ok        bool
+		remaining int
+		retry     time.Duration
+	}{
+		{"allowed", []interface{}{int64(0), int64(9)}, true, 9, 0},
+		{"limited", []interface{}{int64(250), int64(0)}, false, 0, 250 * time.Millisecond},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			conn := redigomock.NewConn()
+			conn.Command("EVALSHA", takeScript.Hash(), 1, prefix+"search:abc", "1", 10,
+				now.UnixNano()/int64(time.Millisecond), int64(11000),
+			).Expect(c.reply)
+
+			r := &Redis{
+				RedisPool: &redis.Pool{
+					Dial: func() (redis.Conn, error) { return conn, nil },
+				},
+			}
+
+			ok, remaining, retry, err := r.Take("search:abc", l, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ok != c.ok || remaining != c.remaining || retry != c.retry {
+				t.Fatalf("got %v, %d, %v; want %v, %d, %v", ok, remaining, retry, c.ok, c.remaining, c.retry)
+			}
+		})
+	}
+}
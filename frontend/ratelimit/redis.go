@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+const prefix = "jivesearch::ratelimit::"
+
+// takeScript takes a token from a bucket atomically so that frontends
+// sharing a Redis server don't race. A bucket is a hash of its tokens and
+// the time (in ms) it was last used, and expires once it would be full again.
+// Returns the retry in ms (0 if a token was taken) and the whole tokens left.
+var takeScript = redis.NewScript(1, `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local b = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(b[1])
+local last = tonumber(b[2])
+if tokens == nil then
+	tokens, last = burst, now
+end
+
+if now > last then
+	tokens = math.min(burst, tokens + (now - last) / 1000 * rate)
+	last = now
+end
+
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+elseif rate > 0 then
+	retry = math.ceil((1 - tokens) / rate * 1000)
+else
+	retry = ttl
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "last", tostring(last))
+redis.call("PEXPIRE", KEYS[1], ttl)
+return {retry, math.floor(tokens)}
+`)
+
+// Redis keeps the buckets in Redis so that frontends share them
+type Redis struct {
+	RedisPool *redis.Pool
+}
+
+// Take takes a token from a bucket
+func (r *Redis) Take(key string, l Limit, now time.Time) (bool, int, time.Duration, error) {
+	c := r.RedisPool.Get()
+	defer c.Close()
+
+	reply, err := redis.Int64s(takeScript.Do(c,
+		prefix+key,
+		fmt.Sprintf("%g", l.Rate),
+		l.Burst,
+		now.UnixNano()/int64(time.Millisecond),
+		int64((l.full()+time.Second)/time.Millisecond),
+	))
+	if err != nil {
+		return false, 0, 0, err
+	}
+
+	if len(reply) != 2 {
+		return false, 0, 0, fmt.Errorf("unexpected reply from the rate limit script: %v", reply)
+	}
+
+	retry := reply[0]
+	return retry == 0, int(reply[1]), time.Duration(retry) * time.Millisecond, nil
+}
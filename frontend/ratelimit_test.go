@@ -0,0 +1,90 @@
+package frontend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jivesearch/jivesearch/frontend/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	ParseTemplates()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, c := range []struct {
+		name        string
+		limiter     *ratelimit.Limiter
+		url         string
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"no limiter", nil, "/?q=cats", "", http.StatusOK, ""},
+		{"html", mockLimiter(), "/?q=cats", "", http.StatusTooManyRequests, "text/html; charset=utf-8"},
+		{"json output", mockLimiter(), "/?q=cats&o=json", "", http.StatusTooManyRequests, "application/json"},
+		{"api", mockLimiter(), "/api/v1/search?q=cats", "", http.StatusTooManyRequests, "application/json"},
+		{"accept", mockLimiter(), "/?q=cats", "application/json", http.StatusTooManyRequests, "application/json"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{RateLimiter: c.limiter}
+			h := f.rateLimit("search", next)
+
+			// the first request empties the bucket
+			var rr *httptest.ResponseRecorder
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest("GET", c.url, nil)
+				req.Header.Set("Accept", c.accept)
+				rr = httptest.NewRecorder()
+				h.ServeHTTP(rr, req)
+			}
+
+			if rr.Code != c.status {
+				t.Fatalf("got %d; want %d", rr.Code, c.status)
+			}
+
+			if c.status == http.StatusOK {
+				return
+			}
+
+			if got := rr.Header().Get("Content-Type"); got != c.contentType {
+				t.Fatalf("got %q; want %q", got, c.contentType)
+			}
+
+			if got := rr.Header().Get("Retry-After"); got != "60" {
+				t.Fatalf("got Retry-After %q; want %q", got, "60")
+			}
+
+			if c.contentType != "application/json" {
+				if !strings.Contains(rr.Body.String(), "60 seconds") {
+					t.Fatalf("expected the retry in the page. got %v", rr.Body.String())
+				}
+				return
+			}
+
+			e := apiError{}
+			if err := json.NewDecoder(rr.Body).Decode(&e); err != nil {
+				t.Fatal(err)
+			}
+
+			if e.Status != http.StatusTooManyRequests {
+				t.Fatalf("got %+v", e)
+			}
+		})
+	}
+}
+
+// mockLimiter allows 1 request a minute
+func mockLimiter() *ratelimit.Limiter {
+	return &ratelimit.Limiter{
+		Store:  ratelimit.NewMemory(),
+		Hasher: &ratelimit.Hasher{Secret: []byte("very secret"), Period: time.Hour},
+		Limits: map[string]ratelimit.Limit{"search": ratelimit.PerMinute(1, 1)},
+	}
+}
@@ -0,0 +1,583 @@
+package frontend
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	htmlatom "golang.org/x/net/html/atom"
+)
+
+// proxyCSP is the Content-Security-Policy of a proxied page. Everything a page
+// loads is rewritten to go through our proxy but the policy makes sure that
+// whatever we missed (or a page sneaks in) isn't loaded from somewhere else.
+const proxyCSP = "default-src 'none'; img-src 'self' data:; style-src 'self' 'unsafe-inline'; " +
+	"font-src 'self' data:; media-src 'self'; frame-src 'self'; object-src 'self'; " +
+	"form-action 'self'; base-uri 'none'; frame-ancestors 'self'"
+
+// resource is what a url is used for, which tells us how to proxy it
+type resource int
+
+const (
+	page  resource = iota // a link opened in the main window
+	frame                 // the document of an iframe or a meta refresh
+	image                 // goes through our image proxy
+	style                 // a stylesheet or an @import
+	media                 // audio, video, subtitles, fonts & embedded objects
+	form                  // the action of a GET form
+)
+
+var (
+	errNotProxied = errors.New("url can't be proxied")
+	errTracker    = errors.New("url is a tracker")
+)
+
+// proxied are the attributes that load a url and how we proxy them.
+// <link>, <form>, <meta> and srcset are handled separately.
+var proxied = []struct {
+	selector string
+	attr     string
+	resource
+}{
+	{"a, area", "href", page},
+	{"iframe, frame", "src", frame},
+	{"img, input[type=image]", "src", image},
+	{"video", "poster", image},
+	{"body, table, td, th", "background", image},
+	{"video, audio, source, track", "src", media},
+	{"object", "data", media},
+	{"embed", "src", media},
+}
+
+// removedAttrs can run a script, make a request we can't proxy or would fail
+// once proxied (e.g. the integrity of a stylesheet we rewrote).
+// Event handlers (on*) are removed too.
+var removedAttrs = map[string]bool{
+	"archive":     true,
+	"classid":     true,
+	"codebase":    true,
+	"dynsrc":      true,
+	"formaction":  true, // buttons submit to the action of their form
+	"formenctype": true,
+	"formmethod":  true,
+	"formtarget":  true,
+	"integrity":   true,
+	"longdesc":    true,
+	"lowsrc":      true,
+	"manifest":    true,
+	"nonce":       true,
+	"ping":        true,
+	"srcdoc":      true,
+}
+
+// trackers are the analytics & ad domains we strip from a page (including their subdomains).
+// An entry with a path only matches that path of the domain.
+var trackers = []string{
+	"2mdn.net",
+	"addthis.com",
+	"adnxs.com",
+	"ads-twitter.com",
+	"ads.linkedin.com",
+	"adservice.google.com",
+	"amazon-adsystem.com",
+	"analytics.twitter.com",
+	"bat.bing.com",
+	"bluekai.com",
+	"chartbeat.com",
+	"chartbeat.net",
+	"clarity.ms",
+	"connect.facebook.net",
+	"crazyegg.com",
+	"criteo.com",
+	"criteo.net",
+	"demdex.net",
+	"doubleclick.net",
+	"facebook.com/tr",
+	"google-analytics.com",
+	"googleadservices.com",
+	"googlesyndication.com",
+	"googletagmanager.com",
+	"googletagservices.com",
+	"hotjar.com",
+	"krxd.net",
+	"mathtag.com",
+	"mixpanel.com",
+	"moatads.com",
+	"mouseflow.com",
+	"nr-data.net",
+	"omtrdc.net",
+	"outbrain.com",
+	"pixel.wp.com",
+	"quantcount.com",
+	"quantserve.com",
+	"scorecardresearch.com",
+	"segment.io",
+	"sharethis.com",
+	"snap.licdn.com",
+	"stats.wp.com",
+	"taboola.com",
+}
+
+// isTracker reports if a url is one of our trackers
+func isTracker(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+
+	for _, t := range trackers {
+		domain, p := t, ""
+		if i := strings.Index(t, "/"); i > -1 {
+			domain, p = t[:i], t[i:]
+		}
+
+		if host != domain && !strings.HasSuffix(host, "."+domain) {
+			continue
+		}
+
+		if p == "" || u.Path == p || strings.HasPrefix(u.Path, p+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fonts are the extensions of the fonts of a stylesheet, which go
+// through our media proxy instead of our image proxy
+var fonts = map[string]bool{
+	".eot":   true,
+	".otf":   true,
+	".ttf":   true,
+	".woff":  true,
+	".woff2": true,
+}
+
+// rewriter rewrites a page so that everything it loads goes through our proxy
+type rewriter struct {
+	base *url.URL
+}
+
+// proxy returns the url of lnk (relative to our base) through our proxy.
+// Links that don't need our proxy (e.g. a data: image or a mailto: link)
+// are returned as is. Anything else that isn't http(s) isn't proxied.
+func (rw *rewriter) proxy(lnk string, r resource) (string, error) {
+	lnk = strings.TrimSpace(lnk)
+	if lnk == "" {
+		return lnk, nil
+	}
+
+	u, err := url.Parse(lnk)
+	if err != nil {
+		return "", errNotProxied
+	}
+
+	u = rw.base.ResolveReference(u)
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	case "data":
+		if r == image || r == media {
+			return lnk, nil
+		}
+		return "", errNotProxied
+	case "mailto", "tel":
+		if r == page {
+			return lnk, nil
+		}
+		return "", errNotProxied
+	default: // e.g. javascript:
+		return "", errNotProxied
+	}
+
+	if isTracker(u) {
+		return "", errTracker
+	}
+
+	return signedURL(u, r), nil
+}
+
+// signedURL is the url of u through our proxy, with the key that lets our proxy know we made it
+func signedURL(u *url.URL, r resource) string {
+	if r == form { // the browser replaces the query with the form's fields
+		u.RawQuery, u.Fragment = "", ""
+	}
+
+	s := u.String()
+	key := hmacKey(s)
+
+	switch r {
+	case image:
+		return fmt.Sprintf("/image/,s%v/%v", key, s)
+	case form:
+		return fmt.Sprintf("/proxy_form/%v/%v", key, base64.RawURLEncoding.EncodeToString([]byte(s)))
+	}
+
+	p := "/proxy"
+	q := url.Values{}
+	q.Add("key", key)
+	q.Add("q", s)
+
+	switch r {
+	case frame:
+		q.Add("iframe", "true")
+	case style:
+		q.Add("css", "true")
+	case media:
+		p = "/proxy_media"
+	}
+
+	return p + "?" + q.Encode()
+}
+
+// rewrite removes scripts, comments & trackers from a page and
+// rewrites the urls of everything it loads to go through our proxy
+func (rw *rewriter) rewrite(doc *goquery.Document) {
+	// JavaScript is disabled so we show what a browser would without it
+	doc.Find("noscript").Each(func(i int, s *goquery.Selection) {
+		nodes, err := html.ParseFragment(strings.NewReader(s.Text()), s.Nodes[0].Parent)
+		if err != nil {
+			s.Remove()
+			return
+		}
+
+		s.ReplaceWithNodes(nodes...)
+	})
+
+	doc.Find("script, noscript, applet, portal").Remove()
+
+	for _, n := range doc.Nodes {
+		removeComments(n)
+	}
+
+	// the page's urls are relative to its first <base>
+	href := doc.Find("base[href]").First().AttrOr("href", "")
+	if u, err := url.Parse(strings.TrimSpace(href)); err == nil {
+		rw.base = rw.base.ResolveReference(u)
+	}
+	doc.Find("base").Remove()
+
+	doc.Find("*").Each(func(i int, s *goquery.Selection) {
+		n := s.Nodes[0]
+		attrs := n.Attr[:0]
+		for _, a := range n.Attr {
+			key := strings.ToLower(a.Key)
+			if strings.HasPrefix(key, "on") || removedAttrs[key] {
+				continue
+			}
+			attrs = append(attrs, a)
+		}
+		n.Attr = attrs
+	})
+
+	rw.meta(doc)
+	rw.links(doc)
+	rw.forms(doc)
+
+	for _, p := range proxied {
+		doc.Find(p.selector).Each(func(i int, s *goquery.Selection) {
+			lnk, ok := s.Attr(p.attr)
+			if !ok {
+				return
+			}
+
+			u, err := rw.proxy(lnk, p.resource)
+			switch {
+			case err == errTracker && p.resource != page:
+				s.Remove()
+			case err != nil:
+				s.RemoveAttr(p.attr)
+			default:
+				s.SetAttr(p.attr, u)
+				if p.resource == page && u != lnk {
+					s.SetAttr("target", "_top") // make all links open in the main page (not w/in the iframe)
+				}
+			}
+		})
+	}
+
+	doc.Find("img[srcset], source[srcset]").Each(func(i int, s *goquery.Selection) {
+		s.SetAttr("srcset", rw.srcset(s.AttrOr("srcset", "")))
+	})
+
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		if isPixel(s) {
+			s.Remove()
+		}
+	})
+
+	// proxy url() & @import within <style></style> tags and inline styles
+	doc.Find("style").Each(func(i int, s *goquery.Selection) {
+		s.SetText(rw.css(s.Text()))
+	})
+
+	doc.Find("[style]").Each(func(i int, s *goquery.Selection) {
+		s.SetAttr("style", rw.css(s.AttrOr("style", "")))
+	})
+}
+
+// meta rewrites the url of a meta refresh & removes the http-equiv that
+// would change how the page is handled (e.g. set-cookie or its own policy).
+// Its charset is removed as the page was converted to UTF-8.
+func (rw *rewriter) meta(doc *goquery.Document) {
+	doc.Find("meta[charset]").Remove()
+
+	doc.Find("meta[http-equiv]").Each(func(i int, s *goquery.Selection) {
+		if !strings.EqualFold(strings.TrimSpace(s.AttrOr("http-equiv", "")), "refresh") {
+			s.Remove()
+			return
+		}
+
+		delay, lnk := parseRefresh(s.AttrOr("content", ""))
+		if lnk == "" {
+			return
+		}
+
+		u, err := rw.proxy(lnk, frame)
+		if err != nil {
+			s.Remove()
+			return
+		}
+
+		s.SetAttr("content", fmt.Sprintf("%v; url=%v", delay, u))
+	})
+}
+
+// parseRefresh splits the content of a meta refresh (e.g. "5; url='/page'")
+// into its delay and url. The url is empty if the page just reloads.
+func parseRefresh(content string) (string, string) {
+	content = strings.TrimSpace(content)
+
+	i := strings.IndexAny(content, ";,")
+	if i < 0 {
+		return content, ""
+	}
+
+	delay, lnk := strings.TrimSpace(content[:i]), strings.TrimSpace(content[i+1:])
+	if len(lnk) > 3 && strings.EqualFold(lnk[:3], "url") {
+		if rest := strings.TrimSpace(lnk[3:]); strings.HasPrefix(rest, "=") {
+			lnk = strings.TrimSpace(rest[1:])
+		}
+	}
+
+	if len(lnk) > 1 && (lnk[0] == '"' || lnk[0] == '\'') {
+		quote := lnk[0]
+		lnk = lnk[1:]
+		if j := strings.IndexByte(lnk, quote); j > -1 {
+			lnk = lnk[:j]
+		}
+	}
+
+	return delay, lnk
+}
+
+// links keeps the stylesheets & icons of a page. Other <link>s (e.g. preload,
+// dns-prefetch or a manifest) are removed as they would leak requests.
+func (rw *rewriter) links(doc *goquery.Document) {
+	doc.Find("link").Each(func(i int, s *goquery.Selection) {
+		r := resource(-1)
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			switch rel {
+			case "stylesheet":
+				r = style
+			case "icon", "apple-touch-icon":
+				if r != style {
+					r = image
+				}
+			}
+		}
+
+		if r < 0 {
+			s.Remove()
+			return
+		}
+
+		u, err := rw.proxy(s.AttrOr("href", ""), r)
+		if err != nil {
+			s.Remove()
+			return
+		}
+
+		s.SetAttr("href", u)
+	})
+}
+
+// forms sends GET forms through our proxy. Forms that would send
+// data (e.g. POST) are disabled by turning them into a <div>.
+func (rw *rewriter) forms(doc *goquery.Document) {
+	doc.Find("form").Each(func(i int, s *goquery.Selection) {
+		switch strings.ToLower(strings.TrimSpace(s.AttrOr("method", "get"))) {
+		case "dialog":
+			s.RemoveAttr("action")
+			return
+		case "post":
+		default: // an invalid method is a GET
+			action := s.AttrOr("action", "")
+			if action == "" {
+				action = rw.base.String() // the form submits to the page itself
+			}
+
+			if u, err := rw.proxy(action, form); err == nil && strings.HasPrefix(u, "/") {
+				s.SetAttr("action", u)
+				s.SetAttr("method", "get")
+				s.SetAttr("target", "_top")
+				s.RemoveAttr("enctype")
+				return
+			}
+		}
+
+		n := s.Nodes[0]
+		n.Data, n.DataAtom = "div", htmlatom.Div
+		for _, a := range []string{"action", "method", "enctype", "target", "accept-charset", "novalidate", "autocomplete"} {
+			s.RemoveAttr(a)
+		}
+	})
+}
+
+// srcset rewrites each image candidate of a srcset
+func (rw *rewriter) srcset(set string) string {
+	candidates := []string{}
+	for _, c := range parseSrcset(set) {
+		u, err := rw.proxy(c.url, image)
+		if err != nil {
+			continue
+		}
+
+		if c.descriptor != "" {
+			u += " " + c.descriptor
+		}
+		candidates = append(candidates, u)
+	}
+
+	return strings.Join(candidates, ", ")
+}
+
+type candidate struct {
+	url        string
+	descriptor string // e.g. "2x" or "480w"
+}
+
+// parseSrcset splits a srcset into its image candidates as browsers do:
+// https://html.spec.whatwg.org/multipage/images.html#parsing-a-srcset-attribute
+// A url can have a comma (e.g. a data: url) but a candidate can't end with one.
+func parseSrcset(set string) []candidate {
+	candidates := []candidate{}
+
+	for i := 0; i < len(set); {
+		for i < len(set) && (isSpace(set[i]) || set[i] == ',') {
+			i++
+		}
+
+		start := i
+		for i < len(set) && !isSpace(set[i]) {
+			i++
+		}
+
+		if start == i {
+			break
+		}
+
+		c := candidate{url: set[start:i]}
+		if strings.HasSuffix(c.url, ",") {
+			c.url = strings.TrimRight(c.url, ",")
+			candidates = append(candidates, c)
+			continue
+		}
+
+		start, parens := i, 0
+		for ; i < len(set); i++ {
+			switch set[i] {
+			case '(':
+				parens++
+			case ')':
+				parens--
+			}
+
+			if set[i] == ',' && parens <= 0 {
+				break
+			}
+		}
+
+		c.descriptor = strings.Join(strings.Fields(set[start:i]), " ")
+		candidates = append(candidates, c)
+	}
+
+	return candidates
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
+
+// isPixel reports if an image is a tracking pixel (an image no bigger than 1x1)
+func isPixel(s *goquery.Selection) bool {
+	for _, a := range []string{"width", "height"} {
+		v, ok := s.Attr(a)
+		if !ok {
+			return false
+		}
+
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(v), "px"))
+		if err != nil || n > 1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// removeComments removes the comments (including IE's conditional comments) below a node
+func removeComments(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode {
+			n.RemoveChild(c)
+		} else {
+			removeComments(c)
+		}
+		c = next
+	}
+}
+
+// reCSSURL matches an @import (with or without url()) and url() with ', " or no quotes.
+// The url is in one of the submatches.
+var reCSSURL = regexp.MustCompile(`(?i)@import\s*(?:url\(\s*(?:"([^"]*)"|'([^']*)'|([^)\s]*))\s*\)|"([^"]*)"|'([^']*)')|url\(\s*(?:"([^"]*)"|'([^']*)'|([^)\s]*))\s*\)`)
+
+// css rewrites the url() & @import of a stylesheet. Fonts go through our
+// media proxy & everything else is an image. An url we can't proxy is
+// replaced with one that never loads.
+func (rw *rewriter) css(s string) string {
+	return reCSSURL.ReplaceAllStringFunc(s, func(m string) string {
+		var lnk string
+		for _, sub := range reCSSURL.FindStringSubmatch(m)[1:] {
+			if sub != "" {
+				lnk = sub
+				break
+			}
+		}
+
+		if lnk == "" || strings.HasPrefix(lnk, "#") { // e.g. a reference to an svg filter
+			return m
+		}
+
+		format := "url(%q)"
+		r := image
+		switch {
+		case strings.HasPrefix(strings.ToLower(m), "@import"):
+			format, r = "@import url(%q)", style
+		case fonts[strings.ToLower(path.Ext(strings.SplitN(lnk, "?", 2)[0]))]:
+			r = media
+		}
+
+		u, err := rw.proxy(lnk, r)
+		if err != nil {
+			u = "about:invalid"
+		}
+
+		return fmt.Sprintf(format, u)
+	})
+}
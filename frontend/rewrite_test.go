@@ -0,0 +1,123 @@
+package frontend
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseSrcset(t *testing.T) {
+	for _, c := range []struct {
+		srcset string
+		want   []candidate
+	}{
+		{"", []candidate{}},
+		{"image.jpg", []candidate{{"image.jpg", ""}}},
+		{"small.jpg 1x, large.jpg 2x", []candidate{{"small.jpg", "1x"}, {"large.jpg", "2x"}}},
+		{" a.jpg  480w ,b.jpg 800w, ", []candidate{{"a.jpg", "480w"}, {"b.jpg", "800w"}}},
+		{"a.jpg, b.jpg 2x", []candidate{{"a.jpg", ""}, {"b.jpg", "2x"}}},
+		{"data:image/png;base64,AAA= 1x, b.jpg 2x", []candidate{{"data:image/png;base64,AAA=", "1x"}, {"b.jpg", "2x"}}},
+		{"image,with,commas.jpg 100w", []candidate{{"image,with,commas.jpg", "100w"}}},
+	} {
+		t.Run(c.srcset, func(t *testing.T) {
+			if got := parseSrcset(c.srcset); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %+v; want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseRefresh(t *testing.T) {
+	for _, c := range []struct {
+		content string
+		delay   string
+		url     string
+	}{
+		{"5", "5", ""},
+		{"0; url=https://example.com", "0", "https://example.com"},
+		{"0;URL='/next page'", "0", "/next page"},
+		{`3, url = "next.html"`, "3", "next.html"},
+		{"1; next.html", "1", "next.html"},
+	} {
+		t.Run(c.content, func(t *testing.T) {
+			delay, u := parseRefresh(c.content)
+			if delay != c.delay || u != c.url {
+				t.Fatalf("got %q %q; want %q %q", delay, u, c.delay, c.url)
+			}
+		})
+	}
+}
+
+func TestIsTracker(t *testing.T) {
+	for _, c := range []struct {
+		url  string
+		want bool
+	}{
+		{"https://www.google-analytics.com/analytics.js", true},
+		{"https://google-analytics.com/collect", true},
+		{"https://ad.doubleclick.net/click", true},
+		{"https://www.facebook.com/tr?id=1", true},
+		{"https://www.facebook.com/tr/", true},
+		{"https://www.facebook.com/travel", false},
+		{"https://notdoubleclick.net", false},
+		{"https://example.com/google-analytics.com", false},
+	} {
+		t.Run(c.url, func(t *testing.T) {
+			u, err := url.Parse(c.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := isTracker(u); got != c.want {
+				t.Fatalf("got %v; want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRewriteCSS(t *testing.T) {
+	hmacSecret = func() string { return "my_secret" }
+
+	base, err := url.Parse("https://example.com/css/style.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rw := &rewriter{base: base}
+
+	for _, c := range []struct {
+		css  string
+		want string
+	}{
+		{
+			`@import url('fonts.css') screen;`,
+			`@import url("/proxy?css=true&key=C_x7s7NzUDKyqzJmvYJT8kvqrWpY1nSOK-SMtqZvH_U%3D&q=https%3A%2F%2Fexample.com%2Fcss%2Ffonts.css") screen;`,
+		},
+		{
+			`@IMPORT "/print.css";`,
+			`@import url("/proxy?css=true&key=xsV7iE10eoqzB5tLJAV4xKtSBpJNk4XMHU66MeUu3-c%3D&q=https%3A%2F%2Fexample.com%2Fprint.css");`,
+		},
+		{
+			`div {background: url( ../img/bg.png ) repeat}`,
+			`div {background: url("/image/,sFyGkkTC62gZGDUb4cr0zc7j7tVGupKXWk8Yc0gc_Nbo=/https://example.com/img/bg.png") repeat}`,
+		},
+		{
+			`@font-face {src: url("font.WOFF2") format("woff2")}`,
+			`@font-face {src: url("/proxy_media?key=oWR3ylCz58s0geMaBVcpHUhiTbXFWYuBYyBAeLKaIps%3D&q=https%3A%2F%2Fexample.com%2Fcss%2Ffont.WOFF2") format("woff2")}`,
+		},
+		{
+			`div {background: url("https://www.google-analytics.com/pixel.gif")}`,
+			`div {background: url("about:invalid")}`,
+		},
+		{
+			`div {background: url(data:image/png;base64,AAA=); filter: url(#blur)}`,
+			`div {background: url("data:image/png;base64,AAA="); filter: url(#blur)}`,
+		},
+	} {
+		t.Run(c.css, func(t *testing.T) {
+			if got := rw.css(c.css); got != c.want {
+				t.Fatalf("got %q; want %q", got, c.want)
+			}
+		})
+	}
+}
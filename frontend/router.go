@@ -15,16 +15,52 @@ func (f *Frontend) Router(cfg config.Provider) *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
 
 	router.NewRoute().Name("search").Methods("GET").Path("/").Handler(
-		f.middleware(appHandler(f.searchHandler)),
+		f.rateLimit("search", f.middleware(appHandler(f.searchHandler))),
 	)
 	router.NewRoute().Name("answer").Methods("GET").Path("/answer").Handler(
-		f.middleware(appHandler(f.answerHandler)),
+		f.rateLimit("search", f.middleware(appHandler(f.answerHandler))),
 	)
 	router.NewRoute().Name("about").Methods("GET").Path("/about").Handler(
 		f.middleware(appHandler(f.aboutHandler)),
 	)
+	router.NewRoute().Name("settings").Methods("GET", "POST").Path("/settings").Handler(
+		f.middleware(appHandler(f.settingsHandler)),
+	)
+	router.NewRoute().Name("links").Methods("GET").Path("/links").Handler(
+		f.rateLimit("search", f.middleware(appHandler(f.linksHandler))),
+	)
+	router.NewRoute().Name("similar").Methods("GET", "POST").Path("/images/similar").Handler(
+		f.rateLimit("image", f.middleware(appHandler(f.similarHandler))),
+	)
 	router.NewRoute().Name("autocomplete").Methods("GET").Path("/autocomplete").Handler(
-		f.middleware(appHandler(f.autocompleteHandler)),
+		f.rateLimit("autocomplete", f.middleware(appHandler(f.autocompleteHandler))),
+	)
+	router.NewRoute().Name("api_search").Methods("GET").Path("/api/v1/search").Handler(
+		f.apiLimit("search", f.middleware(f.apiAuth("search", f.apiSearchHandler))),
+	)
+	router.NewRoute().Name("api_images").Methods("GET").Path("/api/v1/images").Handler(
+		f.apiLimit("image", f.middleware(f.apiAuth("images", f.apiImagesHandler))),
+	)
+	router.NewRoute().Name("api_answer").Methods("GET").Path("/api/v1/answer").Handler(
+		f.apiLimit("search", f.middleware(f.apiAuth("answer", f.apiAnswerHandler))),
+	)
+	router.NewRoute().Name("api_suggest").Methods("GET").Path("/api/v1/suggest").Handler(
+		f.apiLimit("autocomplete", f.middleware(f.apiAuth("suggest", f.apiSuggestHandler))),
+	)
+	router.NewRoute().Name("api_usage").Methods("GET").Path("/api/v1/usage").Handler(
+		f.middleware(f.apiAuth("usage", f.apiUsageHandler)),
+	)
+	router.NewRoute().Name("healthz").Methods("GET").Path("/healthz").Handler(
+		http.HandlerFunc(f.healthzHandler),
+	)
+	router.NewRoute().Name("readyz").Methods("GET").Path("/readyz").Handler(
+		http.HandlerFunc(f.readyzHandler),
+	)
+	router.NewRoute().Name("status").Methods("GET").Path("/status").Handler(
+		http.HandlerFunc(f.statusHandler),
+	)
+	router.NewRoute().Name("metrics").Methods("GET").Path("/metrics").Handler(
+		http.HandlerFunc(f.metricsHandler),
 	)
 	router.NewRoute().Name("favicon").Methods("GET").Path("/favicon.ico").Handler(
 		http.FileServer(http.Dir("static")),
@@ -32,11 +68,20 @@ func (f *Frontend) Router(cfg config.Provider) *mux.Router {
 	router.NewRoute().Name("opensearch").Methods("GET").Path("/opensearch.xml").Handler(
 		f.middleware(appHandler(f.openSearchHandler)),
 	)
+	router.NewRoute().Name("opensearch_suggest").Methods("GET").Path("/opensearch/suggest").Handler(
+		f.rateLimit("autocomplete", f.middleware(appHandler(f.openSearchSuggestHandler))),
+	)
 	router.NewRoute().Name("proxy").Methods("GET").Path("/proxy").Handler(
-		f.middleware(appHandler(f.proxyHandler)),
+		f.rateLimit("proxy", f.middleware(appHandler(f.proxyHandler))),
 	)
 	router.NewRoute().Name("proxy_header").Methods("GET").Path("/proxy_header").Handler(
-		f.middleware(appHandler(f.proxyHeaderHandler)),
+		f.rateLimit("proxy", f.middleware(appHandler(f.proxyHeaderHandler))),
+	)
+	router.NewRoute().Name("proxy_form").Methods("GET").Path("/proxy_form/{key}/{url}").Handler(
+		f.rateLimit("proxy", f.middleware(appHandler(f.proxyFormHandler))),
+	)
+	router.NewRoute().Name("proxy_media").Methods("GET").Path("/proxy_media").Handler(
+		f.rateLimit("image", http.HandlerFunc(f.proxyMediaHandler)),
 	)
 
 	// How do we exclude viewing the entire static directory of /static path?
@@ -53,7 +98,14 @@ func (f *Frontend) Router(cfg config.Provider) *mux.Router {
 	//p.UserAgent = cfg.GetString("useragent") // not implemented yet: https://github.com/willnorris/imageproxy/pull/83
 	p.SignatureKey = []byte(key)
 	p.Timeout = 2 * time.Second
-	router.NewRoute().Name("image").Methods("GET").PathPrefix("/image/").Handler(http.StripPrefix("/image", p))
+	router.NewRoute().Name("thumbnail").Methods("GET").Path("/thumbnail/{key}").Handler(
+		f.rateLimit("image", http.HandlerFunc(f.thumbnailHandler)),
+	)
+	router.NewRoute().Name("image").Methods("GET").PathPrefix("/image/").Handler(
+		f.rateLimit("image", http.StripPrefix("/image", p)),
+	)
+
+	router.Use(instrument)
 
 	/* To generate new HMAC secret...
 	// DON'T RUN IN PLAYGROUND! Will get same secret each time ;)
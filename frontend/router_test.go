@@ -31,11 +31,56 @@ func TestRouter(t *testing.T) {
 			method: "GET",
 			url:    "http://localhost/about",
 		},
+		{
+			name:   "settings",
+			method: "POST",
+			url:    "http://localhost/settings",
+		},
+		{
+			name:   "links",
+			method: "GET",
+			url:    "http://localhost/links?q=example.com",
+		},
+		{
+			name:   "similar",
+			method: "POST",
+			url:    "http://localhost/images/similar",
+		},
+		{
+			name:   "thumbnail",
+			method: "GET",
+			url:    "http://localhost/thumbnail/0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef.jpg",
+		},
 		{
 			name:   "autocomplete",
 			method: "GET",
 			url:    "http://127.0.0.1/autocomplete",
 		},
+		{
+			name:   "api_search",
+			method: "GET",
+			url:    "http://localhost/api/v1/search?q=search+term",
+		},
+		{
+			name:   "api_images",
+			method: "GET",
+			url:    "http://localhost/api/v1/images?q=search+term",
+		},
+		{
+			name:   "api_answer",
+			method: "GET",
+			url:    "http://localhost/api/v1/answer?q=search+term",
+		},
+		{
+			name:   "api_suggest",
+			method: "GET",
+			url:    "http://localhost/api/v1/suggest?q=search+term",
+		},
+		{
+			name:   "api_usage",
+			method: "GET",
+			url:    "http://localhost/api/v1/usage?q=search+term",
+		},
 		{
 			name:   "favicon",
 			method: "GET",
@@ -51,6 +96,11 @@ func TestRouter(t *testing.T) {
 			method: "GET",
 			url:    "http://localhost/opensearch.xml",
 		},
+		{
+			name:   "opensearch_suggest",
+			method: "GET",
+			url:    "http://localhost/opensearch/suggest?q=search+term",
+		},
 		{
 			name:   "proxy",
 			method: "GET",
@@ -61,6 +111,36 @@ func TestRouter(t *testing.T) {
 			method: "GET",
 			url:    "http://localhost/proxy_header",
 		},
+		{
+			name:   "healthz",
+			method: "GET",
+			url:    "http://localhost/healthz",
+		},
+		{
+			name:   "readyz",
+			method: "GET",
+			url:    "http://localhost/readyz",
+		},
+		{
+			name:   "status",
+			method: "GET",
+			url:    "http://localhost/status",
+		},
+		{
+			name:   "metrics",
+			method: "GET",
+			url:    "http://localhost/metrics",
+		},
+		{
+			name:   "proxy_form",
+			method: "GET",
+			url:    "http://localhost/proxy_form/8Nt8W0SkNDM1Zvu5Sy3FsSzbvKCmjJo6pD-gMhj4FYM=/aHR0cHM6Ly9leGFtcGxlLmNvbS9zZWFyY2g?q=search+term",
+		},
+		{
+			name:   "proxy_media",
+			method: "GET",
+			url:    "http://localhost/proxy_media?key=abc&q=https://example.com/video.mp4",
+		},
 	} {
 		t.Run(c.name, func(t *testing.T) {
 			cfg := &mockProvider{
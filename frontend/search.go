@@ -16,10 +16,14 @@ import (
 	"github.com/jivesearch/jivesearch/instant"
 	"github.com/jivesearch/jivesearch/log"
 	"github.com/jivesearch/jivesearch/search"
+	"github.com/jivesearch/jivesearch/search/detect"
+	"github.com/jivesearch/jivesearch/search/document"
 	img "github.com/jivesearch/jivesearch/search/image"
+	"github.com/jivesearch/jivesearch/search/link"
 	"github.com/jivesearch/jivesearch/suggest"
 	"github.com/pkg/errors"
 	"golang.org/x/text/language"
+	"golang.org/x/text/message"
 )
 
 // Context holds a user's request context so we can pass it to our template's form.
@@ -37,12 +41,15 @@ type Context struct {
 	T            string          `json:"-"`
 	Ref          string          `json:"-"`
 	Safe         bool            `json:"-"`
+	ImageFilters img.Filters     `json:"-"`
 	DefaultBangs []DefaultBang   `json:"-"`
 	Preferred    []language.Tag  `json:"-"`
 	Region       language.Region `json:"-"`
 	Number       int             `json:"-"`
 	Page         int             `json:"-"`
 	Theme        string          `json:"-"`
+	locale       language.Tag    // the language of our UI
+	printer      *message.Printer
 }
 
 // DefaultBang is the user's preffered !bang
@@ -118,13 +125,28 @@ func (f *Frontend) defaultBangs(r *http.Request) []DefaultBang {
 }
 
 // Detect the user's preferred language(s).
-// The "l" param takes precedence over the "Accept-Language" header.
+// The "l" param takes precedence over the language of the query,
+// which takes precedence over the "Accept-Language" header.
 func (f *Frontend) detectLanguage(r *http.Request) []language.Tag {
+	preferred := f.userLanguages(r)
+	if strings.TrimSpace(r.FormValue("l")) != "" {
+		return preferred
+	}
+
+	if l, conf := detect.Detect(r.FormValue("q")); conf >= queryConfidence {
+		preferred = append([]language.Tag{l}, preferred...)
+	}
+
+	return preferred
+}
+
+// userLanguages are the languages a user asked for: the "l" param (or setting)
+// followed by the "Accept-Language" header. Unlike detectLanguage it ignores
+// the language of the query, which says nothing about the language of our UI.
+func (f *Frontend) userLanguages(r *http.Request) []language.Tag {
 	preferred := []language.Tag{}
-	if lang := strings.TrimSpace(r.FormValue("l")); lang != "" {
-		if l, err := language.Parse(lang); err == nil {
-			preferred = append(preferred, l)
-		}
+	if l, err := language.Parse(strings.TrimSpace(r.FormValue("l"))); err == nil {
+		preferred = append(preferred, l)
 	}
 
 	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
@@ -133,10 +155,14 @@ func (f *Frontend) detectLanguage(r *http.Request) []language.Tag {
 		return preferred
 	}
 
-	preferred = append(preferred, tags...)
-	return preferred
+	return append(preferred, tags...)
 }
 
+// queryConfidence is how sure we need to be of the language of a query.
+// Queries are short so in practice this is a query written in a script
+// of its own (e.g. Japanese) or a long query.
+const queryConfidence = .5
+
 // Detect the user's region. "r" param takes precedence over the language's region (if any).
 func (f *Frontend) detectRegion(lang language.Tag, r *http.Request) language.Region {
 	reg, err := language.ParseRegion(strings.TrimSpace(r.FormValue("r")))
@@ -150,6 +176,8 @@ func (f *Frontend) detectRegion(lang language.Tag, r *http.Request) language.Reg
 var errIsNaughty = fmt.Errorf("Naughty word")
 
 func (f *Frontend) addQuery(q string) error {
+	defer stageDuration.Since(time.Now(), stageSuggestInsert)
+
 	exists, err := f.Suggest.Exists(q)
 	if err != nil {
 		return err
@@ -170,7 +198,7 @@ func (f *Frontend) addQuery(q string) error {
 }
 
 func (f *Frontend) getData(r *http.Request) data {
-	r.ParseForm() // for POST requests
+	applySettings(r) // parses the form for POST requests too
 
 	d := data{
 		Brand:     f.Brand,
@@ -186,7 +214,13 @@ func (f *Frontend) getData(r *http.Request) data {
 		d.Context.Safe = false
 	}
 
+	d.Context.ImageFilters = img.NewFilters(
+		r.FormValue("size"), r.FormValue("orientation"), r.FormValue("color"),
+		r.FormValue("mime"), r.FormValue("license"),
+	)
+
 	d.Context.setTheme(r)
+	f.localize(d.Context, r)
 
 	// Note: We can combine Safe with F. They are only separate for now
 	// because image filter is a boolean but that can be changed to off, moderate and strict.
@@ -228,13 +262,28 @@ func (f *Frontend) getData(r *http.Request) data {
 
 	// how many results wanted?
 	d.Context.Number, err = strconv.Atoi(strings.TrimSpace(r.FormValue("n")))
-	if err != nil || d.Context.Number > 100 {
+	if err != nil || d.Context.Number < 1 || d.Context.Number > 100 {
 		d.Context.Number = 25
 	}
 
+	d.Context.Page = clampPage(d.Context.Page, d.Context.Number)
+
 	return d
 }
 
+// maxResults is how deep we page into our results.
+// Elasticsearch won't go past its index.max_result_window (10k by default).
+const maxResults = 10000
+
+// clampPage keeps a page within the first maxResults results
+func clampPage(page, number int) int {
+	if last := maxResults / number; page > last {
+		return last
+	}
+
+	return page
+}
+
 func (f *Frontend) searchHandler(w http.ResponseWriter, r *http.Request) *response {
 	d := f.getData(r)
 
@@ -271,7 +320,11 @@ func (f *Frontend) searchHandler(w http.ResponseWriter, r *http.Request) *respon
 	*/
 
 	// is it a !bang? Redirect them
-	if bng, loc, ok := f.Bangs.Detect(d.Context.Q, d.Context.Region, d.Context.lang); ok {
+	start := time.Now()
+	bng, loc, ok := f.Bangs.Detect(d.Context.Q, d.Context.Region, d.Context.lang)
+	stageDuration.Since(start, stageBang)
+
+	if ok {
 		log.Info.Printf("!bang (%v)", bng.Name)
 		return &response{
 			status:   302,
@@ -317,35 +370,7 @@ func (f *Frontend) searchHandler(w http.ResponseWriter, r *http.Request) *respon
 	go func(d data, lang language.Tag, region language.Region) {
 		switch d.Context.T {
 		case "images":
-			key := cacheKey("images", lang, region, r.URL)
-
-			v, err := f.Cache.Get(key)
-			if err != nil {
-				log.Info.Println(err)
-			}
-
-			if v != nil {
-				ir := &img.Results{}
-				if err := json.Unmarshal(v.([]byte), &ir); err != nil {
-					log.Info.Println(err)
-				}
-
-				imageCH <- ir
-				return
-			}
-
-			num := 100
-			offset := d.Context.Page*num - num
-			ir, err := f.Images.Fetch(d.Context.Q, d.Context.Safe, num, offset) // .8 is Yahoo's open_nsfw cutoff for nsfw
-			if err != nil {
-				log.Info.Println(err)
-			}
-
-			if err := f.Cache.Put(key, ir, f.Cache.Search); err != nil {
-				log.Info.Println(err)
-			}
-
-			imageCH <- ir
+			imageCH <- f.imageResults(d, lang, region, r.URL)
 		case "maps":
 			resp.template = "maps"
 			channels--
@@ -365,36 +390,7 @@ func (f *Frontend) searchHandler(w http.ResponseWriter, r *http.Request) *respon
 	for i := 0; i < channels; i++ {
 		select {
 		case d.Images = <-imageCH:
-			// fetch the image & convert to base64 for smoother user experience
-			tmp := make(chan *img.Image, len(d.Images.Images))
-
-			go func() {
-				for im := range tmp {
-					for i, o := range d.Images.Images {
-						if im.ID == o.ID {
-							d.Images.Images[i] = im
-						}
-					}
-				}
-			}()
-
-			var wg sync.WaitGroup
-
-			for _, im := range d.Images.Images {
-				wg.Add(1)
-				go func(im *img.Image) {
-					var err error
-					im, err = f.fetchImage(im)
-					if err != nil {
-						log.Debug.Println(err)
-					}
-					tmp <- im
-					wg.Done()
-				}(im)
-			}
-
-			wg.Wait()
-
+			f.base64Images(d.Images)
 			stats.images = time.Since(strt).Round(time.Millisecond)
 		case d.Instant = <-ic:
 			if d.Instant.Err != nil {
@@ -416,6 +412,7 @@ func (f *Frontend) searchHandler(w http.ResponseWriter, r *http.Request) *respon
 			case errIsNaughty:
 				log.Debug.Println(err)
 			default:
+				providerErrors.Inc("suggest")
 				log.Info.Println(err)
 			}
 			stats.autocomplete = time.Since(strt).Round(time.Millisecond)
@@ -428,8 +425,29 @@ func (f *Frontend) searchHandler(w http.ResponseWriter, r *http.Request) *respon
 
 	log.Info.Printf("ac:%v, images: %v, instant (%v):%v, search:%v\n", stats.autocomplete, stats.images, d.Instant.Type, stats.instant, stats.search)
 
-	if r.FormValue("o") == "json" {
-		resp.template = r.FormValue("o")
+	switch o := r.FormValue("o"); o {
+	case "json":
+		resp.template = o
+	case "rss", "atom": // subscribe to a search in a feed reader
+		fd := feed{
+			brand:  f.Brand,
+			q:      d.Context.Q,
+			page:   d.Context.Page,
+			number: d.Context.Number,
+			res:    d.Search,
+			now:    time.Now().UTC(),
+		}
+		if fd.res == nil {
+			fd.res = &search.Results{}
+		}
+
+		resp.template = o
+		if o == "rss" {
+			resp.data = fd.rss()
+		} else {
+			resp.data = fd.atom()
+		}
+		return resp
 	}
 
 	resp.data = d
@@ -439,41 +457,135 @@ func (f *Frontend) searchHandler(w http.ResponseWriter, r *http.Request) *respon
 func (f *Frontend) searchResults(d data, lang language.Tag, region language.Region, u *url.URL) *search.Results {
 	key := cacheKey("search", lang, region, u)
 
-	v, err := f.Cache.Get(key)
-	if err != nil {
-		log.Info.Println(err)
+	target, domain, isLink := link.Parse(d.Context.Q)
+	if isLink {
+		key += "::" + d.Context.Locale() // the descriptions of link results are localized
 	}
 
-	if v != nil {
-		sr := &search.Results{}
-		if err := json.Unmarshal(v.([]byte), &sr); err != nil {
+	if v := f.cacheGet("search", key); v != nil {
+		cr := cachedResults{}
+		if err := json.Unmarshal(v.([]byte), &cr); err != nil {
 			log.Info.Println(err)
 		}
-		return sr
+		sr := search.Results(cr)
+		return &sr
 	}
 
 	offset := d.Context.Page*d.Context.Number - d.Context.Number
-	sr, err := f.Search.Fetch(d.Context.Q, d.Context.F, lang, region, d.Context.Number, offset)
+
+	var sr *search.Results
+	var err error
+	provider := providerName(f.Search)
+
+	start := time.Now()
+	if isLink && f.Links != nil {
+		provider = "links"
+		sr, err = f.linkResults(d.Context, target, domain, d.Context.Number, offset)
+	} else {
+		sr, err = f.Search.Fetch(d.Context.Q, d.Context.F, lang, region, d.Context.Number, offset)
+	}
+	stageDuration.Since(start, stageSearch)
+
 	if err != nil {
+		providerErrors.Inc(provider)
 		log.Info.Println(err)
 	}
 
 	sr = sr.AddPagination(d.Context.Number, d.Context.Page) // move this to javascript??? (Wouldn't be available in API....)
 
-	if err := f.Cache.Put(key, sr, f.Cache.Search); err != nil {
+	f.cachePut(key, cachedResults(*sr), f.Cache.Search)
+
+	return sr
+}
+
+// cachedResults are search.Results with the fields that
+// are left out of our JSON output (e.g. the count for feeds)
+type cachedResults struct {
+	Provider   search.Provider      `json:"provider"`
+	Count      int64                `json:"count"`
+	Page       string               `json:"page"`
+	Previous   string               `json:"previous"`
+	Next       string               `json:"next"`
+	Last       string               `json:"last"`
+	Pagination []string             `json:"pagination"`
+	Documents  []*document.Document `json:"documents"`
+}
+
+// imagesPerPage is the number of images on a page of results
+const imagesPerPage = 100
+
+// imageResults fetches the images of a query from the cache or the backend
+func (f *Frontend) imageResults(d data, lang language.Tag, region language.Region, u *url.URL) *img.Results {
+	key := imagesCacheKey(lang, region, u, d.Context.ImageFilters)
+
+	if v := f.cacheGet("images", key); v != nil {
+		ir := &img.Results{}
+		if err := json.Unmarshal(v.([]byte), &ir); err != nil {
+			log.Info.Println(err)
+		}
+
+		return ir
+	}
+
+	offset := d.Context.Page*imagesPerPage - imagesPerPage
+	start := time.Now()
+	ir, err := f.Images.Fetch(d.Context.Q, d.Context.Safe, d.Context.ImageFilters, imagesPerPage, offset) // .8 is Yahoo's open_nsfw cutoff for nsfw
+	stageDuration.Since(start, stageImages)
+
+	if err != nil {
+		providerErrors.Inc("images")
 		log.Info.Println(err)
 	}
 
-	return sr
+	f.cachePut(key, ir, f.Cache.Search)
+
+	return ir
+}
+
+// base64Images fetches the images & converts them to base64 for smoother user experience
+func (f *Frontend) base64Images(res *img.Results) {
+	tmp := make(chan *img.Image, len(res.Images))
+
+	go func() {
+		for im := range tmp {
+			for i, o := range res.Images {
+				if im.ID == o.ID {
+					res.Images[i] = im
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	for _, im := range res.Images {
+		wg.Add(1)
+		go func(im *img.Image) {
+			var err error
+			im, err = f.fetchImage(im)
+			if err != nil {
+				log.Debug.Println(err)
+			}
+			tmp <- im
+			wg.Done()
+		}(im)
+	}
+
+	wg.Wait()
 }
 
-// fetchImage fetches and converts an image to Base64
+// fetchImage fetches and converts an image to Base64.
+// Images with a thumbnail are served by the thumbnail route instead
+// so browsers can cache them.
 func (f *Frontend) fetchImage(i *img.Image) (*img.Image, error) {
 	var err error
 
+	if i.Thumbnail != "" && f.Images.Thumbnails != nil {
+		return i, nil
+	}
+
 	// go through image proxy to resize and cache the image
-	key := hmacKey(i.ID)
-	u := fmt.Sprintf("%v/image/225x,s%v/%v", f.Host, key, i.ID)
+	u := f.Host + proxiedImage(i.ID)
 	fmt.Println(u)
 
 	resp, err := f.Images.Client.Get(u)
@@ -499,6 +611,23 @@ func cacheKey(item string, lang language.Tag, region language.Region, u *url.URL
 	return fmt.Sprintf("::%v::%v::%v::%v", item, lang.String(), region.String(), u.String())
 }
 
+// imageFilterParams are the query params of the image filters
+var imageFilterParams = []string{"size", "orientation", "color", "mime", "license"}
+
+// imagesCacheKey replaces the raw image filter params of the url
+// with the filters we actually used so that unsupported values
+// and the order of the params don't create new cache entries.
+func imagesCacheKey(lang language.Tag, region language.Region, u *url.URL, filters img.Filters) string {
+	v := u.Query()
+	for _, p := range imageFilterParams {
+		v.Del(p)
+	}
+
+	uu := *u
+	uu.RawQuery = v.Encode()
+	return fmt.Sprintf("%v::%v", cacheKey("images", lang, region, &uu), filters)
+}
+
 var themes = map[string]bool{
 	"night": true,
 }
@@ -1,9 +1,11 @@
 package frontend
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"testing"
 	"time"
@@ -114,21 +116,32 @@ func TestDetectLanguage(t *testing.T) {
 		name           string
 		acceptLanguage string
 		l              string
+		q              string
 		want           []language.Tag
 	}{
 		{
-			"blank", "", "", []language.Tag{},
+			"blank", "", "", "", []language.Tag{},
 		},
 		{
-			"basic", "", "en", []language.Tag{language.English},
+			"basic", "", "en", "", []language.Tag{language.English},
 		},
 		{
-			"french", "", "fr", []language.Tag{language.French},
+			"french", "", "fr", "", []language.Tag{language.French},
+		},
+		{
+			"query language", "en", "", "東京の天気はどうですか", []language.Tag{language.Japanese, language.English},
+		},
+		{
+			"param overrides query language", "en", "fr", "東京の天気はどうですか", []language.Tag{language.French, language.English},
+		},
+		{
+			"short query", "de", "", "weather", []language.Tag{language.German},
 		},
 		{
 			"Accept-Language header",
 			"fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7",
 			"",
+			"",
 			[]language.Tag{
 				language.MustParse("fr-CH"),
 				language.French,
@@ -140,6 +153,7 @@ func TestDetectLanguage(t *testing.T) {
 			"param overrides Accept-Language header",
 			"fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7",
 			"hr",
+			"",
 			[]language.Tag{
 				language.Croatian,
 				language.MustParse("fr-CH"),
@@ -161,6 +175,7 @@ func TestDetectLanguage(t *testing.T) {
 
 			q := req.URL.Query()
 			q.Add("l", c.l)
+			q.Add("q", c.q)
 
 			req.URL.RawQuery = q.Encode()
 
@@ -428,6 +443,41 @@ func TestSearchHandler(t *testing.T) {
 	}
 }
 
+func TestImagesCacheKey(t *testing.T) {
+	lang, region := language.MustParse("en"), language.MustParseRegion("US")
+
+	for _, c := range []struct {
+		name    string
+		raw     string
+		filters img.Filters
+		want    string
+	}{
+		{
+			name: "no filters",
+			raw:  "q=cats&t=images",
+			want: "::images::en::US::/?q=cats&t=images::",
+		},
+		{
+			name:    "filters",
+			raw:     "size=large&q=cats&color=bw&t=images",
+			filters: img.Filters{Size: "large", Color: "bw"},
+			want:    "::images::en::US::/?q=cats&t=images::color=bw&size=large",
+		},
+		{
+			name: "unsupported",
+			raw:  "q=cats&t=images&size=huge",
+			want: "::images::en::US::/?q=cats&t=images::",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			u := &url.URL{Path: "/", RawQuery: c.raw}
+			if got := imagesCacheKey(lang, region, u, c.filters); got != c.want {
+				t.Fatalf("got %q; want %q", got, c.want)
+			}
+		})
+	}
+}
+
 type mockSearch struct{}
 
 func (s *mockSearch) Fetch(q string, f search.Filter, lang language.Tag, region language.Region, page int, number int) (*search.Results, error) {
@@ -436,10 +486,19 @@ func (s *mockSearch) Fetch(q string, f search.Filter, lang language.Tag, region
 
 type mockImages struct{}
 
-func (i *mockImages) Fetch(q string, safe bool, number int, offset int) (*img.Results, error) {
+func (i *mockImages) Fetch(q string, safe bool, f img.Filters, number int, offset int) (*img.Results, error) {
 	return mockImageResults, nil
 }
 
+func (i *mockImages) Similar(hash uint64, distance int, safe bool, f img.Filters, number int) (*img.Results, error) {
+	return &img.Results{
+		Count: 1,
+		Images: []*img.Image{
+			{ID: "https://www.example.com/similar.jpg", PHash: img.FormatHash(hash)},
+		},
+	}, nil
+}
+
 type mockCacher struct{}
 
 func (c *mockCacher) Get(key string) (interface{}, error) {
@@ -469,7 +528,7 @@ func (c *mockCacher) Put(key string, value interface{}, ttl time.Duration) error
 // mock Stack Overflow Fetcher
 type mockStackOverflowFetcher struct{}
 
-func (s *mockStackOverflowFetcher) Fetch(query string, tags []string) (stackoverflow.Response, error) {
+func (s *mockStackOverflowFetcher) Fetch(ctx context.Context, query string, tags []string) (stackoverflow.Response, error) {
 	return stackoverflow.Response{}, nil
 }
 
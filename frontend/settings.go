@@ -0,0 +1,288 @@
+package frontend
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jivesearch/jivesearch/log"
+	"golang.org/x/text/language"
+)
+
+// Settings are a user's preferences. We don't store them. They are kept
+// in a signed cookie or, for users who don't want cookies, in a token
+// they add to their searches ("?settings=...").
+// Each field is the value of the query param of the same name.
+type Settings struct {
+	Theme    string `json:"theme,omitempty"`
+	Safe     string `json:"safe,omitempty"` // "f" turns off safe search for images
+	Filter   string `json:"f,omitempty"`    // strict, moderate or off
+	Language string `json:"l,omitempty"`
+	Region   string `json:"r,omitempty"`
+	Number   string `json:"n,omitempty"` // results per page
+	Bangs    string `json:"b,omitempty"` // default !bangs ("g,yt")
+}
+
+const (
+	settingsCookie = "settings"
+	settingsParam  = "settings"
+	settingsMaxAge = 365 * 24 * time.Hour
+)
+
+var errInvalidSettings = fmt.Errorf("invalid settings")
+
+// params are the query params of the settings
+func (s Settings) params() map[string]string {
+	return map[string]string{
+		"theme": s.Theme,
+		"safe":  s.Safe,
+		"f":     s.Filter,
+		"l":     s.Language,
+		"r":     s.Region,
+		"n":     s.Number,
+		"b":     s.Bangs,
+	}
+}
+
+// newSettings takes the settings we support from form values
+func (f *Frontend) newSettings(v url.Values) Settings {
+	s := Settings{}
+
+	if th := strings.ToLower(strings.TrimSpace(v.Get("theme"))); themes[th] {
+		s.Theme = th
+	}
+
+	if strings.TrimSpace(v.Get("safe")) == "f" {
+		s.Safe = "f"
+	}
+
+	switch fl := strings.TrimSpace(v.Get("f")); fl {
+	case "strict", "off":
+		s.Filter = fl
+	}
+
+	if l, err := language.Parse(strings.TrimSpace(v.Get("l"))); err == nil {
+		s.Language = l.String()
+	}
+
+	if r, err := language.ParseRegion(strings.TrimSpace(v.Get("r"))); err == nil {
+		s.Region = r.String()
+	}
+
+	if n, err := strconv.Atoi(strings.TrimSpace(v.Get("n"))); err == nil && n > 0 && n <= 100 {
+		s.Number = strconv.Itoa(n)
+	}
+
+	triggers := []string{}
+	for _, t := range strings.Split(v.Get("b"), ",") {
+		t = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(t)), "!")
+		if t != "" && f.isBang(t) {
+			triggers = append(triggers, t)
+		}
+	}
+	s.Bangs = strings.Join(triggers, ",")
+
+	return s
+}
+
+func (f *Frontend) isBang(trigger string) bool {
+	if f.Bangs == nil {
+		return false
+	}
+
+	for _, b := range f.Bangs.Bangs {
+		for _, t := range b.Triggers {
+			if t == trigger {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// encodeSettings signs the settings so that they can't be tampered with
+// (e.g. to make us fetch a language or !bang we don't support).
+// The token is the base64 of the JSON settings and its HMAC.
+func encodeSettings(s Settings) (string, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + settingsSignature(payload), nil
+}
+
+func decodeSettings(token string) (Settings, error) {
+	s := Settings{}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(settingsSignature(parts[0]))) {
+		return s, errInvalidSettings
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return s, errInvalidSettings
+	}
+
+	err = json.Unmarshal(b, &s)
+	return s, err
+}
+
+func settingsSignature(payload string) string {
+	h := hmac.New(sha256.New, []byte(hmacSecret()))
+	h.Write([]byte("settings:" + payload)) // so a settings signature is never a valid image proxy key
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// userSettings are the settings of a request. The fields
+// of the token in the url override those of the cookie.
+func userSettings(r *http.Request) Settings {
+	s := Settings{}
+
+	if c, err := r.Cookie(settingsCookie); err == nil {
+		cs, err := decodeSettings(c.Value)
+		if err != nil {
+			log.Debug.Println(err)
+		}
+		s = s.merge(cs)
+	}
+
+	if token := strings.TrimSpace(r.URL.Query().Get(settingsParam)); token != "" {
+		ts, err := decodeSettings(token)
+		if err != nil {
+			log.Debug.Println(err)
+		}
+		s = s.merge(ts)
+	}
+
+	return s
+}
+
+// merge overrides the settings with the fields of o that are set
+func (s Settings) merge(o Settings) Settings {
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&s.Theme, o.Theme}, {&s.Safe, o.Safe}, {&s.Filter, o.Filter}, {&s.Language, o.Language},
+		{&s.Region, o.Region}, {&s.Number, o.Number}, {&s.Bangs, o.Bangs},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+
+	return s
+}
+
+// applySettings merges a user's settings into a request. Query params take precedence
+// over the settings token, which takes precedence over the settings cookie.
+// The settings are added to the url too so that they are part of our cache keys.
+func applySettings(r *http.Request) {
+	r.ParseForm()
+
+	q := r.URL.Query()
+	changed := q.Get(settingsParam) != ""
+	q.Del(settingsParam)
+	r.Form.Del(settingsParam)
+
+	for k, v := range userSettings(r).params() {
+		if v == "" || strings.TrimSpace(r.Form.Get(k)) != "" {
+			continue
+		}
+
+		q.Set(k, v)
+		r.Form.Set(k, v)
+		changed = true
+	}
+
+	if !changed {
+		return
+	}
+
+	u := *r.URL
+	u.RawQuery = q.Encode()
+	r.URL = &u
+}
+
+type settingsData struct {
+	Brand
+	*Context
+	Settings
+	Token  string
+	Export string // the url of a search with the settings token
+	Saved  bool
+	Themes []string
+}
+
+// settingsHandler shows a user their settings. A POST saves them in a cookie.
+func (f *Frontend) settingsHandler(w http.ResponseWriter, r *http.Request) *response {
+	if r.Method == http.MethodPost {
+		r.ParseForm()
+
+		if r.FormValue("clear") != "" {
+			http.SetCookie(w, &http.Cookie{
+				Name: settingsCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true,
+			})
+
+			return &response{status: http.StatusFound, redirect: "/settings"}
+		}
+
+		token, err := encodeSettings(f.newSettings(r.PostForm))
+		if err != nil {
+			return &response{status: http.StatusInternalServerError, err: err}
+		}
+
+		c := &http.Cookie{
+			Name:     settingsCookie,
+			Value:    token,
+			Path:     "/",
+			MaxAge:   int(settingsMaxAge.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil || strings.HasPrefix(f.Brand.Host, "https://"),
+		}
+
+		// http.Cookie has no SameSite before Go 1.11
+		w.Header().Add("Set-Cookie", c.String()+"; SameSite=Lax")
+
+		return &response{status: http.StatusFound, redirect: "/settings?saved=true&" + settingsParam + "=" + url.QueryEscape(token)}
+	}
+
+	s := userSettings(r)
+	token, err := encodeSettings(s)
+	if err != nil {
+		return &response{status: http.StatusInternalServerError, err: err}
+	}
+
+	d := settingsData{
+		Brand:    f.Brand,
+		Context:  &Context{Theme: s.Theme},
+		Settings: s,
+		Token:    token,
+		Export:   f.Brand.Host + "/?" + settingsParam + "=" + url.QueryEscape(token) + "&q=%s",
+		Saved:    r.URL.Query().Get("saved") != "",
+	}
+	f.localize(d.Context, r)
+
+	for th := range themes {
+		d.Themes = append(d.Themes, th)
+	}
+	sort.Strings(d.Themes)
+
+	return &response{
+		status:   http.StatusOK,
+		template: "settings",
+		data:     d,
+	}
+}
@@ -0,0 +1,184 @@
+package frontend
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSettingsToken(t *testing.T) {
+	hmacSecret = func() string { return "very secret" }
+
+	s := Settings{Theme: "night", Language: "fr", Number: "50", Bangs: "g,yt"}
+	token, err := encodeSettings(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := decodeSettings(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, s) {
+		t.Fatalf("got %+v; want %+v", got, s)
+	}
+
+	parts := strings.Split(token, ".")
+	tampered, _ := encodeSettings(Settings{Number: "1000"})
+	for _, bad := range []string{"", "abc", parts[0], strings.Split(tampered, ".")[0] + "." + parts[1]} {
+		if _, err := decodeSettings(bad); err != errInvalidSettings {
+			t.Fatalf("got %v for %q; want %v", err, bad, errInvalidSettings)
+		}
+	}
+
+	// a token signed with another secret
+	hmacSecret = func() string { return "another secret" }
+	if _, err := decodeSettings(token); err != errInvalidSettings {
+		t.Fatalf("got %v; want %v", err, errInvalidSettings)
+	}
+}
+
+func TestNewSettings(t *testing.T) {
+	bngs, err := bangsFromConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &Frontend{Bangs: bngs}
+
+	for _, c := range []struct {
+		name string
+		v    url.Values
+		want Settings
+	}{
+		{
+			"valid",
+			url.Values{
+				"theme": {"Night"}, "safe": {"f"}, "f": {"strict"}, "l": {"fr"},
+				"r": {"ca"}, "n": {"50"}, "b": {"!g, yt ,notabang"},
+			},
+			Settings{Theme: "night", Safe: "f", Filter: "strict", Language: "fr", Region: "CA", Number: "50", Bangs: "g,yt"},
+		},
+		{
+			"invalid",
+			url.Values{
+				"theme": {"pink"}, "safe": {"yes"}, "f": {"moderate"}, "l": {"!!"},
+				"r": {"nowhere"}, "n": {"1000"}, "b": {"notabang"},
+			},
+			Settings{},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if got := f.newSettings(c.v); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %+v; want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestApplySettings(t *testing.T) {
+	hmacSecret = func() string { return "very secret" }
+
+	cookie, _ := encodeSettings(Settings{Theme: "night", Language: "fr", Number: "50"})
+	token, _ := encodeSettings(Settings{Language: "de", Region: "AT"})
+
+	for _, c := range []struct {
+		name   string
+		url    string
+		cookie string
+		want   string
+	}{
+		{"none", "/?q=cats", "", "q=cats"},
+		{"cookie", "/?q=cats", cookie, "l=fr&n=50&q=cats&theme=night"},
+		{"token overrides cookie", "/?q=cats&settings=" + token, cookie, "l=de&n=50&q=cats&r=AT&theme=night"},
+		{"params override token", "/?q=cats&l=es&n=10&settings=" + token, cookie, "l=es&n=10&q=cats&r=AT&theme=night"},
+		{"invalid cookie", "/?q=cats", "abc.def", "q=cats"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", c.url, nil)
+			if c.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: settingsCookie, Value: c.cookie})
+			}
+
+			applySettings(req)
+
+			if req.URL.RawQuery != c.want {
+				t.Fatalf("got %q; want %q", req.URL.RawQuery, c.want)
+			}
+
+			want, _ := url.ParseQuery(c.want)
+			if !reflect.DeepEqual(req.Form, want) {
+				t.Fatalf("got form %v; want %v", req.Form, want)
+			}
+		})
+	}
+}
+
+func TestSettingsHandler(t *testing.T) {
+	hmacSecret = func() string { return "very secret" }
+
+	bngs, err := bangsFromConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &Frontend{
+		Brand: Brand{Host: "https://jivesearch.com"},
+		Bangs: bngs,
+	}
+
+	// save
+	form := url.Values{"theme": {"night"}, "n": {"50"}, "b": {"g"}}
+	req := httptest.NewRequest("POST", "/settings", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+
+	got := f.settingsHandler(rr, req)
+
+	token, _ := encodeSettings(Settings{Theme: "night", Number: "50", Bangs: "g"})
+	want := &response{status: http.StatusFound, redirect: "/settings?saved=true&settings=" + url.QueryEscape(token)}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v; want %+v", got, want)
+	}
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != token || !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Fatalf("got cookies %+v", cookies)
+	}
+
+	if h := rr.Header().Get("Set-Cookie"); !strings.HasSuffix(h, "; SameSite=Lax") {
+		t.Fatalf("expected a SameSite cookie; got %q", h)
+	}
+
+	// show
+	req = httptest.NewRequest("GET", "/settings", nil)
+	req.AddCookie(cookies[0])
+	got = f.settingsHandler(httptest.NewRecorder(), req)
+
+	d := got.data.(settingsData)
+	if d.Settings.Theme != "night" || d.Token != token || d.Export != "https://jivesearch.com/?settings="+url.QueryEscape(token)+"&q=%s" {
+		t.Fatalf("got %+v", d)
+	}
+
+	ParseTemplates()
+	var buf bytes.Buffer
+	if err := templates[got.template].Execute(&buf, got.data); err != nil {
+		t.Fatal(err)
+	}
+
+	// reset
+	form = url.Values{"clear": {"true"}}
+	req = httptest.NewRequest("POST", "/settings", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+
+	got = f.settingsHandler(rr, req)
+	if got.status != http.StatusFound || rr.Result().Cookies()[0].MaxAge != -1 {
+		t.Fatalf("got %+v; cookies %+v", got, rr.Result().Cookies())
+	}
+}
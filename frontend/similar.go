@@ -0,0 +1,210 @@
+package frontend
+
+import (
+	"fmt"
+	stdimage "image"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jivesearch/jivesearch/log"
+	img "github.com/jivesearch/jivesearch/search/image"
+)
+
+// maxSimilarBytes is the max size of an image we hash for a similar images search
+const maxSimilarBytes = 10 << 20
+
+// similarField is the form field of an uploaded image
+const similarField = "image"
+
+var (
+	errNoSimilarImage = fmt.Errorf("an image url, upload or hash is required")
+	errImageTooLarge  = fmt.Errorf("the image is larger than %d bytes", maxSimilarBytes)
+	errSimilarFetch   = fmt.Errorf("unable to fetch the image")
+)
+
+// privateNets are the networks a url from a user must not make us connect to
+var privateNets = func() []*net.IPNet {
+	nets := []*net.IPNet{}
+	for _, cidr := range []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+		"192.0.0.0/24", "192.168.0.0/16", "198.18.0.0/15", "224.0.0.0/3",
+		"::/128", "::1/128", "fc00::/7", "fe80::/10", "ff00::/8",
+	} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// PublicIPs drops the loopback, private, link-local & multicast addresses
+// a host resolves to. It is the IPFilter of the dialer of the client we
+// fetch the images of users with so they can't reach our internal services.
+func PublicIPs(ips []net.IP) []net.IP {
+	public := []net.IP{}
+
+	for _, ip := range ips {
+		private := false
+		for _, n := range privateNets {
+			if n.Contains(ip) {
+				private = true
+				break
+			}
+		}
+
+		if !private {
+			public = append(public, ip)
+		}
+	}
+
+	return public
+}
+
+// similarHandler finds the images that look like an image.
+// The image is a pHash ("?hash=f0f0f0f0f0f0f0f0"), a url
+// ("?url=https://example.com/cat.jpg") or a multipart upload.
+// Uploads are hashed in memory and are never written to disk,
+// logged or cached.
+func (f *Frontend) similarHandler(w http.ResponseWriter, r *http.Request) *response {
+	d := data{
+		Brand:     f.Brand,
+		MapBoxKey: f.MapBoxKey,
+		Context: &Context{
+			Q:    strings.TrimSpace(r.URL.Query().Get("url")),
+			T:    "images",
+			Safe: r.URL.Query().Get("safe") != "f",
+			ImageFilters: img.NewFilters(
+				r.URL.Query().Get("size"), r.URL.Query().Get("orientation"), r.URL.Query().Get("color"),
+				r.URL.Query().Get("mime"), r.URL.Query().Get("license"),
+			),
+		},
+	}
+
+	hash, label, err := f.similarHash(w, r)
+	switch err {
+	case nil:
+	case errNoSimilarImage, errImageTooLarge, errSimilarFetch, stdimage.ErrFormat, img.ErrTooManyPixels:
+		return &response{status: http.StatusBadRequest, err: err}
+	default:
+		return &response{status: http.StatusBadRequest, err: fmt.Errorf("unable to hash image: %v", err)}
+	}
+
+	if d.Context.Q == "" {
+		d.Context.Q = label
+	}
+	f.localize(d.Context, r) // after the upload was read
+
+	distance, err := strconv.Atoi(r.URL.Query().Get("distance"))
+	if err != nil {
+		distance = img.MaxSimilarDistance
+	}
+
+	res, err := f.Images.Similar(hash, distance, d.Context.Safe, d.Context.ImageFilters, 100)
+	if err != nil {
+		return &response{status: http.StatusInternalServerError, err: err}
+	}
+
+	resp := &response{
+		status:   http.StatusOK,
+		template: "search",
+	}
+
+	if r.URL.Query().Get("o") == "json" {
+		resp.template = "json"
+	} else {
+		f.base64Images(res)
+	}
+
+	d.Images = res
+	resp.data = d
+	return resp
+}
+
+// similarHash is the pHash of the image we want similar images of
+// and a label for it (the filename of an upload or the hash)
+func (f *Frontend) similarHash(w http.ResponseWriter, r *http.Request) (uint64, string, error) {
+	if h := strings.TrimSpace(r.URL.Query().Get("hash")); h != "" {
+		hash, err := img.ParseHash(h)
+		return hash, h, err
+	}
+
+	if r.Method == http.MethodPost {
+		return similarUpload(w, r)
+	}
+
+	u, err := url.Parse(strings.TrimSpace(r.URL.Query().Get("url")))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return 0, "", errNoSimilarImage
+	}
+
+	// the reason we couldn't fetch the image isn't told so that
+	// we don't help anyone scan the hosts & ports we can reach
+	resp, err := f.Images.Client.Get(u.String())
+	if err != nil {
+		log.Debug.Println(err)
+		return 0, "", errSimilarFetch
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Debug.Printf("got status %d for %v", resp.StatusCode, u)
+		return 0, "", errSimilarFetch
+	}
+
+	hash, err := hashImage(resp.Body)
+	return hash, u.String(), err
+}
+
+// similarUpload hashes an uploaded image. We read the multipart body
+// ourselves as ParseMultipartForm writes large files to disk.
+func similarUpload(w http.ResponseWriter, r *http.Request) (uint64, string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSimilarBytes+1<<10) // room for the multipart headers
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return 0, "", errNoSimilarImage
+	}
+
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return 0, "", errNoSimilarImage
+		}
+		if err != nil {
+			return 0, "", err
+		}
+
+		if p.FormName() != similarField {
+			continue
+		}
+
+		hash, err := hashImage(p)
+		return hash, p.FileName(), err
+	}
+}
+
+// hashImage decodes an image in memory and returns its pHash
+func hashImage(r io.Reader) (uint64, error) {
+	b, err := ioutil.ReadAll(io.LimitReader(r, maxSimilarBytes+1))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(b) > maxSimilarBytes {
+		return 0, errImageTooLarge
+	}
+
+	m, _, err := img.DecodeBytes(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return img.PHash(m), nil
+}
@@ -0,0 +1,204 @@
+package frontend
+
+import (
+	"bytes"
+	stdimage "image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/abursavich/nett"
+	img "github.com/jivesearch/jivesearch/search/image"
+)
+
+func TestSimilarHandler(t *testing.T) {
+	m := stdimage.NewRGBA(stdimage.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			m.Set(x, y, color.RGBA{uint8(x * 8), uint8(y * 8), 0, 255})
+		}
+	}
+
+	var png1 bytes.Buffer
+	if err := png.Encode(&png1, m); err != nil {
+		t.Fatal(err)
+	}
+	hash := img.FormatHash(img.PHash(m))
+	bomb := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00") // claims to be 65535x65535
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cat.png":
+			w.Write(png1.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	upload := func(field string, b []byte) (*bytes.Buffer, string) {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		fw, err := mw.CreateFormFile(field, "cat.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(b)
+		mw.Close()
+		return body, mw.FormDataContentType()
+	}
+
+	for _, c := range []struct {
+		name   string
+		method string
+		url    string
+		body   func() (*bytes.Buffer, string)
+		status int
+		err    error
+		query  string
+	}{
+		{
+			name:   "hash",
+			method: "GET",
+			url:    "/images/similar?o=json&hash=" + hash + "&url=https://www.example.com/cat.png",
+			status: http.StatusOK,
+			query:  "https://www.example.com/cat.png",
+		},
+		{
+			name:   "url",
+			method: "GET",
+			url:    "/images/similar?o=json&url=" + ts.URL + "/cat.png",
+			status: http.StatusOK,
+			query:  ts.URL + "/cat.png",
+		},
+		{
+			name:   "upload",
+			method: "POST",
+			url:    "/images/similar?o=json",
+			body:   func() (*bytes.Buffer, string) { return upload(similarField, png1.Bytes()) },
+			status: http.StatusOK,
+			query:  "cat.png",
+		},
+		{
+			name:   "upload wrong field",
+			method: "POST",
+			url:    "/images/similar?o=json",
+			body:   func() (*bytes.Buffer, string) { return upload("file", png1.Bytes()) },
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "not an image",
+			method: "POST",
+			url:    "/images/similar?o=json",
+			body:   func() (*bytes.Buffer, string) { return upload(similarField, []byte("<html></html>")) },
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "too many pixels",
+			method: "POST",
+			url:    "/images/similar?o=json",
+			body:   func() (*bytes.Buffer, string) { return upload(similarField, bomb) },
+			status: http.StatusBadRequest,
+			err:    img.ErrTooManyPixels,
+		},
+		{
+			name:   "url not found",
+			method: "GET",
+			url:    "/images/similar?o=json&url=" + ts.URL + "/dog.png",
+			status: http.StatusBadRequest,
+			err:    errSimilarFetch,
+		},
+		{
+			name:   "missing",
+			method: "GET",
+			url:    "/images/similar",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid hash",
+			method: "GET",
+			url:    "/images/similar?hash=xyz",
+			status: http.StatusBadRequest,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{}
+			f.Images.Fetcher = &mockImages{}
+			f.Images.Client = &http.Client{}
+
+			var req *http.Request
+			var err error
+			if c.body != nil {
+				b, ct := c.body()
+				req, err = http.NewRequest(c.method, c.url, b)
+				req.Header.Set("Content-Type", ct)
+			} else {
+				req, err = http.NewRequest(c.method, c.url, nil)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := f.similarHandler(httptest.NewRecorder(), req)
+			if got.status != c.status {
+				t.Fatalf("got status %d (%v); want %d", got.status, got.err, c.status)
+			}
+
+			if c.err != nil && got.err != c.err {
+				t.Fatalf("got err %v; want %v", got.err, c.err)
+			}
+
+			if c.status != http.StatusOK {
+				return
+			}
+
+			d := got.data.(data)
+			if d.Context.Q != c.query {
+				t.Fatalf("got query %q; want %q", d.Context.Q, c.query)
+			}
+
+			want := []*img.Image{{ID: "https://www.example.com/similar.jpg", PHash: hash}}
+			if !reflect.DeepEqual(d.Images.Images, want) {
+				t.Fatalf("got %+v; want %+v", d.Images.Images, want)
+			}
+		})
+	}
+}
+
+func TestPublicIPs(t *testing.T) {
+	var ips []net.IP
+	for _, ip := range []string{
+		"127.0.0.1", "10.1.2.3", "172.20.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1",
+		"0.0.0.0", "::1", "fd00::1", "fe80::1", "8.8.8.8", "2001:4860:4860::8888",
+	} {
+		ips = append(ips, net.ParseIP(ip))
+	}
+
+	want := []net.IP{net.ParseIP("8.8.8.8"), net.ParseIP("2001:4860:4860::8888")}
+	if got := PublicIPs(ips); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+
+	// a url of a user can't make us connect to ourselves
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("expected no request to a loopback address")
+	}))
+	defer ts.Close()
+
+	f := &Frontend{}
+	f.Images.Client = &http.Client{
+		Transport: &http.Transport{
+			Dial: (&nett.Dialer{IPFilter: PublicIPs}).Dial,
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/images/similar?url="+ts.URL+"/cat.png", nil)
+	if _, _, err := f.similarHash(httptest.NewRecorder(), req); err != errSimilarFetch {
+		t.Fatalf("got %v; want %v", err, errSimilarFetch)
+	}
+}
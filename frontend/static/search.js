@@ -134,6 +134,15 @@ $(document).ready(function() {
     redirect(params);
   });
 
+  $("#similar_image").on('change', function() {
+    $("#similar_upload").submit();
+  });
+
+  $(".image_filter").on('change', function() {
+    params = changeParam($(this).attr("name"), $(this).val());
+    redirect(params);
+  });
+
   $("#search_filter").on('change', function() {
     var checked = $('input[name=search_filter]:checked', '#search_filter').val();
     params = changeParam("f", checked);
@@ -0,0 +1,67 @@
+package frontend
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/jivesearch/jivesearch/log"
+	img "github.com/jivesearch/jivesearch/search/image"
+)
+
+// thumbnailMaxAge is how long browsers can cache a thumbnail.
+// A key is the hash of the thumbnail so the thumbnail never changes.
+const thumbnailMaxAge = 365 * 24 * 60 * 60
+
+var thumbnailTypes = map[string]string{
+	".jpg": "image/jpeg",
+	".png": "image/png",
+}
+
+// thumbnailHandler serves the thumbnails the images command made ("/thumbnail/{key}").
+// If we don't have the thumbnail we redirect to the image proxy for the
+// image in the "u" param. Its signature ("s") must be one we made for that
+// image so that we don't sign any url for whoever asks.
+func (f *Frontend) thumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	if f.Images.Thumbnails != nil && img.ValidKey(key) {
+		b, err := f.Images.Thumbnails.Get(key)
+		switch err {
+		case nil:
+			w.Header().Set("Content-Type", thumbnailTypes[filepath.Ext(key)])
+			w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", thumbnailMaxAge))
+			w.Write(b)
+			return
+		case img.ErrNotFound:
+		default:
+			log.Info.Println(err)
+		}
+	}
+
+	raw := strings.TrimSpace(r.FormValue("u"))
+	signature := strings.TrimSpace(r.FormValue("s"))
+
+	u, err := url.Parse(raw)
+	if raw == "" || err != nil || !validSignature([]byte(hmacSecret()), u, signature) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// not http.Redirect as it would clean the "//" of the image url
+	w.Header().Set("Location", resizedImage(raw, signature))
+	w.WriteHeader(http.StatusFound)
+}
+
+// proxiedImage is the url of an image resized by our image proxy
+func proxiedImage(u string) string {
+	return resizedImage(u, hmacKey(u))
+}
+
+// resizedImage is the url of an image resized by our image proxy with its signature
+func resizedImage(u, signature string) string {
+	return fmt.Sprintf("/image/225x,s%v/%v", signature, u)
+}
@@ -0,0 +1,134 @@
+package frontend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	img "github.com/jivesearch/jivesearch/search/image"
+)
+
+const mockThumbnailKey = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef.jpg"
+
+func TestThumbnailHandler(t *testing.T) {
+	cat := "https://www.example.com/cat.jpg"
+	signed := "?u=" + url.QueryEscape(cat) + "&s=" + url.QueryEscape(hmacKey(cat))
+
+	for _, c := range []struct {
+		name     string
+		store    img.Store
+		url      string
+		status   int
+		location string
+		body     string
+	}{
+		{
+			name:   "hit",
+			store:  &mockThumbnails{},
+			url:    "/thumbnail/" + mockThumbnailKey,
+			status: http.StatusOK,
+			body:   "thumbnail",
+		},
+		{
+			name:     "miss",
+			store:    &mockThumbnails{},
+			url:      "/thumbnail/fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210.jpg" + signed,
+			status:   http.StatusFound,
+			location: proxiedImage(cat),
+		},
+		{
+			name:     "no store",
+			url:      "/thumbnail/" + mockThumbnailKey + signed,
+			status:   http.StatusFound,
+			location: proxiedImage(cat),
+		},
+		{
+			name:   "miss without url",
+			store:  &mockThumbnails{},
+			url:    "/thumbnail/invalid.jpg",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "unsigned",
+			store:  &mockThumbnails{},
+			url:    "/thumbnail/invalid.jpg?u=" + url.QueryEscape(cat),
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "signature of another url",
+			store:  &mockThumbnails{},
+			url:    "/thumbnail/invalid.jpg?u=http://127.0.0.1:9200/&s=" + url.QueryEscape(hmacKey(cat)),
+			status: http.StatusNotFound,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := &Frontend{}
+			f.Images.Thumbnails = c.store
+
+			router := mux.NewRouter()
+			router.Path("/thumbnail/{key}").HandlerFunc(f.thumbnailHandler)
+
+			req, err := http.NewRequest("GET", c.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != c.status {
+				t.Fatalf("got status %d; want %d", rr.Code, c.status)
+			}
+
+			if got := rr.Header().Get("Location"); got != c.location {
+				t.Fatalf("got location %q; want %q", got, c.location)
+			}
+
+			if c.status != http.StatusOK {
+				return
+			}
+
+			if rr.Body.String() != c.body {
+				t.Fatalf("got body %q; want %q", rr.Body.String(), c.body)
+			}
+
+			if got := rr.Header().Get("Content-Type"); got != "image/jpeg" {
+				t.Fatalf("got content type %q", got)
+			}
+
+			if got := rr.Header().Get("Cache-Control"); got != "public, max-age=31536000, immutable" {
+				t.Fatalf("got cache control %q", got)
+			}
+		})
+	}
+}
+
+func TestFetchImageThumbnail(t *testing.T) {
+	f := &Frontend{}
+	f.Images.Thumbnails = &mockThumbnails{}
+
+	// served by the thumbnail route rather than inlined
+	i, err := f.fetchImage(&img.Image{ID: "https://www.example.com/cat.jpg", Thumbnail: mockThumbnailKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if i.Base64 != "" {
+		t.Fatalf("got %q; want no base64", i.Base64)
+	}
+}
+
+type mockThumbnails struct{}
+
+func (m *mockThumbnails) Put(key string, b []byte) error {
+	return nil
+}
+
+func (m *mockThumbnails) Get(key string) ([]byte, error) {
+	if key == mockThumbnailKey {
+		return []byte("thumbnail"), nil
+	}
+	return nil, img.ErrNotFound
+}
@@ -0,0 +1,175 @@
+// Package health probes the services we depend on so that a load balancer
+// or an operator can see which of them are down
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthChecker is a backend that can tell us if it is up.
+// It should return quickly once ctx is done.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// Monitor probes our dependencies
+type Monitor struct {
+	Timeout  time.Duration // of each probe
+	ReadyTTL time.Duration // how long IsReady keeps its answer
+	deps     []*dependency
+
+	mu      sync.Mutex
+	ready   bool
+	readyAt time.Time
+}
+
+type dependency struct {
+	name     string
+	critical bool
+	HealthChecker
+	sync.Mutex
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// Status is the result of a probe
+type Status struct {
+	Name        string        `json:"name"`
+	Critical    bool          `json:"critical"` // we aren't ready without it
+	Up          bool          `json:"up"`
+	Latency     time.Duration `json:"latency"`
+	Error       string        `json:"error,omitempty"`
+	LastError   string        `json:"last_error,omitempty"` // the last time the dependency was down
+	LastErrorAt *time.Time    `json:"last_error_at,omitempty"`
+}
+
+// Add adds a dependency. A critical dependency is one we can't serve results without.
+func (m *Monitor) Add(name string, critical bool, c HealthChecker) {
+	m.deps = append(m.deps, &dependency{name: name, critical: critical, HealthChecker: c})
+}
+
+// Check probes our dependencies at the same time. With critical
+// only those we can't serve results without are probed.
+func (m *Monitor) Check(ctx context.Context, critical bool) []Status {
+	deps := []*dependency{}
+	for _, d := range m.deps {
+		if !critical || d.critical {
+			deps = append(deps, d)
+		}
+	}
+
+	statuses := make([]Status, len(deps))
+
+	var wg sync.WaitGroup
+	for i, d := range deps {
+		statuses[i] = Status{Name: d.name, Critical: d.critical}
+
+		wg.Add(1)
+		go func(d *dependency, s *Status) {
+			defer wg.Done()
+			m.probe(ctx, d, s)
+		}(d, &statuses[i])
+	}
+
+	wg.Wait()
+	return statuses
+}
+
+// Ready reports if the critical dependencies were up
+func Ready(statuses []Status) bool {
+	for _, s := range statuses {
+		if s.Critical && !s.Up {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsReady reports if our critical dependencies are up. The answer is
+// kept for ReadyTTL so a load balancer polling us doesn't probe them
+// on every request. Concurrent callers wait for the same probes.
+func (m *Monitor) IsReady(ctx context.Context) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.readyAt.IsZero() && time.Since(m.readyAt) < m.ReadyTTL {
+		return m.ready
+	}
+
+	ready := Ready(m.Check(ctx, true))
+	if ctx.Err() == nil { // a caller that went away doesn't tell us anything
+		m.ready, m.readyAt = ready, time.Now()
+	}
+
+	return ready
+}
+
+func (m *Monitor) probe(ctx context.Context, d *dependency, s *Status) {
+	if m.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	errc := make(chan error, 1) // a backend that ignores ctx can't hold up the others
+	go func() {
+		errc <- d.HealthCheck(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s.Latency = time.Since(start)
+
+	d.Lock()
+	defer d.Unlock()
+
+	if err != nil {
+		d.lastError, d.lastErrorAt = err.Error(), time.Now()
+		s.Error = err.Error()
+	}
+
+	s.Up = err == nil
+	s.LastError = d.lastError
+	if !d.lastErrorAt.IsZero() {
+		at := d.lastErrorAt
+		s.LastErrorAt = &at
+	}
+}
+
+// HTTP is an API we depend on. It is up if it answers without a server error.
+type HTTP struct {
+	Client *http.Client
+	URL    string
+}
+
+// HealthCheck requests the API's URL
+func (h *HTTP) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequest("HEAD", h.URL, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%v: %v", h.URL, res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockChecker struct {
+	err   error
+	sleep time.Duration
+}
+
+func (m *mockChecker) HealthCheck(ctx context.Context) error {
+	time.Sleep(m.sleep) // ignores ctx like a backend that can't be cancelled
+	return m.err
+}
+
+func TestCheck(t *testing.T) {
+	db := &mockChecker{}
+
+	m := &Monitor{Timeout: 50 * time.Millisecond}
+	m.Add("elasticsearch", true, &mockChecker{})
+	m.Add("redis", true, &mockChecker{sleep: time.Second})
+	m.Add("postgresql", false, db)
+
+	type result struct {
+		name      string
+		up        bool
+		err       string
+		lastError string
+	}
+
+	results := func(statuses []Status) []result {
+		got := []result{}
+		for _, s := range statuses {
+			if s.Latency <= 0 {
+				t.Fatalf("expected a latency for %v", s.Name)
+			}
+
+			if (s.LastError == "") != (s.LastErrorAt == nil) {
+				t.Fatalf("expected the time of the last error of %v", s.Name)
+			}
+
+			got = append(got, result{s.Name, s.Up, s.Error, s.LastError})
+		}
+		return got
+	}
+
+	for _, c := range []struct {
+		name     string
+		critical bool
+		dbErr    error
+		want     []result
+		ready    bool
+	}{
+		{
+			"critical", true, nil,
+			[]result{
+				{"elasticsearch", true, "", ""},
+				{"redis", false, "context deadline exceeded", "context deadline exceeded"},
+			},
+			false,
+		},
+		{
+			"all", false, errors.New("connection refused"),
+			[]result{
+				{"elasticsearch", true, "", ""},
+				{"redis", false, "context deadline exceeded", "context deadline exceeded"},
+				{"postgresql", false, "connection refused", "connection refused"},
+			},
+			false,
+		},
+		{
+			"recovered", false, nil,
+			[]result{
+				{"elasticsearch", true, "", ""},
+				{"redis", false, "context deadline exceeded", "context deadline exceeded"},
+				{"postgresql", true, "", "connection refused"}, // we still show the last error
+			},
+			false,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			db.err = c.dbErr
+
+			start := time.Now()
+			statuses := m.Check(context.Background(), c.critical)
+			if d := time.Since(start); d > 500*time.Millisecond {
+				t.Fatalf("probes took %v", d)
+			}
+
+			if got := results(statuses); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %+v; want %+v", got, c.want)
+			}
+
+			if got := Ready(statuses); got != c.ready {
+				t.Fatalf("got %v; want %v", got, c.ready)
+			}
+		})
+	}
+}
+
+type countingChecker struct {
+	sync.Mutex
+	err   error
+	calls int
+}
+
+func (c *countingChecker) HealthCheck(ctx context.Context) error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.calls++
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return c.err
+}
+
+func (c *countingChecker) set(err error) int {
+	c.Lock()
+	defer c.Unlock()
+
+	c.err = err
+	return c.calls
+}
+
+func TestIsReady(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		ttl   time.Duration
+		calls int
+		ready bool
+	}{
+		{"cached", time.Minute, 1, true}, // we don't see it go down yet
+		{"not cached", 0, 2, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			es := &countingChecker{}
+
+			m := &Monitor{ReadyTTL: c.ttl}
+			m.Add("elasticsearch", true, es)
+
+			if !m.IsReady(context.Background()) {
+				t.Fatal("expected to be ready")
+			}
+
+			es.set(errors.New("down"))
+			if got := m.IsReady(context.Background()); got != c.ready {
+				t.Fatalf("got %v; want %v", got, c.ready)
+			}
+
+			if calls := es.set(nil); calls != c.calls {
+				t.Fatalf("got %d probes; want %d", calls, c.calls)
+			}
+		})
+	}
+
+	t.Run("cancelled", func(t *testing.T) { // the answer of a caller that went away isn't kept
+		m := &Monitor{ReadyTTL: time.Minute}
+		m.Add("elasticsearch", true, &countingChecker{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if m.IsReady(ctx) {
+			t.Fatal("expected a cancelled probe to fail")
+		}
+
+		if !m.IsReady(context.Background()) {
+			t.Fatal("expected to be ready")
+		}
+	})
+}
+
+func TestReady(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		statuses []Status
+		want     bool
+	}{
+		{"none", []Status{}, true},
+		{"up", []Status{{Critical: true, Up: true}, {Critical: false, Up: true}}, true},
+		{"optional down", []Status{{Critical: true, Up: true}, {Critical: false, Up: false}}, true},
+		{"critical down", []Status{{Critical: true, Up: false}, {Critical: false, Up: true}}, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Ready(c.statuses); got != c.want {
+				t.Fatalf("got %v; want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		status int
+		ok     bool
+	}{
+		{"ok", http.StatusOK, true},
+		{"not found", http.StatusNotFound, true}, // the API is up even if its root isn't a page
+		{"down", http.StatusBadGateway, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "HEAD" {
+					t.Fatalf("got %v; want HEAD", r.Method)
+				}
+				w.WriteHeader(c.status)
+			}))
+			defer srv.Close()
+
+			h := &HTTP{Client: srv.Client(), URL: srv.URL}
+			if err := h.HealthCheck(context.Background()); (err == nil) != c.ok {
+				t.Fatalf("got %v; want ok %v", err, c.ok)
+			}
+		})
+	}
+}
@@ -47,6 +47,8 @@ type Instant struct {
 	WeatherFetcher       weather.Fetcher
 	WHOISFetcher         whois.Fetcher
 	WikipediaFetcher     wikipedia.Fetcher
+	Timeout              time.Duration // of solving an external answer
+	Breaker              *Breaker      // skips the external answers that keep failing (optional)
 }
 
 // Answerer outlines methods for an instant answer
@@ -102,6 +104,28 @@ func (i *Instant) Solve(ia Answerer, r *http.Request) Data {
 	return ia.solution()
 }
 
+// External reports if an answer calls a fetcher (an API or a database) that can be slow or down
+func External(ia Answerer) bool {
+	switch ia.(type) {
+	case *Breach, *Congress, *Currency, *Discography, *FedEx, *GDP, *Maps, *Population,
+		*Shortener, *StackOverflow, *StockQuote, *UPS, *USPS, *Weather, *WHOIS, *Wikipedia:
+		return true
+	}
+
+	return false
+}
+
+// InvalidInput reports if an answer's error is due to the query
+// (e.g. an unknown ticker) rather than its fetcher failing
+func InvalidInput(err error) bool {
+	switch err {
+	case ErrInvalidCurrency, ErrInvalidURL, stock.ErrInvalidTicker:
+		return true
+	}
+
+	return false
+}
+
 // setQuery sets the query field
 func (a *Answer) setQuery(r *http.Request, qv string) {
 	q := strings.ToLower(strings.TrimSpace(r.FormValue(qv)))
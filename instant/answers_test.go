@@ -1,6 +1,7 @@
 package instant
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -226,7 +227,7 @@ func TestGetIPAddress(t *testing.T) {
 // mock FedEx Fetcher
 type mockFedExFetcher struct{}
 
-func (f *mockFedExFetcher) Fetch(trackingNumber string) (parcel.Response, error) {
+func (f *mockFedExFetcher) Fetch(ctx context.Context, trackingNumber string) (parcel.Response, error) {
 	r := parcel.Response{
 		TrackingNumber: strings.ToUpper(trackingNumber),
 		Updates: []parcel.Update{
@@ -257,7 +258,7 @@ func (f *mockFedExFetcher) Fetch(trackingNumber string) (parcel.Response, error)
 
 type mockCongressFetcher struct{}
 
-func (m *mockCongressFetcher) FetchMembers(location *congress.Location) (*congress.Response, error) {
+func (m *mockCongressFetcher) FetchMembers(ctx context.Context, location *congress.Location) (*congress.Response, error) {
 	return &congress.Response{
 		Location: &congress.Location{
 			Short: "UT",
@@ -306,7 +307,7 @@ func (m *mockCongressFetcher) FetchMembers(location *congress.Location) (*congre
 	}, nil
 }
 
-func (m *mockCongressFetcher) FetchSenators(location *congress.Location) (*congress.Response, error) {
+func (m *mockCongressFetcher) FetchSenators(ctx context.Context, location *congress.Location) (*congress.Response, error) {
 	return &congress.Response{
 		Location: location,
 		Role:     congress.Senators,
@@ -334,7 +335,7 @@ func (m *mockCongressFetcher) FetchSenators(location *congress.Location) (*congr
 
 type mockCryptoFetcher struct{}
 
-func (m *mockCryptoFetcher) Fetch() (*curr.Response, error) {
+func (m *mockCryptoFetcher) Fetch(ctx context.Context) (*curr.Response, error) {
 	return &curr.Response{
 		Base: curr.USD,
 		History: map[string][]*curr.Rate{
@@ -397,7 +398,7 @@ func (m *mockFXFetcher) Fetch() (*curr.Response, error) {
 // mock gdp fetcher
 type mockGDPFetcher struct{}
 
-func (m *mockGDPFetcher) Fetch(country string, start time.Time, end time.Time) (*ggdp.Response, error) {
+func (m *mockGDPFetcher) Fetch(ctx context.Context, country string, start time.Time, end time.Time) (*ggdp.Response, error) {
 	return &ggdp.Response{
 		History: []ggdp.Instant{
 			{
@@ -420,7 +421,7 @@ func (m *mockGDPFetcher) Fetch(country string, start time.Time, end time.Time) (
 // mock location fetcher
 type mockLocationFetcher struct{}
 
-func (l *mockLocationFetcher) Fetch(ip net.IP) (*location.City, error) {
+func (l *mockLocationFetcher) Fetch(ctx context.Context, ip net.IP) (*location.City, error) {
 	c := &location.City{}
 	c.City.Names = map[string]string{"en": "Someville"}
 	c.Location.Latitude = 12
@@ -430,7 +431,7 @@ func (l *mockLocationFetcher) Fetch(ip net.IP) (*location.City, error) {
 
 type mockPopulationFetcher struct{}
 
-func (m *mockPopulationFetcher) Fetch(country string, start time.Time, end time.Time) (*pop.Response, error) {
+func (m *mockPopulationFetcher) Fetch(ctx context.Context, country string, start time.Time, end time.Time) (*pop.Response, error) {
 	return &pop.Response{
 		History: []pop.Instant{
 			{
@@ -453,7 +454,7 @@ func (m *mockPopulationFetcher) Fetch(country string, start time.Time, end time.
 // mock Stack Overflow Fetcher
 type mockStackOverflowFetcher struct{}
 
-func (s *mockStackOverflowFetcher) Fetch(query string, tags []string) (so.Response, error) {
+func (s *mockStackOverflowFetcher) Fetch(ctx context.Context, query string, tags []string) (so.Response, error) {
 	resp := so.Response{}
 
 	switch query {
@@ -569,7 +570,7 @@ func (s *mockStackOverflowFetcher) Fetch(query string, tags []string) (so.Respon
 // mock stock quote Fetcher
 type mockStockQuoteFetcher struct{}
 
-func (s *mockStockQuoteFetcher) Fetch(ticker string) (*stock.Quote, error) {
+func (s *mockStockQuoteFetcher) Fetch(ctx context.Context, ticker string) (*stock.Quote, error) {
 	location, err := time.LoadLocation("America/New_York")
 	if err != nil {
 		return nil, err
@@ -606,7 +607,7 @@ func (s *mockStockQuoteFetcher) Fetch(ticker string) (*stock.Quote, error) {
 // mock UPS Fetcher
 type mockUPSFetcher struct{}
 
-func (u *mockUPSFetcher) Fetch(trackingNumber string) (parcel.Response, error) {
+func (u *mockUPSFetcher) Fetch(ctx context.Context, trackingNumber string) (parcel.Response, error) {
 	r := parcel.Response{
 		TrackingNumber: strings.ToUpper(trackingNumber),
 		Updates: []parcel.Update{
@@ -631,7 +632,7 @@ func (u *mockUPSFetcher) Fetch(trackingNumber string) (parcel.Response, error) {
 // mock USPS Fetcher
 type mockUSPSFetcher struct{}
 
-func (u *mockUSPSFetcher) Fetch(trackingNumber string) (parcel.Response, error) {
+func (u *mockUSPSFetcher) Fetch(ctx context.Context, trackingNumber string) (parcel.Response, error) {
 	r := parcel.Response{
 		TrackingNumber: strings.ToUpper(trackingNumber),
 		Updates: []parcel.Update{
@@ -668,7 +669,7 @@ type mockWeatherFetcher struct {
 	location.Fetcher
 }
 
-func (m *mockWeatherFetcher) FetchByCity(city string) (*weather.Weather, error) {
+func (m *mockWeatherFetcher) FetchByCity(ctx context.Context, city string) (*weather.Weather, error) {
 	w := &weather.Weather{
 		City: "Bogota",
 		Current: &weather.Instant{
@@ -712,7 +713,7 @@ func (m *mockWeatherFetcher) FetchByCity(city string) (*weather.Weather, error)
 	return w, nil
 }
 
-func (m *mockWeatherFetcher) FetchByLatLong(lat, long float64, timezone string) (*weather.Weather, error) {
+func (m *mockWeatherFetcher) FetchByLatLong(ctx context.Context, lat, long float64, timezone string) (*weather.Weather, error) {
 	w := &weather.Weather{
 		City: "Bountiful",
 		Current: &weather.Instant{
@@ -757,7 +758,7 @@ func (m *mockWeatherFetcher) FetchByLatLong(lat, long float64, timezone string)
 	return w, nil
 }
 
-func (m *mockWeatherFetcher) FetchByZip(zip int) (*weather.Weather, error) {
+func (m *mockWeatherFetcher) FetchByZip(ctx context.Context, zip int) (*weather.Weather, error) {
 	w := &weather.Weather{
 		City: "Bountiful",
 		Current: &weather.Instant{
@@ -804,7 +805,7 @@ func (m *mockWeatherFetcher) FetchByZip(zip int) (*weather.Weather, error) {
 // mock WHOIS Fetcher
 type mockWHOISFetcher struct{}
 
-func (f *mockWHOISFetcher) Fetch(domain string) (*whois.Response, error) {
+func (f *mockWHOISFetcher) Fetch(ctx context.Context, domain string) (*whois.Response, error) {
 	resp := &whois.Response{}
 
 	switch domain {
@@ -932,7 +933,7 @@ func (mf *mockWikipediaFetcher) Setup() error {
 
 type mockBreachFetcher struct{}
 
-func (m *mockBreachFetcher) Fetch(account string) (*breach.Response, error) {
+func (m *mockBreachFetcher) Fetch(ctx context.Context, account string) (*breach.Response, error) {
 	r := &breach.Response{
 		Account: "test@example.com",
 		Breaches: []breach.Breach{
@@ -976,7 +977,7 @@ func (m *mockDiscographyFetcher) Fetch(artist string) ([]disc.Album, error) {
 
 type mockShortener struct{}
 
-func (m *mockShortener) Shorten(u *url.URL) (*shortener.Response, error) {
+func (m *mockShortener) Shorten(ctx context.Context, u *url.URL) (*shortener.Response, error) {
 	shrt, _ := url.Parse("http://shrt.url")
 
 	return &shortener.Response{
@@ -52,7 +52,7 @@ func (b *Breach) setRegex() Answerer {
 }
 
 func (b *Breach) solve(r *http.Request) Answerer {
-	resp, err := b.Fetcher.Fetch(b.remainder)
+	resp, err := b.Fetcher.Fetch(r.Context(), b.remainder)
 	if err != nil {
 		b.Err = err
 		return b
@@ -2,13 +2,14 @@
 package breach
 
 import (
+	"context"
 	"sort"
 	"time"
 )
 
 // Fetcher implements methods to check for security breaches for an account
 type Fetcher interface {
-	Fetch(account string) (*Response, error)
+	Fetch(ctx context.Context, account string) (*Response, error)
 }
 
 type provider string
@@ -1,12 +1,15 @@
 package breach
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/jivesearch/jivesearch/health"
 )
 
 // Pwned holds settings for the haveibeenpwned.com API
@@ -18,13 +21,18 @@ type Pwned struct {
 const HaveIBeenPwnedProvider provider = "Have I Been Pwned"
 
 // Fetch retrieves security breaches from haveibeenpwned.com
-func (p *Pwned) Fetch(account string) (*Response, error) {
+func (p *Pwned) Fetch(ctx context.Context, account string) (*Response, error) {
 	u, err := p.buildURL(account)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.HTTPClient.Get(u.String())
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.HTTPClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -104,3 +112,9 @@ type PwnedResponse []struct {
 	IsSpamList   bool      `json:"IsSpamList"`
 	LogoType     string    `json:"LogoType"`
 }
+
+// HealthCheck makes sure Have I Been Pwned is up
+func (p *Pwned) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: p.HTTPClient, URL: "https://haveibeenpwned.com"}
+	return h.HealthCheck(ctx)
+}
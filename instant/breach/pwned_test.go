@@ -1,6 +1,7 @@
 package breach
 
 import (
+	"context"
 	"net/http"
 	"reflect"
 	"testing"
@@ -63,7 +64,7 @@ func TestFetch(t *testing.T) {
 				HTTPClient: &http.Client{},
 			}
 
-			got, err := p.Fetch(tt.args.account)
+			got, err := p.Fetch(context.Background(), tt.args.account)
 			if err != nil {
 				t.Fatal(err)
 			}
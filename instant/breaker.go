@@ -0,0 +1,85 @@
+package instant
+
+import (
+	"sync"
+	"time"
+)
+
+// Breaker skips the answers whose fetcher keeps failing. Once an answer
+// fails Threshold times in a row it is skipped for Cooldown, after which
+// a single request is let through to see if the fetcher has recovered.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	failures int
+	openedAt time.Time // or when we last let a request through to see if it recovered
+}
+
+// Allow reports if the fetcher of an answer should be called
+func (b *Breaker) Allow(name string) bool {
+	if b == nil {
+		return true
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	c := b.circuit(name)
+	if c.failures < b.Threshold {
+		return true
+	}
+
+	if time.Since(c.openedAt) < b.Cooldown {
+		return false
+	}
+
+	c.openedAt = time.Now() // the others wait while this one tries
+	return true
+}
+
+// Success closes the circuit of an answer
+func (b *Breaker) Success(name string) {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.circuit(name).failures = 0
+}
+
+// Failure counts a failure of an answer, opening its circuit after Threshold in a row
+func (b *Breaker) Failure(name string) {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	c := b.circuit(name)
+	c.failures++
+	if c.failures >= b.Threshold {
+		c.openedAt = time.Now()
+	}
+}
+
+func (b *Breaker) circuit(name string) *circuit {
+	if b.circuits == nil {
+		b.circuits = map[string]*circuit{}
+	}
+
+	c, ok := b.circuits[name]
+	if !ok {
+		c = &circuit{}
+		b.circuits[name] = c
+	}
+
+	return c
+}
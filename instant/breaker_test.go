@@ -0,0 +1,57 @@
+package instant
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	b := &Breaker{Threshold: 2, Cooldown: 50 * time.Millisecond}
+
+	for _, c := range []struct {
+		name    string
+		sleep   time.Duration
+		want    bool
+		outcome string // of the call to the fetcher (if any)
+	}{
+		{"closed", 0, true, "failure"},
+		{"one failure", 0, true, "failure"},
+		{"open", 0, false, ""},
+		{"cooldown", 60 * time.Millisecond, true, ""}, // still trying
+		{"trying", 0, false, ""},                      // only one request tries after the cooldown
+		{"failed again", 60 * time.Millisecond, true, "failure"},
+		{"reopened", 0, false, ""},
+		{"recovering", 60 * time.Millisecond, true, "success"},
+		{"recovered", 0, true, "failure"},
+		{"one failure after recovering", 0, true, ""},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			time.Sleep(c.sleep)
+
+			if got := b.Allow("stockquote"); got != c.want {
+				t.Fatalf("got %v; want %v", got, c.want)
+			}
+
+			if !b.Allow("weather") {
+				t.Fatal("each answer should have its own circuit")
+			}
+
+			switch c.outcome {
+			case "failure":
+				b.Failure("stockquote")
+			case "success":
+				b.Success("stockquote")
+			}
+		})
+	}
+}
+
+func TestNilBreaker(t *testing.T) {
+	var b *Breaker
+	b.Failure("stockquote")
+	b.Success("stockquote")
+
+	if !b.Allow("stockquote") {
+		t.Fatal("a nil breaker should allow everything")
+	}
+}
@@ -72,7 +72,7 @@ func (c *Congress) solve(r *http.Request) Answerer {
 	}
 
 	if _, ok := c.remainderM["members"]; ok {
-		resp, err := c.Fetcher.FetchMembers(loc)
+		resp, err := c.Fetcher.FetchMembers(r.Context(), loc)
 		if err != nil {
 			c.Err = err
 			return c
@@ -82,7 +82,7 @@ func (c *Congress) solve(r *http.Request) Answerer {
 	}
 
 	// Senate
-	resp, err := c.Fetcher.FetchSenators(loc)
+	resp, err := c.Fetcher.FetchSenators(r.Context(), loc)
 	if err != nil {
 		c.Err = err
 		return c
@@ -2,6 +2,7 @@
 package congress
 
 import (
+	"context"
 	"strings"
 )
 
@@ -48,8 +49,8 @@ type Member struct {
 
 // Fetcher implements methods to retrieve members of Congress/Senate for a district/state
 type Fetcher interface {
-	FetchSenators(location *Location) (*Response, error)
-	FetchMembers(location *Location) (*Response, error)
+	FetchSenators(ctx context.Context, location *Location) (*Response, error)
+	FetchMembers(ctx context.Context, location *Location) (*Response, error)
 }
 
 // ValidateState returns a valid code for a State
@@ -1,12 +1,15 @@
 package congress
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
+
+	"github.com/jivesearch/jivesearch/health"
 )
 
 // ProPublica holds settings for the ProPublic API
@@ -19,8 +22,8 @@ type ProPublica struct {
 const ProPublicaProvider Provider = "ProPublica"
 
 // FetchMembers returns House members from ProPublica
-func (p *ProPublica) FetchMembers(location *Location) (*Response, error) {
-	ppr, err := p.fetch("house", location)
+func (p *ProPublica) FetchMembers(ctx context.Context, location *Location) (*Response, error) {
+	ppr, err := p.fetch(ctx, "house", location)
 	if err != nil {
 		return nil, err
 	}
@@ -62,8 +65,8 @@ func (p *ProPublica) FetchMembers(location *Location) (*Response, error) {
 }
 
 // FetchSenators returns Senators from ProPublica
-func (p *ProPublica) FetchSenators(location *Location) (*Response, error) {
-	ppr, err := p.fetch("senate", location)
+func (p *ProPublica) FetchSenators(ctx context.Context, location *Location) (*Response, error) {
+	ppr, err := p.fetch(ctx, "senate", location)
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +97,7 @@ func (p *ProPublica) FetchSenators(location *Location) (*Response, error) {
 	return r, err
 }
 
-func (p *ProPublica) fetch(chamber string, loc *Location) (*proPublicaResponse, error) {
+func (p *ProPublica) fetch(ctx context.Context, chamber string, loc *Location) (*proPublicaResponse, error) {
 	uu := fmt.Sprintf("https://api.propublica.org/congress/v1/members/%v/%v/current.json", chamber, loc.Short)
 
 	u, err := url.Parse(uu)
@@ -108,7 +111,7 @@ func (p *ProPublica) fetch(chamber string, loc *Location) (*proPublicaResponse,
 	}
 	req.Header.Set("X-API-Key", p.Key)
 
-	resp, err := p.HTTPClient.Do(req)
+	resp, err := p.HTTPClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -144,3 +147,9 @@ type proPublicaResponse struct {
 		APIURI          string      `json:"api_uri"`
 	} `json:"results"`
 }
+
+// HealthCheck makes sure ProPublica's API is up
+func (p *ProPublica) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: p.HTTPClient, URL: "https://api.propublica.org"}
+	return h.HealthCheck(ctx)
+}
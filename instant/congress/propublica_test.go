@@ -1,6 +1,7 @@
 package congress
 
 import (
+	"context"
 	"net/http"
 	"reflect"
 	"testing"
@@ -91,7 +92,7 @@ func TestFetchMembers(t *testing.T) {
 
 			loc := ValidateState(tt.args.state)
 
-			got, err := p.FetchMembers(loc)
+			got, err := p.FetchMembers(context.Background(), loc)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -168,7 +169,7 @@ func TestFetchSenators(t *testing.T) {
 
 			loc := ValidateState(tt.args.state)
 
-			got, err := p.FetchSenators(loc)
+			got, err := p.FetchSenators(context.Background(), loc)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -116,7 +116,7 @@ func (c *Currency) solve(r *http.Request) Answerer {
 	ech := make(chan error)
 
 	go func(ch chan *curr.Response) {
-		crytopResp, err := c.CryptoFetcher.Fetch()
+		crytopResp, err := c.CryptoFetcher.Fetch(r.Context())
 		if err != nil {
 			ech <- err
 		}
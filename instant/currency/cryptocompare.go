@@ -1,11 +1,14 @@
 package currency
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/url"
 	"strconv"
 	"time"
+
+	"github.com/jivesearch/jivesearch/health"
 )
 
 // CryptoCompareProvider is a currency provider
@@ -41,7 +44,7 @@ type CryptoCompareResponse struct {
 }
 
 // Fetch retrieves a cryptocurrency quotes from CryptoCompare
-func (c *CryptoCompare) Fetch() (*Response, error) {
+func (c *CryptoCompare) Fetch(ctx context.Context) (*Response, error) {
 	var err error
 
 	type tmp struct {
@@ -62,7 +65,7 @@ func (c *CryptoCompare) Fetch() (*Response, error) {
 				Currency: cur,
 			}
 
-			cr, err := c.fetch(cur, USD)
+			cr, err := c.fetch(ctx, cur, USD)
 			if err != nil {
 				errCh <- err
 			}
@@ -118,13 +121,18 @@ func (c *CryptoCompare) buildURL(from, to Currency) (*url.URL, error) {
 	return u, err
 }
 
-func (c *CryptoCompare) fetch(from, to Currency) (*CryptoCompareResponse, error) {
+func (c *CryptoCompare) fetch(ctx context.Context, from, to Currency) (*CryptoCompareResponse, error) {
 	u, err := c.buildURL(from, to)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.Client.Get(u.String())
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Client.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -136,3 +144,9 @@ func (c *CryptoCompare) fetch(from, to Currency) (*CryptoCompareResponse, error)
 
 	return cr, err
 }
+
+// HealthCheck makes sure CryptoCompare's API is up
+func (c *CryptoCompare) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: c.Client, URL: "https://min-api.cryptocompare.com"}
+	return h.HealthCheck(ctx)
+}
@@ -1,6 +1,7 @@
 package currency
 
 import (
+	"context"
 	"net/http"
 	"reflect"
 	"testing"
@@ -130,7 +131,7 @@ func TestCryptoCompareFetch(t *testing.T) {
 			cc := &CryptoCompare{
 				Client: &http.Client{},
 			}
-			got, err := cc.Fetch()
+			got, err := cc.Fetch(context.Background())
 			if err != nil {
 				t.Fatal(err)
 			}
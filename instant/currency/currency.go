@@ -2,6 +2,7 @@
 package currency
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -20,7 +21,7 @@ type FXFetcher interface {
 
 // CryptoFetcher retrieves cryptocurrency quotes
 type CryptoFetcher interface {
-	Fetch() (*Response, error)
+	Fetch(ctx context.Context) (*Response, error)
 }
 
 type provider string
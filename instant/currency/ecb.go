@@ -1,8 +1,11 @@
 package currency
 
 import (
+	"context"
+	"net/http"
 	"time"
 
+	"github.com/jivesearch/jivesearch/health"
 	"github.com/openprovider/ecbrates"
 )
 
@@ -52,3 +55,10 @@ func (e *ECB) Fetch() (*Response, error) {
 
 	return resp, err
 }
+
+// HealthCheck makes sure the ECB is up.
+// ecbrates fetches the rates with the default client.
+func (e *ECB) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: http.DefaultClient, URL: "http://www.ecb.europa.eu"}
+	return h.HealthCheck(ctx)
+}
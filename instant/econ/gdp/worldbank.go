@@ -1,6 +1,7 @@
 package gdp
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +9,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/jivesearch/jivesearch/health"
 	"github.com/jivesearch/jivesearch/instant/econ"
 )
 
@@ -17,13 +19,18 @@ type WorldBank struct {
 }
 
 // Fetch retrieves GDP data from The World Bank
-func (w *WorldBank) Fetch(country string, from, to time.Time) (*Response, error) {
+func (w *WorldBank) Fetch(ctx context.Context, country string, from, to time.Time) (*Response, error) {
 	u, err := w.buildURL(country, from, to)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := w.HTTPClient.Get(u.String())
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := w.HTTPClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -70,3 +77,9 @@ func (w *WorldBank) buildURL(country string, from, to time.Time) (*url.URL, erro
 
 	return u, err
 }
+
+// HealthCheck makes sure the World Bank's API is up
+func (w *WorldBank) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: w.HTTPClient, URL: "http://api.worldbank.org"}
+	return h.HealthCheck(ctx)
+}
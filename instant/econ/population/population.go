@@ -2,6 +2,7 @@
 package population
 
 import (
+	"context"
 	"sort"
 	"time"
 
@@ -22,7 +23,7 @@ type Response struct {
 
 // Fetcher outlines methods to retrieve population data
 type Fetcher interface {
-	Fetch(country string, start time.Time, end time.Time) (*Response, error)
+	Fetch(ctx context.Context, country string, start time.Time, end time.Time) (*Response, error)
 }
 
 // Sort will organize the History in ascending order by date
@@ -1,6 +1,7 @@
 package population
 
 import (
+	"context"
 	"net/http"
 	"reflect"
 	"testing"
@@ -54,7 +55,7 @@ func TestFetch(t *testing.T) {
 				HTTPClient: &http.Client{},
 			}
 
-			got, err := w.Fetch(tt.args.country, tt.args.from, tt.args.to)
+			got, err := w.Fetch(context.Background(), tt.args.country, tt.args.from, tt.args.to)
 			if err != nil {
 				t.Fatal(err)
 			}
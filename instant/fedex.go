@@ -49,7 +49,7 @@ func (f *FedEx) setRegex() Answerer {
 }
 
 func (f *FedEx) solve(req *http.Request) Answerer {
-	r, err := f.Fetch(f.triggerWord)
+	r, err := f.Fetch(req.Context(), f.triggerWord)
 	if err != nil {
 		f.Err = err
 		return f
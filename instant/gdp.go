@@ -91,7 +91,7 @@ func (g *GDP) solve(r *http.Request) Answerer {
 	n := time.Now().Year()
 	start := n - 50 // 50 years seems to be the max allowed
 
-	resp.Response, err = g.GDPFetcher.Fetch(alpha, time.Date(start, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(n, 1, 1, 0, 0, 0, 0, time.UTC))
+	resp.Response, err = g.GDPFetcher.Fetch(r.Context(), alpha, time.Date(start, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(n, 1, 1, 0, 0, 0, 0, time.UTC))
 	if err != nil {
 		g.Err = err
 		return g
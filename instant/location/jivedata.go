@@ -1,10 +1,13 @@
 package location
 
 import (
+	"context"
 	"encoding/json"
 	"net"
 	"net/http"
 	"net/url"
+
+	"github.com/jivesearch/jivesearch/health"
 )
 
 // JiveData is a location data provider
@@ -14,7 +17,7 @@ type JiveData struct {
 }
 
 // Fetch gets geolocation data from an IP Address
-func (j *JiveData) Fetch(ip net.IP) (*City, error) {
+func (j *JiveData) Fetch(ctx context.Context, ip net.IP) (*City, error) {
 	u, err := url.Parse("https://jivedata.com/geolocation")
 	if err != nil {
 		return nil, err
@@ -25,7 +28,12 @@ func (j *JiveData) Fetch(ip net.IP) (*City, error) {
 	q.Set("ip", ip.String())
 	u.RawQuery = q.Encode()
 
-	resp, err := j.HTTPClient.Get(u.String())
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := j.HTTPClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -36,3 +44,9 @@ func (j *JiveData) Fetch(ip net.IP) (*City, error) {
 	err = json.NewDecoder(resp.Body).Decode(&c)
 	return c, err
 }
+
+// HealthCheck makes sure Jive Data is up
+func (j *JiveData) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: j.HTTPClient, URL: "https://jivedata.com"}
+	return h.HealthCheck(ctx)
+}
@@ -1,6 +1,7 @@
 package location
 
 import (
+	"context"
 	"net"
 	"net/http"
 	"net/url"
@@ -53,7 +54,7 @@ func TestJiveDataFetch(t *testing.T) {
 			responder := httpmock.NewStringResponder(200, tt.raw)
 			httpmock.RegisterResponder("GET", u.String(), responder)
 
-			got, err := j.Fetch(tt.args.ip)
+			got, err := j.Fetch(context.Background(), tt.args.ip)
 			if err != nil {
 				t.Fatal(err)
 			}
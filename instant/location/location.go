@@ -2,6 +2,7 @@
 package location
 
 import (
+	"context"
 	"encoding/xml"
 	"net"
 )
@@ -14,7 +15,7 @@ type Location struct {
 
 // Fetcher retrieves stock quotes
 type Fetcher interface {
-	Fetch(ip net.IP) (*City, error)
+	Fetch(ctx context.Context, ip net.IP) (*City, error)
 }
 
 type xmlMap map[string]string
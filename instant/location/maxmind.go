@@ -1,6 +1,7 @@
 package location
 
 import (
+	"context"
 	"net"
 
 	geoip2 "github.com/oschwald/geoip2-golang"
@@ -17,7 +18,7 @@ var open = func(loc string) (maxMinder, error) {
 }
 
 // Fetch gets geolocation data from an IP Address
-func (m *MaxMind) Fetch(ip net.IP) (*City, error) {
+func (m *MaxMind) Fetch(ctx context.Context, ip net.IP) (*City, error) {
 	db, err := open(m.DB)
 	if err != nil {
 		return nil, err
@@ -77,3 +78,13 @@ type maxMinder interface {
 	Close() error
 	City(ipAddress net.IP) (*geoip2.City, error)
 }
+
+// HealthCheck makes sure our database can be opened
+func (m *MaxMind) HealthCheck(ctx context.Context) error {
+	db, err := open(m.DB)
+	if err != nil {
+		return err
+	}
+
+	return db.Close()
+}
@@ -1,6 +1,7 @@
 package location
 
 import (
+	"context"
 	"net"
 	"reflect"
 	"testing"
@@ -43,7 +44,7 @@ func TestFetch(t *testing.T) {
 			}
 
 			mm := &MaxMind{}
-			got, err := mm.Fetch(tt.args.ip)
+			got, err := mm.Fetch(context.Background(), tt.args.ip)
 			if err != nil {
 				t.Fatal(err)
 			}
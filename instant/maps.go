@@ -69,7 +69,7 @@ func (m *Maps) solve(r *http.Request) Answerer {
 	// The caller is expected to provide the solution when triggered, preferably in JavaScript
 	ip := getIPAddress(r)
 
-	city, err := m.LocationFetcher.Fetch(ip)
+	city, err := m.LocationFetcher.Fetch(r.Context(), ip)
 	if err != nil {
 		m.Err = err
 	}
@@ -1,12 +1,14 @@
 package parcel
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/jivesearch/jivesearch/health"
 	"github.com/jivesearch/jivesearch/log"
 )
 
@@ -253,13 +255,19 @@ func (f *FedEx) buildRequest(number string) string {
 }
 
 // Fetch retrieves from the FedEx API
-func (f *FedEx) Fetch(trackingNumber string) (Response, error) {
+func (f *FedEx) Fetch(ctx context.Context, trackingNumber string) (Response, error) {
 	r := FedExResponse{}
 
 	url := "https://ws.fedex.com/web-services"
 	x := f.buildRequest(trackingNumber)
 
-	resp, err := http.Post(url, "text/xml", strings.NewReader(x))
+	req, err := http.NewRequest("POST", url, strings.NewReader(x))
+	if err != nil {
+		return r.Response, err
+	}
+	req.Header.Set("Content-Type", "text/xml")
+
+	resp, err := f.HTTPClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return r.Response, err
 	}
@@ -268,3 +276,9 @@ func (f *FedEx) Fetch(trackingNumber string) (Response, error) {
 	err = xml.NewDecoder(resp.Body).Decode(&r)
 	return r.Response, err
 }
+
+// HealthCheck makes sure FedEx's API is up
+func (f *FedEx) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: f.HTTPClient, URL: "https://ws.fedex.com"}
+	return h.HealthCheck(ctx)
+}
@@ -1,6 +1,7 @@
 package parcel
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -33,7 +34,7 @@ func TestFedExFetch(t *testing.T) {
 			a := &FedEx{
 				HTTPClient: &http.Client{},
 			}
-			got, err := a.Fetch(tt.name)
+			got, err := a.Fetch(context.Background(), tt.name)
 			if err != nil {
 				t.Fatal(err)
 			}
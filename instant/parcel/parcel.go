@@ -1,11 +1,14 @@
 // Package parcel shows package status for UPS, Fedex and others
 package parcel
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Fetcher retrieves package info from the UPS API
 type Fetcher interface {
-	Fetch(number string) (Response, error)
+	Fetch(ctx context.Context, number string) (Response, error)
 }
 
 // Response is a standardized response for tracking packages
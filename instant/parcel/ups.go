@@ -2,12 +2,14 @@ package parcel
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"time"
 
+	"github.com/jivesearch/jivesearch/health"
 	"github.com/jivesearch/jivesearch/log"
 )
 
@@ -194,7 +196,7 @@ func (u *UPS) buildRequest(number string) (*http.Request, error) {
 }
 
 // Fetch retrieves from the UPS API
-func (u *UPS) Fetch(trackingNumber string) (Response, error) {
+func (u *UPS) Fetch(ctx context.Context, trackingNumber string) (Response, error) {
 	r := UPSResponse{}
 
 	req, err := u.buildRequest(trackingNumber)
@@ -202,7 +204,7 @@ func (u *UPS) Fetch(trackingNumber string) (Response, error) {
 		return r.Response, err
 	}
 
-	resp, err := u.HTTPClient.Do(req)
+	resp, err := u.HTTPClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return r.Response, err
 	}
@@ -230,3 +232,9 @@ func (r *UPSResponse) buildURL() error {
 	r.Response.URL = u.String()
 	return nil
 }
+
+// HealthCheck makes sure UPS's API is up
+func (u *UPS) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: u.HTTPClient, URL: "https://onlinetools.ups.com"}
+	return h.HealthCheck(ctx)
+}
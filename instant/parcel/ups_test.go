@@ -1,6 +1,7 @@
 package parcel
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -91,7 +92,7 @@ func TestUPSFetch(t *testing.T) {
 			a := &UPS{
 				HTTPClient: &http.Client{},
 			}
-			got, err := a.Fetch(tt.name)
+			got, err := a.Fetch(context.Background(), tt.name)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1,12 +1,14 @@
 package parcel
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"net/http"
 	"net/url"
 	"time"
 
+	"github.com/jivesearch/jivesearch/health"
 	"github.com/jivesearch/jivesearch/log"
 )
 
@@ -103,7 +105,7 @@ func (r *USPSResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) erro
 }
 
 // Fetch retrieves from the USPS API
-func (u *USPS) Fetch(trackingNumber string) (Response, error) {
+func (u *USPS) Fetch(ctx context.Context, trackingNumber string) (Response, error) {
 	r := USPSResponse{}
 
 	uu, err := url.Parse("http://production.shippingapis.com/ShippingAPI.dll")
@@ -121,7 +123,12 @@ func (u *USPS) Fetch(trackingNumber string) (Response, error) {
 	q.Set("XML", x)
 	uu.RawQuery = q.Encode()
 
-	resp, err := http.Get(uu.String())
+	req, err := http.NewRequest("GET", uu.String(), nil)
+	if err != nil {
+		return r.Response, err
+	}
+
+	resp, err := u.HTTPClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return r.Response, err
 	}
@@ -130,3 +137,9 @@ func (u *USPS) Fetch(trackingNumber string) (Response, error) {
 	err = xml.NewDecoder(resp.Body).Decode(&r)
 	return r.Response, err
 }
+
+// HealthCheck makes sure USPS's API is up
+func (u *USPS) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: u.HTTPClient, URL: "http://production.shippingapis.com"}
+	return h.HealthCheck(ctx)
+}
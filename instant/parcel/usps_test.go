@@ -1,6 +1,7 @@
 package parcel
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -34,7 +35,7 @@ func TestUSPSFetch(t *testing.T) {
 				HTTPClient: &http.Client{},
 			}
 
-			got, err := a.Fetch(tt.name)
+			got, err := a.Fetch(context.Background(), tt.name)
 			if err != nil {
 				t.Fatal(err)
 			}
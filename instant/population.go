@@ -82,7 +82,7 @@ func (p *Population) solve(r *http.Request) Answerer {
 	n := time.Now().Year()
 	start := n - 50 // 50 years seems to be the max allowed
 
-	resp.Response, err = p.PopulationFetcher.Fetch(alpha, time.Date(start, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(n, 1, 1, 0, 0, 0, 0, time.UTC))
+	resp.Response, err = p.PopulationFetcher.Fetch(r.Context(), alpha, time.Date(start, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(n, 1, 1, 0, 0, 0, 0, time.UTC))
 	if err != nil {
 		p.Err = err
 		return p
@@ -61,7 +61,7 @@ func (s *Shortener) solve(r *http.Request) Answerer {
 		return s
 	}
 
-	resp, err := s.Service.Shorten(u)
+	resp, err := s.Service.Shorten(r.Context(), u)
 	if err != nil {
 		s.Err = err
 		return s
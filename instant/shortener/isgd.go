@@ -1,12 +1,14 @@
 package shortener
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 
+	"github.com/jivesearch/jivesearch/health"
 	"github.com/jivesearch/jivesearch/log"
 )
 
@@ -19,11 +21,16 @@ type IsGd struct {
 var IsGdProvider provider = "is.gd"
 
 // Shorten shortens a url
-func (g *IsGd) Shorten(u *url.URL) (*Response, error) {
+func (g *IsGd) Shorten(ctx context.Context, u *url.URL) (*Response, error) {
 	uu := fmt.Sprintf("https://is.gd/create.php?format=json&url=%v", u.String())
 	fmt.Println(uu)
 
-	resp, err := g.HTTPClient.Get(uu)
+	req, err := http.NewRequest("GET", uu, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := g.HTTPClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -58,3 +65,9 @@ func (g *IsGd) Shorten(u *url.URL) (*Response, error) {
 	r.Short, err = url.Parse(t.ShortURL)
 	return r, err
 }
+
+// HealthCheck makes sure is.gd is up
+func (g *IsGd) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: g.HTTPClient, URL: "https://is.gd"}
+	return h.HealthCheck(ctx)
+}
@@ -1,6 +1,7 @@
 package shortener
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -48,7 +49,7 @@ func TestIsGdService(t *testing.T) {
 			g := &IsGd{
 				HTTPClient: &http.Client{},
 			}
-			got, err := g.Shorten(parseURL(tt.args.original))
+			got, err := g.Shorten(context.Background(), parseURL(tt.args.original))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1,11 +1,14 @@
 // Package shortener shortens urls
 package shortener
 
-import "net/url"
+import (
+	"context"
+	"net/url"
+)
 
 // Service is a url shortening service
 type Service interface {
-	Shorten(u *url.URL) (*Response, error)
+	Shorten(ctx context.Context, u *url.URL) (*Response, error)
 }
 
 type provider string
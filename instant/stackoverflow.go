@@ -107,7 +107,7 @@ func tagger(txt string) string {
 func (s *StackOverflow) solve(r *http.Request) Answerer {
 	a := &StackOverflowAnswer{}
 
-	resp, err := s.Fetch(s.remainder, []string{tagger(s.triggerWord)})
+	resp, err := s.Fetch(r.Context(), s.remainder, []string{tagger(s.triggerWord)})
 	if err != nil {
 		s.Err = err
 		return s
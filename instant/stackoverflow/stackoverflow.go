@@ -2,15 +2,18 @@
 package stackoverflow
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/jivesearch/jivesearch/health"
 )
 
 // Fetcher retrieves a StackOverflowResponse
 type Fetcher interface {
-	Fetch(query string, tags []string) (Response, error)
+	Fetch(ctx context.Context, query string, tags []string) (Response, error)
 }
 
 // API retrieves information from the Stack Overflow API
@@ -77,7 +80,7 @@ func (a *API) buildURL(query string, tags []string) (string, error) {
 }
 
 // Fetch retrieves a Stack Overflow response
-func (a *API) Fetch(query string, tags []string) (Response, error) {
+func (a *API) Fetch(ctx context.Context, query string, tags []string) (Response, error) {
 	r := Response{}
 
 	u, err := a.buildURL(query, tags)
@@ -85,7 +88,12 @@ func (a *API) Fetch(query string, tags []string) (Response, error) {
 		return r, err
 	}
 
-	resp, err := a.HTTPClient.Get(u)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return r, err
+	}
+
+	resp, err := a.HTTPClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return r, err
 	}
@@ -95,3 +103,9 @@ func (a *API) Fetch(query string, tags []string) (Response, error) {
 	err = json.NewDecoder(resp.Body).Decode(&r)
 	return r, err
 }
+
+// HealthCheck makes sure the Stack Exchange API is up
+func (a *API) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: a.HTTPClient, URL: "https://api.stackexchange.com"}
+	return h.HealthCheck(ctx)
+}
@@ -1,6 +1,7 @@
 package stackoverflow
 
 import (
+	"context"
 	"net/http"
 	"reflect"
 	"testing"
@@ -76,7 +77,7 @@ func TestAPIFetch(t *testing.T) {
 				Key:        "",
 				HTTPClient: &http.Client{},
 			}
-			got, err := a.Fetch(tt.args.query, tt.args.tags)
+			got, err := a.Fetch(context.Background(), tt.args.query, tt.args.tags)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -60,7 +60,7 @@ func (s *StockQuote) setRegex() Answerer {
 func (s *StockQuote) solve(r *http.Request) Answerer {
 	ticker := strings.ToUpper(strings.Replace(s.remainder, "$", "", -1))
 
-	resp, err := s.Fetcher.Fetch(ticker)
+	resp, err := s.Fetcher.Fetch(r.Context(), ticker)
 	if err != nil {
 		s.Err = err
 		return s
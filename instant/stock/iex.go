@@ -1,10 +1,13 @@
 package stock
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/jivesearch/jivesearch/health"
 )
 
 // IEX retrieves information from the IEX API
@@ -125,12 +128,17 @@ func (r *iexResponse) UnmarshalJSON(b []byte) error {
 }
 
 // Fetch retrieves from the IEX api
-func (i *IEX) Fetch(ticker string) (*Quote, error) {
+func (i *IEX) Fetch(ctx context.Context, ticker string) (*Quote, error) {
 	iex := iexResponse{}
 
 	u := fmt.Sprintf("https://api.iextrading.com/1.0/stock/%s/batch?types=quote,chart&range=5y", ticker)
 
-	resp, err := i.HTTPClient.Get(u)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := i.HTTPClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -145,3 +153,9 @@ func (i *IEX) Fetch(ticker string) (*Quote, error) {
 
 	return iex.Quote, err
 }
+
+// HealthCheck makes sure IEX's API is up
+func (i *IEX) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: i.HTTPClient, URL: "https://api.iextrading.com"}
+	return h.HealthCheck(ctx)
+}
@@ -1,6 +1,7 @@
 package stock
 
 import (
+	"context"
 	"net/http"
 	"reflect"
 	"testing"
@@ -81,7 +82,7 @@ func TestIEXFetch(t *testing.T) {
 			iex := &IEX{
 				HTTPClient: &http.Client{},
 			}
-			got, err := iex.Fetch(tt.args.ticker)
+			got, err := iex.Fetch(context.Background(), tt.args.ticker)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -2,6 +2,7 @@
 package stock
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"time"
@@ -9,7 +10,7 @@ import (
 
 // Fetcher retrieves stock quotes
 type Fetcher interface {
-	Fetch(ticker string) (*Quote, error)
+	Fetch(ctx context.Context, ticker string) (*Quote, error)
 }
 
 type provider string
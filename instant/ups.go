@@ -70,7 +70,7 @@ func (u *UPS) setRegex() Answerer {
 func (u *UPS) solve(req *http.Request) Answerer {
 	tn := strings.ToUpper(u.triggerWord)
 
-	r, err := u.Fetch(tn)
+	r, err := u.Fetch(req.Context(), tn)
 	if err != nil {
 		u.Err = err
 		return u
@@ -52,7 +52,7 @@ func (u *USPS) setRegex() Answerer {
 func (u *USPS) solve(req *http.Request) Answerer {
 	tn := strings.ToUpper(u.triggerWord)
 
-	r, err := u.Fetch(tn)
+	r, err := u.Fetch(req.Context(), tn)
 	if err != nil {
 		u.Err = err
 		return u
@@ -70,7 +70,7 @@ func (w *Weather) solve(r *http.Request) Answerer {
 			return w.local(r)
 		} else if len(w.remainder) == 5 { // U.S. zipcodes
 			if z, err := strconv.Atoi(w.remainder); err == nil {
-				w.Data.Solution, err = w.Fetcher.FetchByZip(z)
+				w.Data.Solution, err = w.Fetcher.FetchByZip(r.Context(), z)
 				if err != nil {
 					w.Err = err
 				}
@@ -84,7 +84,7 @@ func (w *Weather) solve(r *http.Request) Answerer {
 
 func (w *Weather) city(r *http.Request) *Weather {
 	var err error
-	w.Data.Solution, err = w.Fetcher.FetchByCity(w.remainder)
+	w.Data.Solution, err = w.Fetcher.FetchByCity(r.Context(), w.remainder)
 	if err != nil {
 		w.Err = err
 	}
@@ -97,12 +97,12 @@ func (w *Weather) local(r *http.Request) *Weather {
 	w.Type = "local weather"
 	ip := getIPAddress(r)
 
-	city, err := w.LocationFetcher.Fetch(ip)
+	city, err := w.LocationFetcher.Fetch(r.Context(), ip)
 	if err != nil {
 		w.Err = err
 	}
 
-	w.Data.Solution, err = w.Fetcher.FetchByLatLong(city.Location.Latitude, city.Location.Longitude, city.Location.TimeZone)
+	w.Data.Solution, err = w.Fetcher.FetchByLatLong(r.Context(), city.Location.Latitude, city.Location.Longitude, city.Location.TimeZone)
 	if err != nil {
 		w.Err = err
 	}
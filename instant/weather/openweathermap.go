@@ -1,11 +1,14 @@
 package weather
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/jivesearch/jivesearch/health"
 )
 
 // OpenWeatherMap retrieves information from the OpenWeatherMap API
@@ -94,10 +97,10 @@ type OwmInstant struct {
 }
 
 // FetchByCity retrieves weather for a city from the OpenWeatherMap api
-func (o *OpenWeatherMap) FetchByCity(city string) (*Weather, error) {
+func (o *OpenWeatherMap) FetchByCity(ctx context.Context, city string) (*Weather, error) {
 	c := fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?APPID=%v&q=%v&units=imperial", o.Key, city)
 	f := fmt.Sprintf("https://api.openweathermap.org/data/2.5/forecast?APPID=%v&q=%v&units=imperial", o.Key, city)
-	w, err := o.fetchCurrentAndForecast(c, f)
+	w, err := o.fetchCurrentAndForecast(ctx, c, f)
 	if err != nil {
 		return nil, err
 	}
@@ -106,10 +109,10 @@ func (o *OpenWeatherMap) FetchByCity(city string) (*Weather, error) {
 }
 
 // FetchByLatLong retrieves weather for a latitude/longitude location from the OpenWeatherMap api
-func (o *OpenWeatherMap) FetchByLatLong(lat, long float64, timeZone string) (*Weather, error) {
+func (o *OpenWeatherMap) FetchByLatLong(ctx context.Context, lat, long float64, timeZone string) (*Weather, error) {
 	c := fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?APPID=%v&lat=%v&lon=%v&units=imperial", o.Key, lat, long)
 	f := fmt.Sprintf("https://api.openweathermap.org/data/2.5/forecast?APPID=%v&lat=%v&lon=%v&units=imperial", o.Key, lat, long)
-	w, err := o.fetchCurrentAndForecast(c, f)
+	w, err := o.fetchCurrentAndForecast(ctx, c, f)
 	if err != nil {
 		return nil, err
 	}
@@ -119,11 +122,11 @@ func (o *OpenWeatherMap) FetchByLatLong(lat, long float64, timeZone string) (*We
 }
 
 // FetchByZip retrieves weather for a zipcode from the OpenWeatherMap api
-func (o *OpenWeatherMap) FetchByZip(zip int) (*Weather, error) {
+func (o *OpenWeatherMap) FetchByZip(ctx context.Context, zip int) (*Weather, error) {
 	c := fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?APPID=%v&zip=%d,us&units=imperial", o.Key, zip)
 	f := fmt.Sprintf("https://api.openweathermap.org/data/2.5/forecast?APPID=%v&zip=%d,us&units=imperial", o.Key, zip)
 
-	return o.fetchCurrentAndForecast(c, f)
+	return o.fetchCurrentAndForecast(ctx, c, f)
 }
 
 type item struct {
@@ -133,7 +136,7 @@ type item struct {
 	err error
 }
 
-func (o *OpenWeatherMap) fetchCurrentAndForecast(currentURL, forecastURL string) (*Weather, error) {
+func (o *OpenWeatherMap) fetchCurrentAndForecast(ctx context.Context, currentURL, forecastURL string) (*Weather, error) {
 	ch := make(chan item)
 	items := []item{
 		{
@@ -148,7 +151,14 @@ func (o *OpenWeatherMap) fetchCurrentAndForecast(currentURL, forecastURL string)
 
 	for _, itm := range items {
 		go func(itm item, ch chan item) {
-			resp, err := o.HTTPClient.Get(itm.u)
+			req, err := http.NewRequest("GET", itm.u, nil)
+			if err != nil {
+				itm.err = err
+				ch <- itm
+				return
+			}
+
+			resp, err := o.HTTPClient.Do(req.WithContext(ctx))
 			if err != nil {
 				itm.err = err
 				ch <- itm
@@ -271,3 +281,9 @@ func (i *OwmInstant) convertCode(id int) error {
 
 	return nil
 }
+
+// HealthCheck makes sure OpenWeatherMap's API is up
+func (o *OpenWeatherMap) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: o.HTTPClient, URL: "https://api.openweathermap.org"}
+	return h.HealthCheck(ctx)
+}
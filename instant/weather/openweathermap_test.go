@@ -1,6 +1,7 @@
 package weather
 
 import (
+	"context"
 	"net/http"
 	"reflect"
 	"testing"
@@ -84,7 +85,7 @@ func TestOpenWeatherMapFetchByCity(t *testing.T) {
 				HTTPClient: &http.Client{},
 				Key:        "myappid",
 			}
-			got, err := o.FetchByCity(tt.args.city)
+			got, err := o.FetchByCity(context.Background(), tt.args.city)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -176,7 +177,7 @@ func TestOpenWeatherMapFetchByLatLong(t *testing.T) {
 				HTTPClient: &http.Client{},
 				Key:        "myappid",
 			}
-			got, err := o.FetchByLatLong(tt.args.lat, tt.args.long, tt.args.timezone)
+			got, err := o.FetchByLatLong(context.Background(), tt.args.lat, tt.args.long, tt.args.timezone)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -265,7 +266,7 @@ func TestOpenWeatherMapFetchByZip(t *testing.T) {
 				HTTPClient: &http.Client{},
 				Key:        "myappid",
 			}
-			got, err := o.FetchByZip(tt.args.zip)
+			got, err := o.FetchByZip(context.Background(), tt.args.zip)
 			if err != nil {
 				t.Fatal(err)
 			}
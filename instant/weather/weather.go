@@ -1,16 +1,19 @@
 // Package weather fetches weather data
 package weather
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Fetcher retrieves the current and forecasted weather
 // How to get the timezone of a zipcode?
 // http://download.geonames.org/export/zip/? gives lat/long of a zipcode then
 // https://stackoverflow.com/a/16086964/522962 for the timezone.
 type Fetcher interface {
-	FetchByCity(city string) (*Weather, error)
-	FetchByLatLong(lat, long float64, timeZone string) (*Weather, error)
-	FetchByZip(zip int) (*Weather, error)
+	FetchByCity(ctx context.Context, city string) (*Weather, error)
+	FetchByLatLong(ctx context.Context, lat, long float64, timeZone string) (*Weather, error)
+	FetchByZip(ctx context.Context, zip int) (*Weather, error)
 }
 
 type provider string
@@ -69,3 +72,8 @@ const ThunderStorm Description = "Thunderstorm"
 
 // Windy indicates winds
 const Windy Description = "Windy"
+
+// Descriptions are all of our weather descriptions (e.g. to translate them)
+var Descriptions = []Description{
+	Clear, LightClouds, ScatteredClouds, OvercastClouds, Extreme, Rain, Snow, ThunderStorm, Windy,
+}
@@ -45,7 +45,7 @@ func (w *WHOIS) setRegex() Answerer {
 }
 
 func (w *WHOIS) solve(r *http.Request) Answerer {
-	resp, err := w.Fetch(w.remainder)
+	resp, err := w.Fetch(r.Context(), w.remainder)
 	if err != nil {
 		w.Err = err
 		return w
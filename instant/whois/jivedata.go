@@ -1,9 +1,12 @@
 package whois
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/url"
+
+	"github.com/jivesearch/jivesearch/health"
 )
 
 // JiveData retrieves WHOIS information from Jive Data
@@ -16,7 +19,7 @@ type JiveData struct {
 var JiveDataProvider provider = "Jive Data"
 
 // Fetch retrieves from the IEX api
-func (j *JiveData) Fetch(domain string) (*Response, error) {
+func (j *JiveData) Fetch(ctx context.Context, domain string) (*Response, error) {
 	u, err := url.Parse("https://jivedata.com/whois")
 	if err != nil {
 		return nil, err
@@ -27,7 +30,12 @@ func (j *JiveData) Fetch(domain string) (*Response, error) {
 	q.Set("domain", domain)
 	u.RawQuery = q.Encode()
 
-	resp, err := j.HTTPClient.Get(u.String())
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := j.HTTPClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -38,3 +46,9 @@ func (j *JiveData) Fetch(domain string) (*Response, error) {
 	err = json.NewDecoder(resp.Body).Decode(&r)
 	return r, err
 }
+
+// HealthCheck makes sure Jive Data is up
+func (j *JiveData) HealthCheck(ctx context.Context) error {
+	h := &health.HTTP{Client: j.HTTPClient, URL: "https://jivedata.com"}
+	return h.HealthCheck(ctx)
+}
@@ -1,6 +1,7 @@
 package whois
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -99,7 +100,7 @@ func TestJiveDataFetch(t *testing.T) {
 			responder := httpmock.NewStringResponder(200, tt.raw)
 			httpmock.RegisterResponder("GET", u.String(), responder)
 
-			got, err := j.Fetch(tt.name)
+			got, err := j.Fetch(context.Background(), tt.name)
 			if err != nil {
 				t.Fatal(err)
 			}
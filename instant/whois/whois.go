@@ -2,12 +2,13 @@
 package whois
 
 import (
+	"context"
 	"time"
 )
 
 // Fetcher retrieves WHOIS information for a domain
 type Fetcher interface {
-	Fetch(domain string) (*Response, error)
+	Fetch(ctx context.Context, domain string) (*Response, error)
 }
 
 type provider string
@@ -1,6 +1,7 @@
 package wikipedia
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -644,3 +645,8 @@ func (p *PostgreSQL) Setup() error {
 
 	return nil
 }
+
+// HealthCheck pings the database
+func (p *PostgreSQL) HealthCheck(ctx context.Context) error {
+	return p.PingContext(ctx)
+}
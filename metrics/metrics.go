@@ -0,0 +1,270 @@
+// Package metrics keeps counters & histograms and exports them in the
+// Prometheus text format (https://prometheus.io/docs/instrumenting/exposition_formats/)
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets are the upper bounds (in seconds) of the buckets of a latency histogram
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds our metrics
+type Registry struct {
+	sync.Mutex
+	names   map[string]bool
+	metrics []metric
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]bool{}}
+}
+
+func (r *Registry) register(name string, m metric) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("metric %q is already registered", name))
+	}
+
+	r.names[name] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes our metrics in the Prometheus text format
+func (r *Registry) Write(w io.Writer) error {
+	r.Lock()
+	metrics := append([]metric{}, r.metrics...)
+	r.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+
+	return bw.Flush()
+}
+
+// ServeHTTP serves our metrics to Prometheus
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	r.Write(w)
+}
+
+// vec holds the values of a metric by label values
+type vec struct {
+	sync.Mutex
+	name   string
+	help   string
+	labels []string
+	values map[string][]string // the label values of a key
+}
+
+func (v *vec) key(labelValues []string) string {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metric %q has labels %q but got %q", v.name, v.labels, labelValues))
+	}
+
+	return strings.Join(labelValues, "\xff")
+}
+
+// keys are sorted so that the output doesn't change between scrapes
+func (v *vec) keys() []string {
+	keys := []string{}
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec) header(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %v %v\n", v.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(v.help))
+	fmt.Fprintf(w, "# TYPE %v %v\n", v.name, typ)
+}
+
+// labelPairs formats the labels of a series, with an optional extra label (e.g. "le")
+func (v *vec) labelPairs(values []string, extra ...string) string {
+	pairs := []string{}
+	for i, l := range v.labels {
+		pairs = append(pairs, fmt.Sprintf(`%v="%v"`, l, escape(values[i])))
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%v="%v"`, extra[i], escape(extra[i+1])))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Counter is a value that only goes up (e.g. the number of requests)
+type Counter struct {
+	vec
+	counts map[string]float64
+}
+
+// NewCounter registers a counter with the names of its labels
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{
+		vec:    vec{name: name, help: help, labels: labels, values: map[string][]string{}},
+		counts: map[string]float64{},
+	}
+
+	r.register(name, c)
+	return c
+}
+
+// Inc adds 1 to the counter of the label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds n to the counter of the label values
+func (c *Counter) Add(n float64, labelValues ...string) {
+	k := c.key(labelValues)
+
+	c.Lock()
+	defer c.Unlock()
+
+	if _, ok := c.values[k]; !ok {
+		c.values[k] = append([]string{}, labelValues...)
+	}
+	c.counts[k] += n
+}
+
+// Value is the count of the label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.Lock()
+	defer c.Unlock()
+	return c.counts[c.key(labelValues)]
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.header(w, "counter")
+	for _, k := range c.keys() {
+		fmt.Fprintf(w, "%v%v %v\n", c.name, c.labelPairs(c.values[k]), formatFloat(c.counts[k]))
+	}
+}
+
+// Histogram counts observations (e.g. latencies) in buckets
+type Histogram struct {
+	vec
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	counts []uint64 // of each bucket (not cumulative)
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram registers a histogram with the upper bounds of its buckets and the names of its labels
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		vec:     vec{name: name, help: help, labels: labels, values: map[string][]string{}},
+		buckets: append([]float64{}, buckets...),
+		series:  map[string]*series{},
+	}
+
+	sort.Float64s(h.buckets)
+	r.register(name, h)
+	return h
+}
+
+// Observe adds an observation for the label values
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	k := h.key(labelValues)
+
+	h.Lock()
+	defer h.Unlock()
+
+	s, ok := h.series[k]
+	if !ok {
+		s = &series{counts: make([]uint64, len(h.buckets))}
+		h.series[k] = s
+		h.values[k] = append([]string{}, labelValues...)
+	}
+
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// Since observes the seconds since start
+func (h *Histogram) Since(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// Count is the number of observations of the label values
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.Lock()
+	defer h.Unlock()
+
+	if s, ok := h.series[h.key(labelValues)]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.header(w, "histogram")
+	for _, k := range h.keys() {
+		s, values := h.series[k], h.values[k]
+
+		var cumulative uint64
+		for i, b := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%v_bucket%v %v\n", h.name, h.labelPairs(values, "le", formatFloat(b)), cumulative)
+		}
+
+		fmt.Fprintf(w, "%v_bucket%v %v\n", h.name, h.labelPairs(values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%v_sum%v %v\n", h.name, h.labelPairs(values), formatFloat(s.sum))
+		fmt.Fprintf(w, "%v_count%v %v\n", h.name, h.labelPairs(values), s.count)
+	}
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	c := r.NewCounter("requests_total", "Requests by route.", "route", "status")
+	c.Inc("search", "200")
+	c.Inc("search", "200")
+	c.Add(3, "about", "500")
+	c.Inc(`quo"te`, "200")
+
+	h := r.NewHistogram("latency_seconds", "Latencies.\nIn seconds.", []float64{1, .1}, "stage")
+	h.Observe(.05, "bang")
+	h.Observe(.5, "bang")
+	h.Observe(2, "bang")
+
+	r.NewCounter("unused_total", "No labels.")
+
+	want := `# HELP requests_total Requests by route.
+# TYPE requests_total counter
+requests_total{route="about",status="500"} 3
+requests_total{route="quo\"te",status="200"} 1
+requests_total{route="search",status="200"} 2
+# HELP latency_seconds Latencies.\nIn seconds.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{stage="bang",le="0.1"} 1
+latency_seconds_bucket{stage="bang",le="1"} 2
+latency_seconds_bucket{stage="bang",le="+Inf"} 3
+latency_seconds_sum{stage="bang"} 2.55
+latency_seconds_count{stage="bang"} 3
+# HELP unused_total No labels.
+# TYPE unused_total counter
+`
+
+	buf := &bytes.Buffer{}
+	if err := r.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != want {
+		t.Fatalf("got:\n%v\nwant:\n%v", got, want)
+	}
+
+	if got := c.Value("search", "200"); got != 2 {
+		t.Fatalf("got %v; want 2", got)
+	}
+
+	if got := h.Count("bang"); got != 3 {
+		t.Fatalf("got %v; want 3", got)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != want {
+		t.Fatalf("got %d %v", rec.Code, rec.Body.String())
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Fatalf("got %q", ct)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("requests_total", "Requests by route.", "route")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic on the wrong number of labels")
+		}
+	}()
+
+	c.Inc("search", "200")
+}
+
+func TestRegisterTwice(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("requests_total", "Requests.")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic on a duplicate metric")
+		}
+	}()
+
+	r.NewHistogram("requests_total", "Requests.", DefBuckets)
+}
@@ -0,0 +1,101 @@
+// Package anchor collects the anchor text of links so a page can be
+// found by the words other pages use to describe it.
+package anchor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Anchor is the text of a link pointing to a page
+type Anchor struct {
+	Target string `json:"target"`
+	Text   string `json:"text"`
+	Domain string `json:"domain"` // the domain of the linking page
+}
+
+// MaxLength is the max number of characters we keep of the anchor text
+var MaxLength = 100
+
+var errNoText = fmt.Errorf("no useful anchor text")
+
+// generic anchor text that tells us nothing about the target
+var generic = map[string]bool{
+	"click here": true, "here": true, "link": true, "more": true, "next": true,
+	"previous": true, "read more": true, "continue reading": true, "this": true,
+	"learn more": true, "more info": true, "website": true,
+}
+
+// New creates a new *Anchor and normalizes its text
+func New(target, text, domain string) (*Anchor, error) {
+	text = Normalize(text, MaxLength)
+
+	if text == "" || generic[strings.ToLower(text)] || text == target {
+		return nil, errNoText
+	}
+
+	return &Anchor{Target: target, Text: text, Domain: domain}, nil
+}
+
+// Normalize collapses the whitespace of a text and keeps
+// at most max characters (not bytes) of it
+func Normalize(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	var n int
+	for i := range text {
+		if n == max {
+			return strings.TrimSpace(text[:i])
+		}
+		n++
+	}
+
+	return text
+}
+
+// Aggregate combines the anchor text of a page from all the domains that link
+// to it. Texts used by more domains come first. Texts are case-insensitively
+// deduplicated.
+func Aggregate(byDomain map[string][]string) []string {
+	type count struct {
+		text    string
+		domains int
+	}
+
+	counts := map[string]*count{}
+	for _, texts := range byDomain {
+		seen := map[string]bool{}
+		for _, t := range texts {
+			k := strings.ToLower(t)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+
+			if _, ok := counts[k]; !ok {
+				counts[k] = &count{text: t}
+			}
+			counts[k].domains++
+		}
+	}
+
+	sorted := []*count{}
+	for _, c := range counts {
+		sorted = append(sorted, c)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].domains == sorted[j].domains {
+			return sorted[i].text < sorted[j].text
+		}
+		return sorted[i].domains > sorted[j].domains
+	})
+
+	texts := []string{}
+	for _, c := range sorted {
+		texts = append(texts, c.text)
+	}
+
+	return texts
+}
@@ -0,0 +1,86 @@
+package anchor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	type want struct {
+		anchor *Anchor
+		err    error
+	}
+
+	for _, c := range []struct {
+		name   string
+		target string
+		text   string
+		want
+	}{
+		{
+			name:   "basic",
+			target: "http://www.example.com/",
+			text:   "  An   Example\n Page ",
+			want: want{
+				&Anchor{Target: "http://www.example.com/", Text: "An Example Page", Domain: "example.org"},
+				nil,
+			},
+		},
+		{
+			name:   "truncated",
+			target: "http://www.example.com/",
+			text:   strings.Repeat("a", 150),
+			want: want{
+				&Anchor{Target: "http://www.example.com/", Text: strings.Repeat("a", 100), Domain: "example.org"},
+				nil,
+			},
+		},
+		{
+			name:   "truncated on a character",
+			target: "http://www.example.com/",
+			text:   strings.Repeat("é", 150),
+			want: want{
+				&Anchor{Target: "http://www.example.com/", Text: strings.Repeat("é", 100), Domain: "example.org"},
+				nil,
+			},
+		},
+		{
+			name:   "truncated on a space",
+			target: "http://www.example.com/",
+			text:   strings.Repeat("a", 99) + " b",
+			want: want{
+				&Anchor{Target: "http://www.example.com/", Text: strings.Repeat("a", 99), Domain: "example.org"},
+				nil,
+			},
+		},
+		{"empty", "http://www.example.com/", "   ", want{nil, errNoText}},
+		{"generic", "http://www.example.com/", "Click Here", want{nil, errNoText}},
+		{"url", "http://www.example.com/", "http://www.example.com/", want{nil, errNoText}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := New(c.target, c.text, "example.org")
+			if err != c.want.err {
+				t.Fatalf("got err %v; want %v", err, c.want.err)
+			}
+
+			if !reflect.DeepEqual(got, c.want.anchor) {
+				t.Fatalf("got %+v; want %+v", got, c.want.anchor)
+			}
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	got := Aggregate(map[string][]string{
+		"example.com": {"Jimi Hendrix", "jimi hendrix", "Guitar"},
+		"example.org": {"Jimi Hendrix"},
+		"example.net": {"Are You Experienced", "Jimi Hendrix"},
+	})
+
+	want := []string{"Jimi Hendrix", "Are You Experienced", "Guitar"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v; want %+v", got, want)
+	}
+}
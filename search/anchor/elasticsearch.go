@@ -0,0 +1,134 @@
+package anchor
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/jivesearch/jivesearch/log"
+	"github.com/olivere/elastic"
+)
+
+// ElasticSearch hold connection and index settings.
+// We keep one document for each target & linking domain so that a
+// single domain can't stuff the anchors of a page.
+type ElasticSearch struct {
+	Client       *elastic.Client
+	Index        string
+	Type         string
+	Bulk         *elastic.BulkProcessor
+	MaxPerDomain int // max unique anchor texts we keep from a domain for a target
+	MaxDomains   int // max domains we aggregate for a target
+}
+
+type domainAnchors struct {
+	Target string   `json:"target"`
+	Domain string   `json:"domain"`
+	Texts  []string `json:"texts"`
+}
+
+// appends the text unless it is already there or the domain is at its cap
+const script = `if (ctx._source.texts.contains(params.text) || ctx._source.texts.size() >= params.max) {
+	ctx.op = 'none'
+} else {
+	ctx._source.texts.add(params.text)
+}`
+
+// ID is the document ID of a target & linking domain
+func ID(target, domain string) string {
+	h := sha1.Sum([]byte(target + " " + domain))
+	return hex.EncodeToString(h[:])
+}
+
+// Upsert adds the anchor text to the target & linking domain
+func (e *ElasticSearch) Upsert(a *Anchor) error {
+	s := elastic.NewScript(script).
+		Lang("painless").
+		Param("text", a.Text).
+		Param("max", e.MaxPerDomain)
+
+	item := elastic.NewBulkUpdateRequest().
+		Index(e.Index).
+		Type(e.Type).
+		Id(ID(a.Target, a.Domain)).
+		Script(s).
+		Upsert(&domainAnchors{Target: a.Target, Domain: a.Domain, Texts: []string{a.Text}})
+
+	e.Bulk.Add(item)
+	return nil
+}
+
+// Fetch returns the aggregated anchor text of a target
+func (e *ElasticSearch) Fetch(target string) ([]string, error) {
+	byDomain := map[string][]string{}
+
+	out, err := e.Client.Search().
+		Index(e.Index).
+		Type(e.Type).
+		Query(elastic.NewTermQuery("target", target)).
+		Size(e.MaxDomains).
+		Do(context.TODO())
+
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			err = nil
+		}
+		return []string{}, err
+	}
+
+	for _, h := range out.Hits.Hits {
+		it := &domainAnchors{}
+		if err := json.Unmarshal(*h.Source, it); err != nil {
+			return []string{}, err
+		}
+
+		byDomain[it.Domain] = it.Texts
+	}
+
+	return Aggregate(byDomain), nil
+}
+
+// Setup will create our anchor index
+func (e *ElasticSearch) Setup() error {
+	exists, err := e.Client.IndexExists(e.Index).Do(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		log.Info.Println("Creating index:", e.Index)
+		if _, err = e.Client.CreateIndex(e.Index).Body(e.mapping()).Do(context.TODO()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapping is the mapping of our anchor Index.
+func (e *ElasticSearch) mapping() string {
+	m := `{
+		"mappings": {
+			"anchor": {
+				"_all": {
+					"enabled": false
+				},
+				"dynamic": "strict",
+				"properties": {
+					"target": {
+						"type": "keyword"
+					},
+					"domain": {
+						"type": "keyword"
+					},
+					"texts": {
+						"type": "keyword"
+					}
+				}
+			}
+		}
+	}`
+
+	return m
+}
@@ -0,0 +1,138 @@
+package anchor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/olivere/elastic"
+)
+
+func TestUpsert(t *testing.T) {
+	handler := http.NotFound
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r)
+	}))
+	defer ts.Close()
+
+	handler = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"took": 27,
+			"errors": false,
+			"items": [
+				{
+					"update": {
+						"_index": "anchors",
+						"_type": "anchor",
+						"_id": "6b2bdf5bd2cc0cbb7d2d2a3b6a1b0d33f4de7a3b",
+						"_version": 1,
+						"status": 201
+					}
+				}
+			]
+		}`))
+	}
+
+	e, err := MockService(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Upsert(&Anchor{Target: "http://www.example.com/", Text: "An Example", Domain: "example.org"})
+
+	if err := e.Bulk.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := e.Bulk.Stats()
+	if stats.Succeeded != 1 {
+		t.Fatalf("upsert failed: got %d", stats.Succeeded)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	handler := http.NotFound
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r)
+	}))
+	defer ts.Close()
+
+	handler = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"hits": {
+				"total": 2,
+				"hits": [
+					{
+						"_id": "1",
+						"_source": {"target": "http://www.example.com/", "domain": "example.org", "texts": ["Example", "An Example"]}
+					},
+					{
+						"_id": "2",
+						"_source": {"target": "http://www.example.com/", "domain": "example.net", "texts": ["An Example"]}
+					}
+				]
+			}
+		}`))
+	}
+
+	e, err := MockService(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := e.Fetch("http://www.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"An Example", "Example"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v; want %+v", got, want)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	handler := http.NotFound
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r)
+	}))
+	defer ts.Close()
+
+	handler = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"acknowledged": true}`))
+	}
+
+	e, err := MockService(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Setup(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func MockService(url string) (*ElasticSearch, error) {
+	client, err := elastic.NewSimpleClient(elastic.SetURL(url))
+	if err != nil {
+		return nil, err
+	}
+
+	bulk, err := client.BulkProcessor().Stats(true).Do(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ElasticSearch{
+		Client:       client,
+		Index:        "anchors",
+		Type:         "anchor",
+		Bulk:         bulk,
+		MaxPerDomain: 3,
+		MaxDomains:   100,
+	}, nil
+}
@@ -14,11 +14,15 @@ import (
 	"github.com/garyburd/redigo/redis"
 	"github.com/jivesearch/jivesearch/config"
 	"github.com/jivesearch/jivesearch/log"
+	"github.com/jivesearch/jivesearch/search/anchor"
 	"github.com/jivesearch/jivesearch/search/crawler"
+	"github.com/jivesearch/jivesearch/search/crawler/feed"
 	"github.com/jivesearch/jivesearch/search/crawler/queue"
+	"github.com/jivesearch/jivesearch/search/crawler/resolver"
 	"github.com/jivesearch/jivesearch/search/crawler/robots"
 	"github.com/jivesearch/jivesearch/search/document"
 	img "github.com/jivesearch/jivesearch/search/image"
+	"github.com/jivesearch/jivesearch/search/link"
 	"github.com/olivere/elastic"
 	"github.com/spf13/viper"
 )
@@ -57,17 +61,28 @@ func setup(v *viper.Viper) {
 
 	c = crawler.New(v)
 
+	// the crawler and its dialer share the resolver so we connect to the IP we reserved
+	res := resolver.New(
+		v.GetDuration("crawler.dns.timeout"),
+		v.GetDuration("crawler.dns.ttl.min"),
+		v.GetDuration("crawler.dns.ttl.max"),
+		v.GetDuration("crawler.dns.ttl.negative"),
+		v.GetInt("crawler.dns.cache"),
+	)
+
+	c.Resolver = res
+
 	c.HTTPClient = &http.Client{
 		Transport: &http.Transport{
 			Dial: (&nett.Dialer{
-				Resolver: &nett.CacheResolver{TTL: 10 * time.Minute},
+				Resolver: res,
 				IPFilter: nett.DualStack,
 			}).Dial,
 			DisableKeepAlives: true,
 		},
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			// For robots.txt follow the default redirect policy of max 10 redirects
-			// For all others don't follow redirects
+			// For all others the crawler follows (and records) the redirects itself
 			if strings.ToLower(req.URL.Path) == crawler.RobotsPath.Path {
 				if len(via) >= 10 {
 					return errors.New("stopped after 10 redirects")
@@ -111,7 +126,8 @@ func main() {
 			Index:  v.GetString("elasticsearch.search.index"),
 			Type:   v.GetString("elasticsearch.search.type"),
 		},
-		Bulk: bulk,
+		Bulk:        bulk,
+		MaxFailures: v.GetInt("crawler.stale.failures"),
 	}
 
 	if err := c.Backend.Setup(); err != nil {
@@ -130,6 +146,32 @@ func main() {
 		panic(err)
 	}
 
+	// setup our anchor text index
+	c.AnchorBackend = &anchor.ElasticSearch{
+		Client:       client,
+		Index:        v.GetString("elasticsearch.anchors.index"),
+		Type:         v.GetString("elasticsearch.anchors.type"),
+		Bulk:         bulk,
+		MaxPerDomain: v.GetInt("crawler.anchors.max.domain"),
+		MaxDomains:   v.GetInt("crawler.anchors.max.domains"),
+	}
+
+	if err := c.AnchorBackend.Setup(); err != nil {
+		panic(err)
+	}
+
+	// setup our link index
+	c.LinkBackend = &link.ElasticSearch{
+		Client: client,
+		Index:  v.GetString("elasticsearch.links.index"),
+		Type:   v.GetString("elasticsearch.links.type"),
+		Bulk:   bulk,
+	}
+
+	if err := c.LinkBackend.Setup(); err != nil {
+		panic(err)
+	}
+
 	// Setup our robots.txt cache
 	c.Robots = &robots.ElasticSearch{
 		Client: client,
@@ -169,6 +211,12 @@ func main() {
 	defer rds.RedisPool.Close()
 	c.Queue = rds
 
+	// RSS/Atom feeds share the queue's redis
+	c.Feeds = &feed.Redis{
+		RedisPool: rds.RedisPool,
+		Prefix:    v.GetString("crawler.feeds.prefix"),
+	}
+
 	defer c.Close()
 
 	if err := c.Start(duration); err != nil {
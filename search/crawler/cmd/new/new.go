@@ -78,7 +78,8 @@ func main() {
 			Index:  v.GetString("elasticsearch.search.index"),
 			Type:   v.GetString("elasticsearch.search.type"),
 		},
-		Bulk: bulk,
+		Bulk:        bulk,
+		MaxFailures: v.GetInt("crawler.stale.failures"),
 	}
 
 	if err := backend.Setup(); err != nil {
@@ -191,7 +192,7 @@ func main() {
 				maxLinks = 0
 			}
 
-			if err := doc.SetContent(uaShort, maxLinks, links, images,
+			if err := doc.SetContent(uaShort, maxLinks, links, images, nil,
 				v.GetInt("crawler.truncate.title"), v.GetInt("crawler.truncate.keywords"), v.GetInt("crawler.truncate.description")); err != nil {
 				log.Debug.Printf("document parsing error: %v\n%v", doc.ID, err)
 			}
@@ -0,0 +1,80 @@
+// Command stale reports the stale documents in our index and,
+// with the --purge flag, deletes those past the retention period.
+// A document goes stale after too many failed crawls or when it is gone (410).
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jivesearch/jivesearch/config"
+	"github.com/jivesearch/jivesearch/log"
+	"github.com/jivesearch/jivesearch/search/crawler"
+	"github.com/jivesearch/jivesearch/search/document"
+	"github.com/olivere/elastic"
+	"github.com/spf13/viper"
+)
+
+var now = func() time.Time { return time.Now().UTC() }
+
+func setup(v *viper.Viper) {
+	v.SetEnvPrefix("jivesearch")
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	config.SetDefaults(v)
+
+	if v.GetBool("debug") {
+		log.Debug.SetOutput(os.Stdout)
+	}
+}
+
+func main() {
+	v := viper.New()
+	setup(v)
+
+	client, err := elastic.NewClient(elastic.SetURL(v.GetString("elasticsearch.url")), elastic.SetSniff(false))
+	if err != nil {
+		panic(err)
+	}
+
+	backend := &crawler.ElasticSearch{
+		ElasticSearch: &document.ElasticSearch{
+			Client: client,
+			Index:  v.GetString("elasticsearch.search.index"),
+			Type:   v.GetString("elasticsearch.search.type"),
+		},
+	}
+
+	before := now().Add(-v.GetDuration("crawler.stale.retention"))
+
+	r, err := backend.Stale(before)
+	if err != nil {
+		panic(err)
+	}
+
+	log.Info.Printf("%d stale documents, %d went stale before %v\n", r.Stale, r.Expired, before.Format("2006-01-02"))
+
+	domains := make([]string, 0, len(r.Domains))
+	for d := range r.Domains {
+		domains = append(domains, d)
+	}
+
+	sort.Slice(domains, func(i, j int) bool { return r.Domains[domains[i]] > r.Domains[domains[j]] })
+
+	for _, d := range domains {
+		log.Info.Printf("  %v: %d\n", d, r.Domains[d])
+	}
+
+	if !v.GetBool("crawler.stale.purge") {
+		return
+	}
+
+	deleted, err := backend.Purge(before)
+	if err != nil {
+		panic(err)
+	}
+
+	log.Info.Printf("deleted %d stale documents\n", deleted)
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSetup(t *testing.T) {
+	v := viper.New()
+	setup(v)
+}
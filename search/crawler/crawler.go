@@ -2,18 +2,24 @@
 package crawler
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 
+	"github.com/jivesearch/jivesearch/search/anchor"
 	"github.com/jivesearch/jivesearch/search/document"
 	img "github.com/jivesearch/jivesearch/search/image"
+	"github.com/jivesearch/jivesearch/search/link"
 
 	"github.com/jivesearch/jivesearch/config"
 	"github.com/jivesearch/jivesearch/log"
+	"github.com/jivesearch/jivesearch/search/crawler/feed"
 	"github.com/jivesearch/jivesearch/search/crawler/queue"
+	"github.com/jivesearch/jivesearch/search/crawler/resolver"
 	"github.com/jivesearch/jivesearch/search/crawler/robots"
 	"github.com/pkg/errors"
 	"github.com/temoto/robotstxt"
@@ -33,22 +39,29 @@ type Crawler struct {
 	maxQueueLinks  int64         // max links for our queue
 	maxLinks       int           // max links to extract from a document
 	maxDomainLinks int           // max links to store for a domain by default
+	maxRedirects   int           // max redirects to follow for a link
+	feedInterval   time.Duration // how often we poll a feed
 	truncate
-	Robots robots.Cacher
-	Queue  queue.Queuer
+	Robots   robots.Cacher
+	Queue    queue.Queuer
+	Feeds    feed.Registry // optional...nil disables feed discovery & polling
+	Resolver               // optional...nil disables politeness per IP
 	channels
 	wg    sync.WaitGroup
 	stats *Stats
 	Backend
 	ImageBackend
+	AnchorBackend // optional...nil disables anchor text collection
+	LinkBackend   // optional...nil disables the link store
 }
 
 type channels struct {
-	links  chan string
-	images chan *img.Image
-	ch     chan string
-	cancel chan bool
-	err    chan error
+	links   chan string
+	images  chan *img.Image
+	anchors chan *anchor.Anchor
+	ch      chan string
+	cancel  chan bool
+	err     chan error
 }
 
 // UserAgent holds the full and short version of the crawler's useragent
@@ -66,7 +79,7 @@ type truncate struct {
 // Backend outlines methods to save documents and count the docs a domain has
 type Backend interface {
 	Setup() error
-	CrawledAndCount(u, domain string) (time.Time, int, error) // gotta be a better name for this
+	CrawledAndCount(u, domain string) (time.Time, string, int, error) // gotta be a better name for this
 	Upsert(*document.Document) error
 }
 
@@ -76,6 +89,28 @@ type ImageBackend interface {
 	Upsert(*img.Image) error
 }
 
+// Resolver resolves a host to its IP addresses
+type Resolver interface {
+	Resolve(host string) ([]net.IP, error)
+}
+
+// AnchorBackend outlines methods to save the anchor text of links
+// and to fetch the aggregated anchor text of a page
+type AnchorBackend interface {
+	Setup() error
+	Upsert(*anchor.Anchor) error
+	Fetch(target string) ([]string, error)
+}
+
+// LinkBackend outlines methods to save the outlinks of a page,
+// to delete the ones it dropped and to get the authority of a page
+type LinkBackend interface {
+	Setup() error
+	Upsert(*link.Link) error
+	Prune(source string, targets []string) error
+	Authority(target string) (int64, error)
+}
+
 var now = func() time.Time { return time.Now().UTC() }
 
 // RobotsPath is robots.txt path
@@ -96,17 +131,20 @@ func New(cfg config.Provider) *Crawler {
 		maxQueueLinks:  int64(cfg.GetInt("crawler.max.queue.links")),
 		maxLinks:       cfg.GetInt("crawler.max.links"),
 		maxDomainLinks: cfg.GetInt("crawler.max.domain.links"),
+		maxRedirects:   cfg.GetInt("crawler.max.redirects"),
+		feedInterval:   cfg.Get("crawler.feeds.interval").(time.Duration),
 		truncate: truncate{
 			title:       cfg.GetInt("crawler.truncate.title"),
 			keywords:    cfg.GetInt("crawler.truncate.keywords"),
 			description: cfg.GetInt("crawler.truncate.description"),
 		},
 		channels: channels{
-			links:  make(chan string),
-			images: make(chan *img.Image),
-			ch:     make(chan string),
-			cancel: make(chan bool),
-			err:    make(chan error),
+			links:   make(chan string),
+			images:  make(chan *img.Image),
+			anchors: make(chan *anchor.Anchor),
+			ch:      make(chan string),
+			cancel:  make(chan bool),
+			err:     make(chan error),
 		},
 		wg:    sync.WaitGroup{},
 		stats: &Stats{Start: now(), StatusCodes: make(map[int]int64)},
@@ -122,6 +160,14 @@ func (c *Crawler) Start(t time.Duration) error {
 	go c.imageHandler()
 	go c.startQueue()
 
+	if c.AnchorBackend != nil {
+		go c.anchorHandler()
+	}
+
+	if c.Feeds != nil {
+		go c.pollFeeds(ctx)
+	}
+
 	go func() {
 		for _, lnk := range c.seeds {
 			c.links <- lnk
@@ -152,6 +198,9 @@ func (c *Crawler) Start(t time.Duration) error {
 	c.wg.Wait()
 	close(c.links)
 	close(c.images)
+	if c.anchors != nil {
+		close(c.anchors)
+	}
 
 	return err
 }
@@ -174,6 +223,15 @@ func (c *Crawler) imageHandler() {
 	}
 }
 
+func (c *Crawler) anchorHandler() {
+	for a := range c.anchors {
+		if err := c.AnchorBackend.Upsert(a); err != nil {
+			c.err <- errors.Wrapf(err, "unable to insert anchor for: %v", a.Target)
+			return
+		}
+	}
+}
+
 func (c *Crawler) startQueue() {
 	for {
 		select {
@@ -219,15 +277,28 @@ func (c *Crawler) work(lnk string) {
 
 	var delay time.Duration
 	var ra string // Retry-After header
+	var ip string // the IP we reserved (if any)
+	var attempted bool
 
 	defer func() {
+		// an entry of a feed stays pending until we've had a go at it
+		if attempted {
+			c.entryCrawled(lnk)
+		}
+
 		delay = calculateHostDelay(doc.StatusCode, ra, delay)
-		if err := c.Queue.DelayHost(sh, delay); err != nil {
-			c.err <- errors.Wrapf(err, "host: %q, delay: %q", sh, delay)
+		for _, h := range []string{sh, ip} {
+			if h == "" {
+				continue
+			}
+
+			if err := c.Queue.DelayHost(h, delay); err != nil {
+				c.err <- errors.Wrapf(err, "host: %q, delay: %q", h, delay)
+			}
 		}
 	}()
 
-	crawled, cnt, err := c.Backend.CrawledAndCount(doc.ID, doc.Domain)
+	crawled, stored, cnt, err := c.Backend.CrawledAndCount(doc.ID, doc.Domain)
 	if err != nil {
 		c.err <- errors.Wrapf(err, doc.ID)
 		return
@@ -235,16 +306,47 @@ func (c *Crawler) work(lnk string) {
 
 	// crawled recently...always skip
 	if !crawled.Before(now().Add(-c.since)) {
+		attempted = true
 		return
 	}
 
 	// new doc? only crawl if we have room for that domain
 	if crawled == (time.Time{}) && cnt > c.maxDomainLinks {
+		attempted = true
 		return
 	}
 
+	doc.StoredIn = stored
 	doc.SetStatusCode(-1).SetCrawled(now())
 
+	// Many hosts can share an IP (shared hosting, CDNs, etc) so we are polite to the IP as well
+	if c.Resolver != nil {
+		ips, err := c.Resolver.Resolve(doc.URL.Hostname())
+		switch {
+		case err == resolver.ErrNotFound || (err == nil && len(ips) == 0):
+			c.unreachable(doc, errors.Errorf("no IP for %v", doc.URL.Hostname()))
+			return
+		case err != nil: // e.g. a timeout doesn't mean the page is gone
+			log.Debug.Printf("unable to resolve %v: %v", doc.URL.Hostname(), err)
+			delay = 600 * time.Second
+			return
+		}
+
+		addr, err := c.reserveIP(ips)
+		switch err {
+		case nil:
+			ip, doc.IP = addr, addr
+		case queue.ErrAlreadyReserved:
+			doc.SetStatusCode(-1) // releases the host right away
+			return
+		default:
+			c.err <- errors.Wrapf(err, "ip: %q", addr)
+			return
+		}
+	}
+
+	attempted = true
+
 	rbt := c.fetchRobots(doc)
 	rbtsText, err := robotstxt.FromStatusAndString(rbt.StatusCode, rbt.Body)
 	if err != nil {
@@ -259,14 +361,19 @@ func (c *Crawler) work(lnk string) {
 
 	delay = group.CrawlDelay
 
-	resp, err := c.doRequest(doc.ID)
+	resp, hops, err := c.follow(doc, group)
 	if err != nil {
-		log.Info.Println(err)
+		c.unreachable(doc, err)
 		return
 	}
 
 	defer resp.Body.Close()
 
+	if doc, err = c.redirects(doc, resp, hops); err != nil {
+		c.err <- errors.Wrapf(err, "unable to handle redirects: %v", doc.ID)
+		return
+	}
+
 	c.stats.Update(resp.StatusCode)
 	doc.SetStatusCode(resp.StatusCode)
 	ra = resp.Header.Get("Retry-After")
@@ -303,21 +410,52 @@ func (c *Crawler) work(lnk string) {
 			maxLinks = 0
 		}
 
-		if err := doc.SetContent(c.UserAgent.Short, maxLinks, c.links, c.images,
+		var anchors chan *anchor.Anchor
+		if c.AnchorBackend != nil {
+			anchors = c.anchors
+		}
+
+		if err := doc.SetContent(c.UserAgent.Short, maxLinks, c.links, c.images, anchors,
 			c.truncate.title, c.truncate.keywords, c.truncate.description); err != nil {
 			log.Debug.Printf("document parsing error: %v\n%v", doc.ID, err)
 		}
 
+		if err := c.registerFeeds(doc); err != nil {
+			c.err <- errors.Wrapf(err, "unable to register feeds: %v", doc.ID)
+			return
+		}
+
+		// the links of a duplicate page are already stored for its canonical
+		if doc.SetCanonical(c.links); doc.Canonical && c.LinkBackend != nil {
+			if err := c.storeOutlinks(doc); err != nil {
+				c.err <- errors.Wrapf(err, "unable to store outlinks: %v", doc.ID)
+				return
+			}
+		}
+
 		// don't index content if not wanted or if not canonical
-		if doc.SetCanonical(c.links); !doc.Canonical || !doc.Index {
+		if !doc.Canonical || !doc.Index {
 			doc = &document.Document{
-				ID:      doc.ID,
-				Crawled: doc.Crawled,
+				ID:       doc.ID,
+				Crawled:  doc.Crawled,
+				StoredIn: doc.StoredIn,
 				Content: document.Content{
 					StatusCode: doc.StatusCode,
 					Language:   doc.Language,
 				},
 			}
+		} else {
+			if err := c.setFeedEntry(doc); err != nil {
+				c.err <- errors.Wrapf(err, "unable to get feed entry: %v", doc.ID)
+				return
+			}
+
+			if c.AnchorBackend != nil {
+				if doc.Anchors, err = c.AnchorBackend.Fetch(doc.ID); err != nil {
+					c.err <- errors.Wrapf(err, "unable to fetch anchors: %v", doc.ID)
+					return
+				}
+			}
 		}
 	}
 
@@ -327,6 +465,66 @@ func (c *Crawler) work(lnk string) {
 	}
 }
 
+// storeOutlinks saves the outlinks of a page along with the page's
+// authority so the backlinks of a page can be sorted by it.
+// The links the page no longer has are deleted.
+func (c *Crawler) storeOutlinks(doc *document.Document) error {
+	targets := []string{}
+	for _, l := range doc.Outlinks {
+		targets = append(targets, l.Target)
+	}
+
+	if err := c.LinkBackend.Prune(doc.ID, targets); err != nil {
+		return err
+	}
+
+	if len(doc.Outlinks) == 0 {
+		return nil
+	}
+
+	authority, err := c.LinkBackend.Authority(doc.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range doc.Outlinks {
+		l.Authority, l.Crawled = authority, doc.Crawled
+		if err := c.LinkBackend.Upsert(l); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unreachable records a failed crawl of a page we couldn't fetch at all
+// so that a dead page goes stale like one that returns an error
+func (c *Crawler) unreachable(doc *document.Document, err error) {
+	log.Debug.Println(errors.Wrapf(err, "unable to fetch %v", doc.ID))
+
+	doc.SetStatusCode(document.StatusUnreachable)
+	c.stats.Update(doc.StatusCode)
+
+	if err := c.Backend.Upsert(doc); err != nil {
+		c.err <- errors.Wrapf(err, "unable to insert doc: %v", doc.ID)
+	}
+}
+
+// reserveIP reserves the IP of a host.
+// Hosts with several IPs are reserved by their lowest IP so
+// we reserve the same IP regardless of the order of the records.
+func (c *Crawler) reserveIP(ips []net.IP) (string, error) {
+	lowest := ips[0]
+	for _, ip := range ips[1:] {
+		if bytes.Compare(ip.To16(), lowest.To16()) < 0 {
+			lowest = ip
+		}
+	}
+
+	addr := lowest.String()
+	return addr, c.Queue.ReserveHost(addr, 600*time.Second)
+}
+
 // fetchRobots fetches and caches the robots.txt file
 func (c *Crawler) fetchRobots(doc *document.Document) *robots.Robots {
 	sh := doc.SchemeHost()
@@ -2,7 +2,9 @@ package crawler
 
 import (
 	"bytes"
+	"errors"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -12,8 +14,11 @@ import (
 
 	"github.com/jivesearch/jivesearch/search/document"
 	img "github.com/jivesearch/jivesearch/search/image"
+	"github.com/jivesearch/jivesearch/search/link"
 
 	"github.com/jarcoal/httpmock"
+	"github.com/jivesearch/jivesearch/search/crawler/queue"
+	"github.com/jivesearch/jivesearch/search/crawler/resolver"
 	"github.com/jivesearch/jivesearch/search/crawler/robots"
 	"github.com/spf13/pflag"
 )
@@ -36,6 +41,8 @@ func TestNew(t *testing.T) {
 	p.SetDefault("crawler.max.queue.links", 100000)
 	p.SetDefault("crawler.max.links", 10)
 	p.SetDefault("crawler.max.domain.links", 100)
+	p.SetDefault("crawler.max.redirects", 5)
+	p.SetDefault("crawler.feeds.interval", 15*time.Minute)
 	p.SetDefault("crawler.truncate.title", 100)
 	p.SetDefault("crawler.truncate.keywords", 25)
 	p.SetDefault("crawler.truncate.description", 250)
@@ -53,6 +60,8 @@ func TestNew(t *testing.T) {
 		maxQueueLinks:  100000,
 		maxLinks:       10,
 		maxDomainLinks: 100,
+		maxRedirects:   5,
+		feedInterval:   15 * time.Minute,
 		maxBytes:       10240000,
 		truncate: truncate{
 			title:       100,
@@ -246,6 +255,216 @@ func TestWork(t *testing.T) {
 	httpmock.Reset()
 }
 
+func TestReserveIP(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		ips      []net.IP
+		reserved map[string]bool
+		want     string
+		err      error
+	}{
+		{
+			name: "lowest ip",
+			ips:  []net.IP{net.ParseIP("93.184.216.35"), net.ParseIP("93.184.216.34")},
+			want: "93.184.216.34",
+		},
+		{
+			name:     "already reserved",
+			ips:      []net.IP{net.ParseIP("93.184.216.34")},
+			reserved: map[string]bool{"93.184.216.34": true},
+			want:     "93.184.216.34",
+			err:      queue.ErrAlreadyReserved,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			q := &mockReserveQueue{reserved: c.reserved}
+			cr := &Crawler{
+				Queue: q,
+			}
+
+			got, err := cr.reserveIP(c.ips)
+			if err != c.err {
+				t.Fatalf("got err %v; want %v", err, c.err)
+			}
+
+			if got != c.want {
+				t.Fatalf("got %q; want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestWorkSharedIP(t *testing.T) {
+	q := &mockReserveQueue{reserved: map[string]bool{"93.184.216.34": true}}
+	reg := &mockRegistry{pending: map[string]bool{"http://www.example.com/": true}}
+
+	cr := &Crawler{
+		Queue:    q,
+		Backend:  &mockBackend{},
+		Resolver: &mockResolver{ips: []net.IP{net.ParseIP("93.184.216.34")}},
+		Feeds:    reg,
+		channels: channels{err: make(chan error)},
+	}
+
+	cr.work("http://www.example.com/")
+
+	want := map[string]time.Duration{"http://www.example.com": 0}
+	if !reflect.DeepEqual(q.delayed, want) {
+		t.Fatalf("got %+v; want %+v", q.delayed, want)
+	}
+
+	// the entry of a feed is queued again the next time its feed is polled
+	if !reg.pending["http://www.example.com/"] {
+		t.Fatalf("expected feed entry to still be pending")
+	}
+}
+
+func TestWorkUnreachable(t *testing.T) {
+	for _, c := range []struct {
+		name        string
+		res         *mockResolver
+		unreachable bool
+	}{
+		{"dns", &mockResolver{err: resolver.ErrNotFound}, true},
+		{"no ip", &mockResolver{}, true},
+		{"timeout", &mockResolver{err: errors.New("i/o timeout")}, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			q := &mockReserveQueue{}
+			b := &mockFailBackend{}
+
+			cr := &Crawler{
+				Queue:    q,
+				Backend:  b,
+				Resolver: c.res,
+				since:    45 * 24 * time.Hour,
+				channels: channels{err: make(chan error)},
+				stats:    &Stats{Start: now(), StatusCodes: make(map[int]int64)},
+			}
+
+			cr.work("http://www.example.com/")
+
+			want := map[string]time.Duration{"http://www.example.com": 600 * time.Second}
+			if !reflect.DeepEqual(q.delayed, want) {
+				t.Fatalf("got %+v; want %+v", q.delayed, want)
+			}
+
+			if !c.unreachable {
+				if len(b.docs) != 0 {
+					t.Fatalf("got %+v; a page isn't gone when its host can't be resolved for now", b.docs)
+				}
+				return
+			}
+
+			if len(b.docs) != 1 {
+				t.Fatalf("got %d documents; want the failed document", len(b.docs))
+			}
+
+			doc := b.docs[0]
+			if doc.StatusCode != document.StatusUnreachable || !doc.Failed() || doc.StoredIn != "search-english" {
+				t.Fatalf("got status %d in %q; want %d in search-english", doc.StatusCode, doc.StoredIn, document.StatusUnreachable)
+			}
+		})
+	}
+}
+
+type mockResolver struct {
+	ips []net.IP
+	err error
+}
+
+func (r *mockResolver) Resolve(host string) ([]net.IP, error) {
+	return r.ips, r.err
+}
+
+type mockReserveQueue struct {
+	mockQueue
+	reserved map[string]bool
+	delayed  map[string]time.Duration
+}
+
+func (q *mockReserveQueue) ReserveHost(host string, ttl time.Duration) error {
+	if q.reserved[host] {
+		return queue.ErrAlreadyReserved
+	}
+	return nil
+}
+
+func (q *mockReserveQueue) DelayHost(host string, ttl time.Duration) error {
+	if q.delayed == nil {
+		q.delayed = map[string]time.Duration{}
+	}
+	q.delayed[host] = ttl
+	return nil
+}
+
+func TestStoreOutlinks(t *testing.T) {
+	b := &mockLinkBackend{authority: 12}
+	cr := &Crawler{LinkBackend: b}
+
+	doc, err := document.New("http://www.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc.SetCrawled(time.Date(2018, 7, 2, 0, 0, 0, 0, time.UTC))
+	for _, target := range []string{"http://www.example.org/", "http://www.example.net/"} {
+		l, err := link.New(doc.ID, target, "", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.Outlinks = append(doc.Outlinks, l)
+	}
+
+	if err := cr.storeOutlinks(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.upserted) != 2 {
+		t.Fatalf("got %d links; want 2", len(b.upserted))
+	}
+
+	want := map[string][]string{
+		doc.ID: {"http://www.example.org/", "http://www.example.net/"},
+	}
+	if !reflect.DeepEqual(b.pruned, want) {
+		t.Fatalf("got pruned %+v; want %+v", b.pruned, want)
+	}
+
+	for _, l := range b.upserted {
+		if l.Authority != 12 || l.Crawled != "20180702" {
+			t.Fatalf("got authority %d, crawled %q; want 12, %q", l.Authority, l.Crawled, "20180702")
+		}
+	}
+}
+
+type mockLinkBackend struct {
+	authority int64
+	upserted  []*link.Link
+	pruned    map[string][]string
+}
+
+func (m *mockLinkBackend) Setup() error {
+	return nil
+}
+
+func (m *mockLinkBackend) Upsert(l *link.Link) error {
+	m.upserted = append(m.upserted, l)
+	return nil
+}
+
+func (m *mockLinkBackend) Prune(source string, targets []string) error {
+	if m.pruned == nil {
+		m.pruned = map[string][]string{}
+	}
+	m.pruned[source] = targets
+	return nil
+}
+
+func (m *mockLinkBackend) Authority(target string) (int64, error) {
+	return m.authority, nil
+}
+
 func TestCalculateHostDelay(t *testing.T) {
 	type retryAfter struct {
 		value  string
@@ -337,6 +556,14 @@ func (q *mockQueue) AddLink(lnk string) error {
 	return nil
 }
 
+func (q *mockQueue) AddPriorityLink(lnk string) error {
+	return nil
+}
+
+func (q *mockQueue) MoveLink(from, to string, ttl time.Duration) error {
+	return nil
+}
+
 func (q *mockQueue) CountLinks() (int64, error) {
 	return 100, nil
 }
@@ -363,14 +590,25 @@ func (m *mockBackend) Setup() error {
 	return nil
 }
 
-func (m *mockBackend) CrawledAndCount(u, domain string) (time.Time, int, error) {
-	return time.Date(2016, time.August, 14, 15, 3, 5, 0, time.UTC), 10, nil
+func (m *mockBackend) CrawledAndCount(u, domain string) (time.Time, string, int, error) {
+	return time.Date(2016, time.August, 14, 15, 3, 5, 0, time.UTC), "search-english", 10, nil
 }
 
 func (m *mockBackend) Upsert(*document.Document) error {
 	return nil
 }
 
+// mockFailBackend remembers the documents we upsert
+type mockFailBackend struct {
+	mockBackend
+	docs []*document.Document
+}
+
+func (m *mockFailBackend) Upsert(doc *document.Document) error {
+	m.docs = append(m.docs, doc)
+	return nil
+}
+
 type MockRobotsCache struct {
 	sync.Mutex
 	m map[string]*robots.Robots
@@ -14,7 +14,8 @@ import (
 // ElasticSearch satisfies the crawler's Backend interface
 type ElasticSearch struct {
 	*document.ElasticSearch
-	Bulk *elastic.BulkProcessor
+	Bulk        *elastic.BulkProcessor
+	MaxFailures int // consecutive failures before a document goes stale
 	sync.Mutex
 }
 
@@ -22,6 +23,10 @@ type ElasticSearch struct {
 // NOTE: Elasticsearch has a 512-byte limit on an insert operation.
 // Upsert does not have that limit.
 func (e *ElasticSearch) Upsert(doc *document.Document) error {
+	if doc.Failed() {
+		return e.fail(doc)
+	}
+
 	a, err := e.Analyzer(doc.Language)
 	if err != nil {
 		return err
@@ -40,9 +45,120 @@ func (e *ElasticSearch) Upsert(doc *document.Document) error {
 	return nil
 }
 
-// CrawledAndCount returns the crawled date of the url (if any) and
+// failScript counts the consecutive failures of a document.
+// A document goes stale once it fails too often or is gone (410).
+const failScript = `
+	ctx._source.status = params.status;
+	ctx._source.crawled = params.crawled;
+	ctx._source.failures = (ctx._source.failures == null ? 0 : ctx._source.failures) + 1;
+	if (ctx._source.stale == null && (params.gone || ctx._source.failures >= params.max)) {
+		ctx._source.stale = params.crawled;
+	}
+`
+
+// fail records a failed crawl of a document. The failed response has no
+// language so we update the document in the index we found it in.
+// A document we haven't seen before is simply inserted.
+func (e *ElasticSearch) fail(doc *document.Document) error {
+	idx := doc.StoredIn
+	if idx == "" {
+		a, err := e.Analyzer(doc.Language)
+		if err != nil {
+			return err
+		}
+		idx = e.IndexName(a)
+	}
+
+	doc.Failures = 1
+	if doc.Gone() || doc.Failures >= e.MaxFailures {
+		doc.Stale = &doc.Crawled
+	}
+
+	script := elastic.NewScript(failScript).
+		Lang("painless").
+		Param("status", doc.StatusCode).
+		Param("crawled", doc.Crawled).
+		Param("gone", doc.Gone()).
+		Param("max", e.MaxFailures)
+
+	item := elastic.NewBulkUpdateRequest().
+		Index(idx).
+		Type(e.Type).
+		Id(doc.ID).
+		Script(script).
+		Upsert(doc)
+
+	e.Bulk.Add(item)
+	return nil
+}
+
+// StaleReport summarizes the stale documents in our index
+type StaleReport struct {
+	Stale   int64            // documents that are stale
+	Expired int64            // stale documents past the retention period
+	Domains map[string]int64 // the domains with the most expired documents
+}
+
+// Stale reports the stale documents and those that went stale before a date
+func (e *ElasticSearch) Stale(before time.Time) (*StaleReport, error) {
+	expired := elastic.NewFilterAggregation().
+		Filter(elastic.NewRangeQuery("stale").Lte(before.Format("20060102"))).
+		SubAggregation("domains", elastic.NewTermsAggregation().Field("domain").Size(25))
+
+	res, err := e.Client.Search().
+		Index(e.Index+"-*").
+		Type(e.Type).
+		Query(elastic.NewExistsQuery("stale")).
+		Aggregation("expired", expired).
+		Size(0).
+		Do(context.TODO())
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := &StaleReport{
+		Stale:   res.TotalHits(),
+		Domains: map[string]int64{},
+	}
+
+	agg, ok := res.Aggregations.Filter("expired")
+	if !ok {
+		return r, nil
+	}
+
+	r.Expired = agg.DocCount
+
+	domains, ok := agg.Aggregations.Terms("domains")
+	if !ok {
+		return r, nil
+	}
+
+	for _, b := range domains.Buckets {
+		r.Domains[fmt.Sprint(b.Key)] = b.DocCount
+	}
+
+	return r, nil
+}
+
+// Purge deletes the documents that went stale before a date
+func (e *ElasticSearch) Purge(before time.Time) (int64, error) {
+	res, err := e.Client.DeleteByQuery(e.Index + "-*").
+		Type(e.Type).
+		Query(elastic.NewRangeQuery("stale").Lte(before.Format("20060102"))).
+		ProceedOnVersionConflict().
+		Do(context.TODO())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.Deleted, nil
+}
+
+// CrawledAndCount returns the crawled date & index of the url (if any) and
 // the total number of links a domain has
-func (e *ElasticSearch) CrawledAndCount(u, domain string) (time.Time, int, error) {
+func (e *ElasticSearch) CrawledAndCount(u, domain string) (time.Time, string, int, error) {
 	body := fmt.Sprintf(`{
 		"bool": {
 			"filter": [
@@ -60,7 +176,7 @@ func (e *ElasticSearch) CrawledAndCount(u, domain string) (time.Time, int, error
 		}
 	}`, domain)
 
-	var crawled, cnt = time.Time{}, 0
+	var crawled, idx, cnt = time.Time{}, "", 0
 
 	// even though this technically could be a count request
 	// it s/b faster using multisearch.
@@ -90,7 +206,7 @@ func (e *ElasticSearch) CrawledAndCount(u, domain string) (time.Time, int, error
 	e.Unlock()
 
 	if err != nil {
-		return crawled, cnt, err
+		return crawled, idx, cnt, err
 	}
 
 	r1, r2 := res.Responses[0], res.Responses[1]
@@ -98,16 +214,17 @@ func (e *ElasticSearch) CrawledAndCount(u, domain string) (time.Time, int, error
 	cnt = int(r1.TotalHits())
 
 	if err != nil && !elastic.IsNotFound(r2.Error) {
-		return crawled, cnt, fmt.Errorf(r2.Error.Reason)
+		return crawled, idx, cnt, fmt.Errorf(r2.Error.Reason)
 	}
 
 	for _, h := range r2.Hits.Hits {
 		c := make(map[string]string)
 		if err := json.Unmarshal(*h.Source, &c); err != nil {
-			return crawled, cnt, err
+			return crawled, idx, cnt, err
 		}
+		idx = h.Index
 		crawled, err = time.Parse("20060102", c["crawled"])
 	}
 
-	return crawled, cnt, err
+	return crawled, idx, cnt, err
 }
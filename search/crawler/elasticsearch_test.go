@@ -2,8 +2,12 @@ package crawler
 
 import (
 	"context"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -86,6 +90,7 @@ func TestCrawledAndCount(t *testing.T) {
 	type want struct {
 		count   int
 		crawled time.Time
+		index   string
 		err     error
 	}
 
@@ -184,7 +189,7 @@ func TestCrawledAndCount(t *testing.T) {
 					}
 				]
 			}`,
-			want: want{593, time.Date(2017, time.July, 06, 0, 0, 0, 0, time.UTC), nil},
+			want: want{593, time.Date(2017, time.July, 06, 0, 0, 0, 0, time.UTC), "search-english", nil},
 		},
 		{
 			name:   "does not exist",
@@ -264,7 +269,7 @@ func TestCrawledAndCount(t *testing.T) {
 				]
 			}`,
 			status: http.StatusOK,
-			want:   want{412, time.Time{}, nil},
+			want:   want{412, time.Time{}, "", nil},
 		},
 	} {
 		t.Run(c.name, func(t *testing.T) {
@@ -280,7 +285,7 @@ func TestCrawledAndCount(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			crawled, count, err := e.CrawledAndCount(c.url, c.domain)
+			crawled, index, count, err := e.CrawledAndCount(c.url, c.domain)
 			if err != c.want.err {
 				t.Fatalf("got err %q; want %q", err, c.want.err)
 			}
@@ -292,10 +297,154 @@ func TestCrawledAndCount(t *testing.T) {
 			if crawled != c.want.crawled {
 				t.Fatalf("got %q; want %q", crawled, c.want.crawled)
 			}
+
+			if index != c.want.index {
+				t.Fatalf("got index %q; want %q", index, c.want.index)
+			}
+		})
+	}
+}
+
+func TestFail(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		status int
+		stored string
+		want   []string
+	}{
+		{
+			"existing", http.StatusNotFound, "search-french",
+			[]string{`"_index":"search-french"`, `"script"`, `"upsert":{`, `"failures":1,"stale":null`},
+		},
+		{
+			"new", http.StatusNotFound, "",
+			[]string{`"_index":"search-english"`, `"script"`, `"upsert":{`, `"failures":1,"stale":null`},
+		},
+		{
+			"gone", http.StatusGone, "",
+			[]string{`"_index":"search-english"`, `"gone":true`, `"failures":1,"stale":"20180702"`},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var bulk string
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/_bulk") {
+					http.NotFound(w, r)
+					return
+				}
+
+				b, _ := ioutil.ReadAll(r.Body)
+				mu.Lock()
+				bulk = string(b)
+				mu.Unlock()
+				w.Write([]byte(`{"took":1,"errors":false,"items":[{"update":{"_index":"search-english","_type":"document","_id":"1","status":200}}]}`))
+			}))
+			defer ts.Close()
+
+			e, err := MockService(ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.MaxFailures = 3
+
+			doc, err := document.New("http://www.example.com/dead")
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc.SetStatusCode(c.status).SetCrawled(time.Date(2018, 7, 2, 0, 0, 0, 0, time.UTC))
+			doc.StoredIn = c.stored
+
+			if err := e.Upsert(doc); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := e.Bulk.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, w := range c.want {
+				if !strings.Contains(bulk, w) {
+					t.Fatalf("expected %v in %v", w, bulk)
+				}
+			}
 		})
 	}
 }
 
+func TestStale(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"took": 3,
+			"timed_out": false,
+			"hits": {"total": 12, "max_score": 0, "hits": []},
+			"aggregations": {
+				"expired": {
+					"doc_count": 5,
+					"domains": {
+						"doc_count_error_upper_bound": 0,
+						"sum_other_doc_count": 0,
+						"buckets": [
+							{"key": "example.com", "doc_count": 4},
+							{"key": "example.org", "doc_count": 1}
+						]
+					}
+				}
+			}
+		}`))
+	}))
+	defer ts.Close()
+
+	e, err := MockService(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := e.Stale(time.Date(2018, 7, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &StaleReport{
+		Stale:   12,
+		Expired: 5,
+		Domains: map[string]int64{"example.com": 4, "example.org": 1},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v; want %+v", got, want)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/_delete_by_query") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"took":10,"timed_out":false,"total":5,"deleted":5,"batches":1}`))
+	}))
+	defer ts.Close()
+
+	e, err := MockService(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := e.Purge(time.Date(2018, 7, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != 5 {
+		t.Fatalf("got %d; want 5", got)
+	}
+}
+
 func MockService(url string) (*ElasticSearch, error) {
 	client, err := elastic.NewSimpleClient(elastic.SetURL(url))
 	if err != nil {
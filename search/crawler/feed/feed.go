@@ -0,0 +1,225 @@
+// Package feed discovers, parses and schedules RSS & Atom feeds so that
+// fresh content can be crawled ahead of the normal recrawl cycle.
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Feed is a parsed RSS or Atom feed
+type Feed struct {
+	Title   string
+	Entries []*Entry
+}
+
+// Entry is a single item of a feed
+type Entry struct {
+	Link      string    `json:"link"`
+	Title     string    `json:"title,omitempty"`
+	Summary   string    `json:"summary,omitempty"`
+	Published time.Time `json:"published,omitempty"`
+}
+
+// Registry outlines the methods to schedule feeds for
+// polling and remember the entries we have seen.
+type Registry interface {
+	AddFeed(host, feed string) error
+	Feeds(host string) ([]string, error)
+	Due(t time.Time, number int) ([]string, error)
+	Schedule(feed string, t time.Time) error
+	PutEntry(e *Entry, ttl time.Duration) (bool, error)
+	Crawled(lnk string) error
+	Entry(lnk string) (*Entry, error)
+}
+
+var errUnknownFormat = fmt.Errorf("unknown feed format")
+
+type rss struct {
+	XMLName xml.Name `xml:"rss"`
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+// rdf is RSS 1.0. The items are siblings of the channel.
+type rdf struct {
+	XMLName xml.Name `xml:"RDF"`
+	Channel struct {
+		Title string `xml:"title"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"` // dc:date
+}
+
+type atom struct {
+	XMLName xml.Name    `xml:"feed"`
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+// Parse parses an RSS 2.0, RSS 1.0 or Atom feed.
+// Entries without a link are skipped.
+func Parse(r io.Reader) (*Feed, error) {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charset.NewReaderLabel
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+
+	// find the root element to figure out the format
+	var root xml.StartElement
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		if se, ok := tok.(xml.StartElement); ok {
+			root = se
+			break
+		}
+	}
+
+	f := &Feed{}
+
+	switch strings.ToLower(root.Name.Local) {
+	case "rss":
+		r := &rss{}
+		if err := dec.DecodeElement(r, &root); err != nil {
+			return nil, err
+		}
+		f.Title = clean(r.Channel.Title)
+		f.addItems(r.Channel.Items)
+	case "rdf":
+		r := &rdf{}
+		if err := dec.DecodeElement(r, &root); err != nil {
+			return nil, err
+		}
+		f.Title = clean(r.Channel.Title)
+		f.addItems(r.Items)
+	case "feed":
+		a := &atom{}
+		if err := dec.DecodeElement(a, &root); err != nil {
+			return nil, err
+		}
+		f.Title = clean(a.Title)
+		for _, e := range a.Entries {
+			entry := &Entry{
+				Title:     clean(e.Title),
+				Summary:   clean(e.Summary),
+				Published: parseDate(e.Published, e.Updated),
+			}
+
+			if entry.Summary == "" {
+				entry.Summary = clean(e.Content)
+			}
+
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					entry.Link = strings.TrimSpace(l.Href)
+					break
+				}
+			}
+
+			if entry.Link != "" {
+				f.Entries = append(f.Entries, entry)
+			}
+		}
+	default:
+		return nil, errUnknownFormat
+	}
+
+	return f, nil
+}
+
+func (f *Feed) addItems(items []rssItem) {
+	for _, it := range items {
+		lnk := strings.TrimSpace(it.Link)
+		if lnk == "" && strings.HasPrefix(it.GUID, "http") {
+			lnk = strings.TrimSpace(it.GUID)
+		}
+
+		if lnk == "" {
+			continue
+		}
+
+		f.Entries = append(f.Entries, &Entry{
+			Link:      lnk,
+			Title:     clean(it.Title),
+			Summary:   clean(it.Description),
+			Published: parseDate(it.PubDate, it.Date),
+		})
+	}
+}
+
+// dateFormats are the formats seen in the wild. RSS is supposed to be RFC822
+// but very few feeds actually follow it.
+var dateFormats = []string{
+	time.RFC1123Z, time.RFC1123, time.RFC3339, time.RFC822Z, time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700", "2006-01-02T15:04:05", "2006-01-02",
+}
+
+// parseDate returns the first date that can be parsed
+func parseDate(dates ...string) time.Time {
+	for _, d := range dates {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+
+		for _, f := range dateFormats {
+			if t, err := time.Parse(f, d); err == nil {
+				return t.UTC()
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// clean strips any html from the text and collapses the whitespace
+func clean(s string) string {
+	var b strings.Builder
+	var tag bool
+
+	for _, r := range s {
+		switch {
+		case r == '<':
+			tag = true
+		case r == '>' && tag:
+			tag = false
+			b.WriteRune(' ')
+		case !tag:
+			b.WriteRune(r)
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
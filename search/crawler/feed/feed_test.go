@@ -0,0 +1,118 @@
+package feed
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		body string
+		want *Feed
+		err  error
+	}{
+		{
+			name: "rss",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+				<rss version="2.0">
+					<channel>
+						<title>Example News</title>
+						<item>
+							<title>First Post</title>
+							<link>http://www.example.com/first</link>
+							<description>&lt;p&gt;A summary of the &amp;nbsp;first post&lt;/p&gt;</description>
+							<pubDate>Mon, 02 Jul 2018 15:04:05 +0000</pubDate>
+						</item>
+						<item>
+							<title>Guid only</title>
+							<guid>http://www.example.com/second</guid>
+						</item>
+						<item>
+							<title>No link</title>
+						</item>
+					</channel>
+				</rss>`,
+			want: &Feed{
+				Title: "Example News",
+				Entries: []*Entry{
+					{
+						Link:      "http://www.example.com/first",
+						Title:     "First Post",
+						Summary:   "A summary of the &nbsp;first post",
+						Published: time.Date(2018, 7, 2, 15, 4, 5, 0, time.UTC),
+					},
+					{
+						Link:  "http://www.example.com/second",
+						Title: "Guid only",
+					},
+				},
+			},
+		},
+		{
+			name: "atom",
+			body: `<?xml version="1.0" encoding="utf-8"?>
+				<feed xmlns="http://www.w3.org/2005/Atom">
+					<title>Example Blog</title>
+					<entry>
+						<title>Atom Entry</title>
+						<link rel="edit" href="http://www.example.com/edit/1"/>
+						<link href="http://www.example.com/blog/1"/>
+						<content type="html">The content</content>
+						<updated>2018-07-03T10:00:00Z</updated>
+					</entry>
+				</feed>`,
+			want: &Feed{
+				Title: "Example Blog",
+				Entries: []*Entry{
+					{
+						Link:      "http://www.example.com/blog/1",
+						Title:     "Atom Entry",
+						Summary:   "The content",
+						Published: time.Date(2018, 7, 3, 10, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+		{
+			name: "rdf",
+			body: `<?xml version="1.0"?>
+				<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+					<channel><title>RDF Feed</title></channel>
+					<item>
+						<title>RDF Item</title>
+						<link>http://www.example.com/rdf</link>
+						<dc:date>2018-07-04</dc:date>
+					</item>
+				</rdf:RDF>`,
+			want: &Feed{
+				Title: "RDF Feed",
+				Entries: []*Entry{
+					{
+						Link:      "http://www.example.com/rdf",
+						Title:     "RDF Item",
+						Published: time.Date(2018, 7, 4, 0, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+		{
+			name: "html",
+			body: `<html><head><title>Not a feed</title></head></html>`,
+			err:  errUnknownFormat,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(c.body))
+			if err != c.err {
+				t.Fatalf("got err %v; want %v", err, c.err)
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %+v; want %+v", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package feed
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+const (
+	hostPrefix    = "feeds:"        // set of feeds per host
+	entryPrefix   = "feed:entry:"   // entries we've already seen
+	pendingPrefix = "feed:pending:" // entries that have yet to be crawled
+	schedule      = "feeds"         // sorted set of feeds by next poll time
+)
+
+// putEntryScript stores a new entry and marks it as pending in one go so
+// that an entry is never stored without being queued.
+// Returns 1 if the entry is still pending.
+var putEntryScript = redis.NewScript(2, `
+if redis.call("SET", KEYS[1], ARGV[1], "EX", ARGV[2], "NX") then
+	redis.call("SET", KEYS[2], 1, "EX", ARGV[2])
+	return 1
+end
+return redis.call("EXISTS", KEYS[2])
+`)
+
+// Redis implements the Registry interface
+type Redis struct {
+	RedisPool *redis.Pool
+	Prefix    string // of our keys ("jivesearch:")
+}
+
+func (r *Redis) prefixKey(key string) string {
+	return r.Prefix + key // jivesearch:feeds
+}
+
+// grab connection from pool and do the redis cmd
+func (r *Redis) do(commandName string, args ...interface{}) (reply interface{}, err error) {
+	c := r.RedisPool.Get()
+	defer c.Close()
+
+	return c.Do(commandName, args...)
+}
+
+// AddFeed adds a feed to a host and schedules it to be polled right away
+// if it isn't already scheduled.
+func (r *Redis) AddFeed(host, feed string) error {
+	if _, err := r.do("SADD", r.prefixKey(hostPrefix+host), feed); err != nil {
+		return err
+	}
+
+	_, err := r.do("ZADD", r.prefixKey(schedule), "NX", 0, feed)
+	return err
+}
+
+// Feeds returns the feeds of a host
+func (r *Redis) Feeds(host string) ([]string, error) {
+	return redis.Strings(r.do("SMEMBERS", r.prefixKey(hostPrefix+host)))
+}
+
+// Due returns the feeds that are due to be polled
+func (r *Redis) Due(t time.Time, number int) ([]string, error) {
+	return redis.Strings(r.do("ZRANGEBYSCORE", r.prefixKey(schedule), "-inf", t.Unix(), "LIMIT", 0, number))
+}
+
+// Schedule sets the next time a feed should be polled
+func (r *Redis) Schedule(feed string, t time.Time) error {
+	_, err := r.do("ZADD", r.prefixKey(schedule), t.Unix(), feed)
+	return err
+}
+
+// PutEntry stores an entry and reports whether it has yet to be crawled.
+// Existing entries are not overwritten.
+func (r *Redis) PutEntry(e *Entry, ttl time.Duration) (bool, error) {
+	j, err := json.Marshal(e)
+	if err != nil {
+		return false, err
+	}
+
+	c := r.RedisPool.Get()
+	defer c.Close()
+
+	return redis.Bool(putEntryScript.Do(c,
+		r.prefixKey(entryPrefix+e.Link), r.prefixKey(pendingPrefix+e.Link), j, seconds(ttl)),
+	)
+}
+
+// Crawled marks the entry of a link (if any) as crawled
+func (r *Redis) Crawled(lnk string) error {
+	_, err := r.do("DEL", r.prefixKey(pendingPrefix+lnk))
+	return err
+}
+
+// Entry returns the feed entry of a link (if any)
+func (r *Redis) Entry(lnk string) (*Entry, error) {
+	b, err := redis.Bytes(r.do("GET", r.prefixKey(entryPrefix+lnk)))
+	if err != nil {
+		if err == redis.ErrNil {
+			err = nil
+		}
+		return nil, err
+	}
+
+	e := &Entry{}
+	err = json.Unmarshal(b, e)
+	return e, err
+}
+
+func seconds(ttl time.Duration) int {
+	return int(ttl / time.Second)
+}
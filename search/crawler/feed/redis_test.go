@@ -0,0 +1,197 @@
+package feed
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/rafaeljusto/redigomock"
+)
+
+func TestAddFeed(t *testing.T) {
+	for _, c := range []struct {
+		prefix string
+		host   string
+		feed   string
+	}{
+		{"jivesearch:", "http://www.example.com", "http://www.example.com/rss.xml"},
+		{"test:", "https://blog.example.org", "https://blog.example.org/atom"},
+	} {
+		t.Run(c.feed, func(t *testing.T) {
+			r := &Redis{Prefix: c.prefix}
+			conn := redigomock.NewConn()
+			sadd := conn.Command("SADD", c.prefix+hostPrefix+c.host, c.feed).Expect(int64(1))
+			zadd := conn.Command("ZADD", c.prefix+schedule, "NX", 0, c.feed).Expect(int64(1))
+
+			r.RedisPool = &redis.Pool{
+				Dial: func() (redis.Conn, error) {
+					return conn, nil
+				},
+			}
+			defer r.RedisPool.Close()
+
+			if err := r.AddFeed(c.host, c.feed); err != nil {
+				t.Fatal(err)
+			}
+
+			if conn.Stats(sadd) != 1 {
+				t.Fatalf("expected feed to be added to its host")
+			}
+
+			if conn.Stats(zadd) != 1 {
+				t.Fatalf("expected feed to be scheduled")
+			}
+		})
+	}
+}
+
+func TestFeeds(t *testing.T) {
+	want := []string{"http://www.example.com/rss.xml", "http://www.example.com/atom"}
+
+	r := &Redis{Prefix: "jivesearch:"}
+	conn := redigomock.NewConn()
+	conn.Command("SMEMBERS", "jivesearch:"+hostPrefix+"http://www.example.com").
+		Expect([]interface{}{[]byte(want[0]), []byte(want[1])})
+
+	r.RedisPool = &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return conn, nil
+		},
+	}
+	defer r.RedisPool.Close()
+
+	got, err := r.Feeds("http://www.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v; want %+v", got, want)
+	}
+}
+
+func TestDue(t *testing.T) {
+	now := time.Date(2018, 7, 2, 15, 4, 5, 0, time.UTC)
+	want := []string{"http://www.example.com/rss.xml", "https://blog.example.org/atom"}
+
+	r := &Redis{Prefix: "jivesearch:"}
+	conn := redigomock.NewConn()
+	conn.Command("ZRANGEBYSCORE", "jivesearch:"+schedule, "-inf", now.Unix(), "LIMIT", 0, 10).
+		Expect([]interface{}{[]byte(want[0]), []byte(want[1])})
+
+	r.RedisPool = &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return conn, nil
+		},
+	}
+	defer r.RedisPool.Close()
+
+	got, err := r.Due(now, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v; want %+v", got, want)
+	}
+}
+
+func TestPutEntry(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		reply interface{}
+		want  bool
+	}{
+		{"new", int64(1), true},
+		{"pending", int64(1), true},
+		{"crawled", int64(0), false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			e := &Entry{Link: "http://www.example.com/first", Title: "First Post"}
+			j, _ := json.Marshal(e)
+			ttl := 24 * time.Hour
+
+			r := &Redis{Prefix: "jivesearch:"}
+			conn := redigomock.NewConn()
+			conn.Command("EVALSHA", putEntryScript.Hash(), 2,
+				"jivesearch:"+entryPrefix+e.Link, "jivesearch:"+pendingPrefix+e.Link, j, seconds(ttl),
+			).Expect(c.reply)
+
+			r.RedisPool = &redis.Pool{
+				Dial: func() (redis.Conn, error) {
+					return conn, nil
+				},
+			}
+			defer r.RedisPool.Close()
+
+			got, err := r.PutEntry(e, ttl)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != c.want {
+				t.Fatalf("got %v; want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestCrawled(t *testing.T) {
+	lnk := "http://www.example.com/first"
+
+	r := &Redis{Prefix: "jivesearch:"}
+	conn := redigomock.NewConn()
+	del := conn.Command("DEL", "jivesearch:"+pendingPrefix+lnk).Expect(int64(1))
+
+	r.RedisPool = &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return conn, nil
+		},
+	}
+	defer r.RedisPool.Close()
+
+	if err := r.Crawled(lnk); err != nil {
+		t.Fatal(err)
+	}
+
+	if conn.Stats(del) != 1 {
+		t.Fatalf("expected entry to no longer be pending")
+	}
+}
+
+func TestEntry(t *testing.T) {
+	want := &Entry{
+		Link:      "http://www.example.com/first",
+		Summary:   "A summary",
+		Published: time.Date(2018, 7, 2, 15, 4, 5, 0, time.UTC),
+	}
+	j, _ := json.Marshal(want)
+
+	r := &Redis{Prefix: "jivesearch:"}
+	conn := redigomock.NewConn()
+	conn.Command("GET", "jivesearch:"+entryPrefix+want.Link).Expect(j)
+	conn.Command("GET", "jivesearch:"+entryPrefix+"http://www.example.com/unknown").Expect(nil)
+
+	r.RedisPool = &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return conn, nil
+		},
+	}
+	defer r.RedisPool.Close()
+
+	got, err := r.Entry(want.Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v; want %+v", got, want)
+	}
+
+	got, err = r.Entry("http://www.example.com/unknown")
+	if err != nil || got != nil {
+		t.Fatalf("got %+v, %v; want nil, nil", got, err)
+	}
+}
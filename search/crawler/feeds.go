@@ -0,0 +1,246 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jivesearch/jivesearch/log"
+	"github.com/jivesearch/jivesearch/search/crawler/feed"
+	"github.com/jivesearch/jivesearch/search/crawler/queue"
+	"github.com/jivesearch/jivesearch/search/document"
+	"github.com/pkg/errors"
+	"github.com/temoto/robotstxt"
+)
+
+var errFeedDisallowed = fmt.Errorf("feed disallowed by robots.txt")
+
+// feedRetry is how soon we poll a feed again when its host is busy
+const feedRetry = 10 * time.Second
+
+// maxHostFeeds caps the feeds we poll for a host. Some sites link
+// a comments feed from every post.
+const maxHostFeeds = 5
+
+// registerFeeds adds the feeds found in a document to the host's registry
+func (c *Crawler) registerFeeds(doc *document.Document) error {
+	if c.Feeds == nil || len(doc.Feeds) == 0 {
+		return nil
+	}
+
+	sh := doc.SchemeHost()
+	feeds, err := c.Feeds.Feeds(sh)
+	if err != nil {
+		return err
+	}
+
+	known := map[string]bool{}
+	for _, f := range feeds {
+		known[f] = true
+	}
+
+	for _, f := range doc.Feeds {
+		if known[f] {
+			continue
+		}
+
+		if len(known) >= maxHostFeeds {
+			break
+		}
+
+		if err := c.Feeds.AddFeed(sh, f); err != nil {
+			return err
+		}
+		known[f] = true
+	}
+
+	return nil
+}
+
+// setFeedEntry sets the date and summary of a document that
+// we found in a feed.
+func (c *Crawler) setFeedEntry(doc *document.Document) error {
+	if c.Feeds == nil {
+		return nil
+	}
+
+	e, err := c.Feeds.Entry(doc.ID)
+	if err != nil || e == nil {
+		return err
+	}
+
+	doc.SetFeedEntry(e.Published, e.Summary, c.truncate.description)
+	return nil
+}
+
+// entryCrawled lets the registry know we've had a go at a link
+// so that it is no longer queued each time its feed is polled
+func (c *Crawler) entryCrawled(lnk string) {
+	if c.Feeds == nil {
+		return
+	}
+
+	if err := c.Feeds.Crawled(lnk); err != nil {
+		c.err <- errors.Wrapf(err, "unable to mark feed entry as crawled: %v", lnk)
+	}
+}
+
+// pollFeeds is our fast lane. It polls the feeds that are due
+// and pushes any new entries to the front of the queue.
+func (c *Crawler) pollFeeds(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		feeds, err := c.Feeds.Due(now(), 100)
+		if err != nil {
+			c.err <- errors.Wrap(err, "unable to get feeds that are due")
+			return
+		}
+
+		for _, f := range feeds {
+			next := now().Add(c.feedInterval)
+
+			switch err := c.pollFeed(f); err {
+			case nil:
+			case queue.ErrAlreadyReserved: // try again once the host is free
+				next = now().Add(feedRetry)
+			default:
+				log.Debug.Println(errors.Wrapf(err, "feed: %q", f))
+			}
+
+			// reschedule even if there was an error so a broken feed doesn't hog the poller
+			if err := c.Feeds.Schedule(f, next); err != nil {
+				c.err <- errors.Wrapf(err, "unable to schedule feed: %q", f)
+				return
+			}
+		}
+
+		if len(feeds) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(1 * time.Second):
+			}
+		}
+	}
+}
+
+// pollFeed fetches a feed and queues the entries we haven't crawled yet.
+// An entry stays pending until a worker has had a go at it so one that was
+// dropped (e.g. its host was busy) is queued again on the next poll.
+// Like a page, a feed waits for its host (and IP) and honors the crawl-delay.
+func (c *Crawler) pollFeed(lnk string) error {
+	doc, err := document.New(lnk)
+	if err != nil {
+		return err
+	}
+
+	sh := doc.SchemeHost()
+	if err := c.Queue.ReserveHost(sh, 600*time.Second); err != nil {
+		return err
+	}
+
+	var delay time.Duration
+	var ra string // Retry-After header
+	status := -1  // releases the hosts right away if we don't fetch the feed
+	hosts := []string{sh}
+
+	defer func() {
+		delay = calculateHostDelay(status, ra, delay)
+		for _, h := range hosts {
+			if err := c.Queue.DelayHost(h, delay); err != nil {
+				c.err <- errors.Wrapf(err, "host: %q, delay: %q", h, delay)
+			}
+		}
+	}()
+
+	if c.Resolver != nil {
+		ips, err := c.Resolver.Resolve(doc.URL.Hostname())
+		if err == nil && len(ips) == 0 {
+			err = errors.Errorf("no IP for %v", doc.URL.Hostname())
+		}
+
+		if err != nil {
+			return err
+		}
+
+		ip, err := c.reserveIP(ips)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, ip)
+	}
+
+	rbt := c.fetchRobots(doc)
+	rbtsText, err := robotstxt.FromStatusAndString(rbt.StatusCode, rbt.Body)
+	if err != nil {
+		delay = 600 * time.Second
+		return err
+	}
+
+	group := rbtsText.FindGroup(c.UserAgent.Full)
+	if !group.Test(doc.URL.Path) {
+		return errFeedDisallowed
+	}
+
+	delay = group.CrawlDelay
+
+	resp, err := c.doRequest(doc.ID)
+	if err != nil {
+		status = document.StatusUnreachable
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	status, ra = resp.StatusCode, resp.Header.Get("Retry-After")
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var b io.Reader = resp.Body
+	if c.maxBytes > -1 {
+		b = io.LimitReader(b, c.maxBytes)
+	}
+
+	f, err := feed.Parse(b)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range f.Entries {
+		u, err := url.Parse(e.Link)
+		if err != nil {
+			continue
+		}
+
+		// entries can be relative to the feed
+		u, err = document.ValidateURL(doc.URL.ResolveReference(u).String())
+		if err != nil {
+			continue
+		}
+
+		e.Link = u.String()
+
+		pending, err := c.Feeds.PutEntry(e, c.since)
+		if err != nil {
+			return err
+		}
+
+		if pending {
+			if err := c.Queue.AddPriorityLink(e.Link); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+package crawler
+
+import (
+	"net"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/jivesearch/jivesearch/search/crawler/feed"
+	"github.com/jivesearch/jivesearch/search/crawler/queue"
+	"github.com/jivesearch/jivesearch/search/crawler/robots"
+	"github.com/jivesearch/jivesearch/search/document"
+)
+
+func TestPollFeed(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	lnk := "http://www.example.com/feed.xml"
+
+	httpmock.RegisterResponder("GET", "http://www.example.com/robots.txt",
+		httpmock.NewStringResponder(200, "User-agent: *\nAllow: /\nCrawl-delay: 5"))
+
+	httpmock.RegisterResponder("GET", lnk,
+		httpmock.NewStringResponder(200, `<rss version="2.0">
+			<channel>
+				<item><link>http://www.example.com/new</link><description>Fresh</description></item>
+				<item><link>/relative</link></item>
+				<item><link>http://www.example.com/seen</link></item>
+				<item><link>http://www.example.com/dropped</link></item>
+			</channel>
+		</rss>`))
+
+	for _, c := range []struct {
+		name     string
+		reserved map[string]bool
+		err      error
+		priority []string
+		delayed  map[string]time.Duration
+	}{
+		{
+			name:     "new entries",
+			priority: []string{"http://www.example.com/new", "http://www.example.com/relative", "http://www.example.com/dropped"},
+			delayed: map[string]time.Duration{
+				"http://www.example.com": 5 * time.Second,
+				"93.184.216.34":          5 * time.Second,
+			},
+		},
+		{
+			name:     "host reserved",
+			reserved: map[string]bool{"http://www.example.com": true},
+			err:      queue.ErrAlreadyReserved,
+		},
+		{
+			name:     "ip reserved",
+			reserved: map[string]bool{"93.184.216.34": true},
+			err:      queue.ErrAlreadyReserved,
+			delayed:  map[string]time.Duration{"http://www.example.com": 0},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			q := &mockPriorityQueue{mockReserveQueue: mockReserveQueue{reserved: c.reserved}}
+			reg := &mockRegistry{
+				entries: map[string]*feed.Entry{
+					"http://www.example.com/seen":    {Link: "http://www.example.com/seen"},
+					"http://www.example.com/dropped": {Link: "http://www.example.com/dropped"},
+				},
+				pending: map[string]bool{"http://www.example.com/dropped": true},
+			}
+
+			cr := &Crawler{
+				HTTPClient: http.DefaultClient,
+				UserAgent:  UserAgent{Full: "test-bot-full", Short: "test-bot-short"},
+				since:      45 * 24 * time.Hour,
+				maxBytes:   -1,
+				Robots:     &MockRobotsCache{m: make(map[string]*robots.Robots)},
+				Queue:      q,
+				Resolver:   &mockResolver{ips: []net.IP{net.ParseIP("93.184.216.34")}},
+				Feeds:      reg,
+			}
+
+			if err := cr.pollFeed(lnk); err != c.err {
+				t.Fatalf("got err %v; want %v", err, c.err)
+			}
+
+			if !reflect.DeepEqual(q.priority, c.priority) {
+				t.Fatalf("got %+v; want %+v", q.priority, c.priority)
+			}
+
+			if !reflect.DeepEqual(q.delayed, c.delayed) {
+				t.Fatalf("got delays %+v; want %+v", q.delayed, c.delayed)
+			}
+
+			if c.err != nil {
+				return
+			}
+
+			if e := reg.entries["http://www.example.com/new"]; e == nil || e.Summary != "Fresh" {
+				t.Fatalf("expected entry to be stored; got %+v", e)
+			}
+		})
+	}
+}
+
+func TestSetFeedEntry(t *testing.T) {
+	published := time.Date(2018, 7, 2, 15, 4, 5, 0, time.UTC)
+
+	c := &Crawler{
+		truncate: truncate{description: 250},
+		Feeds: &mockRegistry{
+			entries: map[string]*feed.Entry{
+				"http://www.example.com/new": {
+					Link:      "http://www.example.com/new",
+					Summary:   "Fresh",
+					Published: published,
+				},
+			},
+		},
+	}
+
+	doc, err := document.New("http://www.example.com/new")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc.Feeds = []string{"http://www.example.com/feed.xml"}
+
+	if err := c.registerFeeds(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.setFeedEntry(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Date != "2018-07-02T15:04:05Z" || doc.Summary != "Fresh" {
+		t.Fatalf("got date %q, summary %q", doc.Date, doc.Summary)
+	}
+
+	got := c.Feeds.(*mockRegistry).feeds["http://www.example.com"]
+	if !reflect.DeepEqual(got, doc.Feeds) {
+		t.Fatalf("got %+v; want %+v", got, doc.Feeds)
+	}
+}
+
+func TestRegisterFeeds(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		known []string
+		feeds []string
+		want  []string
+	}{
+		{
+			name:  "new host",
+			feeds: []string{"http://www.example.com/feed.xml", "http://www.example.com/atom"},
+			want:  []string{"http://www.example.com/feed.xml", "http://www.example.com/atom"},
+		},
+		{
+			name:  "known feed",
+			known: []string{"http://www.example.com/feed.xml"},
+			feeds: []string{"http://www.example.com/feed.xml"},
+			want:  []string{"http://www.example.com/feed.xml"},
+		},
+		{
+			name: "too many feeds",
+			known: []string{
+				"http://www.example.com/1/feed", "http://www.example.com/2/feed",
+				"http://www.example.com/3/feed", "http://www.example.com/4/feed",
+			},
+			feeds: []string{"http://www.example.com/5/feed", "http://www.example.com/6/feed"},
+			want: []string{
+				"http://www.example.com/1/feed", "http://www.example.com/2/feed",
+				"http://www.example.com/3/feed", "http://www.example.com/4/feed",
+				"http://www.example.com/5/feed",
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			reg := &mockRegistry{feeds: map[string][]string{}}
+			if c.known != nil {
+				reg.feeds["http://www.example.com"] = c.known
+			}
+
+			cr := &Crawler{Feeds: reg}
+
+			doc, err := document.New("http://www.example.com/post")
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc.Feeds = c.feeds
+
+			if err := cr.registerFeeds(doc); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := reg.feeds["http://www.example.com"]; !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %+v; want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+type mockPriorityQueue struct {
+	mockReserveQueue
+	priority []string
+}
+
+func (q *mockPriorityQueue) AddPriorityLink(lnk string) error {
+	q.priority = append(q.priority, lnk)
+	return nil
+}
+
+type mockRegistry struct {
+	sync.Mutex
+	feeds   map[string][]string
+	entries map[string]*feed.Entry
+	pending map[string]bool
+}
+
+func (m *mockRegistry) AddFeed(host, f string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.feeds == nil {
+		m.feeds = map[string][]string{}
+	}
+	m.feeds[host] = append(m.feeds[host], f)
+	return nil
+}
+
+func (m *mockRegistry) Feeds(host string) ([]string, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.feeds[host], nil
+}
+
+func (m *mockRegistry) Due(t time.Time, number int) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockRegistry) Schedule(f string, t time.Time) error {
+	return nil
+}
+
+func (m *mockRegistry) PutEntry(e *feed.Entry, ttl time.Duration) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.pending == nil {
+		m.pending = map[string]bool{}
+	}
+
+	if _, ok := m.entries[e.Link]; ok {
+		return m.pending[e.Link], nil
+	}
+	m.entries[e.Link] = e
+	m.pending[e.Link] = true
+	return true, nil
+}
+
+func (m *mockRegistry) Crawled(lnk string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.pending, lnk)
+	return nil
+}
+
+func (m *mockRegistry) Entry(lnk string) (*feed.Entry, error) {
+	return m.entries[lnk], nil
+}
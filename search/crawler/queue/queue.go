@@ -10,6 +10,8 @@ import (
 type Queuer interface {
 	CountLinks() (int64, error)
 	AddLink(lnk string) error
+	AddPriorityLink(lnk string) error
+	MoveLink(from, to string, ttl time.Duration) error
 	QueueLink(ttl time.Duration) (string, error)
 	ReserveHost(host string, ttl time.Duration) error
 	DelayHost(host string, ttl time.Duration) error
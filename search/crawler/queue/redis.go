@@ -11,6 +11,7 @@ const (
 	hostPrefix  = "h:"
 	queuePrefix = "q:"
 	links       = prefix + "links"
+	priority    = prefix + "priority"
 )
 
 // Redis implements the Queuer interface
@@ -42,9 +43,34 @@ func (r *Redis) AddLink(lnk string) error {
 	return err
 }
 
-// QueueLink pops a link from our set
+// AddPriorityLink adds a link to the fast lane.
+// These links (e.g. new feed entries) are queued before any other link.
+func (r *Redis) AddPriorityLink(lnk string) error {
+	_, err := r.do("SADD", priority, lnk)
+	return err
+}
+
+// MoveLink replaces a link that permanently redirects with its target.
+// The old link is suppressed for the ttl by marking it as queued.
+func (r *Redis) MoveLink(from, to string, ttl time.Duration) error {
+	if _, err := r.do("SREM", links, from); err != nil {
+		return err
+	}
+
+	if _, err := r.do("SET", r.prefixKey(queuePrefix+from), "", "EX", seconds(ttl)); err != nil {
+		return err
+	}
+
+	return r.AddLink(to)
+}
+
+// QueueLink pops a link from our priority set or, if empty, our set of links
 func (r *Redis) QueueLink(ttl time.Duration) (string, error) {
-	lnk, err := redis.String(r.do("SPOP", links))
+	lnk, err := redis.String(r.do("SPOP", priority))
+	if err == redis.ErrNil {
+		lnk, err = redis.String(r.do("SPOP", links))
+	}
+
 	if err != nil {
 		if err == redis.ErrNil {
 			err = nil
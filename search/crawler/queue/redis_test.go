@@ -39,16 +39,67 @@ func TestAddLink(t *testing.T) {
 	}
 }
 
+func TestAddPriorityLink(t *testing.T) {
+	r := &Redis{}
+	conn := redigomock.NewConn()
+	cmd := conn.Command("SADD", priority, "http://www.example.com/new").Expect(int64(1))
+
+	r.RedisPool = &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return conn, nil
+		},
+	}
+	defer r.RedisPool.Close()
+
+	if err := r.AddPriorityLink("http://www.example.com/new"); err != nil {
+		t.Fatal(err)
+	}
+
+	if conn.Stats(cmd) != 1 {
+		t.Fatal("expected link to be added to the priority set")
+	}
+}
+
+func TestMoveLink(t *testing.T) {
+	from, to := "http://www.example.com/old", "http://www.example.com/new"
+	ttl := 24 * time.Hour
+
+	r := &Redis{}
+	conn := redigomock.NewConn()
+	srem := conn.Command("SREM", links, from).Expect(int64(1))
+	set := conn.Command("SET", prefix+queuePrefix+from, "", "EX", seconds(ttl)).Expect("OK")
+	sadd := conn.Command("SADD", links, to).Expect(int64(1))
+
+	r.RedisPool = &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return conn, nil
+		},
+	}
+	defer r.RedisPool.Close()
+
+	if err := r.MoveLink(from, to, ttl); err != nil {
+		t.Fatal(err)
+	}
+
+	if conn.Stats(srem) != 1 || conn.Stats(set) != 1 || conn.Stats(sadd) != 1 {
+		t.Fatal("expected old link to be suppressed and new link to be added")
+	}
+}
+
 func TestQueueLink(t *testing.T) {
 	for _, c := range []struct {
-		name string
-		link string
+		name     string
+		priority interface{}
+		link     string
 	}{
 		{
-			"first", "http://www.example.com",
+			"first", nil, "http://www.example.com",
 		},
 		{
-			"second", "https://www.somelink.com/and/a/path/?for=fun",
+			"second", nil, "https://www.somelink.com/and/a/path/?for=fun",
+		},
+		{
+			"priority", "http://www.example.com/new", "http://www.example.com/new",
 		},
 	} {
 		t.Run(c.name, func(t *testing.T) {
@@ -56,6 +107,7 @@ func TestQueueLink(t *testing.T) {
 
 			r := &Redis{}
 			conn := redigomock.NewConn()
+			conn.Command("SPOP", priority).Expect(c.priority)
 			conn.Command("SPOP", links).Expect(c.link)
 			conn.Command("SET", r.prefixKey(queuePrefix+c.link), "", "EX", int(ttl/time.Second), "NX").Expect("OK")
 
@@ -0,0 +1,147 @@
+package crawler
+
+import (
+	"net/http"
+
+	"github.com/jivesearch/jivesearch/search/document"
+	"github.com/temoto/robotstxt"
+)
+
+// hop is a redirect from one link to another
+type hop struct {
+	from   string
+	to     string
+	status int
+}
+
+func (h hop) permanent() bool {
+	return h.status == http.StatusMovedPermanently || h.status == http.StatusPermanentRedirect
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// follow requests a link and follows its redirects (up to maxRedirects).
+// We only follow redirects on the same scheme & host since that is the host
+// we reserved and checked robots.txt for. The redirect that ends the chain
+// (if any) is the last hop and its response is the one returned.
+func (c *Crawler) follow(doc *document.Document, group *robotstxt.Group) (*http.Response, []hop, error) {
+	hops := []hop{}
+	seen := map[string]bool{doc.ID: true}
+	lnk := doc.ID
+
+	for {
+		resp, err := c.doRequest(lnk)
+		if err != nil {
+			return nil, hops, err
+		}
+
+		if !isRedirect(resp.StatusCode) {
+			return resp, hops, nil
+		}
+
+		loc, err := resp.Location()
+		if err != nil { // a redirect without a location
+			return resp, hops, nil
+		}
+
+		u, err := document.ValidateURL(loc.String())
+		if err != nil {
+			return resp, hops, nil
+		}
+
+		h := hop{from: lnk, to: u.String(), status: resp.StatusCode}
+		hops = append(hops, h)
+
+		if len(hops) > c.maxRedirects || seen[h.to] ||
+			u.Scheme+"://"+u.Host != doc.SchemeHost() || !group.Test(u.Path) {
+			return resp, hops, nil
+		}
+
+		seen[h.to] = true
+		resp.Body.Close()
+		lnk = h.to
+	}
+}
+
+// redirects handles the redirects of a link and returns the document the
+// response belongs to. Links that permanently redirect (301 & 308) are moved
+// in the queue and become aliases of the document they redirect to.
+// A temporary redirect (302, 303 & 307) doesn't move the document,
+// we only record where it redirects to.
+func (c *Crawler) redirects(doc *document.Document, resp *http.Response, hops []hop) (*document.Document, error) {
+	if len(hops) == 0 {
+		return doc, nil
+	}
+
+	// the redirect that we didn't follow (if any)
+	followed := hops
+	var last *hop
+	if isRedirect(resp.StatusCode) {
+		last = &hops[len(hops)-1]
+		followed = hops[:len(hops)-1]
+	}
+
+	// the document moves along with the permanent redirects at the start of the chain
+	moved := doc.ID
+	aliases := []string{}
+	for _, h := range followed {
+		if !h.permanent() || h.from != moved {
+			break
+		}
+
+		if err := c.Queue.MoveLink(h.from, h.to, c.since); err != nil {
+			return doc, err
+		}
+
+		alias := &document.Document{
+			ID:      h.from,
+			Crawled: doc.Crawled,
+			Content: document.Content{
+				StatusCode: h.status,
+				Redirect:   h.to,
+			},
+		}
+
+		if err := c.Backend.Upsert(alias); err != nil {
+			return doc, err
+		}
+
+		aliases = append(aliases, h.from)
+		moved = h.to
+	}
+
+	if moved != doc.ID {
+		d, err := document.New(moved)
+		if err != nil {
+			return doc, err
+		}
+
+		d.Crawled = doc.Crawled
+		d.IP = doc.IP
+		d.Aliases = aliases
+		doc = d
+	}
+
+	switch {
+	case last != nil:
+		doc.Redirect = last.to
+		switch {
+		case last.to == doc.ID: // a loop
+		case last.permanent() && last.from == doc.ID:
+			return doc, c.Queue.MoveLink(last.from, last.to, c.since)
+		default:
+			return doc, c.Queue.AddLink(last.to)
+		}
+	case len(followed) > len(aliases): // a temporary redirect
+		doc.Redirect = followed[len(followed)-1].to
+	}
+
+	return doc, nil
+}
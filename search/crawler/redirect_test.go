@@ -0,0 +1,214 @@
+package crawler
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/jivesearch/jivesearch/search/document"
+	"github.com/temoto/robotstxt"
+)
+
+func TestRedirects(t *testing.T) {
+	type want struct {
+		id       string
+		status   int
+		redirect string
+		aliases  []string
+		moved    []string
+		added    []string
+		upserted []string
+	}
+
+	for _, c := range []struct {
+		name         string
+		maxRedirects int
+		redirects    map[string]redirect
+		want
+	}{
+		{
+			name:         "permanent",
+			maxRedirects: 5,
+			redirects: map[string]redirect{
+				"http://www.example.com/a": {http.StatusMovedPermanently, "/b"},
+				"http://www.example.com/b": {http.StatusPermanentRedirect, "http://www.example.com/c"},
+			},
+			want: want{
+				id:       "http://www.example.com/c",
+				status:   http.StatusOK,
+				aliases:  []string{"http://www.example.com/a", "http://www.example.com/b"},
+				moved:    []string{"http://www.example.com/a", "http://www.example.com/b"},
+				upserted: []string{"http://www.example.com/a", "http://www.example.com/b"},
+			},
+		},
+		{
+			name:         "temporary",
+			maxRedirects: 5,
+			redirects: map[string]redirect{
+				"http://www.example.com/a": {http.StatusFound, "http://www.example.com/c"},
+			},
+			want: want{
+				id:       "http://www.example.com/a",
+				status:   http.StatusOK,
+				redirect: "http://www.example.com/c",
+			},
+		},
+		{
+			name:         "other host",
+			maxRedirects: 5,
+			redirects: map[string]redirect{
+				"http://www.example.com/a": {http.StatusMovedPermanently, "https://www.example.com/a"},
+			},
+			want: want{
+				id:       "http://www.example.com/a",
+				status:   http.StatusMovedPermanently,
+				redirect: "https://www.example.com/a",
+				moved:    []string{"http://www.example.com/a"},
+			},
+		},
+		{
+			name:         "temporary to other host",
+			maxRedirects: 5,
+			redirects: map[string]redirect{
+				"http://www.example.com/a": {http.StatusTemporaryRedirect, "http://another.com/"},
+			},
+			want: want{
+				id:       "http://www.example.com/a",
+				status:   http.StatusTemporaryRedirect,
+				redirect: "http://another.com/",
+				added:    []string{"http://another.com/"},
+			},
+		},
+		{
+			name:         "hop limit",
+			maxRedirects: 1,
+			redirects: map[string]redirect{
+				"http://www.example.com/a": {http.StatusMovedPermanently, "/b"},
+				"http://www.example.com/b": {http.StatusMovedPermanently, "/c"},
+			},
+			want: want{
+				id:       "http://www.example.com/b",
+				status:   http.StatusMovedPermanently,
+				redirect: "http://www.example.com/c",
+				aliases:  []string{"http://www.example.com/a"},
+				moved:    []string{"http://www.example.com/a", "http://www.example.com/b"},
+				upserted: []string{"http://www.example.com/a"},
+			},
+		},
+		{
+			name:         "loop",
+			maxRedirects: 5,
+			redirects: map[string]redirect{
+				"http://www.example.com/a": {http.StatusFound, "/b"},
+				"http://www.example.com/b": {http.StatusFound, "/a"},
+			},
+			want: want{
+				id:       "http://www.example.com/a",
+				status:   http.StatusFound,
+				redirect: "http://www.example.com/a",
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			for lnk, r := range c.redirects {
+				httpmock.RegisterResponder("GET", lnk, r.responder())
+			}
+			httpmock.RegisterResponder("GET", "http://www.example.com/c", httpmock.NewStringResponder(200, "hello world"))
+
+			q := &mockRedirectQueue{}
+			b := &mockUpsertBackend{}
+
+			cr := &Crawler{
+				HTTPClient: &http.Client{
+					CheckRedirect: func(req *http.Request, via []*http.Request) error {
+						return http.ErrUseLastResponse
+					},
+				},
+				maxRedirects: c.maxRedirects,
+				since:        45 * 24 * time.Hour,
+				Queue:        q,
+				Backend:      b,
+			}
+
+			doc, err := document.New("http://www.example.com/a")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rbts, err := robotstxt.FromString("User-agent: *\nAllow: /")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, hops, err := cr.follow(doc, rbts.FindGroup("test-bot-full"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			doc, err = cr.redirects(doc, resp, hops)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := want{
+				id:       doc.ID,
+				status:   resp.StatusCode,
+				redirect: doc.Redirect,
+				aliases:  doc.Aliases,
+				moved:    q.moved,
+				added:    q.added,
+				upserted: b.upserted,
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %+v; want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+type redirect struct {
+	status   int
+	location string
+}
+
+func (r redirect) responder() httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(r.status, "")
+		resp.Header.Set("Location", r.location)
+		resp.Request = req // needed to resolve a relative location
+		return resp, nil
+	}
+}
+
+type mockRedirectQueue struct {
+	mockQueue
+	moved []string
+	added []string
+}
+
+func (q *mockRedirectQueue) MoveLink(from, to string, ttl time.Duration) error {
+	q.moved = append(q.moved, from)
+	return nil
+}
+
+func (q *mockRedirectQueue) AddLink(lnk string) error {
+	q.added = append(q.added, lnk)
+	return nil
+}
+
+type mockUpsertBackend struct {
+	mockBackend
+	upserted []string
+}
+
+func (m *mockUpsertBackend) Upsert(doc *document.Document) error {
+	m.upserted = append(m.upserted, doc.ID)
+	return nil
+}
@@ -0,0 +1,137 @@
+package resolver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+)
+
+// Just enough of RFC 1035 to ask for the A & AAAA records of a host
+// and to read the answers along with their TTL.
+const (
+	typeA     uint16 = 1
+	typeAAAA  uint16 = 28
+	classINET uint16 = 1
+
+	headerLen = 12
+
+	rcodeSuccess  = 0
+	rcodeNXDomain = 3
+)
+
+var (
+	errMalformed = errors.New("malformed dns message")
+	errMismatch  = errors.New("dns response doesn't answer our question")
+	errTruncated = errors.New("dns response is truncated")
+)
+
+type record struct {
+	ip  net.IP
+	ttl uint32
+}
+
+// newQuery packs a recursive query for a record type of a host
+func newQuery(id uint16, host string, qtype uint16) ([]byte, error) {
+	b := make([]byte, headerLen, 512)
+	binary.BigEndian.PutUint16(b[0:], id)
+	binary.BigEndian.PutUint16(b[2:], 1<<8) // recursion desired
+	binary.BigEndian.PutUint16(b[4:], 1)    // one question
+
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, errMalformed
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+
+	b = append(b, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint16(b[len(b)-4:], qtype)
+	binary.BigEndian.PutUint16(b[len(b)-2:], classINET)
+	return b, nil
+}
+
+// parseResponse unpacks the A & AAAA records of a response to our query
+func parseResponse(b []byte, q []byte) ([]record, int, error) {
+	if len(b) < headerLen || binary.BigEndian.Uint16(b) != binary.BigEndian.Uint16(q) {
+		return nil, 0, errMalformed
+	}
+
+	flags := binary.BigEndian.Uint16(b[2:])
+	if flags&(1<<15) == 0 { // not a response
+		return nil, 0, errMalformed
+	}
+
+	if flags&(1<<9) != 0 { // the answers didn't fit
+		return nil, 0, errTruncated
+	}
+
+	rcode := int(flags & 0xf)
+	qdcount := int(binary.BigEndian.Uint16(b[4:]))
+	ancount := int(binary.BigEndian.Uint16(b[6:]))
+
+	// the question is echoed back (the case of the name may differ)
+	question := q[headerLen:]
+	off := headerLen + len(question)
+	if qdcount != 1 || off > len(b) || !bytes.EqualFold(b[headerLen:off], question) {
+		return nil, rcode, errMismatch
+	}
+
+	var err error
+
+	records := []record{}
+	for i := 0; i < ancount; i++ {
+		if off, err = skipName(b, off); err != nil {
+			return nil, rcode, err
+		}
+
+		if off+10 > len(b) {
+			return nil, rcode, errMalformed
+		}
+
+		typ := binary.BigEndian.Uint16(b[off:])
+		class := binary.BigEndian.Uint16(b[off+2:])
+		ttl := binary.BigEndian.Uint32(b[off+4:])
+		l := int(binary.BigEndian.Uint16(b[off+8:]))
+		off += 10
+
+		if off+l > len(b) {
+			return nil, rcode, errMalformed
+		}
+
+		data := b[off : off+l]
+		off += l
+
+		// CNAMEs are followed by the records of their target so we can skip them
+		switch {
+		case class != classINET:
+		case typ == typeA && l == net.IPv4len, typ == typeAAAA && l == net.IPv6len:
+			ip := make(net.IP, l)
+			copy(ip, data)
+			records = append(records, record{ip: ip, ttl: ttl})
+		}
+	}
+
+	return records, rcode, nil
+}
+
+// skipName returns the offset after a (possibly compressed) domain name
+func skipName(b []byte, off int) (int, error) {
+	for {
+		if off >= len(b) {
+			return off, errMalformed
+		}
+
+		l := int(b[off])
+		switch {
+		case l == 0:
+			return off + 1, nil
+		case l&0xc0 == 0xc0: // a pointer ends the name
+			return off + 2, nil
+		default:
+			off += l + 1
+		}
+	}
+}
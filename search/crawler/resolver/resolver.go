@@ -0,0 +1,459 @@
+// Package resolver is a caching DNS resolver for the crawler.
+// Unlike the resolver of the standard library it knows the TTL of the records
+// so we cache a host for as long as its owner wants us to. Hosts that don't
+// exist are cached as well (negative caching) so a dead domain doesn't cost
+// us a lookup for every one of its links.
+package resolver
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver resolves hosts to their IP addresses.
+// It satisfies the nett.Resolver interface so it can be used by a dialer.
+// Hosts of /etc/hosts, hosts without a dot and lookups that fail for any
+// reason other than the host not existing are left to net.DefaultResolver.
+type Resolver struct {
+	Servers     []string      // nameservers ("8.8.8.8:53")...defaults to those of /etc/resolv.conf
+	Timeout     time.Duration // per query
+	MinTTL      time.Duration // some records have a TTL of just a few seconds
+	MaxTTL      time.Duration
+	NegativeTTL time.Duration // how long we cache a host that doesn't exist
+	Size        int           // the most hosts we cache (0 for no limit)
+	hosts       map[string]bool
+	exchange    func(network, server string, query []byte, timeout time.Duration) ([]byte, error)
+	fallback    func(host string, timeout time.Duration) ([]net.IP, error)
+	mu          sync.Mutex
+	cache       map[string]*list.Element
+	lru         *list.List // most recently used in front
+}
+
+type item struct {
+	host    string
+	ips     []net.IP
+	err     error
+	expires time.Time
+}
+
+// ErrNotFound indicates the host doesn't exist or has no addresses
+var ErrNotFound = errors.New("no such host")
+
+var now = func() time.Time { return time.Now().UTC() }
+
+// New creates a Resolver that uses the nameservers of /etc/resolv.conf
+func New(timeout, minTTL, maxTTL, negativeTTL time.Duration, size int) *Resolver {
+	return &Resolver{
+		Servers:     nameservers("/etc/resolv.conf"),
+		Timeout:     timeout,
+		MinTTL:      minTTL,
+		MaxTTL:      maxTTL,
+		NegativeTTL: negativeTTL,
+		Size:        size,
+		hosts:       hosts("/etc/hosts"),
+	}
+}
+
+// Resolve returns the IPv4 and IPv6 addresses of a host
+func (r *Resolver) Resolve(host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if it, ok := r.get(host); ok {
+		return copyIPs(it.ips), it.err
+	}
+
+	var ips []net.IP
+	var ttl time.Duration
+	var err error
+
+	if r.hosts[host] || !strings.Contains(host, ".") {
+		ips, err = r.lookupDefault(host)
+		ttl = r.MinTTL
+	} else {
+		ips, ttl, err = r.lookup(host)
+		if err != nil && err != ErrNotFound {
+			ips, err = r.lookupDefault(host)
+			ttl = r.MinTTL
+		}
+	}
+
+	switch err {
+	case nil:
+	case ErrNotFound:
+		ttl = r.NegativeTTL
+	default: // we'll try again next time
+		return nil, err
+	}
+
+	r.put(&item{host: host, ips: ips, err: err, expires: now().Add(ttl)})
+	return copyIPs(ips), err
+}
+
+// get returns a cached host that hasn't expired
+func (r *Resolver) get(host string) (*item, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.cache[host]
+	if !ok {
+		return nil, false
+	}
+
+	it := e.Value.(*item)
+	if !now().Before(it.expires) {
+		r.lru.Remove(e)
+		delete(r.cache, host)
+		return nil, false
+	}
+
+	r.lru.MoveToFront(e)
+	return it, true
+}
+
+// put caches a host, evicting the expired and then the least recently used hosts when we are full
+func (r *Resolver) put(it *item) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cache == nil {
+		r.cache = make(map[string]*list.Element)
+		r.lru = list.New()
+	}
+
+	if e, ok := r.cache[it.host]; ok {
+		r.lru.Remove(e)
+	}
+	r.cache[it.host] = r.lru.PushFront(it)
+
+	if r.Size <= 0 || r.lru.Len() <= r.Size {
+		return
+	}
+
+	n := now()
+	for e := r.lru.Back(); e != nil; {
+		prev := e.Prev()
+		if ex := e.Value.(*item); !n.Before(ex.expires) {
+			r.lru.Remove(e)
+			delete(r.cache, ex.host)
+		}
+		e = prev
+	}
+
+	for r.lru.Len() > r.Size {
+		e := r.lru.Back()
+		r.lru.Remove(e)
+		delete(r.cache, e.Value.(*item).host)
+	}
+}
+
+// lookup asks for the A & AAAA records of a host.
+// The TTL is the lowest of the records (within our bounds).
+func (r *Resolver) lookup(host string) ([]net.IP, time.Duration, error) {
+	ips := []net.IP{}
+	var ttl uint32
+	var first error
+
+	for i, qtype := range []uint16{typeA, typeAAAA} {
+		records, err := r.query(host, qtype)
+		if err != nil {
+			if i == 0 {
+				first = err
+			}
+			continue
+		}
+
+		for _, rec := range records {
+			if len(ips) == 0 || rec.ttl < ttl {
+				ttl = rec.ttl
+			}
+			ips = append(ips, rec.ip)
+		}
+	}
+
+	if len(ips) == 0 {
+		if first == nil {
+			first = ErrNotFound
+		}
+		return nil, 0, first
+	}
+
+	d := time.Duration(ttl) * time.Second
+	if d < r.MinTTL {
+		d = r.MinTTL
+	}
+	if r.MaxTTL > 0 && d > r.MaxTTL {
+		d = r.MaxTTL
+	}
+
+	return ips, d, nil
+}
+
+// lookupDefault resolves a host with the resolver of the standard library
+func (r *Resolver) lookupDefault(host string) ([]net.IP, error) {
+	fallback := r.fallback
+	if fallback == nil {
+		fallback = lookupIP
+	}
+
+	ips, err := fallback(host, r.Timeout)
+	if err != nil {
+		if e, ok := err.(*net.DNSError); ok && !e.Temporary() && !e.Timeout() {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if len(ips) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return ips, nil
+}
+
+func lookupIP(host string, timeout time.Duration) ([]net.IP, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := []net.IP{}
+	for _, a := range addrs {
+		ips = append(ips, a.IP)
+	}
+
+	return ips, nil
+}
+
+// query tries each nameserver until one answers
+func (r *Resolver) query(host string, qtype uint16) ([]record, error) {
+	id, err := randomUint16()
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := newQuery(id, host, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fmt.Errorf("no nameservers")
+
+	for _, server := range r.Servers {
+		var records []record
+		var rcode int
+		records, rcode, err = r.ask(server, q)
+		switch {
+		case err != nil:
+			continue
+		case rcode == rcodeNXDomain:
+			return nil, ErrNotFound
+		case rcode != rcodeSuccess:
+			err = fmt.Errorf("nameserver %v returned rcode %d for %v", server, rcode, host)
+			continue
+		}
+
+		return records, nil
+	}
+
+	return nil, err
+}
+
+// ask sends a query to a nameserver over udp and
+// again over tcp if the response didn't fit in a datagram
+func (r *Resolver) ask(server string, q []byte) ([]record, int, error) {
+	exchange := r.exchange
+	if exchange == nil {
+		exchange = exchangeNet
+	}
+
+	resp, err := exchange("udp", server, q, r.Timeout)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	records, rcode, err := parseResponse(resp, q)
+	if err != errTruncated {
+		return records, rcode, err
+	}
+
+	if resp, err = exchange("tcp", server, q, r.Timeout); err != nil {
+		return nil, 0, err
+	}
+
+	return parseResponse(resp, q)
+}
+
+// exchangeNet sends a query to a nameserver and reads its response
+func exchangeNet(network, server string, query []byte, timeout time.Duration) ([]byte, error) {
+	var conn net.Conn
+	var err error
+
+	switch network {
+	case "udp":
+		conn, err = dialUDP(server)
+	default:
+		conn, err = net.DialTimeout(network, server, timeout)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+
+		b := make([]byte, 512) // max size of a udp message w/out EDNS
+		n, err := conn.Read(b)
+		if err != nil {
+			return nil, err
+		}
+
+		return b[:n], nil
+	}
+
+	// over tcp a message is prefixed by its length
+	b := make([]byte, 2, 2+len(query))
+	binary.BigEndian.PutUint16(b, uint16(len(query)))
+	if _, err := conn.Write(append(b, query...)); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.ReadFull(conn, b); err != nil {
+		return nil, err
+	}
+
+	resp := make([]byte, binary.BigEndian.Uint16(b))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// dialUDP connects from a random port so that, along with the random id,
+// a spoofed response has to guess 32 bits rather than 16.
+// A connected socket only reads datagrams from the nameserver.
+func dialUDP(server string) (net.Conn, error) {
+	raddr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; ; i++ {
+		n, err := randomUint16()
+		if err != nil {
+			return nil, err
+		}
+
+		port := 1024 + int(n)%(1<<16-1024)
+		conn, err := net.DialUDP("udp", &net.UDPAddr{Port: port}, raddr)
+		switch {
+		case err == nil:
+			return conn, nil
+		case i == 10: // the ports we tried are taken
+			return nil, err
+		}
+	}
+}
+
+func randomUint16() (uint16, error) {
+	b := make([]byte, 2)
+	if _, err := rand.Read(b); err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint16(b), nil
+}
+
+// nameservers reads the nameservers from a resolv.conf file
+func nameservers(path string) []string {
+	servers := []string{}
+
+	f, err := os.Open(path)
+	if err == nil {
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) > 1 && fields[0] == "nameserver" {
+				servers = append(servers, net.JoinHostPort(fields[1], "53"))
+			}
+		}
+	}
+
+	if len(servers) == 0 {
+		servers = append(servers, "127.0.0.1:53")
+	}
+
+	return servers
+}
+
+// hosts reads the hostnames & aliases of a hosts file
+func hosts(path string) map[string]bool {
+	names := map[string]bool{}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return names
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || net.ParseIP(fields[0]) == nil {
+			continue
+		}
+
+		for _, name := range fields[1:] {
+			names[strings.ToLower(strings.TrimSuffix(name, "."))] = true
+		}
+	}
+
+	return names
+}
+
+func copyIPs(ips []net.IP) []net.IP {
+	if ips == nil {
+		return nil
+	}
+
+	c := make([]net.IP, len(ips))
+	copy(c, ips)
+	return c
+}
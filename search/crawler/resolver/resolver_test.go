@@ -0,0 +1,470 @@
+package resolver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	start := time.Date(2018, 7, 2, 15, 4, 5, 0, time.UTC)
+	now = func() time.Time { return start }
+	defer func() { now = func() time.Time { return time.Now().UTC() } }()
+
+	type answer struct {
+		rcode   int
+		records []record
+	}
+
+	zones := map[string]map[uint16]answer{
+		"www.example.com": {
+			typeA: {records: []record{
+				{net.ParseIP("93.184.216.34").To4(), 300},
+				{net.ParseIP("93.184.216.35").To4(), 120},
+			}},
+			typeAAAA: {records: []record{{net.ParseIP("2606:2800:220:1:248:1893:25c8:1946"), 3600}}},
+		},
+		"short.example.com": {
+			typeA: {records: []record{{net.ParseIP("93.184.216.36").To4(), 1}}},
+		},
+		"nowhere.example.com": {
+			typeA:    {rcode: rcodeNXDomain},
+			typeAAAA: {rcode: rcodeNXDomain},
+		},
+	}
+
+	var queries int
+
+	r := &Resolver{
+		Servers:     []string{"127.0.0.1:53"},
+		MinTTL:      time.Minute,
+		MaxTTL:      time.Hour,
+		NegativeTTL: 5 * time.Minute,
+		exchange: func(network, server string, q []byte, timeout time.Duration) ([]byte, error) {
+			queries++
+			host, qtype := question(q)
+			a := zones[host][qtype]
+			return response(q, a.rcode, a.records), nil
+		},
+	}
+
+	for _, c := range []struct {
+		name    string
+		host    string
+		elapsed time.Duration
+		want    []net.IP
+		err     error
+		queries int
+	}{
+		{
+			name: "lookup",
+			host: "www.example.com",
+			want: []net.IP{
+				net.ParseIP("93.184.216.34").To4(),
+				net.ParseIP("93.184.216.35").To4(),
+				net.ParseIP("2606:2800:220:1:248:1893:25c8:1946"),
+			},
+			queries: 2,
+		},
+		{
+			name: "cached",
+			host: "WWW.EXAMPLE.COM",
+			want: []net.IP{
+				net.ParseIP("93.184.216.34").To4(),
+				net.ParseIP("93.184.216.35").To4(),
+				net.ParseIP("2606:2800:220:1:248:1893:25c8:1946"),
+			},
+			elapsed: 119 * time.Second,
+			queries: 0,
+		},
+		{
+			name: "expired", // the lowest TTL is 120 seconds
+			host: "www.example.com",
+			want: []net.IP{
+				net.ParseIP("93.184.216.34").To4(),
+				net.ParseIP("93.184.216.35").To4(),
+				net.ParseIP("2606:2800:220:1:248:1893:25c8:1946"),
+			},
+			elapsed: 121 * time.Second,
+			queries: 2,
+		},
+		{
+			name:    "min ttl",
+			host:    "short.example.com",
+			want:    []net.IP{net.ParseIP("93.184.216.36").To4()},
+			queries: 2,
+		},
+		{
+			name:    "min ttl cached",
+			host:    "short.example.com",
+			want:    []net.IP{net.ParseIP("93.184.216.36").To4()},
+			elapsed: 59 * time.Second,
+			queries: 0,
+		},
+		{
+			name:    "not found",
+			host:    "nowhere.example.com",
+			err:     ErrNotFound,
+			queries: 2,
+		},
+		{
+			name:    "negative cache",
+			host:    "nowhere.example.com",
+			err:     ErrNotFound,
+			elapsed: 4 * time.Minute,
+			queries: 0,
+		},
+		{
+			name:    "ip",
+			host:    "127.0.0.1",
+			want:    []net.IP{net.ParseIP("127.0.0.1")},
+			queries: 0,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			start = start.Add(c.elapsed)
+			queries = 0
+
+			got, err := r.Resolve(c.host)
+			if err != c.err {
+				t.Fatalf("got err %v; want %v", err, c.err)
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %v; want %v", got, c.want)
+			}
+
+			if queries != c.queries {
+				t.Fatalf("got %d queries; want %d", queries, c.queries)
+			}
+		})
+	}
+}
+
+func TestQueryServers(t *testing.T) {
+	r := &Resolver{
+		Servers: []string{"10.0.0.1:53", "10.0.0.2:53"},
+		exchange: func(network, server string, q []byte, timeout time.Duration) ([]byte, error) {
+			if server == "10.0.0.1:53" {
+				return nil, errors.New("timeout")
+			}
+			return response(q, rcodeSuccess, []record{{net.ParseIP("93.184.216.34").To4(), 60}}), nil
+		},
+	}
+
+	got, err := r.query("www.example.com", typeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []record{{net.ParseIP("93.184.216.34").To4(), 60}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v; want %+v", got, want)
+	}
+}
+
+func TestQueryTruncated(t *testing.T) {
+	networks := []string{}
+
+	r := &Resolver{
+		Servers: []string{"10.0.0.1:53"},
+		exchange: func(network, server string, q []byte, timeout time.Duration) ([]byte, error) {
+			networks = append(networks, network)
+			b := response(q, rcodeSuccess, []record{{net.ParseIP("93.184.216.34").To4(), 60}})
+			if network == "udp" {
+				b = response(q, rcodeSuccess, nil)
+				binary.BigEndian.PutUint16(b[2:], binary.BigEndian.Uint16(b[2:])|1<<9)
+			}
+			return b, nil
+		},
+	}
+
+	got, err := r.query("www.example.com", typeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []record{{net.ParseIP("93.184.216.34").To4(), 60}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v; want %+v", got, want)
+	}
+
+	if !reflect.DeepEqual(networks, []string{"udp", "tcp"}) {
+		t.Fatalf("got %v; want a udp query followed by a tcp query", networks)
+	}
+}
+
+func TestExchangeNet(t *testing.T) {
+	q, err := newQuery(1234, "www.example.com", typeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := response(q, rcodeSuccess, []record{{net.ParseIP("93.184.216.34").To4(), 60}})
+
+	t.Run("udp", func(t *testing.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pc.Close()
+
+		go func() {
+			b := make([]byte, 512)
+			n, addr, err := pc.ReadFrom(b)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(response(b[:n], rcodeSuccess, []record{{net.ParseIP("93.184.216.34").To4(), 60}}), addr)
+		}()
+
+		got, err := exchangeNet("udp", pc.LocalAddr().String(), q, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(got, want) {
+			t.Fatalf("got %v; want %v", got, want)
+		}
+	})
+
+	t.Run("tcp", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+
+		go func() {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			b := make([]byte, 2)
+			if _, err := io.ReadFull(conn, b); err != nil {
+				return
+			}
+
+			query := make([]byte, binary.BigEndian.Uint16(b))
+			if _, err := io.ReadFull(conn, query); err != nil {
+				return
+			}
+
+			resp := response(query, rcodeSuccess, []record{{net.ParseIP("93.184.216.34").To4(), 60}})
+			binary.BigEndian.PutUint16(b, uint16(len(resp)))
+			conn.Write(append(b, resp...))
+		}()
+
+		got, err := exchangeNet("tcp", l.Addr().String(), q, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(got, want) {
+			t.Fatalf("got %v; want %v", got, want)
+		}
+	})
+}
+
+func TestParseResponse(t *testing.T) {
+	q, err := newQuery(1234, "www.example.com", typeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := newQuery(1234, "www.example.org", typeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := []record{{net.ParseIP("93.184.216.34").To4(), 60}}
+
+	for _, c := range []struct {
+		name string
+		resp []byte
+		want []record
+		err  error
+	}{
+		{"ok", response(q, rcodeSuccess, rec), rec, nil},
+		{"case of the name", response(bytes.Replace(q, []byte("example"), []byte("ExAmPlE"), 1), rcodeSuccess, rec), rec, nil},
+		{"different id", response(append([]byte{0, 1}, q[2:]...), rcodeSuccess, rec), nil, errMalformed},
+		{"different question", response(other, rcodeSuccess, rec), nil, errMismatch},
+		{"query", q, nil, errMalformed},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got, _, err := parseResponse(c.resp, q)
+			if err != c.err {
+				t.Fatalf("got err %v; want %v", err, c.err)
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %+v; want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestFallback(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		host     string
+		exchange error
+		want     []net.IP
+		err      error
+		fallback bool
+	}{
+		{"hosts file", "db.internal.example.com", nil, []net.IP{net.ParseIP("10.1.1.1")}, nil, true},
+		{"single label", "localhost", nil, []net.IP{net.ParseIP("10.1.1.1")}, nil, true},
+		{"nameservers down", "www.example.com", errors.New("timeout"), []net.IP{net.ParseIP("10.1.1.1")}, nil, true},
+		{"not found", "www.example.com", nil, nil, ErrNotFound, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var fallback bool
+
+			r := &Resolver{
+				Servers: []string{"10.0.0.1:53"},
+				hosts:   map[string]bool{"db.internal.example.com": true},
+				exchange: func(network, server string, q []byte, timeout time.Duration) ([]byte, error) {
+					if c.exchange != nil {
+						return nil, c.exchange
+					}
+					return response(q, rcodeNXDomain, nil), nil
+				},
+				fallback: func(host string, timeout time.Duration) ([]net.IP, error) {
+					fallback = true
+					return []net.IP{net.ParseIP("10.1.1.1")}, nil
+				},
+			}
+
+			got, err := r.Resolve(c.host)
+			if err != c.err {
+				t.Fatalf("got err %v; want %v", err, c.err)
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %v; want %v", got, c.want)
+			}
+
+			if fallback != c.fallback {
+				t.Fatalf("got fallback %v; want %v", fallback, c.fallback)
+			}
+		})
+	}
+}
+
+func TestTemporaryError(t *testing.T) {
+	var lookups int
+	timeout := &net.DNSError{Err: "i/o timeout", Name: "www.example.com", IsTimeout: true}
+
+	r := &Resolver{
+		Servers:     []string{"10.0.0.1:53"},
+		NegativeTTL: 5 * time.Minute,
+		exchange: func(network, server string, q []byte, timeout time.Duration) ([]byte, error) {
+			return nil, errors.New("timeout")
+		},
+		fallback: func(host string, t time.Duration) ([]net.IP, error) {
+			lookups++
+			return nil, timeout
+		},
+	}
+
+	// a lookup that timed out isn't cached
+	for i := 1; i <= 2; i++ {
+		if _, err := r.Resolve("www.example.com"); err != timeout {
+			t.Fatalf("got err %v; want %v", err, timeout)
+		}
+
+		if lookups != i {
+			t.Fatalf("got %d lookups; want %d", lookups, i)
+		}
+	}
+}
+
+func TestCacheSize(t *testing.T) {
+	start := time.Date(2018, 7, 2, 15, 4, 5, 0, time.UTC)
+	now = func() time.Time { return start }
+	defer func() { now = func() time.Time { return time.Now().UTC() } }()
+
+	queries := map[string]int{}
+
+	r := &Resolver{
+		Servers: []string{"10.0.0.1:53"},
+		MinTTL:  time.Minute,
+		Size:    2,
+		exchange: func(network, server string, q []byte, timeout time.Duration) ([]byte, error) {
+			host, _ := question(q)
+			queries[host]++
+			return response(q, rcodeSuccess, []record{{net.ParseIP("93.184.216.34").To4(), 60}}), nil
+		},
+	}
+
+	// c is the least recently used when d is added
+	for _, host := range []string{"a.example.com", "b.example.com", "a.example.com", "c.example.com", "a.example.com", "d.example.com"} {
+		if _, err := r.Resolve(host); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(r.cache) != 2 || r.lru.Len() != 2 {
+		t.Fatalf("got %d cached hosts; want 2", len(r.cache))
+	}
+
+	for _, host := range []string{"a.example.com", "d.example.com"} {
+		if _, ok := r.cache[host]; !ok {
+			t.Fatalf("expected %v to be cached", host)
+		}
+	}
+
+	// each lookup is an A & an AAAA query
+	want := map[string]int{"a.example.com": 2, "b.example.com": 2, "c.example.com": 2, "d.example.com": 2}
+	if !reflect.DeepEqual(queries, want) {
+		t.Fatalf("got queries %v; want %v", queries, want)
+	}
+}
+
+// question unpacks the host and type of a query
+func question(q []byte) (string, uint16) {
+	host := ""
+	off := headerLen
+	for q[off] != 0 {
+		l := int(q[off])
+		if host != "" {
+			host += "."
+		}
+		host += string(q[off+1 : off+1+l])
+		off += l + 1
+	}
+
+	return host, binary.BigEndian.Uint16(q[off+1:])
+}
+
+// response answers a query with records that point to the name of the question
+func response(q []byte, rcode int, records []record) []byte {
+	b := append([]byte{}, q...)
+	binary.BigEndian.PutUint16(b[2:], 1<<15|1<<8|1<<7|uint16(rcode))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(records)))
+
+	for _, r := range records {
+		typ := typeA
+		if len(r.ip) == net.IPv6len {
+			typ = typeAAAA
+		}
+
+		rr := make([]byte, 12)
+		binary.BigEndian.PutUint16(rr[0:], 0xc000|headerLen) // pointer to the question's name
+		binary.BigEndian.PutUint16(rr[2:], typ)
+		binary.BigEndian.PutUint16(rr[4:], classINET)
+		binary.BigEndian.PutUint32(rr[6:], r.ttl)
+		binary.BigEndian.PutUint16(rr[10:], uint16(len(r.ip)))
+		b = append(append(b, rr...), r.ip...)
+	}
+
+	return b
+}
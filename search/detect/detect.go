@@ -0,0 +1,268 @@
+// Package detect identifies the language of a text.
+// Languages with a script of their own are identified by their script.
+// Languages that share a script (Latin, Cyrillic and Arabic) are identified
+// by comparing the ranked character n-grams of the text to the profile
+// of each language (Cavnar & Trenkle's "out-of-place" measure).
+// http://odur.let.rug.nl/~vannoord/TextCat/textcat.pdf
+package detect
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	maxGram     = 3   // we use 1, 2 & 3-grams
+	profileSize = 300 // number of ranked n-grams in a profile
+	minLetters  = 100 // number of letters needed for full confidence
+)
+
+type profile map[string]int // n-gram -> rank
+
+type script struct {
+	name string
+	*unicode.RangeTable
+}
+
+// the order matters for ties
+var scripts = []script{
+	{"latin", unicode.Latin},
+	{"cyrillic", unicode.Cyrillic},
+	{"arabic", unicode.Arabic},
+	{"han", unicode.Han},
+	{"kana", unicode.Hiragana},
+	{"kana", unicode.Katakana},
+	{"hangul", unicode.Hangul},
+	{"greek", unicode.Greek},
+	{"armenian", unicode.Armenian},
+	{"devanagari", unicode.Devanagari},
+	{"thai", unicode.Thai},
+	{"hebrew", unicode.Hebrew},
+}
+
+// languages identified by script alone
+var unique = map[string]language.Tag{
+	"hangul":     language.Korean,
+	"greek":      language.Greek,
+	"armenian":   language.Armenian,
+	"devanagari": language.Hindi,
+	"thai":       language.Thai,
+	"hebrew":     language.Hebrew,
+}
+
+// profiles by script
+var profiles = map[string]map[language.Tag]profile{}
+
+func init() {
+	for lang, s := range samples {
+		tag := language.MustParse(lang)
+		sc := dominant(count(s))
+		if _, ok := profiles[sc]; !ok {
+			profiles[sc] = map[language.Tag]profile{}
+		}
+		profiles[sc][tag] = newProfile(s, sc)
+	}
+}
+
+// Languages returns the languages we can detect
+func Languages() []language.Tag {
+	tags := []language.Tag{language.Japanese, language.Chinese}
+	for _, t := range unique {
+		tags = append(tags, t)
+	}
+
+	for _, p := range profiles {
+		for t := range p {
+			tags = append(tags, t)
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].String() < tags[j].String() })
+	return tags
+}
+
+// Detect returns the language of a text and how confident we are (0 to 1).
+// Short texts and texts with mixed scripts get a lower confidence.
+// language.Und is returned if the text has no letters.
+func Detect(s string) (language.Tag, float64) {
+	counts := count(s)
+
+	var total int
+	for _, n := range counts {
+		total += n
+	}
+
+	if total == 0 {
+		return language.Und, 0
+	}
+
+	sc := dominant(counts)
+	share := float64(counts[sc]) / float64(total)
+
+	switch sc {
+	case "han", "kana":
+		// Japanese mixes kanji with kana. Chinese has no kana.
+		share = float64(counts["han"]+counts["kana"]) / float64(total)
+		if counts["kana"] > 0 {
+			return language.Japanese, share
+		}
+		return language.Chinese, share
+	}
+
+	if t, ok := unique[sc]; ok {
+		return t, share
+	}
+
+	candidates, ok := profiles[sc]
+	if !ok {
+		return language.Und, 0
+	}
+
+	p := newProfile(s, sc)
+
+	type distance struct {
+		tag language.Tag
+		d   int
+	}
+
+	distances := []distance{}
+	for tag, c := range candidates {
+		distances = append(distances, distance{tag, outOfPlace(p, c)})
+	}
+
+	sort.Slice(distances, func(i, j int) bool {
+		if distances[i].d == distances[j].d {
+			return distances[i].tag.String() < distances[j].tag.String()
+		}
+		return distances[i].d < distances[j].d
+	})
+
+	best := distances[0]
+	if len(distances) == 1 {
+		return best.tag, share * length(counts[sc])
+	}
+
+	// how much better is the best than the runner up?
+	second := distances[1]
+	gap := float64(second.d-best.d) / float64(second.d)
+	conf := share * length(counts[sc]) * min(1, gap/.05)
+
+	return best.tag, conf
+}
+
+func length(letters int) float64 {
+	return min(1, float64(letters)/minLetters)
+}
+
+func min(x, y float64) float64 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+// count counts the letters of each script
+func count(s string) map[string]int {
+	m := map[string]int{}
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		for _, sc := range scripts {
+			if unicode.Is(sc.RangeTable, r) {
+				m[sc.name]++
+				break
+			}
+		}
+	}
+
+	return m
+}
+
+func dominant(counts map[string]int) string {
+	var best string
+	for _, sc := range scripts {
+		if counts[sc.name] > counts[best] {
+			best = sc.name
+		}
+	}
+
+	return best
+}
+
+// newProfile ranks the n-grams of the words of a script.
+// Each word is padded with a space so we know how words start and end.
+func newProfile(s, sc string) profile {
+	var table *unicode.RangeTable
+	for _, scr := range scripts {
+		if scr.name == sc {
+			table = scr.RangeTable
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) || !unicode.Is(table, r)
+	})
+
+	counts := map[string]int{}
+	for _, w := range words {
+		rr := []rune(" " + w + " ")
+		for n := 1; n <= maxGram; n++ {
+			for i := 0; i+n <= len(rr); i++ {
+				g := string(rr[i : i+n])
+				if g == " " {
+					continue
+				}
+				counts[g]++
+			}
+		}
+	}
+
+	grams := make([]string, 0, len(counts))
+	for g := range counts {
+		grams = append(grams, g)
+	}
+
+	sort.Slice(grams, func(i, j int) bool {
+		if counts[grams[i]] == counts[grams[j]] {
+			return grams[i] < grams[j]
+		}
+		return counts[grams[i]] > counts[grams[j]]
+	})
+
+	if len(grams) > profileSize {
+		grams = grams[:profileSize]
+	}
+
+	p := profile{}
+	for i, g := range grams {
+		p[g] = i
+	}
+
+	return p
+}
+
+// outOfPlace sums how far each n-gram of the text is from
+// its rank in the language's profile
+func outOfPlace(text, lang profile) int {
+	var d int
+	for g, rank := range text {
+		r, ok := lang[g]
+		if !ok {
+			d += profileSize
+			continue
+		}
+
+		if r > rank {
+			d += r - rank
+		} else {
+			d += rank - r
+		}
+	}
+
+	return d
+}
@@ -0,0 +1,83 @@
+package detect
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestDetect(t *testing.T) {
+	for _, c := range []struct {
+		text string
+		want language.Tag
+	}{
+		{"The climate of the region is temperate for most of the year, although in winter the temperatures can drop considerably at night and tourists should bring warm clothes.", language.English},
+		{"El clima de la región es templado durante casi todo el año, aunque en invierno las temperaturas pueden bajar bastante por las noches y los turistas deben llevar ropa de abrigo.", language.Spanish},
+		{"O clima da região é temperado durante quase todo o ano, embora no inverno as temperaturas possam cair bastante à noite e os turistas devem levar roupas quentes.", language.Portuguese},
+		{"Il clima della regione è temperato per quasi tutto l'anno, anche se in inverno le temperature possono scendere parecchio di notte e i turisti devono portare vestiti caldi.", language.Italian},
+		{"Het klimaat van de regio is bijna het hele jaar gematigd, hoewel de temperaturen in de winter 's nachts flink kunnen dalen en toeristen warme kleding moeten meenemen.", language.Dutch},
+		{"Климат региона умеренный почти весь год, хотя зимой температура ночью может сильно опускаться, и туристам следует брать с собой тёплую одежду.", language.Russian},
+		{"مناخ المنطقة معتدل طوال معظم أيام السنة، على الرغم من أن درجات الحرارة في الشتاء قد تنخفض كثيرا في الليل ويجب على السياح إحضار ملابس دافئة.", language.Arabic},
+		{"آب و هوای این منطقه در بیشتر سال معتدل است، هرچند در زمستان دما در شب ممکن است به شدت کاهش یابد و گردشگران باید لباس گرم همراه داشته باشند.", language.Persian},
+		{"これは日本語の文です", language.Japanese},
+		{"这是中文句子", language.Chinese},
+		{"이것은 한국어 문장입니다", language.Korean},
+		{"Αυτή είναι μια ελληνική πρόταση", language.Greek},
+		{"1234 !!", language.Und},
+	} {
+		t.Run(c.want.String(), func(t *testing.T) {
+			got, _ := Detect(c.text)
+			if got != c.want {
+				t.Fatalf("got %v; want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	long := "The climate of the region is temperate for most of the year, although in winter the temperatures can drop considerably at night and tourists should bring warm clothes."
+
+	_, high := Detect(long)
+	if high < .9 {
+		t.Fatalf("expected high confidence for a long text; got %v", high)
+	}
+
+	_, low := Detect("weather")
+	if low >= high || low > .5 {
+		t.Fatalf("expected low confidence for a short text; got %v", low)
+	}
+
+	_, mixed := Detect("これは日本語の文です and some English text that is even longer")
+	if mixed > .5 {
+		t.Fatalf("expected low confidence for mixed scripts; got %v", mixed)
+	}
+
+	if _, c := Detect(""); c != 0 {
+		t.Fatalf("expected no confidence for an empty string; got %v", c)
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	// the languages we have analyzers for in document.ElasticSearch
+	for _, want := range []language.Tag{
+		language.Arabic, language.Armenian, language.Bulgarian, language.Catalan,
+		language.Chinese, language.Czech, language.Danish, language.Dutch,
+		language.English, language.Finnish, language.French, language.German,
+		language.Greek, language.Hindi, language.Hungarian, language.Indonesian,
+		language.Italian, language.Japanese, language.Korean, language.Latvian,
+		language.Lithuanian, language.Norwegian, language.Persian, language.Portuguese,
+		language.Romanian, language.Russian, language.Spanish, language.Swedish,
+		language.Thai, language.Turkish,
+	} {
+		var found bool
+		for _, l := range Languages() {
+			if l == want {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatalf("%v is missing", want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package detect
+
+// samples are short texts from which we build the n-gram profile of
+// each language that shares its script with other languages. Most are
+// (adapted from) the first articles of the Universal Declaration of Human
+// Rights plus a few sentences of everyday text. Languages with a script of
+// their own (Greek, Thai, Korean, etc) are identified by script alone.
+var samples = map[string]string{
+	// Latin script
+	"ca": `Tots els éssers humans neixen lliures i iguals en dignitat i en drets. Són dotats de raó i de consciència, i han de comportar-se fraternalment els uns amb els altres. Tothom té tots els drets i llibertats proclamats en aquesta declaració, sense cap distinció de raça, color, sexe, llengua, religió, opinió política o de qualsevol altra mena. La ciutat és molt bonica i cada dia hi ha més gent que vol viure-hi. Aquesta pàgina web és el lloc on trobareu totes les notícies del nostre país.`,
+	"cs": `Všichni lidé rodí se svobodní a sobě rovní co do důstojnosti a práv. Jsou nadáni rozumem a svědomím a mají spolu jednat v duchu bratrství. Každý má všechna práva a všechny svobody stanovené touto deklarací bez jakéhokoli rozlišování podle rasy, barvy pleti, pohlaví, jazyka, náboženství, politického nebo jiného smýšlení. Tato stránka obsahuje nejnovější zprávy a informace pro naše čtenáře, kteří chtějí vědět, co se děje ve světě.`,
+	"da": `Alle mennesker er født frie og lige i værdighed og rettigheder. De er udstyret med fornuft og samvittighed, og de bør handle mod hverandre i en broderskabets ånd. Enhver har krav på alle de rettigheder og friheder, som nævnes i denne erklæring, uden forskel af nogen art, f.eks. på grund af race, farve, køn, sprog, religion, politisk eller anden anskuelse. Det er ikke så let at finde ud af, hvad man skal gøre, når vejret er dårligt og børnene keder sig.`,
+	"de": `Alle Menschen sind frei und gleich an Würde und Rechten geboren. Sie sind mit Vernunft und Gewissen begabt und sollen einander im Geist der Brüderlichkeit begegnen. Jeder hat Anspruch auf alle in dieser Erklärung verkündeten Rechte und Freiheiten ohne irgendeinen Unterschied, etwa nach Rasse, Hautfarbe, Geschlecht, Sprache, Religion, politischer oder sonstiger Überzeugung. Wir haben uns sehr gefreut, dass die Kinder nicht zu spät nach Hause gekommen sind und noch etwas gegessen haben.`,
+	"en": `All human beings are born free and equal in dignity and rights. They are endowed with reason and conscience and should act towards one another in a spirit of brotherhood. Everyone is entitled to all the rights and freedoms set forth in this declaration, without distinction of any kind, such as race, colour, sex, language, religion, political or other opinion. This is the website where you will find the latest news and information about our company and the things we have been working on with our friends.`,
+	"es": `Todos los seres humanos nacen libres e iguales en dignidad y derechos y, dotados como están de razón y conciencia, deben comportarse fraternalmente los unos con los otros. Toda persona tiene todos los derechos y libertades proclamados en esta declaración, sin distinción alguna de raza, color, sexo, idioma, religión, opinión política o de cualquier otra índole. Esta es la página donde encontrará las últimas noticias y toda la información que necesita sobre nuestra empresa y los productos que ofrecemos. Hoy hace buen tiempo, así que vamos a dar un paseo por el parque con los niños. ¿Qué vas a hacer mañana por la noche? Él dijo que vendría, pero todavía no ha llegado. No hay nada mejor que una taza de café por la mañana.`,
+	"eu": `Gizon-emakume guztiak aske jaiotzen dira, duintasun eta eskubide berberak dituztela; eta ezaguera eta kontzientzia dutenez gero, elkarren artean senide legez jokatu beharra dute. Edonork ditu aitorpen honetan aldarrikatzen diren eskubide eta askatasun guztiak, inolako bereizketarik gabe, hala nola arraza, kolore, sexu, hizkuntza, erlijio, iritzi politiko edo beste edozein motatakoa. Gaur goizean etxetik atera eta mendira joan gara lagunekin, eguraldi ona zegoelako.`,
+	"fi": `Kaikki ihmiset syntyvät vapaina ja tasavertaisina arvoltaan ja oikeuksiltaan. Heille on annettu järki ja omatunto, ja heidän on toimittava toisiaan kohtaan veljeyden hengessä. Jokainen on oikeutettu kaikkiin tässä julistuksessa esitettyihin oikeuksiin ja vapauksiin ilman minkäänlaista rotuun, väriin, sukupuoleen, kieleen, uskontoon, poliittiseen tai muuhun mielipiteeseen perustuvaa erotusta. Tällä sivulla kerromme uusimmat uutiset ja tiedot yrityksestämme ja sen tuotteista.`,
+	"fr": `Tous les êtres humains naissent libres et égaux en dignité et en droits. Ils sont doués de raison et de conscience et doivent agir les uns envers les autres dans un esprit de fraternité. Chacun peut se prévaloir de tous les droits et de toutes les libertés proclamés dans la présente déclaration, sans distinction aucune, notamment de race, de couleur, de sexe, de langue, de religion, d'opinion politique ou de toute autre opinion. Vous trouverez sur cette page les dernières nouvelles et toutes les informations sur notre entreprise.`,
+	"ga": `Saolaítear na daoine uile saor agus comhionann ina ndínit agus ina gcearta. Tá bua an réasúin agus an choinsiasa acu agus ba cheart dóibh gníomhú i dtreo a chéile i spiorad an bhráithreachais. Tá gach duine i dteideal na gceart agus na saoirsí go léir atá leagtha amach sa dearbhú seo, gan idirdhealú de shaghas ar bith, mar shampla cine, dath, gnéas, teanga, reiligiún, tuairim pholaitiúil nó tuairim eile. Bhí an aimsir go hálainn inné agus chuaigh muid go dtí an trá leis na páistí.`,
+	"gl": `Todos os seres humanos nacen libres e iguais en dignidade e dereitos e, dotados como están de razón e conciencia, débense comportar fraternalmente uns cos outros. Toda persoa ten os dereitos e liberdades proclamados nesta declaración, sen distinción ningunha de raza, cor, sexo, idioma, relixión, opinión política ou de calquera outra índole. Nesta páxina atoparás as últimas novas e toda a información que precisas sobre a nosa cidade, as súas rúas e a xente que vive nela. Hoxe fai bo tempo, así que imos dar un paseo polo parque cos nenos. Que vas facer mañá pola noite? El dixo que viría, pero aínda non chegou. Non hai nada mellor ca unha cunca de café pola mañá.`,
+	"hu": `Minden emberi lény szabadon születik és egyenlő méltósága és joga van. Az emberek, ésszel és lelkiismerettel bírván, egymással szemben testvéri szellemben kell hogy viseltessenek. Mindenki, bármely megkülönböztetésre, nevezetesen fajra, színre, nemre, nyelvre, vallásra, politikai vagy bármely más véleményre való tekintet nélkül hivatkozhat a jelen nyilatkozatban kinyilvánított összes jogokra és szabadságokra. Ezen az oldalon találja a legfrissebb híreket és információkat a cégünkről.`,
+	"id": `Semua orang dilahirkan merdeka dan mempunyai martabat dan hak-hak yang sama. Mereka dikaruniai akal dan hati nurani dan hendaknya bergaul satu sama lain dalam semangat persaudaraan. Setiap orang berhak atas semua hak dan kebebasan yang tercantum di dalam pernyataan ini dengan tidak ada kekecualian apa pun, seperti ras, warna kulit, jenis kelamin, bahasa, agama, politik atau pendapat yang berlainan. Di halaman ini anda akan menemukan berita terbaru dan informasi tentang perusahaan kami yang sudah berdiri sejak lama.`,
+	"it": `Tutti gli esseri umani nascono liberi ed eguali in dignità e diritti. Essi sono dotati di ragione e di coscienza e devono agire gli uni verso gli altri in spirito di fratellanza. Ad ogni individuo spettano tutti i diritti e tutte le libertà enunciate nella presente dichiarazione, senza distinzione alcuna, per ragioni di razza, di colore, di sesso, di lingua, di religione, di opinione politica o di altro genere. In questa pagina troverete le ultime notizie e tutte le informazioni sulla nostra azienda e sui prodotti che offriamo.`,
+	"lt": `Visi žmonės gimsta laisvi ir lygūs savo orumu ir teisėmis. Jiems suteiktas protas ir sąžinė ir jie turi elgtis vienas kito atžvilgiu kaip broliai. Kiekvienas žmogus turi visas šioje deklaracijoje paskelbtas teises ir laisves be jokių skirtumų, tokių kaip rasė, odos spalva, lytis, kalba, religija, politiniai ar kitokie įsitikinimai. Šiame puslapyje rasite naujausias žinias ir informaciją apie mūsų įmonę ir jos produktus, kuriuos siūlome savo klientams.`,
+	"lv": `Visi cilvēki piedzimst brīvi un vienlīdzīgi savā pašcieņā un tiesībās. Viņi ir apveltīti ar saprātu un sirdsapziņu, un viņiem jāizturas citam pret citu brālības garā. Ikvienam ir jābūt apveltītam ar visām tiesībām un visām brīvībām, kas pasludinātas šajā deklarācijā, bez jebkādas atšķirības attiecībā uz rasi, ādas krāsu, dzimumu, valodu, reliģiju, politiskajiem vai citiem uzskatiem. Šajā lapā jūs atradīsiet jaunākās ziņas un informāciju par mūsu uzņēmumu.`,
+	"nl": `Alle mensen worden vrij en gelijk in waardigheid en rechten geboren. Zij zijn begiftigd met verstand en geweten, en behoren zich jegens elkander in een geest van broederschap te gedragen. Een ieder heeft aanspraak op alle rechten en vrijheden, uiteengezet in deze verklaring, zonder enig onderscheid van welke aard ook, zoals ras, kleur, geslacht, taal, godsdienst, politieke of andere overtuiging. Op deze pagina vindt u het laatste nieuws en alle informatie over ons bedrijf en de producten die wij aanbieden.`,
+	"no": `Alle mennesker er født frie og med samme menneskeverd og menneskerettigheter. De er utstyrt med fornuft og samvittighet og bør handle mot hverandre i brorskapets ånd. Enhver har krav på alle de rettigheter og friheter som er nevnt i denne erklæring, uten forskjell av noen art, f. eks. på grunn av rase, farge, kjønn, språk, religion, politisk eller annen oppfatning. Det er ikke så lett å finne ut hva man skal gjøre når været er dårlig og barna kjeder seg hjemme. Vi har ikke hørt noe fra dem ennå.`,
+	"pt": `Todos os seres humanos nascem livres e iguais em dignidade e em direitos. Dotados de razão e de consciência, devem agir uns para com os outros em espírito de fraternidade. Todos os seres humanos podem invocar os direitos e as liberdades proclamados na presente declaração, sem distinção alguma, nomeadamente de raça, de cor, de sexo, de língua, de religião, de opinião política ou outra. Nesta página você vai encontrar as últimas notícias e todas as informações sobre a nossa empresa e os serviços que oferecemos.`,
+	"ro": `Toate ființele umane se nasc libere și egale în demnitate și în drepturi. Ele sunt înzestrate cu rațiune și conștiință și trebuie să se comporte unele față de altele în spiritul fraternității. Fiecare om se poate prevala de toate drepturile și libertățile proclamate în prezenta declarație fără niciun fel de deosebire ca, de pildă, deosebirea de rasă, culoare, sex, limbă, religie, opinie politică sau orice altă opinie. Pe această pagină găsiți cele mai recente știri și informații despre compania noastră.`,
+	"sv": `Alla människor är födda fria och lika i värde och rättigheter. De har utrustats med förnuft och samvete och bör handla gentemot varandra i en anda av broderskap. Var och en är berättigad till alla de rättigheter och friheter som uttalas i denna förklaring utan åtskillnad av något slag, såsom ras, hudfärg, kön, språk, religion, politisk eller annan uppfattning. Det är inte så lätt att veta vad man ska göra när vädret är dåligt och barnen har tråkigt. Vi har inte hört något från dem än.`,
+	"tr": `Bütün insanlar hür, haysiyet ve haklar bakımından eşit doğarlar. Akıl ve vicdana sahiptirler ve birbirlerine karşı kardeşlik zihniyeti ile hareket etmelidirler. Herkes, ırk, renk, cinsiyet, dil, din, siyasi veya diğer herhangi bir akide, milli veya içtimai menşe, servet, doğuş veya herhangi diğer bir fark gözetilmeksizin işbu beyannamede ilan olunan tekmil haklardan ve hürriyetlerden istifade edebilir. Bu sayfada şirketimiz hakkında en son haberleri ve bilgileri bulabilirsiniz.`,
+	"vi": `Tất cả mọi người sinh ra đều được tự do và bình đẳng về nhân phẩm và quyền lợi. Mọi con người đều được tạo hóa ban cho lý trí và lương tâm và cần phải đối xử với nhau trong tình anh em. Mọi người đều được hưởng tất cả những quyền và tự do nêu trong bản tuyên ngôn này, không phân biệt chủng tộc, màu da, giới tính, ngôn ngữ, tôn giáo, quan điểm chính trị hay quan điểm khác. Trên trang này bạn sẽ tìm thấy những tin tức mới nhất và thông tin về công ty của chúng tôi.`,
+
+	// Cyrillic script
+	"bg": `Всички хора се раждат свободни и равни по достойнство и права. Те са надарени с разум и съвест и следва да се отнасят помежду си в дух на братство. Всеки човек има право на всички права и свободи, провъзгласени в тази декларация, без никакви различия, основани на раса, цвят на кожата, пол, език, религия, политически или други убеждения. На тази страница ще намерите последните новини и информация за нашата фирма и продуктите, които предлагаме. Това е най-хубавият ден в живота ми. Времето днес е хубаво, затова ще отидем на разходка в парка с децата. Какво ще правиш утре вечер? Той каза, че ще дойде, но още не е пристигнал. Няма нищо по-хубаво от чаша кафе сутрин. Тя живее в София от много години и работи в една голяма болница. Ние бяхме там миналата седмица и всичко беше наред.`,
+	"ru": `Все люди рождаются свободными и равными в своем достоинстве и правах. Они наделены разумом и совестью и должны поступать в отношении друг друга в духе братства. Каждый человек должен обладать всеми правами и всеми свободами, провозглашенными настоящей декларацией, без какого бы то ни было различия, как-то в отношении расы, цвета кожи, пола, языка, религии, политических или иных убеждений. На этой странице вы найдёте последние новости и информацию о нашей компании и её продуктах. Это был самый счастливый день в моей жизни. Сегодня хорошая погода, поэтому мы пойдём гулять в парк с детьми. Что ты будешь делать завтра вечером? Он сказал, что придёт, но ещё не пришёл. Нет ничего лучше чашки кофе утром. Она живёт в Москве уже много лет и работает в большой больнице. Мы были там на прошлой неделе, и всё было хорошо.`,
+
+	// Arabic script
+	"ar":  `يولد جميع الناس أحرارا متساوين في الكرامة والحقوق. وقد وهبوا عقلا وضميرا وعليهم أن يعامل بعضهم بعضا بروح الإخاء. لكل إنسان حق التمتع بكافة الحقوق والحريات الواردة في هذا الإعلان، دون أي تمييز، كالتمييز بسبب العنصر أو اللون أو الجنس أو اللغة أو الدين أو الرأي السياسي أو أي رأي آخر. في هذه الصفحة ستجد آخر الأخبار والمعلومات عن شركتنا والمنتجات التي نقدمها لعملائنا.`,
+	"ckb": `هەموو مرۆڤێک بە ئازادی لە دایک دەبێت و لە ڕووی کەرامەت و مافەکانەوە یەکسانن. هەموویان خاوەنی ئەقڵ و ویژدانن و پێویستە لەگەڵ یەکتر بە گیانێکی برایانە ڕەفتار بکەن. هەموو کەسێک بۆی هەیە هەموو ئەو ماف و ئازادییانەی لەم جاڕنامەیەدا هاتوون بەبێ هیچ جیاوازییەک هەبێت. لەم پەڕەیەدا دوایین هەواڵ و زانیاری دەربارەی کۆمپانیاکەمان دەدۆزیتەوە.`,
+	"fa":  `تمام افراد بشر آزاد به دنیا می‌آیند و از لحاظ حیثیت و حقوق با هم برابرند. همه دارای عقل و وجدان هستند و باید نسبت به یکدیگر با روح برادری رفتار کنند. هر کس می‌تواند بدون هیچ گونه تمایز، مخصوصا از حیث نژاد، رنگ، جنس، زبان، مذهب، عقیده سیاسی یا هر عقیده دیگر از تمام حقوق و کلیه آزادی‌هایی که در اعلامیه حاضر ذکر شده است بهره‌مند گردد. در این صفحه آخرین اخبار و اطلاعات درباره شرکت ما را پیدا می‌کنید.`,
+}
@@ -11,7 +11,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jivesearch/jivesearch/search/anchor"
+	"github.com/jivesearch/jivesearch/search/detect"
 	img "github.com/jivesearch/jivesearch/search/image"
+	"github.com/jivesearch/jivesearch/search/link"
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 	"golang.org/x/net/html/charset"
@@ -32,10 +35,12 @@ type Document struct {
 	URL       *url.URL `json:"-"`
 	Scheme    string   `json:"scheme,omitempty"`
 	Host      string   `json:"host,omitempty"`       // not HostName()...we want the port for the robots.txt file
+	IP        string   `json:"ip,omitempty"`         // the IP we crawled the host at
 	Domain    string   `json:"domain,omitempty"`     // tld+1 -> example.com
 	TLD       string   `json:"tld,omitempty"`        // com, org, uk, etc (we don't want co.uk just uk)
 	PathParts string   `json:"path_parts,omitempty"` // https://api.example.com/path/to/something -> "path to something"
 	Crawled   string   `json:"crawled,omitempty"`
+	StoredIn  string   `json:"-"` // the index we found the document in (if we've crawled it before)
 	header    http.Header
 	MIME      string `json:"mime,omitempty"`
 	tokenizer *html.Tokenizer
@@ -52,9 +57,27 @@ type Content struct {
 	Title       string       `json:"title,omitempty"`
 	Keywords    string       `json:"keywords,omitempty"`
 	Description string       `json:"description,omitempty"`
+	Summary     string       `json:"summary,omitempty"` // from the entry of a feed
+	Feeds       []string     `json:"-"`                 // RSS/Atom feeds discovered in the <head>
+	Outlinks    []*link.Link `json:"-"`                 // the links of the page (including nofollow)
+	Alternates  []Alternate  `json:"alternates,omitempty"`
+	Anchors     []string     `json:"anchors,omitempty"`  // aggregated anchor text of inbound links
+	Redirect    string       `json:"redirect,omitempty"` // where the page redirects to
+	Aliases     []string     `json:"aliases,omitempty"`  // links that permanently redirect to the page
+	Failures    int          `json:"failures"`           // consecutive failed crawls
+	Stale       *string      `json:"stale"`              // the date the page went stale (null if not stale)
+	Spam        float64      `json:"spam"`               // 0 (not spam) to 1 (spam or a parked domain)
 	Policy
 }
 
+// Alternate is a translation or regional version of a page.
+// Together the alternates make up the hreflang cluster of a document.
+// https://support.google.com/webmasters/answer/189077
+type Alternate struct {
+	Lang string `json:"lang"` // "fr", "en-GB", "x-default", etc
+	URL  string `json:"url"`
+}
+
 // Policy tells us if we can index the content & store the links
 type Policy struct {
 	Index  bool `json:"index,omitempty"` // are we allowed to index the page?
@@ -136,6 +159,22 @@ func (d *Document) SetStatusCode(code int) *Document {
 	return d
 }
 
+// StatusUnreachable is the status of a page we couldn't fetch at all
+// (the host doesn't resolve, the connection is refused or times out, etc).
+// Some proxies use 599 for a network timeout.
+const StatusUnreachable = 599
+
+// Failed reports if the page couldn't be crawled.
+// Too many requests (429) is our fault, not the page's.
+func (d *Document) Failed() bool {
+	return d.StatusCode >= 400 && d.StatusCode != http.StatusTooManyRequests
+}
+
+// Gone reports if the page was removed on purpose
+func (d *Document) Gone() bool {
+	return d.StatusCode == http.StatusGone
+}
+
 // SetCrawled marks the date the doc was crawled
 func (d *Document) SetCrawled(t time.Time) *Document {
 	d.Crawled = t.Format("20060102")
@@ -215,35 +254,120 @@ func (d *Document) SetTokenizer(b io.Reader) error {
 }
 
 // SetContent parses the html and sets the language, title, description, extracts links, etc.
+// The anchor text of each link we follow is sent to the anchors channel (if not nil).
 func (d *Document) SetContent(bot string, maxLinks int, links chan string, images chan *img.Image,
-	truncateTitle, truncateKeywords, truncateDescription int) error {
+	anchors chan *anchor.Anchor, truncateTitle, truncateKeywords, truncateDescription int) error {
 
 	var collected int
 
 	var tt html.TokenType
 	var title bool
 
+	// the link we are collecting anchor text for
+	var target string
+	var text []string
+
+	// the text we detect the language from
+	var declared, skip bool
+	var content strings.Builder
+
+	// the signals of a spammy page
+	var sp spam
+	var inLink bool
+
+	// the outlink we are collecting anchor text for
+	var out *link.Link
+	var outText []string
+
+	// the context of the images of the page
+	var heading, wrap string
+	var headingText, caption []string
+	var inHeading, inFigure, inCaption bool
+	var figure []*img.Image
+
+	sendImage := func(i *img.Image) {
+		i.Page, i.PageTitle = d.ID, d.Title
+		images <- i
+	}
+
+	// the caption of a <figure> comes after its images
+	sendFigure := func() {
+		c := d.extractText(strings.Join(caption, " "), maxCaptionLength)
+		for _, i := range figure {
+			i.Caption = c
+			sendImage(i)
+		}
+		figure, caption, inFigure, inCaption = nil, nil, false, false
+	}
+
+	sendAnchor := func() {
+		if target != "" && anchors != nil {
+			if a, err := anchor.New(target, strings.Join(text, " "), d.Domain); err == nil {
+				anchors <- a
+			}
+		}
+		target, text, wrap = "", nil, ""
+
+		if out != nil {
+			out.Anchor = d.extractText(strings.Join(outText, " "), link.MaxAnchorLength)
+		}
+		out, outText = nil, nil
+	}
+
 	for {
 		tt = d.tokenizer.Next()
 
 		switch tt {
 		case html.ErrorToken:
+			sendAnchor() // unclosed <a>
+			sendFigure() // unclosed <figure>
+			d.detectLanguage(declared, d.Title+" "+d.Description+" "+content.String())
+			d.Spam = sp.score(d.Title + " " + d.Description + " " + content.String())
 			return nil
 		case html.TextToken:
+			txt := string(d.tokenizer.Text()) // Text() can only be called once per token
+
 			if title {
-				d.Title = d.extractText(string(d.tokenizer.Text()), truncateTitle)
+				d.Title = d.extractText(txt, truncateTitle)
+			} else if !skip {
+				sp.addText(txt, inLink)
+				if content.Len() < maxDetect {
+					content.WriteString(txt + " ")
+				}
+			}
+
+			if target != "" {
+				text = append(text, txt)
+			}
+
+			if out != nil {
+				outText = append(outText, txt)
+			}
+
+			if inHeading {
+				headingText = append(headingText, txt)
+			}
+
+			if inCaption {
+				caption = append(caption, txt)
 			}
 		case html.StartTagToken, html.SelfClosingTagToken:
 			t := d.tokenizer.Token()
 
+			if tt == html.StartTagToken {
+				style, _ := getAttribute(t, "style")
+				_, hidden := getAttribute(t, "hidden")
+				sp.start(t.Data, hides(style, hidden))
+			}
+
 			// Note: comparing DataAtom is faster (& uses less memory) than n.Data=="title", etc.
 			switch t.DataAtom {
 			case atom.Html:
 				// A document may have multiple languages for different
-				// sections of the <body>, <span>, etc. Since we only
-				// extract text from the <head> section we
-				// only need the language from the <html> tag.
-				d.setLanguage(t)
+				// sections of the <body>, <span>, etc. We only
+				// need the language from the <html> tag. If it is missing
+				// or wrong the text of the page will tell us (see detectLanguage).
+				declared = d.setLanguage(t)
 				/*
 					TODO: How to deal with rtl text (Arabic, Hebrew, etc)...simply reverse it?
 					// Can we make use of MustParseScript in language package, which represents
@@ -254,15 +378,43 @@ func (d *Document) SetContent(bot string, maxLinks int, links chan string, image
 					}
 				*/
 			case atom.Link:
-				if rel, _ := getAttribute(t, "rel"); rel == "canonical" {
+				rel, _ := getAttribute(t, "rel")
+				switch {
+				case rel == "canonical":
 					lnk, _ := getAttribute(t, "href")
 					if lnk != d.ID {
 						d.canonical = lnk
 						links <- lnk
 					}
+				case contains(strings.Fields(rel), "alternate"):
+					// <link rel="alternate" type="application/rss+xml" href="/feed.xml">
+					// <link rel="alternate" hreflang="fr" href="https://example.com/fr/">
+					typ, _ := getAttribute(t, "type")
+					href, _ := getAttribute(t, "href")
+					hreflang, _ := getAttribute(t, "hreflang")
+
+					switch {
+					case feedTypes[strings.ToLower(strings.TrimSpace(typ))]:
+						if u, err := d.handleLink(href); err == nil {
+							d.Feeds = append(d.Feeds, u)
+						}
+					case strings.TrimSpace(hreflang) != "":
+						d.addAlternate(hreflang, href)
+					}
 				}
 			case atom.Title:
 				title = true
+			case atom.Script, atom.Style:
+				skip = tt == html.StartTagToken
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				inHeading, headingText = tt == html.StartTagToken, nil
+			case atom.Figure:
+				if tt == html.StartTagToken {
+					sendFigure() // <figure> can be nested but we only keep the caption of the innermost
+					inFigure = true
+				}
+			case atom.Figcaption:
+				inCaption = inFigure && tt == html.StartTagToken
 			case atom.Meta:
 				if name, _ := getAttribute(t, "name"); name == "keywords" {
 					if kw, ok := getAttribute(t, "content"); ok {
@@ -287,18 +439,42 @@ func (d *Document) SetContent(bot string, maxLinks int, links chan string, image
 					d.setPolicy(bot, content)
 				}
 			case atom.A:
-				if d.Policy.follow && (maxLinks == -1 || collected < maxLinks) {
-					rel, _ := getAttribute(t, "rel")
-					if !contains(strings.Fields(rel), "nofollow") {
-						href, _ := getAttribute(t, "href")
-						u, err := d.handleLink(href)
-						if err == nil {
-							links <- u
-							collected++
-						}
+				sendAnchor() // <a> can't be nested
+				rel, _ := getAttribute(t, "rel")
+				nofollow := contains(strings.Fields(rel), "nofollow")
+
+				if href, ok := getAttribute(t, "href"); ok {
+					sp.addLink(d.external(href))
+					inLink = tt == html.StartTagToken
+					if l := d.addOutlink(href, nofollow || !d.Policy.follow); tt == html.StartTagToken {
+						out = l
+					}
+					if u, err := d.handleLink(href); err == nil && tt == html.StartTagToken {
+						wrap = u
+					}
+				}
+
+				// we collect the anchor text of a link we'd follow even if we don't queue it
+				if d.Policy.follow && !nofollow {
+					href, _ := getAttribute(t, "href")
+					if u, err := d.handleLink(href); err == nil && (maxLinks == -1 || collected < maxLinks) {
+						links <- u
+						collected++
+					}
+					if u, err := d.normalizeLink(href); err == nil && tt == html.StartTagToken {
+						target = u
 					}
 				}
 			case atom.Img:
+				// the alt text of an image is the anchor text of an image link
+				alt, _ := getAttribute(t, "alt")
+				if target != "" {
+					text = append(text, alt)
+				}
+				if out != nil {
+					outText = append(outText, alt)
+				}
+
 				src, _ := getAttribute(t, "src")
 				u, err := d.handleLink(src)
 				if err != nil {
@@ -310,8 +486,16 @@ func (d *Document) SetContent(bot string, maxLinks int, links chan string, image
 					continue
 				}
 
-				img.Alt, _ = getAttribute(t, "alt")
-				images <- img
+				img.Alt = alt
+				img.Heading = heading
+				img.Link = wrap
+
+				if inFigure {
+					figure = append(figure, img)
+					continue
+				}
+
+				sendImage(img)
 			case atom.Time:
 				// There are a few ways to get the creation date (or modified) date of the document:
 
@@ -335,17 +519,50 @@ func (d *Document) SetContent(bot string, maxLinks int, links chan string, image
 			}
 		case html.EndTagToken:
 			t := d.tokenizer.Token()
+			sp.end(t.Data)
 
 			switch t.DataAtom {
 			case atom.Title:
 				title = false
+			case atom.Script, atom.Style:
+				skip = false
+			case atom.A:
+				sendAnchor()
+				inLink = false
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				if inHeading {
+					heading = d.extractText(strings.Join(headingText, " "), maxHeadingLength)
+				}
+				inHeading, headingText = false, nil
+			case atom.Figcaption:
+				inCaption = false
+			case atom.Figure:
+				sendFigure()
 			}
 		}
 	}
 }
 
+// feedTypes are the types of a <link rel="alternate"> that point to an RSS or Atom feed
+var feedTypes = map[string]bool{
+	"application/rss+xml":  true,
+	"application/atom+xml": true,
+	"application/rdf+xml":  true,
+}
+
 var canonicalHeader = regexp.MustCompile(`<(.*?)>; rel="canonical"`)
 
+// SetFeedEntry sets the date and summary from the entry of a feed.
+// A date found in the content of the page takes precedence.
+func (d *Document) SetFeedEntry(published time.Time, summary string, truncateDescription int) *Document {
+	if d.Date == "" && !published.IsZero() {
+		d.Date = published.UTC().Format(time.RFC3339)
+	}
+
+	d.Summary = d.extractText(summary, truncateDescription)
+	return d
+}
+
 // SetCanonical sets Canonical to true if the Document's ID is the canonical URL
 func (d *Document) SetCanonical(ch chan string) *Document {
 	d.Canonical = true // assume it is unless proven otherwise
@@ -418,6 +635,55 @@ func (d *Document) handleLink(href string) (string, error) {
 	return "", err
 }
 
+// normalizeLink resolves a link of the page to the ID of its document
+// (i.e. without the fragment and with a lowercase host)
+func (d *Document) normalizeLink(href string) (string, error) {
+	u, err := d.handleLink(href)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := ValidateURL(u)
+	if err != nil {
+		return "", err
+	}
+
+	if v.String() == d.ID {
+		return "", fmt.Errorf("link to itself")
+	}
+
+	return v.String(), nil
+}
+
+// the max length of the context of an image
+const (
+	maxCaptionLength = 250
+	maxHeadingLength = 100
+)
+
+// maxOutlinks is the max number of outlinks we keep of a page
+const maxOutlinks = 500
+
+// addOutlink adds a link of the page to its outlinks
+func (d *Document) addOutlink(href string, nofollow bool) *link.Link {
+	if len(d.Outlinks) >= maxOutlinks {
+		return nil
+	}
+
+	u, err := d.normalizeLink(href)
+	if err != nil {
+		return nil
+	}
+
+	l, err := link.New(d.ID, u, "", nofollow)
+	if err != nil {
+		return nil
+	}
+
+	d.Outlinks = append(d.Outlinks, l)
+	return l
+}
+
 func getAttribute(t html.Token, key string) (string, bool) {
 	for _, a := range t.Attr {
 		if a.Key == key {
@@ -436,14 +702,128 @@ func (d *Document) extractText(s string, max int) string {
 	return strings.TrimSpace(s)
 }
 
-func (d *Document) setLanguage(t html.Token) {
+// addAlternate adds an hreflang alternate. Note: we keep the
+// self-referencing alternate as it tells us the page's own hreflang.
+func (d *Document) addAlternate(hreflang, href string) {
+	hreflang = strings.ToLower(strings.TrimSpace(hreflang))
+	if hreflang != "x-default" {
+		if _, err := language.Parse(hreflang); err != nil {
+			return
+		}
+	}
+
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return
+	}
+
+	u, err = ValidateURL(d.URL.ResolveReference(u).String())
+	if err != nil {
+		return
+	}
+
+	for _, a := range d.Alternates {
+		if a.Lang == hreflang {
+			return // first one wins
+		}
+	}
+
+	d.Alternates = append(d.Alternates, Alternate{Lang: hreflang, URL: u.String()})
+}
+
+// Alternate returns the url of the alternate that is a better match for the
+// user's language and region than the document itself (if any).
+// A document with no self-referencing alternate is assumed to be a
+// reasonable match since it was found in the user's language index.
+func (d *Document) Alternate(lang language.Tag, region language.Region) (string, bool) {
+	base, _ := lang.Base()
+
+	score := func(hreflang string) int {
+		t, err := language.Parse(hreflang)
+		if err != nil {
+			return 0
+		}
+
+		if b, _ := t.Base(); b != base {
+			return 0
+		}
+
+		r, conf := t.Region()
+		switch {
+		case conf == language.Exact && r == region:
+			return 3
+		case conf != language.Exact: // "fr" rather than "fr-CA"
+			return 2
+		default:
+			return 1
+		}
+	}
+
+	best, lnk := 2, ""
+	for _, a := range d.Alternates {
+		if a.URL == d.ID {
+			best = score(a.Lang)
+			break
+		}
+	}
+
+	for _, a := range d.Alternates {
+		if a.URL == d.ID {
+			continue
+		}
+
+		if s := score(a.Lang); s > best {
+			best, lnk = s, a.URL
+		}
+	}
+
+	return lnk, lnk != ""
+}
+
+// setLanguage sets the language from the <html> tag and reports if one was declared
+func (d *Document) setLanguage(t html.Token) bool {
 	tag := language.Tag{}
 
-	if lang, _ := getAttribute(t, "lang"); lang != "" {
+	lang, _ := getAttribute(t, "lang")
+	if lang != "" {
 		tag = language.Make(strings.ToLower(lang))
 	}
 
 	d.Language, _, _ = Matcher.Match(tag) // we ignore the error
+	return lang != ""
+}
+
+const (
+	maxDetect          = 2000 // bytes of the <body> used to detect the language
+	minConfidence      = .5   // needed when the <html> tag has no language
+	overrideConfidence = .9   // needed to overrule the language of the <html> tag
+)
+
+// detectLanguage detects the language from the text of the document when
+// the <html> tag doesn't declare one or when the declared language is
+// clearly wrong (e.g. a template that always says lang="en").
+func (d *Document) detectLanguage(declared bool, text string) {
+	tag, conf := detect.Detect(text)
+	if tag == language.Und {
+		return
+	}
+
+	if declared {
+		detected, _ := tag.Base()
+		current, _ := d.Language.Base()
+		if conf < overrideConfidence || detected == current {
+			return
+		}
+	} else if conf < minConfidence {
+		return
+	}
+
+	t, _, c := Matcher.Match(tag)
+	if c == language.No { // a language we don't support
+		return
+	}
+
+	d.Language = t
 }
 
 // Languages (will) verifies that languages are supported.
@@ -5,11 +5,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/jivesearch/jivesearch/search/anchor"
 	img "github.com/jivesearch/jivesearch/search/image"
+	"github.com/jivesearch/jivesearch/search/link"
 	"golang.org/x/text/language"
 )
 
@@ -143,6 +146,30 @@ func TestSetStatusCode(t *testing.T) {
 	}
 }
 
+func TestFailed(t *testing.T) {
+	for _, c := range []struct {
+		status int
+		failed bool
+		gone   bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusMovedPermanently, false, false},
+		{http.StatusNotFound, true, false},
+		{http.StatusGone, true, true},
+		{http.StatusTooManyRequests, false, false},
+		{http.StatusServiceUnavailable, true, false},
+	} {
+		t.Run(strconv.Itoa(c.status), func(t *testing.T) {
+			d := &Document{}
+			d.SetStatusCode(c.status)
+
+			if d.Failed() != c.failed || d.Gone() != c.gone {
+				t.Fatalf("got failed %v, gone %v; want %v, %v", d.Failed(), d.Gone(), c.failed, c.gone)
+			}
+		})
+	}
+}
+
 func TestSetCrawled(t *testing.T) {
 	for _, c := range []struct {
 		tme  time.Time
@@ -291,6 +318,7 @@ func TestSetContent(t *testing.T) {
 		header              http.Header
 		body                string
 		links               []string
+		anchors             []*anchor.Anchor
 		maxLinks            int
 		ch                  chan string
 		images              chan *img.Image
@@ -319,6 +347,10 @@ func TestSetContent(t *testing.T) {
 				"http://www.example.com/link/to/somewhere",
 				"http://www.example.com/link/to/somewhere/else",
 			},
+			anchors: []*anchor.Anchor{
+				{Target: "http://www.example.com/link/to/somewhere", Text: "A link", Domain: "example.com"},
+				{Target: "http://www.example.com/link/to/somewhere/else", Text: "A link to somewhere else", Domain: "example.com"},
+			},
 			maxLinks:            10,
 			ch:                  make(chan string),
 			truncateTitle:       100,
@@ -330,7 +362,21 @@ func TestSetContent(t *testing.T) {
 				Title:       "The title of a page",
 				Keywords:    "some keywords for a search",
 				Description: "A description",
-				Policy:      Policy{Index: true, follow: true},
+				Outlinks: []*link.Link{
+					{
+						Source: "http://www.example.com", Target: "http://www.example.com/link/to/somewhere",
+						Domain: "example.com", TargetDomain: "example.com", Anchor: "A link",
+					},
+					{
+						Source: "http://www.example.com", Target: "http://www.example.com/donotfollow",
+						Domain: "example.com", TargetDomain: "example.com", Anchor: "Don't follow this link!", NoFollow: true,
+					},
+					{
+						Source: "http://www.example.com", Target: "http://www.example.com/link/to/somewhere/else",
+						Domain: "example.com", TargetDomain: "example.com", Anchor: "A link to somewhere else",
+					},
+				},
+				Policy: Policy{Index: true, follow: true},
 			},
 		},
 		{
@@ -358,7 +404,60 @@ func TestSetContent(t *testing.T) {
 				Title:       "",
 				Keywords:    "",
 				Description: "",
-				Policy:      Policy{Index: false, follow: false},
+				Outlinks: []*link.Link{
+					{
+						Source: "http://www.example.com", Target: "http://www.example.com/link/to/somewhere",
+						Domain: "example.com", TargetDomain: "example.com", Anchor: "A link", NoFollow: true,
+					},
+				},
+				Policy: Policy{Index: false, follow: false},
+			},
+		},
+		{
+			name:   "detected language",
+			url:    "http://www.example.com",
+			status: http.StatusOK,
+			body: `<html>
+						<head><title>El clima de la región</title></head>
+						<body>
+							<script>var notText = "The quick brown fox jumps over the lazy dog";</script>
+							<p>El clima de la región es templado durante casi todo el año, aunque en invierno las temperaturas
+							pueden bajar bastante por las noches y los turistas deben llevar ropa de abrigo.</p>
+						</body>
+					</html>`,
+			links:               []string{},
+			maxLinks:            10,
+			ch:                  make(chan string),
+			truncateTitle:       100,
+			truncateKeywords:    5,
+			truncateDescription: 14,
+			want: Content{
+				StatusCode: http.StatusOK,
+				Language:   language.Spanish,
+				Title:      "El clima de la región",
+				Policy:     Policy{Index: true, follow: true},
+			},
+		},
+		{
+			name:   "wrong language",
+			url:    "http://www.example.com",
+			status: http.StatusOK,
+			body: `<html lang="en">
+						<body>
+							<p>Het klimaat van de regio is bijna het hele jaar gematigd, hoewel de temperaturen in de winter
+							's nachts flink kunnen dalen en toeristen warme kleding moeten meenemen.</p>
+						</body>
+					</html>`,
+			links:               []string{},
+			maxLinks:            10,
+			ch:                  make(chan string),
+			truncateTitle:       100,
+			truncateKeywords:    5,
+			truncateDescription: 14,
+			want: Content{
+				StatusCode: http.StatusOK,
+				Language:   language.Dutch,
+				Policy:     Policy{Index: true, follow: true},
 			},
 		},
 		{
@@ -380,6 +479,9 @@ func TestSetContent(t *testing.T) {
 				"https://example.com/canonical.php",
 				"http://www.example.com/link/to/somewhere",
 			},
+			anchors: []*anchor.Anchor{
+				{Target: "http://www.example.com/link/to/somewhere", Text: "A link", Domain: "example.com"},
+			},
 			maxLinks:            10,
 			ch:                  make(chan string),
 			truncateTitle:       100,
@@ -392,7 +494,85 @@ func TestSetContent(t *testing.T) {
 				Title:       "The title of a page",
 				Keywords:    "some keywords for a search",
 				Description: "A description",
-				Policy:      Policy{Index: true, follow: true},
+				Outlinks: []*link.Link{
+					{
+						Source: "https://example.com", Target: "http://www.example.com/link/to/somewhere",
+						Domain: "example.com", TargetDomain: "example.com", Anchor: "A link",
+					},
+				},
+				Policy: Policy{Index: true, follow: true},
+			},
+		},
+		{
+			name:   "queue is full",
+			url:    "https://example.com",
+			status: http.StatusOK,
+			body: `<html>
+				     <head>
+					   <title>A page</title>
+					 </head>
+					 <body>
+					   <a href="http://WWW.Example.com/somewhere#top">A link</a>
+					   <a href="https://example.com#top">Back to the top</a><!--link to itself-->
+					 </body>
+				   </html>`,
+			links: []string{},
+			anchors: []*anchor.Anchor{
+				{Target: "http://www.example.com/somewhere", Text: "A link", Domain: "example.com"},
+			},
+			maxLinks:            0,
+			ch:                  make(chan string),
+			truncateTitle:       100,
+			truncateKeywords:    5,
+			truncateDescription: 14,
+			want: Content{
+				StatusCode: http.StatusOK,
+				Language:   language.English,
+				Title:      "A page",
+				Outlinks: []*link.Link{
+					{
+						Source: "https://example.com", Target: "http://www.example.com/somewhere",
+						Domain: "example.com", TargetDomain: "example.com", Anchor: "A link",
+					},
+				},
+				Policy: Policy{Index: true, follow: true},
+			},
+		},
+		{
+			name:   "feeds",
+			url:    "https://example.com/blog/",
+			status: http.StatusOK,
+			body: `<html>
+				     <head>
+					   <title>A blog</title>
+					   <link rel="alternate" type="application/rss+xml" title="RSS" href="/blog/feed.xml" />
+					   <link rel="alternate" type="application/atom+xml" href="https://example.com/blog/atom" />
+					   <link rel="alternate" hreflang="fr" href="https://example.com/fr/blog/" /><!--not a feed-->
+					   <link rel="alternate" hreflang="en" href="https://example.com/blog/" />
+					   <link rel="alternate" hreflang="x-default" href="/blog/" />
+					   <link rel="alternate" hreflang="not a language" href="/bad/" />
+					 </head>
+				   </html>`,
+			links:               []string{},
+			maxLinks:            10,
+			ch:                  make(chan string),
+			truncateTitle:       100,
+			truncateKeywords:    5,
+			truncateDescription: 14,
+			want: Content{
+				StatusCode: http.StatusOK,
+				Language:   language.English,
+				Title:      "A blog",
+				Feeds: []string{
+					"https://example.com/blog/feed.xml",
+					"https://example.com/blog/atom",
+				},
+				Alternates: []Alternate{
+					{Lang: "fr", URL: "https://example.com/fr/blog/"},
+					{Lang: "en", URL: "https://example.com/blog/"},
+					{Lang: "x-default", URL: "https://example.com/blog/"},
+				},
+				Policy: Policy{Index: true, follow: true},
 			},
 		},
 	} {
@@ -407,6 +587,17 @@ func TestSetContent(t *testing.T) {
 				collected <- lnks
 			}()
 
+			anchors := make(chan *anchor.Anchor)
+			collectedAnchors := make(chan []*anchor.Anchor)
+
+			go func() {
+				a := []*anchor.Anchor{}
+				for an := range anchors {
+					a = append(a, an)
+				}
+				collectedAnchors <- a
+			}()
+
 			d, err := New(c.url)
 			if err != nil {
 				t.Fatalf("expected nil error; got %q", err)
@@ -433,7 +624,7 @@ func TestSetContent(t *testing.T) {
 				t.Fatalf("expected nil error; got %q", err)
 			}
 
-			err = d.SetContent("", c.maxLinks, c.ch, c.images,
+			err = d.SetContent("", c.maxLinks, c.ch, c.images, anchors,
 				c.truncateTitle, c.truncateKeywords, c.truncateDescription)
 
 			if err != nil {
@@ -447,6 +638,17 @@ func TestSetContent(t *testing.T) {
 				t.Fatalf("got %v links; want %v", got, c.links)
 			}
 
+			close(anchors)
+			gotAnchors := <-collectedAnchors
+
+			if c.anchors == nil {
+				c.anchors = []*anchor.Anchor{}
+			}
+
+			if !reflect.DeepEqual(gotAnchors, c.anchors) {
+				t.Fatalf("got %+v anchors; want %+v", gotAnchors, c.anchors)
+			}
+
 			if !reflect.DeepEqual(d.Content, c.want) {
 				t.Fatalf("got %+v; want: %+v", d.Content, c.want)
 			}
@@ -461,6 +663,175 @@ func TestSetContent(t *testing.T) {
 	}
 }
 
+func TestSetContentImages(t *testing.T) {
+	body := `<html>
+		<head><title>Birds of Paris</title></head>
+		<body>
+			<img src="/logo.png">
+			<h2>The <em>pigeons</em></h2>
+			<a href="/pigeons/"><img src="https://cdn.example.com/pigeon.jpg" alt="A pigeon"></a>
+			<figure>
+				<img src="/sparrow.jpg">
+				<img src="/sparrows.jpg">
+				<figcaption>Two sparrows in the Tuileries</figcaption>
+			</figure>
+			<h3>Crows</h3>
+			<figure><img src="/crow.jpg"><!-- unclosed -->
+		</body>
+	</html>`
+
+	want := []*img.Image{
+		{
+			ID: "https://www.example.com/logo.png", Domain: "example.com",
+			Source: img.Source{Page: "https://www.example.com/birds", PageTitle: "Birds of Paris"},
+		},
+		{
+			ID: "https://cdn.example.com/pigeon.jpg", Domain: "example.com", Alt: "A pigeon",
+			Source: img.Source{
+				Page: "https://www.example.com/birds", PageTitle: "Birds of Paris",
+				Heading: "The pigeons", Link: "https://www.example.com/pigeons/",
+			},
+		},
+		{
+			ID: "https://www.example.com/sparrow.jpg", Domain: "example.com",
+			Source: img.Source{
+				Page: "https://www.example.com/birds", PageTitle: "Birds of Paris",
+				Caption: "Two sparrows in the Tuileries", Heading: "The pigeons",
+			},
+		},
+		{
+			ID: "https://www.example.com/sparrows.jpg", Domain: "example.com",
+			Source: img.Source{
+				Page: "https://www.example.com/birds", PageTitle: "Birds of Paris",
+				Caption: "Two sparrows in the Tuileries", Heading: "The pigeons",
+			},
+		},
+		{
+			ID: "https://www.example.com/crow.jpg", Domain: "example.com",
+			Source: img.Source{Page: "https://www.example.com/birds", PageTitle: "Birds of Paris", Heading: "Crows"},
+		},
+	}
+
+	d, err := New("https://www.example.com/birds")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.SetStatusCode(http.StatusOK).SetTokenizer(strings.NewReader(body)); err != nil {
+		t.Fatal(err)
+	}
+
+	links := make(chan string)
+	images := make(chan *img.Image)
+	collected := make(chan []*img.Image)
+
+	go func() {
+		for range links {
+		}
+	}()
+
+	go func() {
+		got := []*img.Image{}
+		for i := range images {
+			got = append(got, i)
+		}
+		collected <- got
+	}()
+
+	if err := d.SetContent("", -1, links, images, nil, 100, 5, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	close(links)
+	close(images)
+
+	got := <-collected
+	if len(got) != len(want) {
+		t.Fatalf("got %d images; want %d", len(got), len(want))
+	}
+
+	for i := range got {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Fatalf("got %+v; want %+v", got[i], want[i])
+		}
+	}
+}
+
+func TestSetFeedEntry(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		date      string
+		published time.Time
+		summary   string
+		want      Content
+	}{
+		{
+			name:      "basic",
+			published: time.Date(2018, 7, 2, 15, 4, 5, 0, time.UTC),
+			summary:   "  A summary  of the   post ",
+			want: Content{
+				Date:    "2018-07-02T15:04:05Z",
+				Summary: "A summary of",
+			},
+		},
+		{
+			name:      "date from content",
+			date:      "2018-06-30",
+			published: time.Date(2018, 7, 2, 15, 4, 5, 0, time.UTC),
+			want: Content{
+				Date: "2018-06-30",
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			d := &Document{}
+			d.Date = c.date
+			d.SetFeedEntry(c.published, c.summary, 12)
+
+			if !reflect.DeepEqual(d.Content, c.want) {
+				t.Fatalf("got %+v; want: %+v", d.Content, c.want)
+			}
+		})
+	}
+}
+
+func TestAlternate(t *testing.T) {
+	alternates := []Alternate{
+		{Lang: "en", URL: "https://example.com/"},
+		{Lang: "en-gb", URL: "https://example.co.uk/"},
+		{Lang: "fr", URL: "https://example.com/fr/"},
+		{Lang: "fr-ca", URL: "https://example.ca/fr/"},
+		{Lang: "x-default", URL: "https://example.com/"},
+	}
+
+	for _, c := range []struct {
+		name       string
+		id         string
+		alternates []Alternate
+		lang       language.Tag
+		region     language.Region
+		want       string
+	}{
+		{"same language", "https://example.com/", alternates, language.English, language.MustParseRegion("US"), ""},
+		{"region", "https://example.com/", alternates, language.English, language.MustParseRegion("GB"), "https://example.co.uk/"},
+		{"language", "https://example.com/", alternates, language.French, language.MustParseRegion("FR"), "https://example.com/fr/"},
+		{"language and region", "https://example.com/", alternates, language.French, language.MustParseRegion("CA"), "https://example.ca/fr/"},
+		{"no match", "https://example.com/", alternates, language.German, language.MustParseRegion("DE"), ""},
+		{"no self reference", "https://example.com/other", alternates[1:2], language.English, language.MustParseRegion("US"), ""},
+		{"no alternates", "https://example.com/", nil, language.French, language.MustParseRegion("FR"), ""},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			d := &Document{ID: c.id}
+			d.Alternates = c.alternates
+
+			got, ok := d.Alternate(c.lang, c.region)
+			if got != c.want || ok != (c.want != "") {
+				t.Fatalf("got %q, %v; want %q", got, ok, c.want)
+			}
+		})
+	}
+}
+
 func TestLanguages(t *testing.T) {
 	for _, c := range []struct {
 		name string
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/jivesearch/jivesearch/log"
+	"github.com/jivesearch/jivesearch/search/mapping"
 	"github.com/olivere/elastic"
 	"golang.org/x/text/language"
 )
@@ -65,6 +66,11 @@ func (e *ElasticSearch) Setup() error {
 			if _, err = e.Client.CreateIndex(idx).Body(e.mapping(a)).Do(context.TODO()); err != nil {
 				return err
 			}
+			continue
+		}
+
+		if err := mapping.Put(e.Client, idx, e.Type, e.mapping(a)); err != nil {
+			return err
 		}
 	}
 
@@ -118,7 +124,7 @@ func (e *ElasticSearch) mapping(a string) string {
 			}
 		},
 		"mappings": {
-			"document": {
+			"%v": {
 				"_all": {
 					"enabled": false
 				},
@@ -150,6 +156,24 @@ func (e *ElasticSearch) mapping(a string) string {
 							}
 						}
 					},
+					"anchors": {
+						"type": "text",
+						"fields": {
+							"lang": {
+								"type":     "text",
+								"analyzer": "%v" 
+							}
+						}
+					},
+					"summary": {
+						"type": "text",
+						"fields": {
+							"lang": {
+								"type":     "text",
+								"analyzer": "%v" 
+							}
+						}
+					},
 					"id": {
 						"type": "keyword"
 					},
@@ -169,6 +193,9 @@ func (e *ElasticSearch) mapping(a string) string {
 					"host": {
 						"type": "keyword"
 					},						
+					"ip": {
+						"type": "ip"
+					},
 					"path_parts": {
 						"type":  "text",
 						"analyzer": "path_analyzer"
@@ -191,13 +218,39 @@ func (e *ElasticSearch) mapping(a string) string {
 						"type": "text",
 						"index": "false"
 					},
+					"failures": {
+						"type": "short"
+					},
+					"stale": {
+						"type": "date",
+						"format": "basic_date"
+					},
+					"spam": {
+						"type": "half_float"
+					},
+					"redirect": {
+						"type": "keyword"
+					},
+					"aliases": {
+						"type": "keyword"
+					},
+					"alternates": {
+						"properties": {
+							"lang": {
+								"type": "keyword"
+							},
+							"url": {
+								"type": "keyword"
+							}
+						}
+					},
 					"mime": {
 						"type": "keyword"
 					}
 				}
 			}
 		}
-	}`, a, a)
+	}`, e.Type, a, a, a, a)
 
 	return m
 }
@@ -287,3 +340,17 @@ func init() {
 	langAnalyzer[language.TraditionalChinese] = "cjk" //  zh-Hant
 	//langAnalyzer[language.Zulu] = ""                       //  zu
 }
+
+// HealthCheck makes sure the cluster can serve our documents
+func (e *ElasticSearch) HealthCheck(ctx context.Context) error {
+	res, err := e.Client.ClusterHealth().Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	if res.Status == "red" { // yellow is fine as we can still search
+		return fmt.Errorf("elasticsearch cluster %q is red", res.ClusterName)
+	}
+
+	return nil
+}
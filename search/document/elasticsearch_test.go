@@ -1,8 +1,11 @@
 package document
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/olivere/elastic"
@@ -62,28 +65,45 @@ func TestAnalyzer(t *testing.T) {
 
 func TestSetup(t *testing.T) {
 	for _, c := range []struct {
-		name   string
-		status int
-		resp   string
+		name    string
+		exists  bool
+		path    string // of the request that maps our english index
+		want    []string
+		notWant []string
 	}{
 		{
-			name:   "ok",
-			status: http.StatusOK,
-			resp:   `{"acknowledged": true}`,
+			"new index", false, "/search-english",
+			[]string{`"settings"`, `"dynamic":"strict"`, `"spam"`},
+			[]string{},
+		},
+		{
+			"existing index", true, "/search-english/_mapping/document",
+			[]string{`"dynamic":"strict"`, `"alternates"`, `"anchors"`, `"redirect"`, `"stale"`, `"ip"`, `"spam"`},
+			[]string{`"settings"`, `"mappings"`},
 		},
 	} {
 		t.Run(c.name, func(t *testing.T) {
-			handler := http.NotFound
+			var mu sync.Mutex
+			bodies := map[string]string{}
+
 			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				handler(w, r)
+				switch r.Method {
+				case "HEAD":
+					if !c.exists {
+						http.NotFound(w, r)
+						return
+					}
+				case "PUT":
+					b, _ := ioutil.ReadAll(r.Body)
+					mu.Lock()
+					bodies[r.URL.Path] = strings.Join(strings.Fields(string(b)), "")
+					mu.Unlock()
+				}
+
+				w.Write([]byte(`{"acknowledged": true}`))
 			}))
 			defer ts.Close()
 
-			handler = func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(c.status)
-				w.Write([]byte(c.resp))
-			}
-
 			e, err := MockService(ts.URL)
 			if err != nil {
 				t.Fatal(err)
@@ -92,6 +112,27 @@ func TestSetup(t *testing.T) {
 			if err := e.Setup(); err != nil {
 				t.Fatal(err)
 			}
+
+			body, ok := bodies[c.path]
+			if !ok {
+				t.Fatalf("expected a request to %v; got %v", c.path, bodies)
+			}
+
+			if len(bodies) != 33 {
+				t.Fatalf("got %d requests; want one per analyzer", len(bodies))
+			}
+
+			for _, w := range c.want {
+				if !strings.Contains(body, w) {
+					t.Fatalf("expected %v in %v", w, body)
+				}
+			}
+
+			for _, w := range c.notWant {
+				if strings.Contains(body, w) {
+					t.Fatalf("didn't expect %v in %v", w, body)
+				}
+			}
 		})
 	}
 }
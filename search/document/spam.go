@@ -0,0 +1,232 @@
+package document
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// spam collects the signals of a spammy or parked page while we parse it.
+// The rules are deliberately conservative as a false positive hides a good page.
+type spam struct {
+	words       int // visible words in the <body>
+	linkWords   int // words inside of links
+	hiddenWords int // words in elements hidden with css or the hidden attribute
+	links       int
+	external    int // links to another domain
+	ads         int // links to parking & ad networks
+	freq        map[string]int
+	hiddenTag   string // the element that hides its content (if any)
+	hiddenDepth int
+}
+
+const (
+	minSpamWords     = 50  // fewer words than this isn't enough to judge density or ratios
+	maxDensity       = .08 // share of the most frequent word
+	maxLinkRatio     = .7  // share of the words that are links
+	maxHiddenRatio   = .2  // share of the words that are hidden
+	maxExternalLinks = 100
+)
+
+// parkedPhrases are common to parked domains but also show up on
+// legitimate pages (e.g. "related searches", an article about domain
+// parking or a post that quotes a "this domain is for sale" page).
+// They only count on a tiny page that links to an ad network.
+var parkedPhrases = []string{
+	"this domain is for sale",
+	"this domain may be for sale",
+	"the domain owner may be",
+	"domain is parked",
+	"this web page is parked",
+	"inquire about this domain",
+	"buy this domain",
+	"parked free",
+	"parked domain",
+	"domain parking",
+	"related searches",
+}
+
+// adNetworks are the domains that parked pages link to for their ads & sales
+var adNetworks = map[string]bool{
+	"above.com":             true,
+	"afternic.com":          true,
+	"bodis.com":             true,
+	"dan.com":               true,
+	"doubleclick.net":       true,
+	"domainsponsor.com":     true,
+	"googlesyndication.com": true,
+	"hugedomains.com":       true,
+	"parkingcrew.net":       true,
+	"parklogic.com":         true,
+	"sedo.com":              true,
+	"sedoparking.com":       true,
+	"smartname.com":         true,
+}
+
+// hides reports if a start tag hides its content
+func hides(style string, hidden bool) bool {
+	if hidden {
+		return true
+	}
+
+	s := strings.Replace(strings.ToLower(style), " ", "", -1)
+	for _, h := range []string{"display:none", "visibility:hidden", "font-size:0;", "font-size:0px"} {
+		if strings.Contains(s, h) {
+			return true
+		}
+	}
+
+	return strings.HasSuffix(s, "font-size:0")
+}
+
+// void elements have no end tag (and no content to hide)
+var void = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// start is called for each start tag (that isn't self-closing)
+func (s *spam) start(tag string, hidden bool) {
+	switch {
+	case void[tag]:
+	case s.hiddenTag == "" && hidden:
+		s.hiddenTag, s.hiddenDepth = tag, 1
+	case s.hiddenTag == tag:
+		s.hiddenDepth++
+	}
+}
+
+// end is called for each end tag
+func (s *spam) end(tag string) {
+	if s.hiddenTag != tag {
+		return
+	}
+
+	if s.hiddenDepth--; s.hiddenDepth == 0 {
+		s.hiddenTag = ""
+	}
+}
+
+func (s *spam) addText(txt string, link bool) {
+	words := strings.FieldsFunc(strings.ToLower(txt), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	if s.hiddenTag != "" {
+		s.hiddenWords += len(words)
+		return
+	}
+
+	s.words += len(words)
+	if link {
+		s.linkWords += len(words)
+	}
+
+	if s.freq == nil {
+		s.freq = make(map[string]int)
+	}
+
+	for _, w := range words {
+		if len(w) > 3 { // skip "the", "and", etc
+			s.freq[w]++
+		}
+	}
+}
+
+// addLink counts a link by the domain it points to ("" for our own domain)
+func (s *spam) addLink(domain string) {
+	s.links++
+	if domain == "" {
+		return
+	}
+
+	s.external++
+	if adNetworks[domain] {
+		s.ads++
+	}
+}
+
+// external returns the domain of a link that points to another domain
+func (d *Document) external(href string) string {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+
+	u = d.URL.ResolveReference(u)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+
+	dom, err := ExtractDomain(u)
+	if err != nil || dom == d.Domain {
+		return ""
+	}
+
+	return dom
+}
+
+// score combines the signals into a score from 0 (not spam) to 1 (spam).
+// text is the beginning of the page (title, description & body).
+func (s *spam) score(text string) float64 {
+	var score float64
+
+	text = strings.ToLower(text)
+	if s.parked(text) {
+		score += .6
+	}
+
+	total := s.words + s.hiddenWords
+	if total >= minSpamWords {
+		if r := float64(s.hiddenWords) / float64(total); r > maxHiddenRatio {
+			score += .3
+		}
+	}
+
+	if s.words >= minSpamWords {
+		// keyword stuffing
+		var top int
+		for _, n := range s.freq {
+			if n > top {
+				top = n
+			}
+		}
+
+		if d := float64(top) / float64(s.words); d > maxDensity {
+			score += min((d-maxDensity)*5, .4)
+		}
+
+		// link farms and doorway pages are mostly links
+		if r := float64(s.linkWords) / float64(s.words); r > maxLinkRatio {
+			score += .2
+		}
+	}
+
+	if s.external > maxExternalLinks {
+		score += .2
+	}
+
+	return min(score, 1)
+}
+
+// parked reports if a page is the template of a parked domain
+func (s *spam) parked(text string) bool {
+	if s.words >= minSpamWords || s.ads == 0 {
+		return false
+	}
+
+	for _, p := range parkedPhrases {
+		if strings.Contains(text, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func min(x, y float64) float64 {
+	if x < y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,147 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSpam(t *testing.T) {
+	article := `Jimi Hendrix was an American rock guitarist, singer and songwriter.
+		Although his mainstream career spanned only four years, he is widely regarded
+		as one of the most influential electric guitarists in the history of popular music,
+		and one of the most celebrated musicians of the 20th century. The Rock and Roll
+		Hall of Fame describes him as arguably the greatest instrumentalist in the history
+		of rock music. Born in Seattle, Washington, he began playing guitar at the age of 15.`
+
+	var farm strings.Builder
+	for i := 0; i < 120; i++ {
+		fmt.Fprintf(&farm, `<a href="http://site%d.com/">casino</a> `, i)
+	}
+
+	for _, c := range []struct {
+		name string
+		body string
+		min  float64
+		max  float64
+	}{
+		{
+			name: "article",
+			body: `<html><head><title>Jimi Hendrix</title></head><body><p>` + article +
+				`</p><a href="/bio">Biography</a> <a href="https://en.wikipedia.org/">Wikipedia</a></body></html>`,
+			min: 0, max: 0,
+		},
+		{
+			name: "hidden input",
+			body: `<html><body><form><input type="hidden" hidden name="q"></form><p>` + article + `</p></body></html>`,
+			min:  0, max: 0,
+		},
+		{
+			name: "parked",
+			body: `<html><head><title>example.com</title></head><body><h1>This domain is for sale!</h1>
+				<a href="https://sedo.com/search/details/?domain=example.com">Make an offer</a></body></html>`,
+			min: .6, max: .6,
+		},
+		{
+			name: "for sale without ads",
+			body: `<html><head><title>example.com</title></head><body><h1>This domain is for sale!</h1>
+				<a href="mailto:owner@example.com">Contact the owner</a></body></html>`,
+			min: 0, max: 0,
+		},
+		{
+			name: "about a domain for sale",
+			body: `<html><head><title>Selling a domain</title></head><body><p>` + article + `</p>
+				<p>The old fan site now says "this domain is for sale" with a link to
+				<a href="https://sedo.com/">Sedo</a>.</p></body></html>`,
+			min: 0, max: 0,
+		},
+		{
+			name: "parked related searches",
+			body: `<html><head><title>example.com</title></head><body><h1>Related Searches</h1>
+				<a href="http://www.sedoparking.com/search?q=guitars">Guitars</a></body></html>`,
+			min: .6, max: .6,
+		},
+		{
+			name: "related searches",
+			body: `<html><head><title>Jimi Hendrix</title></head><body><p>` + article + `</p>
+				<h2>Related searches</h2><a href="/bio">Biography</a>
+				<a href="https://pagead2.googlesyndication.com/">Ad</a></body></html>`,
+			min: 0, max: 0,
+		},
+		{
+			name: "tiny page without ads",
+			body: `<html><head><title>Guitars</title></head><body><h1>Related searches</h1>
+				<a href="/guitars">Guitars</a> <a href="https://en.wikipedia.org/">Wikipedia</a></body></html>`,
+			min: 0, max: 0,
+		},
+		{
+			name: "about domain parking",
+			body: `<html><head><title>What is domain parking?</title></head><body><p>Domain parking
+				is the registration of a domain without using it for services such as email or a website.
+				A parked domain usually shows ads and a link to buy this domain. Registrars offer it when
+				a domain is registered but its owner hasn't built a site yet, and marketplaces such as
+				<a href="https://sedo.com/">Sedo</a> list parked domains for sale to anyone that wants one.</p></body></html>`,
+			min: 0, max: 0,
+		},
+		{
+			name: "keyword stuffing",
+			body: `<html><body><p>` + strings.Repeat("cheap watches buy cheap watches online ", 20) + `</p></body></html>`,
+			min:  .4, max: .4,
+		},
+		{
+			name: "hidden text",
+			body: `<html><body><p>` + article + `</p><div style="DISPLAY: none"><div>` +
+				strings.Repeat("best loans cheap insurance fast money ", 10) + `</div></div><p>Thanks for reading</p></body></html>`,
+			min: .3, max: .3,
+		},
+		{
+			name: "link farm",
+			body: `<html><body>` + farm.String() + `</body></html>`,
+			min:  .8, max: 1,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			d, err := New("http://www.example.com/")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			d.SetPolicyFromHeader("")
+			if err := d.SetTokenizer(strings.NewReader(c.body)); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := d.SetContent("", 0, nil, nil, nil, 100, 25, 250); err != nil {
+				t.Fatal(err)
+			}
+
+			if d.Spam < c.min || d.Spam > c.max {
+				t.Fatalf("got %v; want %v to %v", d.Spam, c.min, c.max)
+			}
+		})
+	}
+}
+
+func TestHides(t *testing.T) {
+	for _, c := range []struct {
+		style  string
+		hidden bool
+		want   bool
+	}{
+		{"", false, false},
+		{"", true, true},
+		{"color: red", false, false},
+		{"display:none", false, true},
+		{"Visibility: Hidden;", false, true},
+		{"font-size: 0", false, true},
+		{"font-size: 0px; color: #fff", false, true},
+		{"font-size: 0.9em", false, false},
+		{"font-size: 10px", false, false},
+	} {
+		t.Run(c.style, func(t *testing.T) {
+			if got := hides(c.style, c.hidden); got != c.want {
+				t.Fatalf("got %v; want %v", got, c.want)
+			}
+		})
+	}
+}
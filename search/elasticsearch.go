@@ -3,6 +3,7 @@ package search
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"strings"
 
 	"github.com/jivesearch/jivesearch/search/document"
@@ -13,15 +14,24 @@ import (
 // ElasticSearch embeds our main Elasticsearch instance
 type ElasticSearch struct {
 	*document.ElasticSearch
+	MaxSpam float64 // documents with a higher spam score are filtered out (0 to keep them all)
 }
 
+// demoteScript scales the score of a document down by its spam score.
+// Documents crawled before we had a spam score aren't demoted.
+const demoteScript = `doc['spam'].size() == 0 ? 1 : 1 - doc['spam'].value`
+
 // Fetch returns search results for a search query
 // https://www.elastic.co/guide/en/elasticsearch/guide/current/one-lang-docs.html
 // https://www.elastic.co/guide/en/elasticsearch/guide/current/_single_query_string.html#know-your-data
-// The idea here is to first filter out docs that do not want to be indexed.
+// The idea here is to first filter out docs that do not want to be indexed,
+// links that permanently redirect elsewhere, stale docs (dead for too long or gone)
+// and docs whose spam score is too high. Docs that are somewhat spammy are demoted.
 // We then search multiple fields for the search query, giving more weight to certain fields.
 // We also are searching the standard analyzer and the language-specific analyzer.
 // We weight the domain > path, path > title, title > description.
+// The anchor text of inbound links gets a boost of its own as it often describes
+// a page better than the page's own title does.
 // We also give extra weight for bigram matches (need trigram????):
 // https://www.elastic.co/guide/en/elasticsearch/guide/current/shingles.html
 // Note: "It is not useful to mix not_analyzed fields with analyzed fields in multi_match queries."
@@ -29,13 +39,13 @@ type ElasticSearch struct {
 func (e *ElasticSearch) Fetch(q string, filter Filter, lang language.Tag, region language.Region, number int, offset int) (*Results, error) {
 	res := &Results{}
 
-	qu := elastic.NewBoolQuery().
-		Filter(elastic.NewTermQuery("index", true)).
+	qu := e.filter().
 		Must(
 			elastic.NewMultiMatchQuery(
 				q,
 				"domain^3", "path^2",
 				"title^1.5", "title.lang^1.5",
+				"anchors^1.25", "anchors.lang^1.25",
 				"description", "description.lang",
 			).Type("cross_fields").MinimumShouldMatch("-25%"),
 		).
@@ -56,6 +66,11 @@ func (e *ElasticSearch) Fetch(q string, filter Filter, lang language.Tag, region
 		}
 	}
 
+	fq := elastic.NewFunctionScoreQuery().
+		Query(qu).
+		AddScoreFunc(elastic.NewScriptFunction(elastic.NewScript(demoteScript).Lang("painless"))).
+		BoostMode("multiply")
+
 	a, err := e.Analyzer(lang)
 	if err != nil {
 		return res, err
@@ -63,7 +78,7 @@ func (e *ElasticSearch) Fetch(q string, filter Filter, lang language.Tag, region
 
 	idx := e.IndexName(a)
 
-	out, err := e.Client.Search().Index(idx).Type(e.Type).Query(qu).From(offset).Size(number).Do(context.TODO())
+	out, err := e.Client.Search().Index(idx).Type(e.Type).Query(fq).From(offset).Size(number).Do(context.TODO())
 	if err != nil {
 		return res, err
 	}
@@ -91,5 +106,77 @@ func (e *ElasticSearch) Fetch(q string, filter Filter, lang language.Tag, region
 		res.Documents = append(res.Documents, doc)
 	}
 
+	err = e.swapAlternates(res, lang, region)
 	return res, err
 }
+
+// filter leaves out the documents that don't want to be indexed, links that
+// permanently redirect elsewhere, stale documents and those that are too spammy
+func (e *ElasticSearch) filter() *elastic.BoolQuery {
+	qu := elastic.NewBoolQuery().
+		Filter(elastic.NewTermQuery("index", true)).
+		MustNot(
+			elastic.NewTermsQuery("status", http.StatusMovedPermanently, http.StatusPermanentRedirect),
+			elastic.NewExistsQuery("stale"),
+		)
+
+	if e.MaxSpam > 0 {
+		qu = qu.MustNot(elastic.NewRangeQuery("spam").Gt(e.MaxSpam))
+	}
+
+	return qu
+}
+
+// swapAlternates swaps a document for its hreflang alternate when the alternate
+// is a better match for the user's language or region (e.g. the fr-CA version
+// of a page for a Canadian user). The alternate may live in another
+// language's index so we search all of them. An alternate is filtered
+// the same way the results are.
+func (e *ElasticSearch) swapAlternates(res *Results, lang language.Tag, region language.Region) error {
+	seen := map[string]bool{}
+	for _, doc := range res.Documents {
+		seen[doc.ID] = true
+	}
+
+	swaps := map[string]int{}
+	ids := []string{}
+
+	for i, doc := range res.Documents {
+		lnk, ok := doc.Alternate(lang, region)
+		if !ok || seen[lnk] {
+			continue
+		}
+
+		seen[lnk] = true
+		swaps[lnk] = i
+		ids = append(ids, lnk)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	qu := e.filter().Filter(elastic.NewIdsQuery(e.Type).Ids(ids...))
+
+	out, err := e.Client.Search().Index(e.Index + "-*").Type(e.Type).Query(qu).Size(len(ids)).Do(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	for _, h := range out.Hits.Hits {
+		i, ok := swaps[h.Id]
+		if !ok {
+			continue
+		}
+
+		doc := &document.Document{}
+		if err := json.Unmarshal(*h.Source, doc); err != nil {
+			return err
+		}
+
+		doc.ID = h.Id
+		res.Documents[i] = doc
+	}
+
+	return nil
+}
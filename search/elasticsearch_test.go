@@ -1,9 +1,11 @@
 package search
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/jivesearch/jivesearch/search/document"
@@ -196,6 +198,121 @@ func TestFetch(t *testing.T) {
 	}
 }
 
+func TestSwapAlternates(t *testing.T) {
+	responses := []string{
+		`{
+			"hits": {
+				"total": 2,
+				"hits": [
+					{
+						"_id": "https://example.com/",
+						"_source": {
+							"title": "English",
+							"alternates": [
+								{"lang": "en", "url": "https://example.com/"},
+								{"lang": "fr", "url": "https://example.com/fr/"}
+							]
+						}
+					},
+					{
+						"_id": "https://another.com/",
+						"_source": {"title": "No alternates"}
+					}
+				]
+			}
+		}`,
+		`{
+			"hits": {
+				"total": 1,
+				"hits": [
+					{
+						"_id": "https://example.com/fr/",
+						"_source": {"title": "Français"}
+					}
+				]
+			}
+		}`,
+	}
+
+	var i int
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(responses[i]))
+		i++
+	}))
+	defer ts.Close()
+
+	e, err := MockService(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.MaxSpam = .8
+
+	got, err := e.Fetch("example", Moderate, language.French, language.MustParseRegion("FR"), 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"https://example.com/fr/", "https://another.com/"}
+	for j, doc := range got.Documents {
+		if doc.ID != want[j] {
+			t.Fatalf("got %q; want %q", doc.ID, want[j])
+		}
+	}
+
+	if got.Documents[0].Title != "Français" {
+		t.Fatalf("got %q; want %q", got.Documents[0].Title, "Français")
+	}
+
+	// the alternate is filtered like the results
+	for _, w := range []string{`"ids"`, `"index":true`, `"status":[301,308]`, `"exists":{"field":"stale"}`, `"range":{"spam":{"from":0.8`} {
+		if !strings.Contains(body, w) {
+			t.Fatalf("expected %v in %v", w, body)
+		}
+	}
+}
+
+func TestFetchSpam(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		maxSpam float64
+		filter  bool
+	}{
+		{"filter", .8, true},
+		{"demote only", 0, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var body string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				body = string(b)
+				w.Write([]byte(`{"hits": {"total": 0, "hits": []}}`))
+			}))
+			defer ts.Close()
+
+			e, err := MockService(ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.MaxSpam = c.maxSpam
+
+			if _, err := e.Fetch("example", Moderate, language.English, language.MustParseRegion("US"), 10, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(body, `"function_score"`) || !strings.Contains(body, demoteScript) {
+				t.Fatalf("spam isn't demoted: %v", body)
+			}
+
+			if got := strings.Contains(body, `"range":{"spam":{"from":0.8`); got != c.filter {
+				t.Fatalf("got spam filter %v; want %v: %v", got, c.filter, body)
+			}
+		})
+	}
+}
+
 func MockService(url string) (*ElasticSearch, error) {
 	client, err := elastic.NewSimpleClient(elastic.SetURL(url))
 	if err != nil {
@@ -0,0 +1,227 @@
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Classifier scores how likely images are to be NSFW and labels what they show.
+// It sets the NSFW score & Classification of each image and returns an error
+// for each image (nil if it was classified) so that one bad image doesn't
+// fail the others.
+type Classifier interface {
+	Classify(images []*Image) []error
+}
+
+// ClassifyError is an image we were unable to classify
+type ClassifyError struct {
+	ID       string
+	Attempts int
+	Err      error
+}
+
+func (e *ClassifyError) Error() string {
+	return fmt.Sprintf("unable to classify %v after %d attempt(s): %v", e.ID, e.Attempts, e.Err)
+}
+
+// Batcher classifies images in batches and retries the images that failed
+type Batcher struct {
+	Classifier
+	Size    int           // max images per call to the Classifier
+	Retries int           // retries of an image after the first attempt
+	Backoff time.Duration // wait before a retry, multiplied by the attempt
+}
+
+// Classify classifies images in batches of Size. The errors are *ClassifyErrors.
+func (b *Batcher) Classify(images []*Image) []error {
+	errs := make([]error, len(images))
+
+	size := b.Size
+	if size < 1 {
+		size = len(images)
+	}
+
+	for start := 0; start < len(images); start += size {
+		end := start + size
+		if end > len(images) {
+			end = len(images)
+		}
+
+		b.batch(images[start:end], errs[start:end])
+	}
+
+	return errs
+}
+
+// batch classifies a batch of images and puts their errors in errs
+func (b *Batcher) batch(images []*Image, errs []error) {
+	pending := make([]int, len(images)) // the indexes of the images not classified yet
+	for i := range pending {
+		pending[i] = i
+	}
+
+	for attempt := 1; len(pending) > 0; attempt++ {
+		if attempt > 1 {
+			time.Sleep(b.Backoff * time.Duration(attempt-1))
+		}
+
+		batch := make([]*Image, len(pending))
+		for j, i := range pending {
+			batch[j] = images[i]
+		}
+
+		failed := []int{}
+		for j, err := range b.Classifier.Classify(batch) {
+			i := pending[j]
+			if err == nil {
+				errs[i] = nil
+				continue
+			}
+
+			errs[i] = &ClassifyError{ID: images[i].ID, Attempts: attempt, Err: err}
+			failed = append(failed, i)
+		}
+
+		if attempt > b.Retries {
+			return
+		}
+
+		pending = failed
+	}
+}
+
+// HTTPClassifier classifies images with a web service (e.g. Yahoo's open_nsfw
+// and TensorFlow's imagenet in search/image/cmd/classifier.py).
+// The service is passed the url of an image ("?image=https://...") and returns
+// {"nsfw_score": .1, "classification": {"punching bag, punch bag": .8}}
+type HTTPClassifier struct {
+	*http.Client
+	Host string
+}
+
+// Classify classifies each image with the service
+func (h *HTTPClassifier) Classify(images []*Image) []error {
+	errs := make([]error, len(images))
+	for i, im := range images {
+		errs[i] = h.classify(im)
+	}
+
+	return errs
+}
+
+func (h *HTTPClassifier) classify(i *Image) error {
+	resp, err := h.Client.Get(h.Host + "?image=" + url.QueryEscape(i.ID))
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("classifier returned status %d", resp.StatusCode)
+	}
+
+	im := &Image{}
+	if err := json.NewDecoder(resp.Body).Decode(im); err != nil {
+		return err
+	}
+
+	i.NSFW = math.Round(im.NSFW/.0001) / 10000
+	i.Classification = separateKeys(im.Classification)
+	return nil
+}
+
+// separateKeys turns "punching bag, punch bag" to 2 items
+// In case of duplicate keys we take that with highest value
+func separateKeys(c map[string]float64) map[string]float64 {
+	m := map[string]float64{}
+	for key, val := range c {
+		rounded := math.Round(val/.001) / 1000
+		for _, s := range strings.Split(key, ",") {
+			s = strings.TrimSpace(s)
+			if v, ok := m[s]; ok {
+				if v >= rounded {
+					continue
+				}
+			}
+			m[s] = rounded
+		}
+	}
+
+	return m
+}
+
+// Heuristic is a local stand-in for a real classifier. It doesn't label
+// images and its NSFW score is only a rough guess from the share of skin
+// tones (set by Decode) and words in the domain and alt text of an image.
+// Useful for development & testing without the classifier service.
+type Heuristic struct{}
+
+// nsfwWords are signs of adult content in a domain or alt text
+var nsfwWords = []string{
+	"porn", "xxx", "sex", "nude", "naked", "nsfw", "hentai", "erotic",
+}
+
+// nsfwStems are the nsfwWords that are seldom part of other words so
+// they count even when words are run together ("freexxxpics.com").
+// The others only count as a whole label ("sex.example.com" but not "essex.ac.uk").
+var nsfwStems = []string{"porn", "xxx", "nsfw", "hentai"}
+
+// the weights of the signals of the Heuristic
+const (
+	skinWeight   = .6
+	maxSkin      = .6 // share of skin tones that maxes out the skin signal
+	domainWeight = .3
+	altWeight    = .2
+)
+
+// Classify scores the images. It never fails.
+func (h *Heuristic) Classify(images []*Image) []error {
+	errs := make([]error, len(images))
+
+	for _, i := range images {
+		score := skinWeight * math.Min(i.Skin/maxSkin, 1)
+
+		domain := strings.ToLower(i.Domain)
+		labels := strings.FieldsFunc(domain, func(r rune) bool { return r == '.' || r == '-' })
+		if hasWord(labels, nsfwWords) || containsAny(domain, nsfwStems) {
+			score += domainWeight
+		}
+
+		if hasWord(strings.Fields(strings.ToLower(i.Alt)), nsfwWords) {
+			score += altWeight
+		}
+
+		i.NSFW = math.Round(math.Min(score, 1)/.0001) / 10000
+	}
+
+	return errs
+}
+
+func containsAny(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasWord(fields []string, words []string) bool {
+	for _, f := range fields {
+		f = strings.Trim(f, ".,;:!?\"'()")
+		for _, w := range words {
+			if f == w {
+				return true
+			}
+		}
+	}
+
+	return false
+}
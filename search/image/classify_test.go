@@ -0,0 +1,134 @@
+package image
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBatcher(t *testing.T) {
+	images := []*Image{{ID: "a"}, {ID: "flaky"}, {ID: "b"}, {ID: "broken"}, {ID: "c"}}
+
+	m := &mockClassifier{attempts: map[string]int{}}
+	b := &Batcher{Classifier: m, Size: 2, Retries: 2}
+
+	errs := b.Classify(images)
+
+	got := []string{}
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		ce, ok := err.(*ClassifyError)
+		if !ok {
+			t.Fatalf("got %T; want *ClassifyError", err)
+		}
+		got = append(got, fmt.Sprintf("%v:%v:%d", i, ce.ID, ce.Attempts))
+	}
+
+	want := []string{"3:broken:3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got errors %v; want %v", got, want)
+	}
+
+	wantBatches := [][]string{{"a", "flaky"}, {"flaky"}, {"b", "broken"}, {"broken"}, {"broken"}, {"c"}}
+	if !reflect.DeepEqual(m.batches, wantBatches) {
+		t.Fatalf("got batches %v; want %v", m.batches, wantBatches)
+	}
+
+	for _, im := range images {
+		if im.ID != "broken" && im.NSFW != .5 {
+			t.Fatalf("%v wasn't classified", im.ID)
+		}
+	}
+}
+
+func TestHTTPClassifier(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("image") {
+		case "https://www.example.com/bag.jpg?size=large":
+			w.Write([]byte(`{"nsfw_score": 0.123456, "classification": {"punching bag, punch bag": 0.81234, "punch bag": 0.5}}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	h := &HTTPClassifier{Client: &http.Client{}, Host: ts.URL}
+
+	images := []*Image{
+		{ID: "https://www.example.com/bag.jpg?size=large"},
+		{ID: "https://www.example.com/error.jpg"},
+	}
+
+	errs := h.Classify(images)
+	if errs[0] != nil || errs[1] == nil {
+		t.Fatalf("got errors %v", errs)
+	}
+
+	want := &Image{
+		ID:             "https://www.example.com/bag.jpg?size=large",
+		NSFW:           .1235,
+		Classification: map[string]float64{"punching bag": .812, "punch bag": .812},
+	}
+
+	if !reflect.DeepEqual(images[0], want) {
+		t.Fatalf("got %+v; want %+v", images[0], want)
+	}
+}
+
+func TestHeuristic(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		*Image
+		want float64
+	}{
+		{"nothing", &Image{Domain: "example.com", Alt: "a cat"}, 0},
+		{"some skin", &Image{Domain: "example.com", Skin: .3}, .3},
+		{"all signals", &Image{Domain: "freexxxpics.com", Alt: "Nude!", Skin: .9}, 1},
+		{"word in alt", &Image{Domain: "example.com", Alt: "nudes and more", Skin: .6}, .6},
+		{"domain", &Image{Domain: "pornhub.com", Skin: .6}, .9},
+		{"word in domain", &Image{Domain: "free-sex-pics.com", Skin: .6}, .9},
+		{"part of a word in domain", &Image{Domain: "essex.ac.uk", Skin: .6}, .6},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			errs := (&Heuristic{}).Classify([]*Image{c.Image})
+			if errs[0] != nil {
+				t.Fatal(errs[0])
+			}
+
+			if c.Image.NSFW != c.want {
+				t.Fatalf("got %v; want %v", c.Image.NSFW, c.want)
+			}
+		})
+	}
+}
+
+// mockClassifier fails "flaky" on its 1st attempt & "broken" always
+type mockClassifier struct {
+	attempts map[string]int
+	batches  [][]string
+}
+
+func (m *mockClassifier) Classify(images []*Image) []error {
+	errs := make([]error, len(images))
+	ids := []string{}
+
+	for i, im := range images {
+		ids = append(ids, im.ID)
+		m.attempts[im.ID]++
+
+		if im.ID == "broken" || (im.ID == "flaky" && m.attempts[im.ID] == 1) {
+			errs[i] = fmt.Errorf("oops")
+			continue
+		}
+
+		im.NSFW = .5
+	}
+
+	m.batches = append(m.batches, ids)
+	return errs
+}
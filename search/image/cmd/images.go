@@ -2,15 +2,13 @@ package main
 
 import (
 	"context"
-	"io/ioutil"
-	"math"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
-	"time"
-
-	"encoding/json"
 	"sync"
+	"time"
 
 	"github.com/jivesearch/jivesearch/config"
 	"github.com/jivesearch/jivesearch/log"
@@ -20,12 +18,19 @@ import (
 )
 
 type conf struct {
-	e       *img.ElasticSearch
-	workers int
-	client  *http.Client
-	host    string
-	since   time.Time
-	ch      chan *img.Image
+	e          *img.ElasticSearch
+	workers    int
+	client     *http.Client
+	classifier img.Classifier
+	thumbnails *img.Thumbnailer
+	batch      int
+	useragent  string
+	maxBytes   int64
+	since      time.Time
+	ch         chan []*img.Image
+
+	sync.Mutex
+	failed map[string]bool // the images we couldn't classify during this run
 }
 
 var c *conf
@@ -41,7 +46,7 @@ func afterFn(executionID int64, requests []elastic.BulkableRequest, resp *elasti
 	}
 
 	if err != nil {
-		panic(err)
+		log.Info.Printf("bulk request failed: %v\n", err)
 	}
 }
 
@@ -55,14 +60,46 @@ func setup(v *viper.Viper) {
 		log.Debug.SetOutput(os.Stdout)
 	}
 
+	client := &http.Client{
+		Timeout: 25 * time.Second,
+	}
+
+	var classifier img.Classifier = &img.HTTPClassifier{
+		Client: client,
+		Host:   v.GetString("nsfw.host"),
+	}
+
+	if v.GetString("nsfw.classifier") == "heuristic" {
+		classifier = &img.Heuristic{}
+	}
+
+	batch := v.GetInt("nsfw.batch")
+	if batch < 1 {
+		batch = 1
+	}
+
 	c = &conf{
 		workers: v.GetInt("nsfw.workers"),
-		client: &http.Client{
-			Timeout: 25 * time.Second,
+		client:  client,
+		classifier: &img.Batcher{
+			Classifier: classifier,
+			Size:       batch,
+			Retries:    v.GetInt("nsfw.retries"),
+			Backoff:    v.GetDuration("nsfw.backoff"),
 		},
-		host:  v.GetString("nsfw.host"),
-		since: v.GetTime("nsfw.since"),
-		ch:    make(chan *img.Image),
+		batch:     batch,
+		useragent: v.GetString("useragent"),
+		maxBytes:  int64(v.GetInt("images.max.bytes")),
+		since:     v.GetTime("nsfw.since"),
+		ch:        make(chan []*img.Image),
+	}
+
+	if dir := v.GetString("images.thumbnail.dir"); dir != "" {
+		c.thumbnails = &img.Thumbnailer{
+			Store:   &img.DiskStore{Dir: dir},
+			Size:    v.GetInt("images.thumbnail.size"),
+			Quality: v.GetInt("images.thumbnail.quality"),
+		}
 	}
 }
 
@@ -100,15 +137,8 @@ func main() {
 		go func(w int) {
 			defer wg.Done()
 
-			for lnk := range c.ch {
-				im, err := c.fetchImage(lnk)
-				if err != nil {
-					log.Info.Println(err)
-				}
-
-				if err = c.e.Upsert(im); err != nil {
-					panic(err)
-				}
+			for images := range c.ch {
+				c.process(images)
 			}
 		}(worker)
 	}
@@ -116,30 +146,30 @@ func main() {
 	for {
 		images, err := c.e.Uncrawled(10000, c.since)
 		if err != nil {
-			panic(err)
+			log.Info.Println(err)
+			break
 		}
 
+		// the images that failed are retried on the next run
+		images = c.notFailed(images)
+
 		if len(images) < 100 { // otherwise will keep crawling same links even w/ a manual flush & refresh.
 			break
 		}
 
-		for _, im := range images {
-			log.Info.Println(im.ID)
-			c.ch <- im
+		for start := 0; start < len(images); start += c.batch {
+			end := start + c.batch
+			if end > len(images) {
+				end = len(images)
+			}
+			c.ch <- images[start:end]
 		}
 
 		// flush & refresh so that we don't keep recrawling
 		// images if len(images) is < bulk refresh rate.
-		if err = c.e.Bulk.Flush(); err != nil {
-			panic(err)
-		}
-
-		if _, err = c.e.Client.Flush().Index(c.e.Index).Do(context.Background()); err != nil {
-			panic(err)
-		}
-
-		if _, err = c.e.Client.Refresh().Index(c.e.Index).Do(context.Background()); err != nil {
-			panic(err)
+		if err := c.refresh(); err != nil {
+			log.Info.Println(err)
+			break
 		}
 
 		// not sure how to use the "wait_for" for indices refresh
@@ -150,59 +180,110 @@ func main() {
 	wg.Wait()
 }
 
-func (c *conf) fetchImage(i *img.Image) (*img.Image, error) {
-	i.Crawled = time.Now().Format("20060102")
-
-	// The following is a pure Go attempt to cut out the Python server altogether
-	// but doesn't seem to work very well:
-	// https://gist.github.com/brentadamson/e601d8a2704e10dcd2d3ea5c31301994
-	u := c.host + "?image=" + i.ID
-	resp, err := c.client.Get(u)
-	if err != nil {
-		return i, err
+// process decodes & classifies a batch of images and saves them.
+// An image that fails is logged and saved so that we move on to other images.
+// An image we couldn't classify isn't marked as crawled so it is retried
+// unless we couldn't download it either (it is likely gone).
+func (c *conf) process(images []*img.Image) {
+	crawled := time.Now().Format("20060102")
+	decoded := make([]bool, len(images))
+
+	for j, i := range images {
+		log.Info.Println(i.ID)
+
+		// we still want the nsfw score of an image we can't decode
+		if err := c.decode(i); err != nil {
+			log.Debug.Printf("unable to decode %v: %v\n", i.ID, err)
+			continue
+		}
+		decoded[j] = true
 	}
 
-	defer resp.Body.Close()
+	for j, err := range c.classifier.Classify(images) {
+		switch {
+		case err == nil, !decoded[j]:
+			images[j].Crawled = crawled
+		default:
+			log.Info.Println(err)
+			c.fail(images[j])
+		}
 
-	if resp.StatusCode == 200 {
-		bdy, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return i, err
+		if err := c.e.Upsert(images[j]); err != nil {
+			log.Info.Printf("unable to save %v: %v\n", images[j].ID, err)
 		}
+	}
+}
+
+// fail remembers an image we couldn't classify so that we don't
+// keep retrying it during this run
+func (c *conf) fail(i *img.Image) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.failed == nil {
+		c.failed = map[string]bool{}
+	}
+	c.failed[i.ID] = true
+}
 
-		im := &img.Image{}
+// notFailed drops the images we couldn't classify during this run
+func (c *conf) notFailed(images []*img.Image) []*img.Image {
+	c.Lock()
+	defer c.Unlock()
 
-		if err := json.Unmarshal(bdy, &im); err != nil {
-			return i, err
+	kept := []*img.Image{}
+	for _, i := range images {
+		if !c.failed[i.ID] {
+			kept = append(kept, i)
 		}
+	}
+
+	return kept
+}
 
-		i.NSFW = math.Round(im.NSFW/.0001) / 10000
-		i.Width = im.Width
-		i.Height = im.Height
-		i.MIME = im.MIME
-		i.EXIF = im.EXIF
-		i.Classification = separateKeys(im.Classification)
+func (c *conf) refresh() error {
+	if err := c.e.Bulk.Flush(); err != nil {
+		return err
 	}
 
-	return i, err
+	if _, err := c.e.Client.Flush().Index(c.e.Index).Do(context.Background()); err != nil {
+		return err
+	}
+
+	_, err := c.e.Client.Refresh().Index(c.e.Index).Do(context.Background())
+	return err
 }
 
-// separateKeys turns "punching bag, punch bag" to 2 items
-// In case of duplicate keys we take that with highest value
-func separateKeys(c map[string]float64) map[string]float64 {
-	m := map[string]float64{}
-	for key, val := range c {
-		rounded := math.Round(val/.001) / 1000
-		for _, s := range strings.Split(key, ",") {
-			s = strings.TrimSpace(s)
-			if v, ok := m[s]; ok {
-				if v >= rounded {
-					continue
-				}
-			}
-			m[s] = rounded
-		}
+// decode downloads an image and reads its dimensions, format, colors, hashes and EXIF data
+// and saves a thumbnail of it
+func (c *conf) decode(i *img.Image) error {
+	req, err := http.NewRequest("GET", i.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", c.useragent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > c.maxBytes {
+		return fmt.Errorf("image is too large: %d bytes", resp.ContentLength)
+	}
+
+	m, err := i.Decode(io.LimitReader(resp.Body, c.maxBytes))
+	if err != nil || c.thumbnails == nil {
+		return err
 	}
 
-	return m
+	i.Thumbnail, err = c.thumbnails.Save(m, i.Transparent)
+	return err
 }
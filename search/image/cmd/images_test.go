@@ -1,8 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	stdimage "image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	img "github.com/jivesearch/jivesearch/search/image"
+	"github.com/olivere/elastic"
 	"github.com/spf13/viper"
 )
 
@@ -13,4 +23,101 @@ func TestSetup(t *testing.T) {
 	if c == nil {
 		t.Fatalf("c is nil")
 	}
+
+	if _, ok := c.classifier.(*img.Batcher).Classifier.(*img.HTTPClassifier); !ok {
+		t.Fatalf("got classifier %T; want *image.HTTPClassifier", c.classifier.(*img.Batcher).Classifier)
+	}
+
+	v.Set("nsfw.classifier", "heuristic")
+	setup(v)
+
+	if _, ok := c.classifier.(*img.Batcher).Classifier.(*img.Heuristic); !ok {
+		t.Fatalf("got classifier %T; want *image.Heuristic", c.classifier.(*img.Batcher).Classifier)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	m := stdimage.NewRGBA(stdimage.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			m.Set(x, y, color.RGBA{220, 170, 140, 255}) // skin
+		}
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, m); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/skin.png" || r.URL.Path == "/unclassifiable.png" {
+			w.Write(b.Bytes())
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+
+	client, err := elastic.NewSimpleClient(elastic.SetURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bulk, err := client.BulkProcessor().Do(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &conf{
+		e:          &img.ElasticSearch{Client: client, Index: "images", Type: "image", Bulk: bulk},
+		client:     &http.Client{},
+		classifier: &img.Batcher{Classifier: &mockClassifier{}, Size: 2, Retries: 1},
+		batch:      2,
+		maxBytes:   1 << 20,
+	}
+
+	images := []*img.Image{
+		{ID: ts.URL + "/skin.png", Domain: "example.com"},
+		{ID: ts.URL + "/missing.png", Domain: "example.com"},
+		{ID: ts.URL + "/unclassifiable.png", Domain: "example.com"},
+		{ID: ts.URL + "/gone/unclassifiable.png", Domain: "example.com"},
+	}
+
+	c.process(images)
+
+	if images[0].Width != 16 || images[0].NSFW != 1 {
+		t.Fatalf("got %+v", images[0])
+	}
+
+	// an image we couldn't download is likely gone so we don't retry it
+	for _, im := range []*img.Image{images[0], images[1], images[3]} {
+		if im.Crawled == "" {
+			t.Fatalf("%v wasn't marked as crawled", im.ID)
+		}
+	}
+
+	if images[2].Crawled != "" || images[2].NSFW != 0 {
+		t.Fatalf("got crawled %q, nsfw score %v for an image that failed", images[2].Crawled, images[2].NSFW)
+	}
+
+	if got := c.notFailed(images); len(got) != 3 || got[2] != images[3] {
+		t.Fatalf("got %+v; want the images that didn't fail", got)
+	}
+}
+
+// mockClassifier fails the images named unclassifiable
+// and scores the other images by their skin tones
+type mockClassifier struct{}
+
+func (m *mockClassifier) Classify(images []*img.Image) []error {
+	errs := make([]error, len(images))
+	for i, im := range images {
+		if bytes.Contains([]byte(im.ID), []byte("unclassifiable")) {
+			errs[i] = fmt.Errorf("unable to classify")
+			continue
+		}
+		im.NSFW = im.Skin
+	}
+
+	return errs
 }
@@ -0,0 +1,283 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	stdimage "image"
+	"image/color"
+	_ "image/gif"  // register the gif decoder
+	_ "image/jpeg" // register the jpeg decoder
+	_ "image/png"  // register the png decoder
+	"io"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rwcarlsen/goexif/exif"
+	_ "golang.org/x/image/bmp" // register the bmp decoder
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff" // register the tiff decoder
+	_ "golang.org/x/image/webp" // register the webp decoder
+)
+
+// maxColors is the number of dominant colors we keep of an image
+const maxColors = 3
+
+// minColorShare is the share of the pixels a color needs to be dominant
+const minColorShare = .05
+
+// maxPixels is the most pixels we'll decode an image of. A few bytes
+// of header can claim dimensions that would take gigabytes to decode.
+const maxPixels = 50 * 1000 * 1000
+
+// ErrTooManyPixels indicates an image is too large to decode
+var ErrTooManyPixels = fmt.Errorf("image has too many pixels")
+
+// DecodeBytes decodes an image unless its header claims more than maxPixels
+func DecodeBytes(b []byte) (stdimage.Image, string, error) {
+	cfg, _, err := stdimage.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return nil, "", ErrTooManyPixels
+	}
+
+	return stdimage.Decode(bytes.NewReader(b))
+}
+
+// Decode reads an image and sets its dimensions, format,
+// dominant colors, perceptual hashes and EXIF data.
+// The decoded image is returned so a thumbnail can be made of it.
+func (i *Image) Decode(r io.Reader) (stdimage.Image, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m, format, err := DecodeBytes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := m.Bounds()
+	i.Width, i.Height = bounds.Dx(), bounds.Dy()
+	i.MIME = format
+	thumb := thumbnail(m)
+	i.Colors = dominantColors(thumb)
+	i.Palette = palette(i.Colors)
+	i.Grayscale, i.Transparent = tone(thumb)
+	i.Skin = skinRatio(thumb)
+	i.DHash = FormatHash(DHash(m))
+	ph := PHash(m)
+	i.PHash = FormatHash(ph)
+	i.Chunks = Chunks(ph)
+	i.EXIF = decodeEXIF(b)
+	return m, nil
+}
+
+// decodeEXIF reads the camera, date, location & copyright of an image.
+// The license comes from the XMP packet (if any) as EXIF has no field for it.
+func decodeEXIF(b []byte) EXIF {
+	e := EXIF{
+		License: xmpLicense(b),
+	}
+
+	x, err := exif.Decode(bytes.NewReader(b))
+	if err != nil {
+		return e
+	}
+
+	str := func(name exif.FieldName) string {
+		tag, err := x.Get(name)
+		if err != nil {
+			return ""
+		}
+		s, err := tag.StringVal()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(strings.Trim(s, "\x00"))
+	}
+
+	e.Copyright = str(exif.Copyright)
+	e.Artist = str(exif.Artist)
+	e.Camera = strings.TrimSpace(str(exif.Make) + " " + str(exif.Model))
+
+	if t, err := x.DateTime(); err == nil {
+		e.Taken = t.Format("20060102")
+	}
+
+	if lat, lon, err := x.LatLong(); err == nil {
+		e.Location = &Location{Lat: lat, Lon: lon}
+	}
+
+	return e
+}
+
+// licenses in an XMP packet
+// <cc:license rdf:resource="https://creativecommons.org/licenses/by/4.0/"/>
+// xmpRights:WebStatement="https://creativecommons.org/licenses/by-sa/4.0/"
+var reLicense = regexp.MustCompile(`(?:cc:license\s+rdf:resource|xmpRights:WebStatement)\s*=\s*"([^"]+)"|<(?:cc:license|xmpRights:WebStatement)>([^<]+)<`)
+
+func xmpLicense(b []byte) string {
+	m := reLicense.FindSubmatch(b)
+	if m == nil {
+		return ""
+	}
+
+	if len(m[1]) > 0 {
+		return string(m[1])
+	}
+
+	return strings.TrimSpace(string(m[2]))
+}
+
+// bucket is the sum of the pixels of similar color
+type bucket struct {
+	r, g, b, n int
+}
+
+// thumbnailSize is the width & height of the thumbnail we analyze the colors of
+const thumbnailSize = 64
+
+// maxGraySpread is the max difference between the channels of a gray pixel
+const maxGraySpread = 24
+
+func thumbnail(m stdimage.Image) *stdimage.RGBA {
+	thumb := stdimage.NewRGBA(stdimage.Rect(0, 0, thumbnailSize, thumbnailSize))
+	draw.ApproxBiLinear.Scale(thumb, thumb.Bounds(), m, m.Bounds(), draw.Src, nil)
+	return thumb
+}
+
+// tone tells us if all the (opaque) pixels of a thumbnail are gray
+// and if any of them are transparent
+func tone(thumb *stdimage.RGBA) (grayscale, transparent bool) {
+	grayscale = true
+
+	for y := 0; y < thumbnailSize; y++ {
+		for x := 0; x < thumbnailSize; x++ {
+			c := thumb.RGBAAt(x, y)
+			if c.A < 128 {
+				transparent = true
+				continue
+			}
+
+			hi, lo := c.R, c.R
+			for _, v := range []uint8{c.G, c.B} {
+				if v > hi {
+					hi = v
+				}
+				if v < lo {
+					lo = v
+				}
+			}
+
+			if hi-lo > maxGraySpread {
+				grayscale = false
+			}
+		}
+	}
+
+	return grayscale, transparent
+}
+
+// skinRatio is the share of the opaque pixels of a thumbnail that are skin tones.
+// https://arxiv.org/pdf/0707.1143.pdf (Kovac, et al)
+func skinRatio(thumb *stdimage.RGBA) float64 {
+	var skin, total int
+
+	for y := 0; y < thumbnailSize; y++ {
+		for x := 0; x < thumbnailSize; x++ {
+			c := thumb.RGBAAt(x, y)
+			if c.A < 128 {
+				continue
+			}
+			total++
+
+			r, g, b := int(c.R), int(c.G), int(c.B)
+			hi, lo := r, r
+			for _, v := range []int{g, b} {
+				if v > hi {
+					hi = v
+				}
+				if v < lo {
+					lo = v
+				}
+			}
+
+			if r > 95 && g > 40 && b > 20 && hi-lo > 15 && r-g > 15 && r > b {
+				skin++
+			}
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return math.Round(float64(skin)/float64(total)*100) / 100
+}
+
+// dominantColors buckets the pixels of a thumbnail by color and
+// returns the average color of the biggest buckets ("#ff0000")
+func dominantColors(thumb *stdimage.RGBA) []string {
+	buckets := map[int]*bucket{}
+	var total int
+
+	for y := 0; y < thumbnailSize; y++ {
+		for x := 0; x < thumbnailSize; x++ {
+			c := thumb.RGBAAt(x, y)
+			if c.A < 128 { // transparent
+				continue
+			}
+
+			// 3 bits per channel
+			k := int(c.R>>5)<<6 | int(c.G>>5)<<3 | int(c.B>>5)
+			bk, ok := buckets[k]
+			if !ok {
+				bk = &bucket{}
+				buckets[k] = bk
+			}
+
+			bk.r += int(c.R)
+			bk.g += int(c.G)
+			bk.b += int(c.B)
+			bk.n++
+			total++
+		}
+	}
+
+	sorted := []*bucket{}
+	for _, bk := range buckets {
+		sorted = append(sorted, bk)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].n == sorted[j].n {
+			return hex(sorted[i].color()) < hex(sorted[j].color())
+		}
+		return sorted[i].n > sorted[j].n
+	})
+
+	colors := []string{}
+	for _, bk := range sorted {
+		if len(colors) == maxColors || float64(bk.n)/float64(total) < minColorShare {
+			break
+		}
+		colors = append(colors, hex(bk.color()))
+	}
+
+	return colors
+}
+
+func (bk *bucket) color() color.RGBA {
+	return color.RGBA{uint8(bk.r / bk.n), uint8(bk.g / bk.n), uint8(bk.b / bk.n), 255}
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,227 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	stdimage "image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	m := stdimage.NewRGBA(stdimage.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= 32 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			m.Set(x, y, c)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, m); err != nil {
+		t.Fatal(err)
+	}
+
+	i := &Image{ID: "https://www.example.com/flag.png"}
+	if _, err := i.Decode(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if i.Width != 64 || i.Height != 48 || i.MIME != "png" {
+		t.Fatalf("got %dx%d %q; want 64x48 png", i.Width, i.Height, i.MIME)
+	}
+
+	want := []string{"#0000ff", "#ff0000"}
+	if !reflect.DeepEqual(i.Colors, want) {
+		t.Fatalf("got colors %v; want %v", i.Colors, want)
+	}
+
+	if !reflect.DeepEqual(i.Palette, []string{"blue", "red"}) || i.Grayscale || i.Transparent {
+		t.Fatalf("got palette %v, grayscale %v, transparent %v", i.Palette, i.Grayscale, i.Transparent)
+	}
+
+	if i.DHash != FormatHash(DHash(m)) || i.PHash != FormatHash(PHash(m)) {
+		t.Fatalf("got hashes %q, %q", i.DHash, i.PHash)
+	}
+
+	if !reflect.DeepEqual(i.Chunks, Chunks(PHash(m))) {
+		t.Fatalf("got chunks %v", i.Chunks)
+	}
+
+	if !reflect.DeepEqual(i.EXIF, EXIF{}) {
+		t.Fatalf("got EXIF %+v; want none", i.EXIF)
+	}
+}
+
+func TestDecodeTooManyPixels(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		b    []byte
+	}{
+		{
+			// a 13 byte gif header claiming to be 65535x65535
+			"bomb", []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00"),
+		},
+		{
+			"empty", []byte("GIF89a\x00\x00\x00\x00\x00\x00\x00"),
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			i := &Image{}
+			if _, err := i.Decode(bytes.NewReader(c.b)); err != ErrTooManyPixels {
+				t.Fatalf("got %v; want %v", err, ErrTooManyPixels)
+			}
+		})
+	}
+}
+
+func TestDecodeTone(t *testing.T) {
+	// a gray square on a transparent background
+	m := stdimage.NewNRGBA(stdimage.Rect(0, 0, 32, 32))
+	for y := 8; y < 24; y++ {
+		for x := 8; x < 24; x++ {
+			m.Set(x, y, color.NRGBA{100, 100, 110, 255})
+		}
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, m); err != nil {
+		t.Fatal(err)
+	}
+
+	i := &Image{}
+	if _, err := i.Decode(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !i.Grayscale || !i.Transparent {
+		t.Fatalf("got grayscale %v, transparent %v; want true, true", i.Grayscale, i.Transparent)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	i := &Image{}
+	if _, err := i.Decode(bytes.NewReader([]byte("<html>not an image</html>"))); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestDecodeEXIF(t *testing.T) {
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, stdimage.NewGray(stdimage.Rect(0, 0, 16, 16)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	xmp := `<x:xmpmeta><rdf:Description xmpRights:WebStatement="https://creativecommons.org/licenses/by/4.0/"/></x:xmpmeta>`
+
+	// insert the EXIF & XMP segments after the start of image marker
+	jpg := append([]byte{}, b.Bytes()[:2]...)
+	jpg = append(jpg, segment(append([]byte("Exif\x00\x00"), tiffEXIF()...))...)
+	jpg = append(jpg, segment(append([]byte("http://ns.adobe.com/xap/1.0/\x00"), xmp...))...)
+	jpg = append(jpg, b.Bytes()[2:]...)
+
+	i := &Image{}
+	if _, err := i.Decode(bytes.NewReader(jpg)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := EXIF{
+		Copyright: "Jimi Hendrix",
+		Camera:    "Canon Canon EOS 5D",
+		Taken:     "20180702",
+		Location:  &Location{Lat: 48.858333333333334, Lon: -2.2944444444444443},
+		License:   "https://creativecommons.org/licenses/by/4.0/",
+	}
+
+	if !reflect.DeepEqual(i.EXIF, want) {
+		t.Fatalf("got %+v (%+v); want %+v (%+v)", i.EXIF, i.EXIF.Location, want, want.Location)
+	}
+}
+
+// segment is an APP1 segment of a jpeg
+func segment(data []byte) []byte {
+	s := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(s[2:], uint16(len(data)+2))
+	return append(s, data...)
+}
+
+type tiffEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func ascii(tag uint16, s string) tiffEntry {
+	return tiffEntry{tag, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func degrees(tag uint16, d, m, s uint32) tiffEntry {
+	data := make([]byte, 24)
+	for i, v := range []uint32{d, 1, m, 1, s, 1} {
+		binary.LittleEndian.PutUint32(data[i*4:], v)
+	}
+	return tiffEntry{tag, 5, 3, data}
+}
+
+// ifd packs an image file directory that starts at offset
+func ifd(offset uint32, entries []tiffEntry) []byte {
+	b := make([]byte, 2+12*len(entries)+4)
+	binary.LittleEndian.PutUint16(b, uint16(len(entries)))
+
+	var extra []byte
+	for i, e := range entries {
+		p := b[2+12*i:]
+		binary.LittleEndian.PutUint16(p, e.tag)
+		binary.LittleEndian.PutUint16(p[2:], e.typ)
+		binary.LittleEndian.PutUint32(p[4:], e.count)
+
+		if len(e.data) <= 4 {
+			copy(p[8:12], e.data)
+			continue
+		}
+
+		binary.LittleEndian.PutUint32(p[8:], offset+uint32(len(b)+len(extra)))
+		extra = append(extra, e.data...)
+		if len(extra)%2 == 1 {
+			extra = append(extra, 0)
+		}
+	}
+
+	return append(b, extra...)
+}
+
+// tiffEXIF is the TIFF structure of the EXIF data of a photo
+func tiffEXIF() []byte {
+	ifd0 := func(gps uint32) []byte {
+		p := make([]byte, 4)
+		binary.LittleEndian.PutUint32(p, gps)
+
+		return ifd(8, []tiffEntry{
+			ascii(0x010f, "Canon"),
+			ascii(0x0110, "Canon EOS 5D"),
+			ascii(0x0132, "2018:07:02 15:04:05"),
+			ascii(0x8298, "Jimi Hendrix"),
+			{0x8825, 4, 1, p}, // pointer to the GPS directory
+		})
+	}
+
+	gpsOffset := 8 + uint32(len(ifd0(0)))
+
+	gps := ifd(gpsOffset, []tiffEntry{
+		ascii(1, "N"),
+		degrees(2, 48, 51, 30),
+		ascii(3, "W"),
+		degrees(4, 2, 17, 40),
+	})
+
+	b := []byte{'I', 'I', 42, 0, 8, 0, 0, 0}
+	b = append(b, ifd0(gpsOffset)...)
+	return append(b, gps...)
+}
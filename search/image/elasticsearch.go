@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/jivesearch/jivesearch/log"
+	"github.com/jivesearch/jivesearch/search/mapping"
 	"github.com/olivere/elastic"
 )
 
@@ -17,12 +18,40 @@ type ElasticSearch struct {
 	Type          string
 	Bulk          *elastic.BulkProcessor
 	NSFWThreshold float64
+	MaxCandidates int // the most images we compare with a hash for similar images (DefaultMaxCandidates if 0)
 }
 
-// Fetch returns image results for a search query
-func (e *ElasticSearch) Fetch(q string, safe bool, number int, offset int) (*Results, error) {
+// maxWindow is the deepest Elasticsearch lets us page (index.max_result_window)
+const maxWindow = 10000
+
+// Fetch returns image results for a search query.
+// Copies of an image (resized, recompressed, etc) are collapsed
+// into the best ranked one by their perceptual hash. We collapse
+// the images before the page we want too so that the copies of an
+// image on one page don't show up on the next. To still fill a page
+// we look at twice as many images as we need.
+func (e *ElasticSearch) Fetch(q string, safe bool, f Filters, number int, offset int) (*Results, error) {
 	res := &Results{}
 
+	window := 2 * (offset + number)
+	if window > maxWindow {
+		window = maxWindow
+	}
+
+	filters := []interface{}{}
+	for _, fq := range f.queries() {
+		src, err := fq.Source()
+		if err != nil {
+			return res, err
+		}
+		filters = append(filters, src)
+	}
+
+	filter, err := json.Marshal(filters)
+	if err != nil {
+		return res, err
+	}
+
 	var safeQuery string
 
 	switch safe {
@@ -42,7 +71,7 @@ func (e *ElasticSearch) Fetch(q string, safe bool, number int, offset int) (*Res
 								"multi_match": {
 									"query": "%v",
 									"fields": [
-										"alt"
+										"alt^3", "caption^2", "heading", "page_title"
 									]
 								}
 							}
@@ -55,7 +84,8 @@ func (e *ElasticSearch) Fetch(q string, safe bool, number int, offset int) (*Res
 									}
 								}
 							}
-						]
+						],
+						"filter": %s
 					}
 				},
 				"field_value_factor": {
@@ -67,17 +97,15 @@ func (e *ElasticSearch) Fetch(q string, safe bool, number int, offset int) (*Res
 				"boost_mode": "sum"
 			}
 		},
-		"from": %d,
 		"size": %d
-	}`, q, safeQuery, q, offset, number)
+	}`, q, safeQuery, filter, q, window)
 
 	out, err := e.Client.Search(e.Index).Source(qu).Do(context.TODO())
 	if err != nil {
 		return res, err
 	}
 
-	res.Count = out.TotalHits()
-
+	images := []*Image{}
 	for _, h := range out.Hits.Hits {
 		img := &Image{
 			ID: h.Id,
@@ -87,12 +115,82 @@ func (e *ElasticSearch) Fetch(q string, safe bool, number int, offset int) (*Res
 			return res, err
 		}
 
-		res.Images = append(res.Images, img)
+		images = append(images, img)
+	}
+
+	kept := Collapse(images, DuplicateDistance)
+
+	// the copies past our window aren't taken out of the count
+	res.Count = out.TotalHits() - int64(len(images)-len(kept))
+
+	if offset < len(kept) {
+		end := offset + number
+		if end > len(kept) {
+			end = len(kept)
+		}
+		res.Images = kept[offset:end]
 	}
 
 	return res, err
 }
 
+// Similar returns the images that look like the image with the pHash
+// hash, the most similar first. Distance is capped at MaxSimilarDistance.
+// The candidates that share the most chunks with the hash are looked at
+// first and at most MaxCandidates of them.
+func (e *ElasticSearch) Similar(hash uint64, distance int, safe bool, f Filters, number int) (*Results, error) {
+	res := &Results{}
+
+	if distance < 0 || distance > MaxSimilarDistance {
+		distance = MaxSimilarDistance
+	}
+
+	max := e.MaxCandidates
+	if max <= 0 {
+		max = DefaultMaxCandidates
+	}
+
+	nsfw := elastic.NewRangeQuery("nsfw_score").Gte(e.NSFWThreshold)
+	if safe {
+		nsfw = elastic.NewRangeQuery("nsfw_score").Lt(e.NSFWThreshold)
+	}
+
+	// each chunk an image shares with the hash adds 1 to its score
+	q := elastic.NewBoolQuery().MinimumNumberShouldMatch(1).Filter(nsfw)
+	for _, c := range Chunks(hash) {
+		q = q.Should(elastic.NewConstantScoreQuery(elastic.NewTermQuery("phash_chunks", c)).Boost(1))
+	}
+
+	for _, fq := range f.queries() {
+		q = q.Filter(fq)
+	}
+
+	out, err := e.Client.Search(e.Index).Query(q).Size(max).Do(context.TODO())
+	if err != nil {
+		return res, err
+	}
+
+	candidates := []*Image{}
+	for _, h := range out.Hits.Hits {
+		img := &Image{
+			ID: h.Id,
+		}
+		if err := json.Unmarshal(*h.Source, img); err != nil {
+			return res, err
+		}
+
+		candidates = append(candidates, img)
+	}
+
+	res.Images = nearest(hash, candidates, distance)
+	res.Count = int64(len(res.Images))
+	if len(res.Images) > number {
+		res.Images = res.Images[:number]
+	}
+
+	return res, nil
+}
+
 // Upsert updates an image link or inserts it if it doesn't exist
 // NOTE: Elasticsearch has a 512-byte limit on an insert operation.
 // Upsert does not have that limit.
@@ -212,20 +310,19 @@ func (e *ElasticSearch) Setup() error {
 
 	if !exists {
 		log.Info.Println("Creating index:", e.Index)
-		if _, err = e.Client.CreateIndex(e.Index).Body(e.mapping()).Do(context.TODO()); err != nil {
-			return err
-		}
+		_, err = e.Client.CreateIndex(e.Index).Body(e.mapping()).Do(context.TODO())
+		return err
 	}
 
-	return nil
+	return mapping.Put(e.Client, e.Index, e.Type, e.mapping())
 }
 
 // mapping is the mapping of our image Index.
 func (e *ElasticSearch) mapping() string {
-	m := `{
+	m := fmt.Sprintf(`{
 		"index.mapping.total_fields.limit": 100000,
 		"mappings": {
-			"image": {
+			"%v": {
 				"_all": {
 					"enabled": false
 				},
@@ -240,9 +337,68 @@ func (e *ElasticSearch) mapping() string {
 					"alt": {
 						"type": "text"
 					},
+					"page": {
+						"type": "keyword"
+					},
+					"page_title": {
+						"type": "text"
+					},
+					"caption": {
+						"type": "text"
+					},
+					"heading": {
+						"type": "text"
+					},
+					"link": {
+						"type": "keyword"
+					},
 					"copyright": {
 						"type": "text"
 					},
+					"artist": {
+						"type": "text"
+					},
+					"camera": {
+						"type": "keyword"
+					},
+					"taken": {
+						"type": "date",
+						"format": "basic_date"
+					},
+					"location": {
+						"type": "geo_point"
+					},
+					"license": {
+						"type": "keyword"
+					},
+					"colors": {
+						"type": "keyword"
+					},
+					"palette": {
+						"type": "keyword"
+					},
+					"grayscale": {
+						"type": "boolean"
+					},
+					"transparent": {
+						"type": "boolean"
+					},
+					"skin": {
+						"type": "half_float"
+					},
+					"dhash": {
+						"type": "keyword"
+					},
+					"phash": {
+						"type": "keyword"
+					},
+					"phash_chunks": {
+						"type": "keyword"
+					},
+					"thumbnail": {
+						"type": "keyword",
+						"index": false
+					},
 					"mime": {
 						"type": "keyword"
 					},
@@ -268,7 +424,7 @@ func (e *ElasticSearch) mapping() string {
 				}
 			}
 		}
-	}`
+	}`, e.Type)
 
 	return m
 }
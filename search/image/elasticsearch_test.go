@@ -2,9 +2,12 @@ package image
 
 import (
 	"context"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -32,7 +35,6 @@ func TestFetch(t *testing.T) {
 			query:  "Bob Dylan",
 			safe:   true,
 			number: 25,
-			offset: 1,
 			status: http.StatusOK,
 			resp: `{
 				"took": 2,
@@ -109,6 +111,78 @@ func TestFetch(t *testing.T) {
 				nil,
 			},
 		},
+		{
+			name:   "duplicates",
+			query:  "Bob Dylan",
+			safe:   true,
+			number: 25,
+			status: http.StatusOK,
+			resp: `{
+				"hits": {
+				  "total": 3,
+				  "hits": [
+					{
+					  "_id": "https://www.example.com/dylan.jpg",
+					  "_source": {"id": "https://www.example.com/dylan.jpg", "domain": "example.com", "dhash": "3c3c7e7effff7e3c"}
+					},
+					{
+					  "_id": "https://www.example.org/dylan-small.jpg",
+					  "_source": {"id": "https://www.example.org/dylan-small.jpg", "domain": "example.org", "dhash": "3c3c7e7efffe7e3c"}
+					},
+					{
+					  "_id": "https://www.example.net/guitar.jpg",
+					  "_source": {"id": "https://www.example.net/guitar.jpg", "domain": "example.net", "dhash": "c3c38181000081c3"}
+					}
+				  ]
+				}
+			}`,
+			want: want{
+				&Results{
+					Count: 2,
+					Images: []*Image{
+						{ID: "https://www.example.com/dylan.jpg", Domain: "example.com", DHash: "3c3c7e7effff7e3c"},
+						{ID: "https://www.example.net/guitar.jpg", Domain: "example.net", DHash: "c3c38181000081c3"},
+					},
+				},
+				nil,
+			},
+		},
+		{
+			name:   "duplicates on the page before",
+			query:  "Bob Dylan",
+			safe:   true,
+			number: 1,
+			offset: 1,
+			status: http.StatusOK,
+			resp: `{
+				"hits": {
+				  "total": 3,
+				  "hits": [
+					{
+					  "_id": "https://www.example.com/dylan.jpg",
+					  "_source": {"id": "https://www.example.com/dylan.jpg", "domain": "example.com", "dhash": "3c3c7e7effff7e3c"}
+					},
+					{
+					  "_id": "https://www.example.org/dylan-small.jpg",
+					  "_source": {"id": "https://www.example.org/dylan-small.jpg", "domain": "example.org", "dhash": "3c3c7e7efffe7e3c"}
+					},
+					{
+					  "_id": "https://www.example.net/guitar.jpg",
+					  "_source": {"id": "https://www.example.net/guitar.jpg", "domain": "example.net", "dhash": "c3c38181000081c3"}
+					}
+				  ]
+				}
+			}`,
+			want: want{
+				&Results{
+					Count: 2,
+					Images: []*Image{
+						{ID: "https://www.example.net/guitar.jpg", Domain: "example.net", DHash: "c3c38181000081c3"},
+					},
+				},
+				nil,
+			},
+		},
 	} {
 		t.Run(c.name, func(t *testing.T) {
 			handler := http.NotFound
@@ -127,7 +201,7 @@ func TestFetch(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			got, err := e.Fetch(c.query, c.safe, c.number, c.offset)
+			got, err := e.Fetch(c.query, c.safe, Filters{}, c.number, c.offset)
 			if err != c.want.err {
 				t.Fatalf("got err %q; want %q", err, c.want.err)
 			}
@@ -139,6 +213,126 @@ func TestFetch(t *testing.T) {
 	}
 }
 
+func TestFetchFilters(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		filters Filters
+		want    []string
+	}{
+		{
+			name:    "none",
+			filters: Filters{},
+			want:    []string{`"filter":[]`, `"fields":["alt^3","caption^2","heading","page_title"]`, `"size":50}`},
+		},
+		{
+			name:    "wallpaper",
+			filters: Filters{Size: "wallpaper", Orientation: "wide"},
+			want: []string{
+				`{"range":{"width":{"from":1920,"include_lower":true,"include_upper":true,"to":null}}}`,
+				`{"range":{"height":{"from":1080,"include_lower":true,"include_upper":true,"to":null}}}`,
+				`"source":"doc['width'].value \u003e doc['height'].value * params.ratio"`,
+			},
+		},
+		{
+			name:    "transparent png",
+			filters: Filters{Color: "transparent", MIME: "png"},
+			want: []string{
+				`{"term":{"transparent":true}}`,
+				`{"terms":{"mime":["png","image/png"]}}`,
+			},
+		},
+		{
+			name:    "red creative commons",
+			filters: Filters{Color: "red", License: "commercial"},
+			want: []string{
+				`{"term":{"palette":"red"}}`,
+				`{"prefix":{"license":"https://creativecommons.org/licenses/"}}`,
+				`{"wildcard":{"license":{"wildcard":"*-nc*"}}}`,
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var body string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				body = strings.Join(strings.Fields(string(b)), "")
+				w.Write([]byte(`{"hits":{"total":0,"hits":[]}}`))
+			}))
+			defer ts.Close()
+
+			e, err := MockService(ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := e.Fetch("cat", true, c.filters, 25, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, w := range c.want {
+				if !strings.Contains(body, strings.Join(strings.Fields(w), "")) {
+					t.Fatalf("%v not in query %v", w, body)
+				}
+			}
+		})
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{
+			"hits": {
+				"total": 3,
+				"hits": [
+					{"_id": "https://www.example.com/far.jpg", "_source": {"id": "https://www.example.com/far.jpg", "phash": "0f0f0f0f0f0f0f0f"}},
+					{"_id": "https://www.example.com/close.jpg", "_source": {"id": "https://www.example.com/close.jpg", "phash": "f0f0f0f0f0f0f0f3"}},
+					{"_id": "https://www.example.com/same.jpg", "_source": {"id": "https://www.example.com/same.jpg", "phash": "f0f0f0f0f0f0f0f0"}}
+				]
+			}
+		}`))
+	}))
+	defer ts.Close()
+
+	e, err := MockService(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.NSFWThreshold = .8
+	e.MaxCandidates = 500
+
+	got, err := e.Similar(0xf0f0f0f0f0f0f0f0, 100, true, Filters{Size: "large"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Results{
+		Count: 2,
+		Images: []*Image{
+			{ID: "https://www.example.com/same.jpg", PHash: "f0f0f0f0f0f0f0f0"},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v; want %+v", got, want)
+	}
+
+	for _, w := range []string{
+		`{"constant_score":{"boost":1,"filter":{"term":{"phash_chunks":"0:f0"}}}}`,
+		`{"constant_score":{"boost":1,"filter":{"term":{"phash_chunks":"7:f0"}}}}`,
+		`"minimum_should_match":"1"`,
+		`{"range":{"nsfw_score":{"from":null,"include_lower":true,"include_upper":false,"to":0.8}}}`,
+		`{"range":{"width":{"from":1024`,
+		`"size":500`,
+	} {
+		if !strings.Contains(body, w) {
+			t.Fatalf("%v not in query %v", w, body)
+		}
+	}
+}
+
 func TestUpsert(t *testing.T) {
 	for _, c := range []struct {
 		name   string
@@ -351,28 +545,45 @@ func TestUncrawled(t *testing.T) {
 
 func TestSetup(t *testing.T) {
 	for _, c := range []struct {
-		name   string
-		status int
-		resp   string
+		name    string
+		exists  bool
+		path    string
+		want    []string
+		notWant []string
 	}{
 		{
-			name:   "ok",
-			status: http.StatusOK,
-			resp:   `{"acknowledged": true}`,
+			"new index", false, "/images",
+			[]string{`total_fields`, `"dynamic":"strict"`, `"phash"`},
+			[]string{},
+		},
+		{
+			"existing index", true, "/images/_mapping/image",
+			[]string{`"dynamic":"strict"`, `"camera"`, `"colors"`, `"phash"`, `"phash_chunks"`, `"caption"`, `"thumbnail"`},
+			[]string{`total_fields`, `"mappings"`},
 		},
 	} {
 		t.Run(c.name, func(t *testing.T) {
-			handler := http.NotFound
+			var mu sync.Mutex
+			bodies := map[string]string{}
+
 			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				handler(w, r)
+				switch r.Method {
+				case "HEAD":
+					if !c.exists {
+						http.NotFound(w, r)
+						return
+					}
+				case "PUT":
+					b, _ := ioutil.ReadAll(r.Body)
+					mu.Lock()
+					bodies[r.URL.Path] = strings.Join(strings.Fields(string(b)), "")
+					mu.Unlock()
+				}
+
+				w.Write([]byte(`{"acknowledged": true}`))
 			}))
 			defer ts.Close()
 
-			handler = func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(c.status)
-				w.Write([]byte(c.resp))
-			}
-
 			e, err := MockService(ts.URL)
 			if err != nil {
 				t.Fatal(err)
@@ -381,6 +592,26 @@ func TestSetup(t *testing.T) {
 			if err := e.Setup(); err != nil {
 				t.Fatal(err)
 			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			body, ok := bodies[c.path]
+			if !ok || len(bodies) != 1 {
+				t.Fatalf("expected a single request to %v; got %v", c.path, bodies)
+			}
+
+			for _, w := range c.want {
+				if !strings.Contains(body, w) {
+					t.Fatalf("expected %v in %v", w, body)
+				}
+			}
+
+			for _, w := range c.notWant {
+				if strings.Contains(body, w) {
+					t.Fatalf("didn't expect %v in %v", w, body)
+				}
+			}
 		})
 	}
 }
@@ -0,0 +1,275 @@
+package image
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/olivere/elastic"
+)
+
+// Filters narrow down the image results.
+// The zero value doesn't filter anything.
+type Filters struct {
+	Size        string `json:"size,omitempty"`        // small, medium, large or wallpaper
+	Orientation string `json:"orientation,omitempty"` // square, wide or tall
+	Color       string `json:"color,omitempty"`       // a color of the Palette, bw or transparent
+	MIME        string `json:"mime,omitempty"`        // jpeg, png, gif, webp, etc
+	License     string `json:"license,omitempty"`     // cc, commercial or publicdomain
+}
+
+// Sizes are the size buckets of an image. Small is < 400px, medium
+// is < 1024px, large is >= 1024px and wallpaper is at least 1920x1080.
+var Sizes = []string{"small", "medium", "large", "wallpaper"}
+
+// Orientations of an image
+var Orientations = []string{"square", "wide", "tall"}
+
+// Palette is the named colors that an image's dominant colors are mapped to
+var Palette = []string{
+	"red", "orange", "yellow", "green", "teal", "blue",
+	"purple", "pink", "brown", "black", "gray", "white",
+}
+
+// Colors are the color filters. "bw" is black & white.
+var Colors = append(append([]string{}, Palette...), "bw", "transparent")
+
+// MIMEs are the image formats we filter by
+var MIMEs = []string{"jpeg", "png", "gif", "webp", "bmp", "tiff"}
+
+// Licenses filter images with a Creative Commons license (cc),
+// one that allows commercial use or a public domain dedication
+var Licenses = []string{"cc", "commercial", "publicdomain"}
+
+// squareRatio is how far the aspect ratio of a square image can be from 1
+const squareRatio = 1.1
+
+// NewFilters creates Filters and drops unsupported values
+func NewFilters(size, orientation, color, mime, license string) Filters {
+	valid := func(s string, options []string) string {
+		s = strings.ToLower(strings.TrimSpace(s))
+		for _, o := range options {
+			if s == o {
+				return s
+			}
+		}
+		return ""
+	}
+
+	return Filters{
+		Size:        valid(size, Sizes),
+		Orientation: valid(orientation, Orientations),
+		Color:       valid(color, Colors),
+		MIME:        valid(mime, MIMEs),
+		License:     valid(license, Licenses),
+	}
+}
+
+// String is a canonical representation of the filters
+// (e.g. "color=red&size=large") that can be used in a cache key
+func (f Filters) String() string {
+	v := url.Values{}
+	for k, s := range map[string]string{
+		"size":        f.Size,
+		"orientation": f.Orientation,
+		"color":       f.Color,
+		"mime":        f.MIME,
+		"license":     f.License,
+	} {
+		if s != "" {
+			v.Set(k, s)
+		}
+	}
+
+	return v.Encode()
+}
+
+// queries translates the filters into Elasticsearch filter queries
+func (f Filters) queries() []elastic.Query {
+	qs := []elastic.Query{}
+
+	switch f.Size {
+	case "small":
+		qs = append(qs,
+			elastic.NewRangeQuery("width").Lt(400),
+			elastic.NewRangeQuery("height").Lt(400),
+		)
+	case "medium":
+		qs = append(qs,
+			elastic.NewRangeQuery("width").Lt(1024),
+			elastic.NewRangeQuery("height").Lt(1024),
+			elastic.NewBoolQuery().Should(
+				elastic.NewRangeQuery("width").Gte(400),
+				elastic.NewRangeQuery("height").Gte(400),
+			).MinimumNumberShouldMatch(1),
+		)
+	case "large":
+		qs = append(qs,
+			elastic.NewBoolQuery().Should(
+				elastic.NewRangeQuery("width").Gte(1024),
+				elastic.NewRangeQuery("height").Gte(1024),
+			).MinimumNumberShouldMatch(1),
+		)
+	case "wallpaper":
+		qs = append(qs,
+			elastic.NewRangeQuery("width").Gte(1920),
+			elastic.NewRangeQuery("height").Gte(1080),
+		)
+	}
+
+	// the aspect ratio isn't indexed so we compare the dimensions in a script
+	var orientation string
+	switch f.Orientation {
+	case "square":
+		orientation = "doc['width'].value <= doc['height'].value * params.ratio && doc['height'].value <= doc['width'].value * params.ratio"
+	case "wide":
+		orientation = "doc['width'].value > doc['height'].value * params.ratio"
+	case "tall":
+		orientation = "doc['height'].value > doc['width'].value * params.ratio"
+	}
+
+	if orientation != "" {
+		qs = append(qs,
+			elastic.NewExistsQuery("width"),
+			elastic.NewExistsQuery("height"),
+			elastic.NewScriptQuery(
+				elastic.NewScript(orientation).Lang("painless").Param("ratio", squareRatio),
+			),
+		)
+	}
+
+	switch f.Color {
+	case "":
+	case "bw":
+		qs = append(qs, elastic.NewTermQuery("grayscale", true))
+	case "transparent":
+		qs = append(qs, elastic.NewTermQuery("transparent", true))
+	default:
+		qs = append(qs, elastic.NewTermQuery("palette", f.Color))
+	}
+
+	if f.MIME != "" {
+		mimes := []interface{}{f.MIME, "image/" + f.MIME}
+		if f.MIME == "jpeg" {
+			mimes = append(mimes, "jpg", "image/jpg")
+		}
+		qs = append(qs, elastic.NewTermsQuery("mime", mimes...))
+	}
+
+	cc := func(paths ...string) *elastic.BoolQuery {
+		q := elastic.NewBoolQuery().MinimumNumberShouldMatch(1)
+		for _, p := range paths {
+			for _, scheme := range []string{"https", "http"} {
+				q = q.Should(elastic.NewPrefixQuery("license", scheme+"://creativecommons.org/"+p))
+			}
+		}
+		return q
+	}
+
+	switch f.License {
+	case "cc":
+		qs = append(qs, cc("licenses/", "publicdomain/"))
+	case "commercial":
+		qs = append(qs, cc("licenses/", "publicdomain/").MustNot(elastic.NewWildcardQuery("license", "*-nc*")))
+	case "publicdomain":
+		qs = append(qs, cc("publicdomain/"))
+	}
+
+	return qs
+}
+
+// colorName maps a color to the closest color of the Palette
+func colorName(r, g, b uint8) string {
+	h, s, l := hsl(r, g, b)
+
+	switch {
+	case l < .15:
+		return "black"
+	case l > .9:
+		return "white"
+	case s < .15:
+		return "gray"
+	case (h < 45 || h >= 345) && l < .4:
+		return "brown"
+	case h < 15 || h >= 345:
+		return "red"
+	case h < 45:
+		return "orange"
+	case h < 70:
+		return "yellow"
+	case h < 160:
+		return "green"
+	case h < 200:
+		return "teal"
+	case h < 260:
+		return "blue"
+	case h < 320:
+		return "purple"
+	default:
+		return "pink"
+	}
+}
+
+// hsl converts a color to its hue (in degrees), saturation & lightness
+func hsl(r, g, b uint8) (h, s, l float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+
+	hi, lo := rf, rf
+	for _, v := range []float64{gf, bf} {
+		if v > hi {
+			hi = v
+		}
+		if v < lo {
+			lo = v
+		}
+	}
+
+	l = (hi + lo) / 2
+	if hi == lo {
+		return 0, 0, l
+	}
+
+	d := hi - lo
+	if l > .5 {
+		s = d / (2 - hi - lo)
+	} else {
+		s = d / (hi + lo)
+	}
+
+	switch hi {
+	case rf:
+		h = (gf - bf) / d
+		if gf < bf {
+			h += 6
+		}
+	case gf:
+		h = (bf-rf)/d + 2
+	default:
+		h = (rf-gf)/d + 4
+	}
+
+	return h * 60, s, l
+}
+
+// palette is the distinct names of colors
+func palette(colors []string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+
+	for _, c := range colors {
+		var r, g, b uint8
+		if _, err := fmt.Sscanf(c, "#%02x%02x%02x", &r, &g, &b); err != nil {
+			continue
+		}
+
+		n := colorName(r, g, b)
+		if !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,60 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewFilters(t *testing.T) {
+	for _, c := range []struct {
+		name                                    string
+		size, orientation, color, mime, license string
+		want                                    Filters
+		key                                     string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "all", size: "Large", orientation: "wide", color: "bw", mime: " png ", license: "cc",
+			want: Filters{Size: "large", Orientation: "wide", Color: "bw", MIME: "png", License: "cc"},
+			key:  "color=bw&license=cc&mime=png&orientation=wide&size=large",
+		},
+		{
+			name: "unsupported", size: "huge", orientation: "wide", color: "plaid", mime: "exe", license: "gpl",
+			want: Filters{Orientation: "wide"},
+			key:  "orientation=wide",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got := NewFilters(c.size, c.orientation, c.color, c.mime, c.license)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %+v; want %+v", got, c.want)
+			}
+
+			if got.String() != c.key {
+				t.Fatalf("got key %q; want %q", got.String(), c.key)
+			}
+		})
+	}
+}
+
+func TestPalette(t *testing.T) {
+	for _, c := range []struct {
+		colors []string
+		want   []string
+	}{
+		{[]string{"#ff0000", "#0000ff"}, []string{"blue", "red"}},
+		{[]string{"#000000", "#ffffff", "#808080"}, []string{"black", "gray", "white"}},
+		{[]string{"#ff8c00", "#ffd700", "#228b22", "#008080"}, []string{"green", "orange", "teal", "yellow"}},
+		{[]string{"#800080", "#ff69b4", "#8b4513"}, []string{"brown", "pink", "purple"}},
+		{[]string{"#fe0000", "#ff0101", "invalid"}, []string{"red"}},
+	} {
+		t.Run(c.colors[0], func(t *testing.T) {
+			got := palette(c.colors)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %v; want %v", got, c.want)
+			}
+		})
+	}
+}
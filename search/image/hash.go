@@ -0,0 +1,173 @@
+package image
+
+import (
+	"fmt"
+	stdimage "image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+
+	"golang.org/x/image/draw"
+)
+
+// Perceptual hashes are 64 bit fingerprints of what an image looks like.
+// Unlike a checksum, a resized or recompressed copy of an image has the same
+// (or a very similar) hash so we can find duplicates by the Hamming
+// distance of their hashes.
+// http://www.hackerfactor.com/blog/?/archives/529-Kind-of-Like-That.html
+const (
+	hashSize  = 8
+	dctSize   = 32
+	hashWidth = 16 // hex chars
+)
+
+// DuplicateDistance is the max Hamming distance between the
+// dHashes of two images for them to be (near) duplicates
+var DuplicateDistance = 6
+
+// DHash is the difference hash of an image. Each bit tells us if
+// a pixel is brighter than its neighbor to the right.
+func DHash(m stdimage.Image) uint64 {
+	g := gray(m, hashSize+1, hashSize)
+
+	var h uint64
+	for y := 0; y < hashSize; y++ {
+		for x := 0; x < hashSize; x++ {
+			h <<= 1
+			if g.GrayAt(x, y).Y > g.GrayAt(x+1, y).Y {
+				h |= 1
+			}
+		}
+	}
+
+	return h
+}
+
+// PHash is the perceptual hash of an image. Each bit tells us if a low
+// frequency of the discrete cosine transform is above the median.
+func PHash(m stdimage.Image) uint64 {
+	g := gray(m, dctSize, dctSize)
+
+	pixels := make([][]float64, dctSize)
+	for y := range pixels {
+		pixels[y] = make([]float64, dctSize)
+		for x := range pixels[y] {
+			pixels[y][x] = float64(g.GrayAt(x, y).Y)
+		}
+	}
+
+	coeffs := dct2(pixels)
+
+	low := make([]float64, 0, hashSize*hashSize)
+	for y := 0; y < hashSize; y++ {
+		for x := 0; x < hashSize; x++ {
+			low = append(low, coeffs[y][x])
+		}
+	}
+
+	// the DC coefficient (the average brightness) would skew the median
+	sorted := append([]float64{}, low[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var h uint64
+	for _, c := range low {
+		h <<= 1
+		if c > median {
+			h |= 1
+		}
+	}
+
+	return h
+}
+
+// Distance is the Hamming distance between two hashes
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// FormatHash formats a hash as a fixed width hex string
+func FormatHash(h uint64) string {
+	return fmt.Sprintf("%0*x", hashWidth, h)
+}
+
+// ParseHash parses a hash formatted by FormatHash
+func ParseHash(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// Collapse removes the images that are (near) duplicates of an
+// image that comes before them. Images without a hash are kept.
+func Collapse(images []*Image, distance int) []*Image {
+	kept := []*Image{}
+	hashes := []uint64{}
+
+outer:
+	for _, im := range images {
+		h, err := ParseHash(im.DHash)
+		if err != nil {
+			kept = append(kept, im)
+			continue
+		}
+
+		for _, k := range hashes {
+			if Distance(h, k) <= distance {
+				continue outer
+			}
+		}
+
+		hashes = append(hashes, h)
+		kept = append(kept, im)
+	}
+
+	return kept
+}
+
+// gray scales an image down to a grayscale thumbnail
+func gray(m stdimage.Image, w, h int) *stdimage.Gray {
+	g := stdimage.NewGray(stdimage.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(g, g.Bounds(), m, m.Bounds(), draw.Src, nil)
+	return g
+}
+
+// dct2 is the 2 dimensional (type II) discrete cosine transform of a square matrix
+func dct2(m [][]float64) [][]float64 {
+	n := len(m)
+
+	rows := make([][]float64, n)
+	for y := range m {
+		rows[y] = dct(m[y])
+	}
+
+	out := make([][]float64, n)
+	for y := range out {
+		out[y] = make([]float64, n)
+	}
+
+	col := make([]float64, n)
+	for x := 0; x < n; x++ {
+		for y := 0; y < n; y++ {
+			col[y] = rows[y][x]
+		}
+		for y, c := range dct(col) {
+			out[y][x] = c
+		}
+	}
+
+	return out
+}
+
+func dct(v []float64) []float64 {
+	n := len(v)
+	out := make([]float64, n)
+	for k := range out {
+		var sum float64
+		for i, x := range v {
+			sum += x * math.Cos(math.Pi/float64(n)*(float64(i)+.5)*float64(k))
+		}
+		out[k] = sum
+	}
+
+	return out
+}
@@ -0,0 +1,96 @@
+package image
+
+import (
+	stdimage "image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/draw"
+)
+
+func TestHashes(t *testing.T) {
+	original := pattern(128, 96, false)
+
+	resized := stdimage.NewRGBA(stdimage.Rect(0, 0, 300, 225))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), original, original.Bounds(), draw.Src, nil)
+
+	different := pattern(128, 96, true)
+
+	for _, c := range []struct {
+		name string
+		hash func(stdimage.Image) uint64
+	}{
+		{"dhash", DHash},
+		{"phash", PHash},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			h := c.hash(original)
+
+			if d := Distance(h, c.hash(resized)); d > DuplicateDistance {
+				t.Fatalf("resized copy has a distance of %d", d)
+			}
+
+			if d := Distance(h, c.hash(different)); d <= DuplicateDistance {
+				t.Fatalf("different image has a distance of %d", d)
+			}
+		})
+	}
+}
+
+func TestFormatHash(t *testing.T) {
+	for _, h := range []uint64{0, 1, 0xf0f0f0f0f0f0f0f0, 1<<64 - 1} {
+		s := FormatHash(h)
+		if len(s) != 16 {
+			t.Fatalf("got %q; want 16 chars", s)
+		}
+
+		got, err := ParseHash(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != h {
+			t.Fatalf("got %x; want %x", got, h)
+		}
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	images := []*Image{
+		{ID: "a", DHash: "f0f0f0f0f0f0f0f0"},
+		{ID: "b", DHash: "0f0f0f0f0f0f0f0f"},
+		{ID: "c", DHash: "f0f0f0f0f0f0f0f1"}, // near duplicate of a
+		{ID: "d"},                            // not hashed yet
+		{ID: "e", DHash: "f0f0f0f0f0f0f0f0"}, // exact duplicate of a
+	}
+
+	got := []string{}
+	for _, im := range Collapse(images, DuplicateDistance) {
+		got = append(got, im.ID)
+	}
+
+	want := []string{"a", "b", "d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
+
+// pattern draws diagonal bands (or their negative)
+func pattern(w, h int, negative bool) *stdimage.RGBA {
+	m := stdimage.NewRGBA(stdimage.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8((x*3 + y*5) % 256)
+			if (x/32+y/24)%2 == 0 {
+				v = 255 - v
+			}
+			if negative {
+				v = 255 - v
+			}
+			m.Set(x, y, color.RGBA{v, v / 2, 255 - v, 255})
+		}
+	}
+
+	return m
+}
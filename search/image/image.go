@@ -10,13 +10,23 @@ import (
 
 // Image is a link to an image
 type Image struct {
-	ID     string  `json:"id"`
-	Domain string  `json:"domain"`
-	Alt    string  `json:"alt,omitempty"`
-	NSFW   float64 `json:"nsfw_score,omitempty"`
-	Width  int     `json:"width,omitempty"`
-	Height int     `json:"height,omitempty"`
+	ID          string   `json:"id"`
+	Domain      string   `json:"domain"`
+	Alt         string   `json:"alt,omitempty"`
+	NSFW        float64  `json:"nsfw_score,omitempty"`
+	Width       int      `json:"width,omitempty"`
+	Height      int      `json:"height,omitempty"`
+	Colors      []string `json:"colors,omitempty"`  // dominant colors ("#ff0000")
+	Palette     []string `json:"palette,omitempty"` // names of the dominant colors ("red")
+	Grayscale   bool     `json:"grayscale,omitempty"`
+	Transparent bool     `json:"transparent,omitempty"`
+	Skin        float64  `json:"skin,omitempty"`  // share of the (opaque) pixels that are skin tones
+	DHash       string   `json:"dhash,omitempty"` // difference hash (hex)
+	PHash       string   `json:"phash,omitempty"` // perceptual hash (hex)
+	Chunks      []string `json:"phash_chunks,omitempty"`
+	Thumbnail   string   `json:"thumbnail,omitempty"` // key of the thumbnail in a Store
 	EXIF
+	Source
 	Classification map[string]float64 `json:"classification,omitempty"`
 	MIME           string             `json:"mime,omitempty"`
 	Crawled        string             `json:"crawled,omitempty"`
@@ -24,14 +34,35 @@ type Image struct {
 }
 
 // EXIF is the metadata of an image
-// What other fields do we need???
 type EXIF struct {
-	Copyright string `json:"copyright,omitempty"`
+	Copyright string    `json:"copyright,omitempty"`
+	Artist    string    `json:"artist,omitempty"`
+	Camera    string    `json:"camera,omitempty"` // make & model
+	Taken     string    `json:"taken,omitempty"`  // the date the photo was taken
+	Location  *Location `json:"location,omitempty"`
+	License   string    `json:"license,omitempty"` // from the XMP packet (e.g. a Creative Commons URL)
+}
+
+// Source is the page an image was found on and the text around it.
+// Most images have no alt text so this is how we find them.
+type Source struct {
+	Page      string `json:"page,omitempty"`       // url of the page
+	PageTitle string `json:"page_title,omitempty"` // <title> of the page
+	Caption   string `json:"caption,omitempty"`    // <figcaption> of the <figure> the image is in
+	Heading   string `json:"heading,omitempty"`    // the closest heading before the image
+	Link      string `json:"link,omitempty"`       // where the <a> around the image points to
+}
+
+// Location is where a photo was taken (from GPS)
+type Location struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
 }
 
 // Fetcher outlines the methods used to retrieve the image results
 type Fetcher interface {
-	Fetch(q string, safe bool, number int, offset int) (*Results, error)
+	Fetch(q string, safe bool, f Filters, number int, offset int) (*Results, error)
+	Similar(hash uint64, distance int, safe bool, f Filters, number int) (*Results, error)
 }
 
 // Results are the image results from a query
@@ -0,0 +1,73 @@
+package image
+
+import (
+	"fmt"
+	"sort"
+)
+
+// We find similar images with multi-index hashing. The perceptual
+// hash is split into chunks that are indexed as exact terms. If two hashes
+// are within a Hamming distance of r of each other then at least one of
+// their r+1 chunks must be identical (pigeonhole principle) so a term
+// lookup gives us the candidates without scanning the whole index.
+// The real distance of each candidate is then computed in Go.
+// A common chunk (e.g. of a plain image) can be shared by millions of
+// images so we only look at the MaxCandidates that share the most chunks
+// with the hash. Those are the closest ones but a match beyond the cap is missed.
+// https://www.cs.toronto.edu/~norouzi/research/papers/multi_index_hashing.pdf
+const (
+	hashChunks = 8
+	chunkBits  = 64 / hashChunks
+)
+
+// MaxSimilarDistance is the max Hamming distance between the pHashes
+// of two similar images that share at least one chunk.
+var MaxSimilarDistance = hashChunks - 1
+
+// DefaultMaxCandidates is the default number of images we compare with a hash
+const DefaultMaxCandidates = 1000
+
+// Chunks splits a hash into the terms we index ("0:a1", "1:ff", etc).
+// The position is part of the term so a chunk only matches the same
+// chunk of another hash.
+func Chunks(h uint64) []string {
+	chunks := make([]string, hashChunks)
+	for i := range chunks {
+		shift := uint(64 - chunkBits*(i+1))
+		chunks[i] = fmt.Sprintf("%d:%02x", i, (h>>shift)&(1<<chunkBits-1))
+	}
+
+	return chunks
+}
+
+// nearest keeps the images whose pHash is within distance of h,
+// the closest ones first
+func nearest(h uint64, images []*Image, distance int) []*Image {
+	type match struct {
+		*Image
+		distance int
+	}
+
+	matches := []match{}
+	for _, im := range images {
+		ph, err := ParseHash(im.PHash)
+		if err != nil {
+			continue
+		}
+
+		if d := Distance(h, ph); d <= distance {
+			matches = append(matches, match{im, d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	kept := []*Image{}
+	for _, m := range matches {
+		kept = append(kept, m.Image)
+	}
+
+	return kept
+}
@@ -0,0 +1,57 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunks(t *testing.T) {
+	got := Chunks(0x0123456789abcdef)
+	want := []string{"0:01", "1:23", "2:45", "3:67", "4:89", "5:ab", "6:cd", "7:ef"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
+
+func TestChunksPigeonhole(t *testing.T) {
+	h := uint64(0xf0f0f0f0f0f0f0f0)
+
+	// flip a bit in each of 7 chunks. One chunk must still match.
+	var o uint64 = h
+	for i := 0; i < MaxSimilarDistance; i++ {
+		o ^= 1 << uint(i*chunkBits)
+	}
+
+	shared := 0
+	other := Chunks(o)
+	for i, c := range Chunks(h) {
+		if c == other[i] {
+			shared++
+		}
+	}
+
+	if shared == 0 {
+		t.Fatalf("hashes %x and %x with distance %d share no chunks", h, o, Distance(h, o))
+	}
+}
+
+func TestNearest(t *testing.T) {
+	images := []*Image{
+		{ID: "far", PHash: "0f0f0f0f0f0f0f0f"},
+		{ID: "close", PHash: "f0f0f0f0f0f0f0f3"},
+		{ID: "no hash"},
+		{ID: "same", PHash: "f0f0f0f0f0f0f0f0"},
+		{ID: "closer", PHash: "f0f0f0f0f0f0f0f1"},
+	}
+
+	got := []string{}
+	for _, im := range nearest(0xf0f0f0f0f0f0f0f0, images, 4) {
+		got = append(got, im.ID)
+	}
+
+	want := []string{"same", "closer", "close"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+}
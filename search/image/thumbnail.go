@@ -0,0 +1,163 @@
+package image
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	stdimage "image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"golang.org/x/image/draw"
+)
+
+// Store saves thumbnails by their key
+type Store interface {
+	Put(key string, b []byte) error
+	Get(key string) ([]byte, error)
+}
+
+// ErrNotFound is returned by a Store when there is no thumbnail for a key
+var ErrNotFound = fmt.Errorf("thumbnail not found")
+
+var errInvalidKey = fmt.Errorf("invalid thumbnail key")
+
+// reKey is a key made by a Thumbnailer: the sha256 of the thumbnail & its extension
+var reKey = regexp.MustCompile(`^[0-9a-f]{64}\.(jpg|png)$`)
+
+// ValidKey tells us if a key could have been made by a Thumbnailer
+func ValidKey(key string) bool {
+	return reKey.MatchString(key)
+}
+
+// Thumbnailer makes small copies of images to show in the results.
+// Thumbnails are JPEGs, or PNGs for images that are transparent.
+// They are content-addressed so copies of an image on different
+// urls share a thumbnail and a thumbnail never changes.
+// NOTE: x/image/webp can only decode. We'll add WebP once there is
+// an encoder that doesn't need cgo.
+type Thumbnailer struct {
+	Store
+	Size    int // max width & height
+	Quality int // JPEG quality (1-100)
+}
+
+// Save makes a thumbnail of an image, stores it and returns its key
+func (t *Thumbnailer) Save(m stdimage.Image, transparent bool) (string, error) {
+	thumb := resize(m, t.Size)
+
+	var b bytes.Buffer
+	ext := "jpg"
+
+	var err error
+	if transparent {
+		ext = "png"
+		err = png.Encode(&b, thumb)
+	} else {
+		err = jpeg.Encode(&b, thumb, &jpeg.Options{Quality: t.Quality})
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b.Bytes())
+	key := fmt.Sprintf("%x.%v", sum, ext)
+	return key, t.Store.Put(key, b.Bytes())
+}
+
+// resize scales an image down to fit in a size x size box.
+// Smaller images are left as is.
+func resize(m stdimage.Image, size int) stdimage.Image {
+	b := m.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return m
+	}
+
+	if w > h {
+		w, h = size, h*size/w
+	} else {
+		w, h = w*size/h, size
+	}
+
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	thumb := stdimage.NewNRGBA(stdimage.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(thumb, thumb.Bounds(), m, b, draw.Src, nil)
+	return thumb
+}
+
+// DiskStore keeps thumbnails on disk. The files are spread over
+// subdirectories by the first 2 chars of their key ("ab/abcd...jpg").
+type DiskStore struct {
+	Dir string
+}
+
+// Put writes a thumbnail. As keys are content-addressed we
+// don't need to rewrite a thumbnail we already have.
+func (s *DiskStore) Put(key string, b []byte) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(p); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	// write to a temp file first so a reader never sees a partial thumbnail
+	tmp, err := ioutil.TempFile(filepath.Dir(p), ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), p)
+}
+
+// Get reads a thumbnail
+func (s *DiskStore) Get(key string) ([]byte, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return b, err
+}
+
+func (s *DiskStore) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", errInvalidKey
+	}
+
+	return filepath.Join(s.Dir, key[:2], key), nil
+}
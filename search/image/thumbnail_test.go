@@ -0,0 +1,113 @@
+package image
+
+import (
+	"bytes"
+	stdimage "image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestThumbnailer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	th := &Thumbnailer{Store: &DiskStore{Dir: dir}, Size: 100, Quality: 80}
+
+	for _, c := range []struct {
+		name        string
+		w, h        int
+		transparent bool
+		ext         string
+		wantW       int
+		wantH       int
+	}{
+		{"wide", 400, 200, false, ".jpg", 100, 50},
+		{"tall", 150, 300, false, ".jpg", 50, 100},
+		{"small", 40, 30, false, ".jpg", 40, 30},
+		{"transparent", 300, 300, true, ".png", 100, 100},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			m := stdimage.NewNRGBA(stdimage.Rect(0, 0, c.w, c.h))
+			for y := 0; y < c.h; y++ {
+				for x := 0; x < c.w; x++ {
+					m.Set(x, y, color.NRGBA{uint8(x), uint8(y), 100, 255})
+				}
+			}
+
+			key, err := th.Save(m, c.transparent)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !ValidKey(key) || filepath.Ext(key) != c.ext {
+				t.Fatalf("got key %q", key)
+			}
+
+			// saving the same image again gives the same key
+			again, err := th.Save(m, c.transparent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != key {
+				t.Fatalf("got key %q; want %q", again, key)
+			}
+
+			b, err := th.Get(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, _, err := stdimage.DecodeConfig(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if cfg.Width != c.wantW || cfg.Height != c.wantH {
+				t.Fatalf("got %dx%d; want %dx%d", cfg.Width, cfg.Height, c.wantW, c.wantH)
+			}
+		})
+	}
+}
+
+func TestDiskStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &DiskStore{Dir: dir}
+	key := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef.jpg"
+
+	if _, err := s.Get(key); err != ErrNotFound {
+		t.Fatalf("got err %v; want %v", err, ErrNotFound)
+	}
+
+	if err := s.Put(key, []byte("thumbnail")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "01", key)); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := s.Get(key)
+	if err != nil || string(b) != "thumbnail" {
+		t.Fatalf("got %q, %v", b, err)
+	}
+
+	for _, k := range []string{"../../etc/passwd", "abc.jpg", key + "/..", "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef.gif"} {
+		if err := s.Put(k, []byte("x")); err != errInvalidKey {
+			t.Fatalf("%q: got err %v; want %v", k, err, errInvalidKey)
+		}
+		if _, err := s.Get(k); err != errInvalidKey {
+			t.Fatalf("%q: got err %v; want %v", k, err, errInvalidKey)
+		}
+	}
+}
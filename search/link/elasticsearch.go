@@ -0,0 +1,196 @@
+package link
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/jivesearch/jivesearch/log"
+	"github.com/olivere/elastic"
+)
+
+// ElasticSearch hold connection and index settings.
+// We keep one document for each source & target.
+type ElasticSearch struct {
+	Client *elastic.Client
+	Index  string
+	Type   string
+	Bulk   *elastic.BulkProcessor
+}
+
+// ID is the document ID of a source & target
+func ID(source, target string) string {
+	h := sha1.Sum([]byte(source + " " + target))
+	return hex.EncodeToString(h[:])
+}
+
+// Upsert saves a link. A link we've seen before gets the
+// anchor text & authority of the latest crawl of its source.
+func (e *ElasticSearch) Upsert(l *Link) error {
+	item := elastic.NewBulkIndexRequest().
+		Index(e.Index).
+		Type(e.Type).
+		Id(ID(l.Source, l.Target)).
+		Doc(l)
+
+	e.Bulk.Add(item)
+	return nil
+}
+
+// Prune deletes the links from a source that aren't to one of its
+// current targets so a link the source dropped stops counting
+func (e *ElasticSearch) Prune(source string, targets []string) error {
+	ids := []string{}
+	for _, t := range targets {
+		ids = append(ids, ID(source, t))
+	}
+
+	qu := elastic.NewBoolQuery().
+		Filter(elastic.NewTermQuery("source", source)).
+		MustNot(elastic.NewIdsQuery(e.Type).Ids(ids...))
+
+	_, err := e.Client.DeleteByQuery(e.Index).
+		Type(e.Type).
+		Query(qu).
+		Conflicts("proceed").
+		Do(context.TODO())
+
+	if err != nil && elastic.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Authority is the number of other domains that link to a page
+func (e *ElasticSearch) Authority(target string) (int64, error) {
+	qu := elastic.NewBoolQuery().Filter(elastic.NewTermQuery("target", target))
+	if dom, err := Domain(target); err == nil {
+		qu = qu.MustNot(elastic.NewTermQuery("domain", dom))
+	}
+
+	out, err := e.Client.Search().
+		Index(e.Index).
+		Type(e.Type).
+		Query(qu).
+		Aggregation("domains", elastic.NewCardinalityAggregation().Field("domain")).
+		Size(0).
+		Do(context.TODO())
+
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			err = nil
+		}
+		return 0, err
+	}
+
+	agg, ok := out.Aggregations.Cardinality("domains")
+	if !ok || agg.Value == nil {
+		return 0, nil
+	}
+
+	return int64(*agg.Value), nil
+}
+
+// Fetch returns the links to a page (or to any page of a domain)
+// with the links from the pages with the most authority first
+func (e *ElasticSearch) Fetch(target string, domain bool, number, offset int) (*Results, error) {
+	res := &Results{Target: target, Domain: domain, Links: []*Link{}}
+
+	field := "target"
+	if domain {
+		field = "target_domain"
+	}
+
+	out, err := e.Client.Search().
+		Index(e.Index).
+		Type(e.Type).
+		Query(elastic.NewTermQuery(field, target)).
+		Sort("authority", false).
+		Sort("source", true).
+		From(offset).
+		Size(number).
+		Do(context.TODO())
+
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			err = nil
+		}
+		return res, err
+	}
+
+	res.Count = out.TotalHits()
+
+	for _, h := range out.Hits.Hits {
+		l := &Link{}
+		if err := json.Unmarshal(*h.Source, l); err != nil {
+			return res, err
+		}
+
+		res.Links = append(res.Links, l)
+	}
+
+	return res, nil
+}
+
+// Setup will create our link index
+func (e *ElasticSearch) Setup() error {
+	exists, err := e.Client.IndexExists(e.Index).Do(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		log.Info.Println("Creating index:", e.Index)
+		if _, err = e.Client.CreateIndex(e.Index).Body(e.mapping()).Do(context.TODO()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapping is the mapping of our link Index.
+func (e *ElasticSearch) mapping() string {
+	m := `{
+		"mappings": {
+			"link": {
+				"_all": {
+					"enabled": false
+				},
+				"dynamic": "strict",
+				"properties": {
+					"source": {
+						"type": "keyword"
+					},
+					"target": {
+						"type": "keyword"
+					},
+					"domain": {
+						"type": "keyword"
+					},
+					"target_domain": {
+						"type": "keyword"
+					},
+					"anchor": {
+						"type": "text",
+						"index": "false"
+					},
+					"nofollow": {
+						"type": "boolean"
+					},
+					"authority": {
+						"type": "integer"
+					},
+					"crawled": {
+						"type": "date",
+						"format": "basic_date"
+					}
+				}
+			}
+		}
+	}`
+
+	return m
+}
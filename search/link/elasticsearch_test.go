@@ -0,0 +1,239 @@
+package link
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/olivere/elastic"
+)
+
+func TestUpsert(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"took": 27,
+			"errors": false,
+			"items": [
+				{
+					"index": {
+						"_index": "links",
+						"_type": "link",
+						"_id": "3d1b2c4b9b3a5f4c8d2b6a0e1f7c9d8e5a4b3c2d",
+						"_version": 1,
+						"status": 201
+					}
+				}
+			]
+		}`))
+	}))
+	defer ts.Close()
+
+	e, err := MockService(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := New("http://www.example.org/", "http://www.example.com/", "An Example", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Upsert(l)
+
+	if err := e.Bulk.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := e.Bulk.Stats()
+	if stats.Succeeded != 1 {
+		t.Fatalf("upsert failed: got %d", stats.Succeeded)
+	}
+}
+
+func TestAuthority(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{
+			"hits": {"total": 12, "hits": []},
+			"aggregations": {"domains": {"value": 7}}
+		}`))
+	}))
+	defer ts.Close()
+
+	e, err := MockService(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := e.Authority("http://www.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != 7 {
+		t.Fatalf("got %d; want 7", got)
+	}
+
+	// links from the page's own domain don't count
+	if !strings.Contains(body, `"must_not":{"term":{"domain":"example.com"}}`) {
+		t.Fatalf("internal links aren't excluded: %v", body)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	var path, body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		path, body = r.URL.Path, string(b)
+		w.Write([]byte(`{"took": 3, "deleted": 1, "failures": []}`))
+	}))
+	defer ts.Close()
+
+	e, err := MockService(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := "http://www.example.org/"
+	if err := e.Prune(src, []string{"http://www.example.com/"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(path, "/_delete_by_query") {
+		t.Fatalf("got path %q; want a delete by query", path)
+	}
+
+	for _, want := range []string{
+		`{"term":{"source":"http://www.example.org/"}}`,
+		`"must_not":{"ids":{"type":"link","values":["` + ID(src, "http://www.example.com/") + `"]}}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("got %v; want %v", body, want)
+		}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		target string
+		domain bool
+		field  string
+	}{
+		{"url", "http://www.example.com/", false, `{"term":{"target":"http://www.example.com/"}}`},
+		{"domain", "example.com", true, `{"term":{"target_domain":"example.com"}}`},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var body string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				body = string(b)
+				w.Write([]byte(`{
+					"hits": {
+						"total": 42,
+						"hits": [
+							{
+								"_id": "1",
+								"_source": {
+									"source": "https://www.example.org/", "target": "http://www.example.com/",
+									"domain": "example.org", "target_domain": "example.com",
+									"anchor": "Example", "nofollow": false, "authority": 120, "crawled": "20180702"
+								}
+							},
+							{
+								"_id": "2",
+								"_source": {
+									"source": "https://www.example.net/", "target": "http://www.example.com/about",
+									"domain": "example.net", "target_domain": "example.com",
+									"nofollow": true, "authority": 3
+								}
+							}
+						]
+					}
+				}`))
+			}))
+			defer ts.Close()
+
+			e, err := MockService(ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := e.Fetch(c.target, c.domain, 2, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := &Results{
+				Target: c.target,
+				Domain: c.domain,
+				Count:  42,
+				Links: []*Link{
+					{
+						Source: "https://www.example.org/", Target: "http://www.example.com/",
+						Domain: "example.org", TargetDomain: "example.com",
+						Anchor: "Example", Authority: 120, Crawled: "20180702",
+					},
+					{
+						Source: "https://www.example.net/", Target: "http://www.example.com/about",
+						Domain: "example.net", TargetDomain: "example.com",
+						NoFollow: true, Authority: 3,
+					},
+				},
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %+v; want %+v", got, want)
+			}
+
+			for _, s := range []string{c.field, `"from":10`, `"size":2`, `{"authority":{"order":"desc"}}`} {
+				if !strings.Contains(body, s) {
+					t.Fatalf("%v not in %v", s, body)
+				}
+			}
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"acknowledged": true}`))
+	}))
+	defer ts.Close()
+
+	e, err := MockService(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Setup(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func MockService(url string) (*ElasticSearch, error) {
+	client, err := elastic.NewSimpleClient(elastic.SetURL(url))
+	if err != nil {
+		return nil, err
+	}
+
+	bulk, err := client.BulkProcessor().Stats(true).Do(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ElasticSearch{
+		Client: client,
+		Index:  "links",
+		Type:   "link",
+		Bulk:   bulk,
+	}, nil
+}
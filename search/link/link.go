@@ -0,0 +1,125 @@
+// Package link stores the links between pages so we can look up
+// the pages that link to a URL or a domain (backlinks).
+package link
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jivesearch/jivesearch/search/anchor"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Link is a link from a source page to a target
+type Link struct {
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	Domain       string `json:"domain"`        // the domain of the source
+	TargetDomain string `json:"target_domain"` // the domain of the target
+	Anchor       string `json:"anchor,omitempty"`
+	NoFollow     bool   `json:"nofollow"`
+	Authority    int64  `json:"authority"` // the number of domains that link to the source
+	Crawled      string `json:"crawled,omitempty"`
+}
+
+// Results are the pages that link to a URL or domain
+type Results struct {
+	Target string  `json:"target"`
+	Domain bool    `json:"domain"` // are these the links to any page of a domain?
+	Count  int64   `json:"count"`
+	Links  []*Link `json:"links"`
+}
+
+// Fetcher outlines the methods used to look up the links to a URL or domain
+type Fetcher interface {
+	Fetch(target string, domain bool, number, offset int) (*Results, error)
+}
+
+// MaxAnchorLength is the max number of characters we keep of the anchor text
+var MaxAnchorLength = 100
+
+// Operator is the query operator for link lookups ("link:example.com")
+const Operator = "link:"
+
+// The descriptions of a link when it is shown as a search result
+const (
+	LinksTo           = "Links to %v"
+	LinksToWithAnchor = "Links to %v with %q"
+	LinksToNoFollow   = "%v (nofollow)"
+)
+
+// Descriptions are all of our link descriptions (e.g. to translate them)
+var Descriptions = []string{LinksTo, LinksToWithAnchor, LinksToNoFollow}
+
+var errInvalid = fmt.Errorf("invalid link")
+
+// New creates a Link and normalizes its anchor text
+func New(source, target, text string, nofollow bool) (*Link, error) {
+	src, err := Domain(source)
+	if err != nil {
+		return nil, err
+	}
+
+	dst, err := Domain(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Link{
+		Source:       source,
+		Target:       target,
+		Domain:       src,
+		TargetDomain: dst,
+		Anchor:       anchor.Normalize(text, MaxAnchorLength),
+		NoFollow:     nofollow,
+	}, nil
+}
+
+// Domain returns the domain (tld+1) of a link
+func Domain(lnk string) (string, error) {
+	u, err := url.Parse(lnk)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Hostname() == "" {
+		return "", errInvalid
+	}
+
+	return publicsuffix.EffectiveTLDPlusOne(strings.ToLower(u.Hostname()))
+}
+
+// Parse extracts the target of a "link:" query.
+// A URL matches the links to that page while a domain ("link:example.com")
+// matches the links to any page of the domain.
+func Parse(q string) (target string, domain bool, ok bool) {
+	q = strings.TrimSpace(q)
+	if !strings.HasPrefix(strings.ToLower(q), Operator) {
+		return "", false, false
+	}
+
+	target = strings.TrimSpace(q[len(Operator):])
+	if target == "" || strings.ContainsAny(target, " \t\n") {
+		return "", false, false
+	}
+
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		u, err := url.Parse(target)
+		if err != nil || u.Hostname() == "" {
+			return "", false, false
+		}
+		u.Fragment = ""
+		if u.Path == "" {
+			u.Path = "/"
+		}
+		return u.String(), false, true
+	}
+
+	dom, err := Domain("http://" + target)
+	if err != nil {
+		return "", false, false
+	}
+
+	return dom, true, true
+}
@@ -0,0 +1,113 @@
+package link
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	type want struct {
+		link *Link
+		err  bool
+	}
+
+	for _, c := range []struct {
+		name     string
+		source   string
+		target   string
+		anchor   string
+		nofollow bool
+		want
+	}{
+		{
+			name:   "basic",
+			source: "https://blog.example.org/post",
+			target: "http://www.Example.com/",
+			anchor: "  An   Example\n Page ",
+			want: want{
+				link: &Link{
+					Source:       "https://blog.example.org/post",
+					Target:       "http://www.Example.com/",
+					Domain:       "example.org",
+					TargetDomain: "example.com",
+					Anchor:       "An Example Page",
+				},
+			},
+		},
+		{
+			name:     "nofollow",
+			source:   "https://www.example.co.uk/",
+			target:   "https://www.example.com/",
+			anchor:   strings.Repeat("a", 150),
+			nofollow: true,
+			want: want{
+				link: &Link{
+					Source:       "https://www.example.co.uk/",
+					Target:       "https://www.example.com/",
+					Domain:       "example.co.uk",
+					TargetDomain: "example.com",
+					Anchor:       strings.Repeat("a", 100),
+					NoFollow:     true,
+				},
+			},
+		},
+		{
+			name:   "multibyte",
+			source: "https://www.example.fr/",
+			target: "https://www.example.com/",
+			anchor: strings.Repeat("é", 150),
+			want: want{
+				link: &Link{
+					Source:       "https://www.example.fr/",
+					Target:       "https://www.example.com/",
+					Domain:       "example.fr",
+					TargetDomain: "example.com",
+					Anchor:       strings.Repeat("é", 100),
+				},
+			},
+		},
+		{
+			name:   "no host",
+			source: "https://www.example.com/",
+			target: "/relative",
+			want:   want{err: true},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := New(c.source, c.target, c.anchor, c.nofollow)
+			if (err != nil) != c.want.err {
+				t.Fatalf("got err %v; want err %v", err, c.want.err)
+			}
+
+			if !reflect.DeepEqual(got, c.want.link) {
+				t.Fatalf("got %+v; want %+v", got, c.want.link)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		q      string
+		target string
+		domain bool
+		ok     bool
+	}{
+		{"link:example.com", "example.com", true, true},
+		{"  LINK:www.Example.co.uk ", "example.co.uk", true, true},
+		{"link:https://www.example.com", "https://www.example.com/", false, true},
+		{"link:https://www.example.com/page#top", "https://www.example.com/page", false, true},
+		{"link:", "", false, false},
+		{"link:example.com jimi hendrix", "", false, false},
+		{"jimi hendrix", "", false, false},
+		{"hyperlink:example.com", "", false, false},
+	} {
+		t.Run(c.q, func(t *testing.T) {
+			target, domain, ok := Parse(c.q)
+			if target != c.target || domain != c.domain || ok != c.ok {
+				t.Fatalf("got %q, %v, %v; want %q, %v, %v", target, domain, ok, c.target, c.domain, c.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Package mapping updates the mapping of an existing index
+package mapping
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/olivere/elastic"
+)
+
+// Put maps a type of an existing index from the body the index was
+// created with. Our mappings are strict so the fields we've added
+// since an index was created have to be mapped before we can store them.
+func Put(client *elastic.Client, index, typ, body string) error {
+	m, err := typeMapping(body, typ)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.PutMapping().Index(index).Type(typ).BodyString(m).Do(context.TODO())
+	return err
+}
+
+// typeMapping is the mapping of a type without the settings of the index
+func typeMapping(body, typ string) (string, error) {
+	m := struct {
+		Mappings map[string]json.RawMessage `json:"mappings"`
+	}{}
+
+	if err := json.Unmarshal([]byte(body), &m); err != nil {
+		return "", err
+	}
+
+	tm, ok := m.Mappings[typ]
+	if !ok {
+		return "", fmt.Errorf("no mapping for type %q", typ)
+	}
+
+	return string(tm), nil
+}
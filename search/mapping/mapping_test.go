@@ -0,0 +1,62 @@
+package mapping
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olivere/elastic"
+)
+
+func TestPut(t *testing.T) {
+	body := `{
+		"settings": {"number_of_shards": 1},
+		"mappings": {"page": {"dynamic": "strict", "properties": {"title": {"type": "text"}}}}
+	}`
+
+	for _, c := range []struct {
+		name string
+		typ  string
+		path string
+		want string
+		err  bool
+	}{
+		{
+			name: "ok",
+			typ:  "page",
+			path: "/search/_mapping/page",
+			want: `{"dynamic": "strict", "properties": {"title": {"type": "text"}}}`,
+		},
+		{
+			name: "unknown type",
+			typ:  "document",
+			err:  true,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var path, got string
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				path, got = r.URL.Path, string(b)
+				w.Write([]byte(`{"acknowledged": true}`))
+			}))
+			defer ts.Close()
+
+			client, err := elastic.NewSimpleClient(elastic.SetURL(ts.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = Put(client, "search", c.typ, body)
+			if (err != nil) != c.err {
+				t.Fatalf("got err %v; want err %v", err, c.err)
+			}
+
+			if path != c.path || got != c.want {
+				t.Fatalf("got %q %q; want %q %q", path, got, c.path, c.want)
+			}
+		})
+	}
+}